@@ -451,13 +451,18 @@ func (b *SimulatedBackend) GetStakingCaller(indexCfg *staking.IndexConfigs) (sta
 	if err != nil {
 		return nil, err
 	}
+	return b.StakingCallerAt(state, indexCfg), nil
+}
 
+// StakingCallerAt returns staking caller reading the state at the current block for testing, e.g. a current stateDB
+// modified by the test
+func (b *SimulatedBackend) StakingCallerAt(state *state.StateDB, indexCfg *staking.IndexConfigs) staking.StakingCaller {
 	if indexCfg != nil {
-		return staking.NewStateDbStakingCaller(state, indexCfg), nil
+		return staking.NewStateDbStakingCaller(state, indexCfg)
 	}
 
 	header := b.blockchain.CurrentHeader()
-	return staking.NewEVMStakingCaller(state, b.blockchain, header, b.config, vm.Config{}), nil
+	return staking.NewEVMStakingCaller(state, b.blockchain, header, b.config, vm.Config{})
 }
 
 // CurrentStateDb returns the current stateDB for testing
@@ -542,6 +547,9 @@ func (fb *filterBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEve
 func (fb *filterBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
 	return fb.bc.SubscribeLogsEvent(ch)
 }
+func (fb *filterBackend) SubscribeValidatorSetChangedEvent(ch chan<- core.ValidatorSetChangedEvent) event.Subscription {
+	return fb.bc.SubscribeValidatorSetChangedEvent(ch)
+}
 
 func (fb *filterBackend) BloomStatus() (uint64, uint64) { return 4096, 0 }
 func (fb *filterBackend) ServiceFilter(ctx context.Context, ms *bloombits.MatcherSession) {
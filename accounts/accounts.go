@@ -40,6 +40,8 @@ const (
 	MimetypeTypedData         = "data/typed"
 	MimetypeClique            = "application/x-clique-header"
 	MimetypeTextPlain         = "text/plain"
+	MimetypeTendermint        = "application/x-tendermint"
+	MimetypeTendermintVote    = "application/x-tendermint-vote"
 )
 
 // Wallet represents a software or hardware wallet that might contain one or more
@@ -426,6 +426,12 @@ func signer(c *cli.Context) error {
 		"light-kdf", lightKdf, "advanced", advanced)
 	am := core.StartClefAccountManager(ksLoc, nousb, lightKdf, scpath)
 	apiImpl := core.NewSignerAPI(am, chainId, nousb, ui, db, advanced, pwStorage)
+	// Persist the Tendermint votes signed by the accounts, so that double signing is refused across restarts
+	if info, err := os.Stat(configDir); err == nil && info.IsDir() {
+		apiImpl.SetVoteGuardDir(configDir)
+	} else {
+		log.Warn("Config directory is missing, Tendermint votes are only guarded in memory", "dir", configDir)
+	}
 
 	// Establish the bidirectional communication, by creating a new UI backend and registering
 	// it with the UI.
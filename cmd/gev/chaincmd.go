@@ -191,6 +191,10 @@ Use "ethereum dump 0" to dump the genesis block.`,
 			utils.TendermintTimeoutCommitFlag,
 			utils.TendermintFaultyModeFlag,
 			utils.TendermintSCUseEVMCallerFlag,
+			utils.TendermintSignerFlag,
+			utils.TendermintExternalSignerFlag,
+			utils.TendermintBLSKeyFlag,
+			utils.TendermintRecordFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 	}
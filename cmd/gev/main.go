@@ -91,6 +91,8 @@ var (
 		utils.TxPoolGlobalSlotsFlag,
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
+		utils.TxPoolAccountQuotaFlag,
+		utils.TxPoolProviderQuotaFlag,
 		utils.TxPoolLifetimeFlag,
 		utils.ULCModeConfigFlag,
 		utils.OnlyAnnounceModeFlag,
@@ -139,6 +141,7 @@ var (
 		utils.RinkebyFlag,
 		utils.GoerliFlag,
 		utils.VMEnableDebugFlag,
+		utils.TraceIndexFlag,
 		utils.NetworkIdFlag,
 		utils.ConstantinopleOverrideFlag,
 		utils.EthStatsURLFlag,
@@ -159,6 +162,10 @@ var (
 		utils.TendermintTimeoutPrecommitDeltaFlag,
 		utils.TendermintTimeoutCommitFlag,
 		utils.TendermintSCUseEVMCallerFlag,
+		utils.TendermintSignerFlag,
+		utils.TendermintExternalSignerFlag,
+		utils.TendermintBLSKeyFlag,
+		utils.TendermintRecordFlag,
 	}
 
 	rpcFlags = []cli.Flag{
@@ -229,9 +229,9 @@ func (e *NoRewardEngine) accumulateRewards(config *params.ChainConfig, state *st
 }
 
 func (e *NoRewardEngine) Finalize(chain consensus.FullChainReader, header *types.Header, statedb *state.StateDB, txs []*types.Transaction,
-	uncles []*types.Header) error {
+	uncles []*types.Header, receipts []*types.Receipt) error {
 	if e.rewardsOn {
-		return e.inner.Finalize(chain, header, statedb, txs, uncles)
+		return e.inner.Finalize(chain, header, statedb, txs, uncles, receipts)
 	} else {
 		e.accumulateRewards(chain.Config(), statedb, header, uncles)
 		header.Root = statedb.IntermediateRoot(chain.Config().IsEIP158(header.Number))
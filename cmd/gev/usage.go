@@ -131,6 +131,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TxPoolGlobalSlotsFlag,
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
+			utils.TxPoolAccountQuotaFlag,
+			utils.TxPoolProviderQuotaFlag,
 			utils.TxPoolLifetimeFlag,
 		},
 	},
@@ -218,6 +220,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "VIRTUAL MACHINE",
 		Flags: []cli.Flag{
 			utils.VMEnableDebugFlag,
+			utils.TraceIndexFlag,
 			utils.EVMInterpreterFlag,
 			utils.EWASMInterpreterFlag,
 		},
@@ -259,6 +262,10 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TendermintTimeoutCommitFlag,
 			utils.TendermintFaultyModeFlag,
 			utils.TendermintSCUseEVMCallerFlag,
+			utils.TendermintSignerFlag,
+			utils.TendermintExternalSignerFlag,
+			utils.TendermintBLSKeyFlag,
+			utils.TendermintRecordFlag,
 		},
 	},
 	{
@@ -113,7 +113,7 @@ func (w *wizard) makeGenesis() {
 			copy(genesis.ExtraData[32+i*common.AddressLength:], signer[:])
 		}
 	case choice == "" || choice == "3":
-		fmt.Println("What is policy to select proposer (default 0 - roundrobin)")
+		fmt.Println("What is policy to select proposer (default 0 - roundrobin, 1 - sticky, 2 - weighted roundrobin)")
 		policy := uint64(w.readDefaultInt(0))
 		genesis.Config.Tendermint = &params.TendermintConfig{
 			ProposerPolicy: policy,
@@ -0,0 +1,173 @@
+// tmreplay replays a recording of the Tendermint consensus of a node, see the --tendermint.record flag of gev.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/signer"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+var (
+	nodeKeyFile = flag.String("nodekey", "", "private key of the recorded node, to sign and compare the messages it sent")
+	stepMode    = flag.Bool("step", false, "wait for a command before replaying each entry, enter h for the commands")
+	until       = flag.Int("until", -1, "stop after replaying the entry at this index")
+	advance     = flag.Duration("advance", 0, "move the virtual clock forward by this duration once the recording is replayed")
+	verbose     = flag.Bool("verbose", false, "print the answers of the backend and the sent messages too")
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage:", os.Args[0], "[-nodekey <file>] [-step] [-until <index>] [-advance <duration>] [-verbose] <filename>")
+		flag.PrintDefaults()
+		fmt.Fprintln(os.Stderr, `
+Replays the consensus messages and timeouts recorded by a node against the Tendermint core, with a virtual clock.
+Every entry is printed along with the state of the core after it is replayed, and where the replay diverges.`)
+	}
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	entries, err := core.ReadRecording(flag.Arg(0))
+	if err != nil {
+		// a node may crash in the middle of an entry, the entries before are still replayed
+		if len(entries) == 0 {
+			die(err)
+		}
+		fmt.Fprintln(os.Stderr, "Warning:", err)
+	}
+	var consensusSigner tendermint.Signer
+	if *nodeKeyFile != "" {
+		key, err := crypto.LoadECDSA(*nodeKeyFile)
+		if err != nil {
+			die(err)
+		}
+		consensusSigner = signer.NewKeySigner(key, nil, nil)
+	}
+
+	replayer, err := core.NewReplayer(entries, consensusSigner)
+	if err != nil {
+		die(err)
+	}
+	fmt.Printf("replaying %d entries\n", len(entries))
+	var (
+		input       = bufio.NewReader(os.Stdin)
+		divergences int
+	)
+	for !replayer.Done() {
+		if *stepMode && !prompt(input, replayer) {
+			break
+		}
+		step, err := replayer.Step()
+		if err != nil {
+			die(err)
+		}
+		if step.Divergence != "" {
+			divergences++
+		}
+		if *verbose || *stepMode || isInbound(step.Entry.Kind) || step.Divergence != "" {
+			printStep(step)
+		}
+		if *until >= 0 && step.Index >= *until {
+			break
+		}
+	}
+	if replayer.Done() && *advance > 0 {
+		for deadline := replayer.Now().Add(*advance); replayer.Now().Before(deadline); {
+			step := replayer.Advance(deadline.Sub(replayer.Now()))
+			if step == nil {
+				break
+			}
+			printStep(step)
+		}
+	}
+	for _, block := range replayer.Committed() {
+		fmt.Printf("committed block %v %s\n", block.Number(), block.Hash().Hex())
+	}
+	if divergences > 0 {
+		fmt.Printf("the replay diverges from the recording at %d entries\n", divergences)
+		os.Exit(1)
+	}
+}
+
+// prompt reads the commands of the user until the next entry should be replayed, it returns false to quit
+func prompt(input *bufio.Reader, replayer *core.Replayer) bool {
+	for {
+		fmt.Print("> ")
+		line, err := input.ReadString('\n')
+		if err != nil {
+			return false
+		}
+		switch strings.TrimSpace(line) {
+		case "":
+			return true
+		case "c":
+			*stepMode = false
+			return true
+		case "q":
+			return false
+		case "s":
+			state, err := replayer.Engine().ConsensusState()
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			data, _ := json.MarshalIndent(state, "", "  ")
+			fmt.Println(string(data))
+		case "v":
+			state, err := replayer.Engine().ConsensusState()
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			votes, err := replayer.Engine().RoundVotes(state.BlockNumber, state.Round)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			data, _ := json.MarshalIndent(votes, "", "  ")
+			fmt.Println(string(data))
+		default:
+			fmt.Println("enter: replay the next entry, s: print the state, v: print the votes of the round, c: continue without stepping, q: quit")
+		}
+	}
+}
+
+func isInbound(kind core.RecordKind) bool {
+	switch kind {
+	case core.RecordStart, core.RecordMessage, core.RecordNewBlock, core.RecordFinalCommitted, core.RecordTimeout:
+		return true
+	}
+	return false
+}
+
+func printStep(step *core.ReplayStep) {
+	fmt.Printf("#%d %s %s %s\n", step.Index, step.Time.Format(time.RFC3339Nano), step.Entry.Kind, step.Entry.Describe())
+	if step.Err != nil {
+		fmt.Printf("\terror: %v\n", step.Err)
+	}
+	if step.Divergence != "" {
+		fmt.Printf("\tdivergence: %s\n", step.Divergence)
+	}
+	if state := step.State; state != nil {
+		fmt.Printf("\tstate: block %v, round %d, step %s, locked round %d, valid round %d\n",
+			state.BlockNumber, state.Round, state.Step, state.LockedRound, state.ValidRound)
+	}
+}
+
+func die(args ...interface{}) {
+	fmt.Fprintln(os.Stderr, args...)
+	os.Exit(1)
+}
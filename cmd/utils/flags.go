@@ -334,6 +334,16 @@ var (
 		Usage: "Maximum number of non-executable transaction slots for all accounts",
 		Value: evr.DefaultConfig.TxPool.GlobalQueue,
 	}
+	TxPoolAccountQuotaFlag = cli.Uint64Flag{
+		Name:  "txpool.accountquota",
+		Usage: "Maximum number of transaction slots, executable or not, permitted per remote account",
+		Value: evr.DefaultConfig.TxPool.AccountQuota,
+	}
+	TxPoolProviderQuotaFlag = cli.Uint64Flag{
+		Name:  "txpool.providerquota",
+		Usage: "Maximum number of remote transaction slots a single provider is permitted to pay for",
+		Value: evr.DefaultConfig.TxPool.ProviderQuota,
+	}
 	TxPoolLifetimeFlag = cli.DurationFlag{
 		Name:  "txpool.lifetime",
 		Usage: "Maximum amount of time non-executable transaction are queued",
@@ -445,6 +455,10 @@ var (
 		Name:  "vmdebug",
 		Usage: "Record information useful for VM and contract debugging",
 	}
+	TraceIndexFlag = cli.BoolFlag{
+		Name:  "traceindex",
+		Usage: "Record the internal transactions of the chain to serve the trace API (requires --gcmode=archive and --syncmode=full from genesis)",
+	}
 	InsecureUnlockAllowedFlag = cli.BoolFlag{
 		Name:  "allow-insecure-unlock",
 		Usage: "Allow insecure account unlocking when account-related RPCs are exposed by http",
@@ -709,6 +723,22 @@ var (
 		Name:  "tendermint.use-evm-caller",
 		Usage: "The flag allowance reading data from stateDB or EVM",
 	}
+	TendermintSignerFlag = cli.StringFlag{
+		Name:  "tendermint.signer",
+		Usage: "Account signing the consensus messages instead of the node key (keystore or external signer account)",
+	}
+	TendermintExternalSignerFlag = cli.StringFlag{
+		Name:  "tendermint.external-signer",
+		Usage: "External signer holding the tendermint.signer account (url or path to ipc file)",
+	}
+	TendermintBLSKeyFlag = cli.StringFlag{
+		Name:  "tendermint.blskey",
+		Usage: "File holding the BLS key signing the aggregated committed seals, created if missing (relative to the data directory, default = derived from the node key)",
+	}
+	TendermintRecordFlag = cli.StringFlag{
+		Name:  "tendermint.record",
+		Usage: "File recording the consensus messages and timeouts to replay them with tmreplay (relative to the data directory)",
+	}
 
 	// Metrics flags
 	MetricsEnabledFlag = cli.BoolFlag{
@@ -1282,6 +1312,12 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolGlobalQueueFlag.Name) {
 		cfg.GlobalQueue = ctx.GlobalUint64(TxPoolGlobalQueueFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolAccountQuotaFlag.Name) {
+		cfg.AccountQuota = ctx.GlobalUint64(TxPoolAccountQuotaFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolProviderQuotaFlag.Name) {
+		cfg.ProviderQuota = ctx.GlobalUint64(TxPoolProviderQuotaFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
@@ -1393,6 +1429,23 @@ func setTendermint(ctx *cli.Context, cfg *tendermint.Config) {
 	if ctx.GlobalIsSet(TendermintTimeoutCommitFlag.Name) {
 		cfg.TimeoutCommit = ctx.GlobalDuration(TendermintTimeoutCommitFlag.Name)
 	}
+	if ctx.GlobalIsSet(TendermintSignerFlag.Name) {
+		account := ctx.GlobalString(TendermintSignerFlag.Name)
+		if !common.IsHexAddress(account) {
+			Fatalf("Invalid tendermint signer account: %v", account)
+		}
+		address := common.HexToAddress(account)
+		cfg.SignerAccount = &address
+	}
+	if ctx.GlobalIsSet(TendermintExternalSignerFlag.Name) {
+		cfg.ExternalSigner = ctx.GlobalString(TendermintExternalSignerFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintBLSKeyFlag.Name) {
+		cfg.BLSKeyFile = ctx.GlobalString(TendermintBLSKeyFlag.Name)
+	}
+	if ctx.GlobalIsSet(TendermintRecordFlag.Name) {
+		cfg.RecordFile = ctx.GlobalString(TendermintRecordFlag.Name)
+	}
 
 	if ctx.IsSet(TendermintSCUseEVMCallerFlag.Name) {
 		cfg.UseEVMCaller = true
@@ -1425,6 +1478,23 @@ func setTendermint(ctx *cli.Context, cfg *tendermint.Config) {
 	if ctx.IsSet(TendermintTimeoutCommitFlag.Name) {
 		cfg.TimeoutCommit = ctx.Duration(TendermintTimeoutCommitFlag.Name)
 	}
+	if ctx.IsSet(TendermintSignerFlag.Name) {
+		account := ctx.String(TendermintSignerFlag.Name)
+		if !common.IsHexAddress(account) {
+			Fatalf("Invalid tendermint signer account: %v", account)
+		}
+		address := common.HexToAddress(account)
+		cfg.SignerAccount = &address
+	}
+	if ctx.IsSet(TendermintExternalSignerFlag.Name) {
+		cfg.ExternalSigner = ctx.String(TendermintExternalSignerFlag.Name)
+	}
+	if ctx.IsSet(TendermintBLSKeyFlag.Name) {
+		cfg.BLSKeyFile = ctx.String(TendermintBLSKeyFlag.Name)
+	}
+	if ctx.IsSet(TendermintRecordFlag.Name) {
+		cfg.RecordFile = ctx.String(TendermintRecordFlag.Name)
+	}
 }
 
 // checkExclusive verifies that only a single instance of the provided flags was
@@ -1544,6 +1614,9 @@ func SetEvrConfig(ctx *cli.Context, stack *node.Node, cfg *evr.Config) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
 	}
+	if ctx.GlobalIsSet(TraceIndexFlag.Name) {
+		cfg.TraceIndex = ctx.GlobalBool(TraceIndexFlag.Name)
+	}
 
 	if ctx.GlobalIsSet(EWASMInterpreterFlag.Name) {
 		cfg.EWASMInterpreter = ctx.GlobalString(EWASMInterpreterFlag.Name)
@@ -1757,6 +1830,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 		tdmintConfig.StakingSCAddress = config.Tendermint.StakingSCAddress
 		tdmintConfig.FixedValidators = config.Tendermint.FixedValidators
 		tdmintConfig.BlockReward = config.Tendermint.BlockReward
+		tdmintConfig.ValSetHashBlock = config.Tendermint.ValSetHashBlock
 		engine = tdmintBackend.New(tdmintConfig, stack.Config().NodeKey())
 	} else {
 		engine = ethash.NewFaker()
@@ -38,6 +38,8 @@ const (
 	AddressLength = 20
 	// MaxProvider is the maximum of provider
 	MaxProvider = 16
+	// MaxProviderAllowance is the maximum of provider allowances of a contract
+	MaxProviderAllowance = 64
 )
 
 var (
@@ -98,7 +98,7 @@ type Engine interface {
 	// Note: The block header and state database might be updated to reflect any
 	// consensus rules that happen at finalization (e.g. block rewards).
 	Finalize(chain FullChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction,
-		uncles []*types.Header) error
+		uncles []*types.Header, receipts []*types.Receipt) error
 
 	// FinalizeAndAssemble runs any post-transaction state modifications (e.g. block
 	// rewards) and assembles the final block.
@@ -169,6 +169,17 @@ type Tendermint interface {
 	Address() common.Address
 }
 
+// FinalityVerifier is implemented by consensus engines with instant finality, so that a light client can follow the
+// chain by verifying only the checkpoint headers, which contain the validator set of the next epoch.
+type FinalityVerifier interface {
+	// Epoch returns the number of blocks between two checkpoints.
+	Epoch() uint64
+
+	// VerifyFinality checks that the header is committed by the validator set stored in its checkpoint header.
+	// The checkpoint must have been verified beforehand.
+	VerifyFinality(checkpoint *types.Header, header *types.Header) error
+}
+
 // Handler should be implemented is the consensus needs to handle and send peer's message
 type Handler interface {
 	// HandleNewChainHead handles a new head block comes
@@ -21,6 +21,13 @@ type Backend interface {
 	// Sign signs input data with the backend's private key
 	Sign([]byte) ([]byte, error)
 
+	// SignVote signs input data, the message or the seal of a vote, with the backend's private key.
+	// It returns ErrDoubleSign if the vote conflicts with one signed before.
+	SignVote(*VoteStep, []byte) ([]byte, error)
+
+	// SignBLS signs input data with the backend's BLS key, the signature can be aggregated with other validators' ones
+	SignBLS([]byte) ([]byte, error)
+
 	// Gossip sends a message to all validators (exclude self)
 	// these message are send via p2p network interface.
 	Gossip(valSet ValidatorSet, blockNumber *big.Int, round int64, msgType uint64, payload []byte) error
@@ -37,6 +44,9 @@ type Backend interface {
 	// we should only use this method when core is started.
 	Validators(blockNumber *big.Int) ValidatorSet
 
+	// Config returns the config of the consensus of the block number, with the timeouts governed on-chain
+	Config(blockNumber *big.Int) *Config
+
 	// CurrentHeadBlock get the current block of from the canonical chain.
 	CurrentHeadBlock() *types.Block
 
@@ -57,4 +67,9 @@ type Backend interface {
 	// VerifyProposalBlock verify post-processor state of proposal block (txs, Root, receipt).
 	// If success, the result will be send to the pending tasks of miner
 	VerifyProposalBlock(block *types.Block) error
+
+	// ReportEvidence adds an evidence of conflicting votes to the pending evidences
+	// which will be included in the next proposed blocks.
+	// It returns true if the evidence is new and should be gossiped to other nodes.
+	ReportEvidence(ev *types.TendermintEvidence) bool
 }
@@ -0,0 +1,128 @@
+package backend
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto/bls"
+	"github.com/Evrynetlabs/evrynet-node/log"
+)
+
+// verifyAggregatedSeal checks that the aggregated seal of the block hash is signed by validators having at least
+// the minimum majority of the voting power
+func verifyAggregatedSeal(hash common.Hash, aggregatedSeal *types.TendermintAggregatedSeal, valSet tendermint.ValidatorSet) error {
+	if aggregatedSeal == nil {
+		return tendermint.ErrEmptyCommittedSeals
+	}
+	signers, err := utils.SignerIndexes(aggregatedSeal.Signers, valSet.Size())
+	if err != nil {
+		return errors.Wrap(tendermint.ErrInvalidAggregatedSeal, err.Error())
+	}
+	var (
+		votingPower int
+		publicKeys  = make([][]byte, 0, len(signers))
+	)
+	for _, i := range signers {
+		val := valSet.GetByIndex(int64(i))
+		votingPower += int(val.VotingPower())
+		publicKeys = append(publicKeys, val.BLSPublicKey())
+	}
+	if votingPower < valSet.MinMajority() {
+		return tendermint.ErrInvalidCommittedSeals
+	}
+	if !bls.VerifyAggregate(publicKeys, utils.PrepareCommittedSeal(hash), aggregatedSeal.Signature) {
+		return tendermint.ErrInvalidAggregatedSeal
+	}
+	return nil
+}
+
+// aggregatedSealSigners returns the validators whose signatures are in the aggregated seal
+func aggregatedSealSigners(aggregatedSeal *types.TendermintAggregatedSeal, valSet tendermint.ValidatorSet) ([]common.Address, error) {
+	indexes, err := utils.SignerIndexes(aggregatedSeal.Signers, valSet.Size())
+	if err != nil {
+		return nil, err
+	}
+	signers := make([]common.Address, 0, len(indexes))
+	for _, i := range indexes {
+		signers = append(signers, valSet.GetByIndex(int64(i)).Address())
+	}
+	return signers, nil
+}
+
+// getNextBLSPublicKeys returns the BLS public keys of the validators of the checkpoint block number, read from the state
+// of its parent header. It returns nil if the commits are not aggregated at the block number or if a validator has not
+// registered its BLS public key, the blocks of the epoch are then committed with committed seals.
+func (sb *Backend) getNextBLSPublicKeys(chainReader consensus.FullChainReader, parent *types.Header, number *big.Int, validators []common.Address) ([][]byte, error) {
+	config := chainReader.Config().Tendermint
+	if config == nil || !config.IsBLSAggregation(number) || len(config.FixedValidators) > 0 {
+		return nil, nil
+	}
+	stateDB, err := chainReader.StateAt(parent.Root)
+	if err != nil {
+		return nil, err
+	}
+	publicKeys := make([][]byte, len(validators))
+	for i, val := range validators {
+		publicKeys[i] = staking.GetBLSPublicKey(stateDB, sb.stakingContractAddr, val)
+		if publicKeys[i] == nil {
+			log.Warn("validator has no bls public key, the commits of the next epoch are not aggregated", "number", number, "validator", val)
+			return nil, nil
+		}
+	}
+	return publicKeys, nil
+}
+
+// processBLSRegistrations stores the BLS public keys registered by the successful transactions of the block to the
+// staking contract. A registration must be sent by the candidate or its owner with a proof of possession of the key
+// for the candidate, invalid registrations are ignored.
+func (sb *Backend) processBLSRegistrations(chain consensus.FullChainReader, header *types.Header, stateDB *state.StateDB, txs []*types.Transaction, receipts []*types.Receipt) error {
+	config := chain.Config().Tendermint
+	if config == nil || !config.IsBLSAggregation(header.Number) || len(config.FixedValidators) > 0 {
+		return nil
+	}
+	calls, err := sb.stakingCalls(txs, receipts, staking.BLSRegistrationSelector)
+	if err != nil {
+		return err
+	}
+	signer := types.MakeSigner(chain.Config(), header.Number)
+	for _, tx := range calls {
+		logger := log.New("number", header.Number, "tx", tx.Hash())
+		reg, err := staking.DecodeBLSRegistration(tx.Data())
+		if err != nil {
+			logger.Warn("ignored invalid bls key registration", "err", err)
+			continue
+		}
+		if err := reg.VerifyPossession(); err != nil {
+			logger.Warn("ignored bls key registration without a valid proof of possession", "candidate", reg.Candidate, "err", err)
+			continue
+		}
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			return err
+		}
+		validatorsData, err := sb.getStakingCaller(chain, stateDB, header).GetValidatorsData(sb.stakingContractAddr, []common.Address{reg.Candidate})
+		if err != nil {
+			return err
+		}
+		owner := validatorsData[reg.Candidate].Owner
+		if (owner == common.Address{}) || (sender != owner && sender != reg.Candidate) {
+			logger.Warn("ignored bls key registration from an unauthorized sender", "candidate", reg.Candidate, "sender", sender)
+			continue
+		}
+		if registered := staking.GetBLSKeyCandidate(stateDB, sb.stakingContractAddr, reg.PublicKey); (registered != common.Address{}) {
+			logger.Warn("ignored bls key registration of a key already registered", "candidate", reg.Candidate, "registered", registered)
+			continue
+		}
+		staking.SetBLSPublicKey(stateDB, sb.stakingContractAddr, reg.Candidate, reg.PublicKey)
+		logger.Info("registered bls public key", "candidate", reg.Candidate)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package backend
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/crypto/bls"
+)
+
+func TestVerifyAggregatedSeal(t *testing.T) {
+	var (
+		keys          []*ecdsa.PrivateKey
+		validators    []common.Address
+		blsPublicKeys [][]byte
+	)
+	for i := 0; i < 4; i++ {
+		key := tests_utils.MakeNodeKey()
+		keys = append(keys, key)
+		validators = append(validators, crypto.PubkeyToAddress(key.PublicKey))
+		blsPublicKeys = append(blsPublicKeys, bls.DeriveSecretKey(crypto.FromECDSA(key)).PublicKey())
+	}
+	valSet := validator.NewWeightedSet(validators, nil, blsPublicKeys, tendermint.RoundRobin, 1, 0)
+	require.True(t, valSet.AggregatesCommits())
+
+	header := tests_utils.MakeBlockWithoutSeal(tests_utils.MakeGenesisHeader(validators)).Header()
+	writeAggregatedSeal := func(signerKeys []*ecdsa.PrivateKey) {
+		var (
+			signers []int
+			sigs    [][]byte
+		)
+		for _, key := range signerKeys {
+			index, _ := valSet.GetByAddress(crypto.PubkeyToAddress(key.PublicKey))
+			signers = append(signers, index)
+			sigs = append(sigs, bls.DeriveSecretKey(crypto.FromECDSA(key)).Sign(utils.PrepareCommittedSeal(header.Hash())))
+		}
+		signature, err := bls.AggregateSignatures(sigs)
+		require.NoError(t, err)
+		require.NoError(t, utils.WriteAggregatedSeal(header, &types.TendermintAggregatedSeal{
+			Signers:   utils.SignerBitmap(valSet.Size(), signers),
+			Signature: signature,
+		}))
+	}
+	verify := func() error {
+		extra, err := types.ExtractTendermintExtra(header)
+		require.NoError(t, err)
+		return verifyAggregatedSeal(header.Hash(), extra.AggregatedSeal, valSet)
+	}
+
+	// the proposal has no aggregated seal yet
+	assert.Equal(t, tendermint.ErrEmptyCommittedSeals, verify())
+
+	writeAggregatedSeal(keys[:3])
+	assert.NoError(t, verify())
+
+	// 2 of 4 validators are not a majority
+	writeAggregatedSeal(keys[:2])
+	assert.Equal(t, tendermint.ErrInvalidCommittedSeals, verify())
+
+	// the signature does not match the signers
+	writeAggregatedSeal(keys[1:])
+	extra, err := types.ExtractTendermintExtra(header)
+	require.NoError(t, err)
+	extra.AggregatedSeal.Signers = utils.SignerBitmap(valSet.Size(), []int{0, 1, 2, 3})
+	require.NoError(t, utils.WriteAggregatedSeal(header, extra.AggregatedSeal))
+	assert.Equal(t, tendermint.ErrInvalidAggregatedSeal, verify())
+}
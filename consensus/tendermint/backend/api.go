@@ -1,10 +1,26 @@
 package backend
 
 import (
+	"context"
 	"math/big"
+	"time"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
 	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/core/governance"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+)
+
+const (
+	// maxRewardEpochs is the maximum number of epochs GetRewardsByAddress looks up
+	maxRewardEpochs = 1024
+	// stepEventChanSize is the size of the channel buffering the steps notified to a subscriber
+	stepEventChanSize = 128
 )
 
 // TendermintAPI is a user facing RPC API to dump tendermint state
@@ -30,3 +46,276 @@ func (api *TendermintAPI) GetValidators(number *uint64) []common.Address {
 	}
 	return validators
 }
+
+// GetUptimes returns the number of blocks each validator committed in the uptime window ending at the block's number
+func (api *TendermintAPI) GetUptimes(number *uint64) (map[common.Address]*ValidatorUptime, error) {
+	header := api.chain.CurrentHeader()
+	if number != nil {
+		header = api.chain.GetHeaderByNumber(*number)
+	}
+	if header == nil {
+		return nil, tendermint.ErrUnknownBlock
+	}
+	return api.be.getUptimes(api.chain, header, api.be.uptimeWindow(api.chain))
+}
+
+// BLSRegistration is the registration of the BLS public key of this node
+type BLSRegistration struct {
+	Candidate common.Address `json:"candidate"`
+	PublicKey hexutil.Bytes  `json:"publicKey"`
+	Proof     hexutil.Bytes  `json:"proof"`
+	// Data is the input of the transaction to send to the staking contract from the candidate or its owner
+	Data hexutil.Bytes `json:"data"`
+}
+
+// GetBLSRegistration returns the registration of the BLS public key this node signs the aggregated committed seals with
+func (api *TendermintAPI) GetBLSRegistration() (*BLSRegistration, error) {
+	key, err := api.be.signer.BLSKey()
+	if err != nil {
+		return nil, err
+	}
+	reg := &staking.BLSRegistration{
+		Candidate: api.be.address,
+		PublicKey: key.PublicKey(),
+		Proof:     staking.ProveBLSPossession(key, api.be.address),
+	}
+	data, err := staking.EncodeBLSRegistration(reg)
+	if err != nil {
+		return nil, err
+	}
+	return &BLSRegistration{
+		Candidate: reg.Candidate,
+		PublicKey: reg.PublicKey,
+		Proof:     reg.Proof,
+		Data:      data,
+	}, nil
+}
+
+// ChainParam is the value of a chain parameter governed by the validators
+type ChainParam struct {
+	Value      *hexutil.Big    `json:"value"`
+	Pending    *hexutil.Big    `json:"pending,omitempty"`    // the value scheduled to activate at the Activation block
+	Activation *hexutil.Uint64 `json:"activation,omitempty"` // the checkpoint the pending value activates at
+}
+
+// GetChainParams returns the chain parameters governed by the validators in the state of the block's number.
+// The timeouts are in milliseconds.
+func (api *TendermintAPI) GetChainParams(number *uint64) (map[string]*ChainParam, error) {
+	chain := api.be.chain
+	if chain == nil {
+		return nil, tendermint.ErrStoppedEngine
+	}
+	header := chain.CurrentHeader()
+	if number != nil {
+		header = chain.GetHeaderByNumber(*number)
+	}
+	if header == nil {
+		return nil, tendermint.ErrUnknownBlock
+	}
+	stateDB, err := chain.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		config = governedConfig(chain, api.be.config, stateDB)
+		values = map[governance.ChainParam]*big.Int{
+			governance.ParamGasPrice:         governance.GasPrice(chain.Config(), stateDB),
+			governance.ParamBlockReward:      governance.BlockReward(chain.Config(), stateDB),
+			governance.ParamTimeoutPropose:   big.NewInt(int64(config.TimeoutPropose / time.Millisecond)),
+			governance.ParamTimeoutPrevote:   big.NewInt(int64(config.TimeoutPrevote / time.Millisecond)),
+			governance.ParamTimeoutPrecommit: big.NewInt(int64(config.TimeoutPrecommit / time.Millisecond)),
+			governance.ParamTimeoutCommit:    big.NewInt(int64(config.TimeoutCommit / time.Millisecond)),
+		}
+		params = make(map[string]*ChainParam, len(values))
+	)
+	for param, value := range values {
+		p := &ChainParam{Value: (*hexutil.Big)(value)}
+		if api.be.stakingContractAddr != (common.Address{}) {
+			if pending, activation := governance.GetPending(stateDB, api.be.stakingContractAddr, param); pending != nil {
+				p.Pending = (*hexutil.Big)(pending)
+				p.Activation = (*hexutil.Uint64)(&activation)
+			}
+		}
+		params[param.String()] = p
+	}
+	return params, nil
+}
+
+// EpochReward is the part of the reward of a validator for an epoch credited to an address
+type EpochReward struct {
+	Epoch     hexutil.Uint64 `json:"epoch"`
+	Address   common.Address `json:"address"` // the owner of the validator or one of its voters
+	Validator common.Address `json:"validator"`
+	Amount    *hexutil.Big   `json:"amount"`
+}
+
+// GetEpochRewards returns the rewards of the epoch credited at its last block, the checkpoint (epoch+1)*Epoch.
+// Epoch 0 is made of the blocks 1 to Epoch. The rewards are only available for the checkpoints processed by this node.
+func (api *TendermintAPI) GetEpochRewards(epoch uint64) ([]*EpochReward, error) {
+	if api.be.db == nil {
+		return nil, ErrNoRewardHistory
+	}
+	header := api.chain.GetHeaderByNumber((epoch + 1) * api.be.config.Epoch)
+	if header == nil {
+		return nil, tendermint.ErrUnknownBlock
+	}
+	return api.epochRewards(epoch, header)
+}
+
+// AddressRewards is the rewards credited to an address in a range of epochs
+type AddressRewards struct {
+	Address common.Address `json:"address"`
+	Total   *hexutil.Big   `json:"total"`
+	Rewards []*EpochReward `json:"rewards"`
+}
+
+// GetRewardsByAddress returns the rewards credited to the address from the epoch fromEpoch to the epoch toEpoch
+// included, the epochs which have not ended yet are skipped. It fails if the rewards of an ended epoch are not available.
+func (api *TendermintAPI) GetRewardsByAddress(address common.Address, fromEpoch, toEpoch uint64) (*AddressRewards, error) {
+	if api.be.db == nil {
+		return nil, ErrNoRewardHistory
+	}
+	if toEpoch < fromEpoch || toEpoch-fromEpoch >= maxRewardEpochs {
+		return nil, ErrTooManyEpochs
+	}
+	var (
+		total  = new(big.Int)
+		result = &AddressRewards{Address: address, Rewards: []*EpochReward{}}
+	)
+	for epoch := fromEpoch; epoch <= toEpoch; epoch++ {
+		header := api.chain.GetHeaderByNumber((epoch + 1) * api.be.config.Epoch)
+		if header == nil {
+			break
+		}
+		rewards, err := api.epochRewards(epoch, header)
+		if err != nil {
+			return nil, err
+		}
+		for _, reward := range rewards {
+			if reward.Address != address {
+				continue
+			}
+			total.Add(total, reward.Amount.ToInt())
+			result.Rewards = append(result.Rewards, reward)
+		}
+	}
+	result.Total = (*hexutil.Big)(total)
+	return result, nil
+}
+
+// epochRewards returns the rewards credited at the committed checkpoint of the epoch.
+// The rewards of the head checkpoint may still be pending as they are stored when the next block is finalized.
+func (api *TendermintAPI) epochRewards(epoch uint64, checkpoint *types.Header) ([]*EpochReward, error) {
+	if !api.be.storeEpochRewards(checkpoint) {
+		return nil, ErrUnavailableRewards
+	}
+	rewards := rawdb.ReadEpochRewards(api.be.db, checkpoint.Number.Uint64(), checkpoint.Root)
+	result := make([]*EpochReward, 0, len(rewards))
+	for _, reward := range rewards {
+		result = append(result, &EpochReward{
+			Epoch:     hexutil.Uint64(epoch),
+			Address:   reward.Address,
+			Validator: reward.Validator,
+			Amount:    (*hexutil.Big)(reward.Amount),
+		})
+	}
+	return result, nil
+}
+
+// GetConsensusState returns the block number, round and step the consensus of this node is at,
+// with the proposer of the round and the blocks the node is locked on and knows as valid
+func (api *TendermintAPI) GetConsensusState() (*tendermint.ConsensusState, error) {
+	return api.be.core.ConsensusState()
+}
+
+// GetRoundVotes returns the block each validator prevoted and precommitted in the round.
+// The votes are only kept for the block number the consensus is at.
+func (api *TendermintAPI) GetRoundVotes(number uint64, round int64) (*tendermint.RoundVotes, error) {
+	return api.be.core.RoundVotes(new(big.Int).SetUint64(number), round)
+}
+
+// CommitInfo is the proposer and the validators which committed a block
+type CommitInfo struct {
+	Number     hexutil.Uint64   `json:"number"`
+	Hash       common.Hash      `json:"hash"`
+	Proposer   common.Address   `json:"proposer"`
+	Signers    []common.Address `json:"signers"`
+	Missing    []common.Address `json:"missing"`    // the validators of the block which did not commit it
+	Aggregated bool             `json:"aggregated"` // whether the committed seals are aggregated with BLS
+}
+
+// GetCommitInfo returns the proposer and the validators which committed the block of the number
+func (api *TendermintAPI) GetCommitInfo(number *uint64) (*CommitInfo, error) {
+	header := api.chain.CurrentHeader()
+	if number != nil {
+		header = api.chain.GetHeaderByNumber(*number)
+	}
+	if header == nil || header.Number.Sign() == 0 {
+		return nil, tendermint.ErrUnknownBlock
+	}
+	extra, err := types.ExtractTendermintExtra(header)
+	if err != nil {
+		return nil, err
+	}
+	proposer, err := blockProposer(header)
+	if err != nil {
+		return nil, err
+	}
+	valSet, err := api.be.valSetInfo.GetValSet(api.chain, header.Number)
+	if err != nil {
+		return nil, err
+	}
+	signers, err := api.be.commitSigners(header, valSet)
+	if err != nil {
+		return nil, err
+	}
+	signed := make(map[common.Address]bool, len(signers))
+	for _, signer := range signers {
+		signed[signer] = true
+	}
+	info := &CommitInfo{
+		Number:     hexutil.Uint64(header.Number.Uint64()),
+		Hash:       header.Hash(),
+		Proposer:   proposer,
+		Signers:    signers,
+		Missing:    []common.Address{},
+		Aggregated: extra.AggregatedSeal != nil,
+	}
+	for _, val := range valSet.List() {
+		if !signed[val.Address()] {
+			info.Missing = append(info.Missing, val.Address())
+		}
+	}
+	return info, nil
+}
+
+// ConsensusSteps creates a subscription notified of the steps the consensus of this node moves to,
+// a subscriber which cannot keep up misses some of the steps
+func (api *TendermintAPI) ConsensusSteps(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		steps := make(chan tendermint.StepEvent, stepEventChanSize)
+		stepsSub := api.be.core.SubscribeStepEvent(steps)
+
+		for {
+			select {
+			case step := <-steps:
+				_ = notifier.Notify(rpcSub.ID, step)
+			case <-rpcSub.Err():
+				stepsSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				stepsSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
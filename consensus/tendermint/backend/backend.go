@@ -17,8 +17,8 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/backend/fixed_valset_info"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/backend/staking"
 	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/signer"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
-	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/event"
 	"github.com/Evrynetlabs/evrynet-node/evrdb"
 	"github.com/Evrynetlabs/evrynet-node/log"
@@ -33,25 +33,57 @@ const (
 	initialBroadcastSleepTime    = time.Millisecond * 100
 	broadcastSleepTimeIncreament = time.Millisecond * 100
 	inMemoryValset               = 10
+	inMemoryPendingRewards       = 16 // number of finalized checkpoint proposals whose rewards are kept until one is committed
 )
 
 var (
 	//ErrNoBroadcaster is return when trying to access backend.Broadcaster without SetBroadcaster first
 	ErrNoBroadcaster = errors.New("no broadcaster is set")
+	//ErrNoRewardHistory is returned when the reward history is requested from a backend without database
+	ErrNoRewardHistory = errors.New("reward history is not stored")
+	//ErrUnavailableRewards is returned when the rewards of an epoch were not recorded by this node, e.g. its checkpoint was fast-synced
+	ErrUnavailableRewards = errors.New("rewards are not available")
+	//ErrTooManyEpochs is returned when the rewards of more than maxRewardEpochs epochs are requested at once
+	ErrTooManyEpochs = errors.New("too many epochs requested")
 )
 
 //Option return an optional function for backend's initial behaviour
 type Option func(b *Backend) error
 
+//WithDB return an option to set the database where core persists its write-ahead log
+func WithDB(db evrdb.Database) Option {
+	return func(b *Backend) error {
+		b.db = db
+		return nil
+	}
+}
+
+//WithSigner return an option to set the signer of the consensus messages, which holds the validator's keys.
+//The signer defaults to one with the node's private key.
+func WithSigner(signer tendermint.Signer) Option {
+	return func(b *Backend) error {
+		b.signer = signer
+		return nil
+	}
+}
+
+//WithRecorder return an option to record the inputs of the core, so that its execution can be replayed
+func WithRecorder(recorder tendermintCore.Recorder) Option {
+	return func(b *Backend) error {
+		b.recorder = recorder
+		return nil
+	}
+}
+
 // New creates an backend for Istanbul core engine.
 // The p2p communication, i.e, broadcaster is set separately by calling backend.SetBroadcaster
 func New(config *tendermint.Config, privateKey *ecdsa.PrivateKey, opts ...Option) consensus.Tendermint {
 	valSetCache, _ := lru.NewARC(inMemoryValset)
+	parentSignersCache, _ := lru.NewARC(inMemoryParentSigners)
+	pendingRewardsCache, _ := lru.NewARC(inMemoryPendingRewards)
 	be := &Backend{
 		config:                     config,
 		tendermintEventMux:         new(event.TypeMux),
-		privateKey:                 privateKey,
-		address:                    crypto.PubkeyToAddress(privateKey.PublicKey),
 		commitChs:                  newCommitChannels(),
 		mutex:                      &sync.RWMutex{},
 		storingMsgs:                queue.NewFIFO(),
@@ -59,6 +91,9 @@ func New(config *tendermint.Config, privateKey *ecdsa.PrivateKey, opts ...Option
 		closingBackgroundThreadsCh: make(chan struct{}),
 		controlChan:                make(chan struct{}),
 		computedValSetCache:        valSetCache,
+		evidencePool:               newEvidencePool(),
+		parentSignersCache:         parentSignersCache,
+		pendingRewardsCache:        pendingRewardsCache,
 	}
 
 	if config.FixedValidators != nil && len(config.FixedValidators) > 0 {
@@ -70,13 +105,24 @@ func New(config *tendermint.Config, privateKey *ecdsa.PrivateKey, opts ...Option
 		}
 		be.stakingContractAddr = *config.StakingSCAddress
 	}
-	be.core = tendermintCore.New(be, config)
-
 	for _, opt := range opts {
 		if err := opt(be); err != nil {
 			log.Error("error at initialization of backend", err)
 		}
 	}
+	if be.signer == nil {
+		be.signer = signer.NewKeySigner(privateKey, nil, nil)
+	}
+	be.address = be.signer.Address()
+
+	var coreOpts []tendermintCore.Option
+	if be.db != nil {
+		coreOpts = append(coreOpts, tendermintCore.WithWAL(be.db))
+	}
+	if be.recorder != nil {
+		coreOpts = append(coreOpts, tendermintCore.WithRecorder(be.recorder))
+	}
+	be.core = tendermintCore.New(be, config, coreOpts...)
 
 	go be.dequeueMsgLoop()
 	return be
@@ -91,9 +137,10 @@ func (sb *Backend) SetBroadcaster(broadcaster consensus.Broadcaster) {
 type Backend struct {
 	config             *tendermint.Config
 	tendermintEventMux *event.TypeMux
-	privateKey         *ecdsa.PrivateKey
+	signer             tendermint.Signer // signer signs the consensus messages and the aggregated committed seals
 	core               tendermintCore.Engine
 	db                 evrdb.Database
+	recorder           tendermintCore.Recorder // recorder records the inputs of the core if it is set
 	broadcaster        consensus.Broadcaster
 	address            common.Address
 
@@ -118,6 +165,9 @@ type Backend struct {
 	valSetInfo          ValidatorSetInfo
 	stakingContractAddr common.Address // stakingContractAddr stores the address of staking smart-contract
 	computedValSetCache *lru.ARCCache  // computedValSetCache stores the valset is computed from stateDB
+	evidencePool        *evidencePool  // evidencePool stores the evidences of conflicting votes to be included in blocks
+	parentSignersCache  *lru.ARCCache  // parentSignersCache stores the validators who committed the parent of a block by its hash
+	pendingRewardsCache *lru.ARCCache  // pendingRewardsCache stores the rewards of the finalized checkpoints by state root until they are committed
 }
 
 // EventMux implements tendermint.Backend.EventMux
@@ -127,8 +177,21 @@ func (sb *Backend) EventMux() *event.TypeMux {
 
 // Sign implements tendermint.Backend.Sign
 func (sb *Backend) Sign(data []byte) ([]byte, error) {
-	hashData := crypto.Keccak256(data)
-	return crypto.Sign(hashData, sb.privateKey)
+	return sb.signer.Sign(data)
+}
+
+// SignVote implements tendermint.Backend.SignVote
+func (sb *Backend) SignVote(vote *tendermint.VoteStep, data []byte) ([]byte, error) {
+	return sb.signer.SignVote(vote, data)
+}
+
+// SignBLS implements tendermint.Backend.SignBLS
+func (sb *Backend) SignBLS(data []byte) ([]byte, error) {
+	key, err := sb.signer.BLSKey()
+	if err != nil {
+		return nil, err
+	}
+	return key.Sign(data), nil
 }
 
 // Address implements tendermint.Backend.Address
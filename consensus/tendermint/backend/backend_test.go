@@ -14,6 +14,7 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/signer"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
 	evrynetCore "github.com/Evrynetlabs/evrynet-node/core"
@@ -28,7 +29,7 @@ func TestSign(t *testing.T) {
 	privateKey, err := tests_utils.GeneratePrivateKey()
 	require.NoError(t, err)
 	b := &Backend{
-		privateKey: privateKey,
+		signer: signer.NewKeySigner(privateKey, nil, nil),
 	}
 	data := []byte("Here is a string....")
 	sig, err := b.Sign(data)
@@ -36,14 +37,14 @@ func TestSign(t *testing.T) {
 	// Check signature recover
 	hashData := crypto.Keccak256([]byte(data))
 	pubkey, _ := crypto.Ecrecover(hashData, sig)
-	var signer common.Address
-	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+	var signerAddr common.Address
+	copy(signerAddr[:], crypto.Keccak256(pubkey[1:])[12:])
 
 	// Get Address from private key
 	publicKeyECDSA, ok := privateKey.Public().(*ecdsa.PublicKey)
 	require.True(t, ok)
 	address := crypto.PubkeyToAddress(*publicKeyECDSA)
-	assert.Equal(t, signer, address, "address mismatch")
+	assert.Equal(t, signerAddr, address, "address mismatch")
 }
 
 func TestValidators(t *testing.T) {
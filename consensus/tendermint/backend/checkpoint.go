@@ -0,0 +1,180 @@
+package backend
+
+import (
+	"math/big"
+	"reflect"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// nextValSet is the validator set written in the extra-data of a checkpoint block for the epoch starting after it
+type nextValSet struct {
+	validators    []common.Address
+	votingPowers  []uint64
+	blsPublicKeys [][]byte
+}
+
+// getNextValSet computes the validator set of the checkpoint block number from the state of its parent header.
+//
+// If the staking contract fails to return a validator set, e.g. it is broken or self-destructed, every node fails the
+// same way, so the validator set of the current epoch is carried forward and the chain keeps running with it.
+// Any other error is returned as the set can not be computed by this node, e.g. the state of the parent or the headers
+// of the uptime window are missing: the node must then neither propose nor accept the checkpoint block.
+func (sb *Backend) getNextValSet(chain consensus.FullChainReader, parent *types.Header, number *big.Int) (*nextValSet, error) {
+	if _, err := chain.StateAt(parent.Root); err != nil {
+		log.Error("can't compute the next validator set without the state of the parent", "number", number, "err", err)
+		return nil, tendermint.ErrUnavailableValSet
+	}
+	next, err := sb.computeNextValSet(chain, parent, number)
+	if err == nil {
+		return next, nil
+	}
+	if !staking.IsContractError(err) {
+		log.Error("failed to compute the next validator set", "number", number, "err", err)
+		return nil, err
+	}
+	log.Error("failed to compute the next validator set from the staking contract, carry the current one forward",
+		"number", number, "err", err)
+	return sb.currentValSet(chain, parent)
+}
+
+// computeNextValSet computes the validator set of the checkpoint block number from the staking contract
+func (sb *Backend) computeNextValSet(chain consensus.FullChainReader, parent *types.Header, number *big.Int) (*nextValSet, error) {
+	validators, err := sb.getNextValidatorSet(chain, parent)
+	if err != nil {
+		return nil, err
+	}
+	if len(validators) == 0 {
+		return nil, tendermint.ErrEmptyValSet
+	}
+	votingPowers, err := sb.getNextVotingPowers(chain, parent, number, validators)
+	if err != nil {
+		return nil, err
+	}
+	blsPublicKeys, err := sb.getNextBLSPublicKeys(chain, parent, number, validators)
+	if err != nil {
+		return nil, err
+	}
+	return &nextValSet{
+		validators:    validators,
+		votingPowers:  votingPowers,
+		blsPublicKeys: blsPublicKeys,
+	}, nil
+}
+
+// currentValSet returns the validator set of the epoch of the parent header, read from its checkpoint header
+func (sb *Backend) currentValSet(chain consensus.ChainReader, parent *types.Header) (*nextValSet, error) {
+	var (
+		number     = parent.Number.Uint64() + 1
+		checkpoint = parent
+	)
+	for checkpoint != nil && checkpoint.Number.Uint64() > utils.GetCheckpointNumber(sb.config.Epoch, number) {
+		checkpoint = chain.GetHeader(checkpoint.ParentHash, checkpoint.Number.Uint64()-1)
+	}
+	if checkpoint == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	extra, err := types.ExtractTendermintExtra(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	var validators []common.Address
+	if err := rlp.DecodeBytes(extra.ValidatorAdds, &validators); err != nil || len(validators) == 0 {
+		log.Error("no validator set to carry forward", "number", number, "checkpoint", checkpoint.Number)
+		return nil, tendermint.ErrEmptyValSet
+	}
+	return &nextValSet{
+		validators:    validators,
+		votingPowers:  extra.VotingPowers,
+		blsPublicKeys: extra.BLSPublicKeys,
+	}, nil
+}
+
+// writeTo writes the validator set to the extra-data of the checkpoint header
+func (v *nextValSet) writeTo(header *types.Header) error {
+	if err := utils.WriteValSet(header, v.validators); err != nil {
+		return err
+	}
+	if len(v.votingPowers) > 0 {
+		if err := utils.WriteVotingPowers(header, v.votingPowers); err != nil {
+			return err
+		}
+	}
+	if len(v.blsPublicKeys) > 0 {
+		return utils.WriteBLSPublicKeys(header, v.blsPublicKeys)
+	}
+	return nil
+}
+
+// verifyCheckpoint checks the validator set of the header if it is a checkpoint and the state of its parent is available.
+// When the parent is verified in the same batch, its state is not written yet and the validator set is verified by
+// Finalize while processing the block.
+func (sb *Backend) verifyCheckpoint(chain consensus.ChainReader, header *types.Header, parent *types.Header) error {
+	if header.Number.Uint64()%sb.config.Epoch != 0 || len(sb.config.FixedValidators) > 0 {
+		return nil
+	}
+	fullChain, ok := chain.(consensus.FullChainReader)
+	if !ok {
+		return nil
+	}
+	if _, err := fullChain.StateAt(parent.Root); err != nil {
+		return nil
+	}
+	return sb.verifyCheckpointValSet(fullChain, header, parent)
+}
+
+// verifyCheckpointValSet checks that the validator set in the extra-data of the checkpoint header is the one computed
+// from the state of its parent
+func (sb *Backend) verifyCheckpointValSet(chain consensus.FullChainReader, header *types.Header, parent *types.Header) error {
+	next, err := sb.getNextValSet(chain, parent, header.Number)
+	if err != nil {
+		return err
+	}
+	valSetInHeader, err := utils.GetValSetAddresses(header)
+	if err != nil {
+		log.Info("No validators in the extra-data", "number", header.Number, "err", err)
+		return err
+	}
+	if !reflect.DeepEqual(next.validators, valSetInHeader) {
+		return tendermint.ErrMismatchValSet
+	}
+	extra, err := types.ExtractTendermintExtra(header)
+	if err != nil {
+		return err
+	}
+	if len(next.votingPowers) != len(extra.VotingPowers) || (len(next.votingPowers) > 0 && !reflect.DeepEqual(next.votingPowers, extra.VotingPowers)) {
+		return tendermint.ErrMismatchValSet
+	}
+	if len(next.blsPublicKeys) != len(extra.BLSPublicKeys) || (len(next.blsPublicKeys) > 0 && !reflect.DeepEqual(next.blsPublicKeys, extra.BLSPublicKeys)) {
+		return tendermint.ErrMismatchValSet
+	}
+	return nil
+}
+
+// verifyValSetHash checks that a checkpoint header after the validator set hash fork seals the hash of its validator
+// set and that other headers do not. As the hash is covered by the committed seals, the validator set of a committed
+// checkpoint can not be altered, e.g. by a peer serving it to a node syncing from checkpoint to checkpoint.
+func (sb *Backend) verifyValSetHash(header *types.Header) error {
+	extra, err := types.ExtractTendermintExtra(header)
+	if err != nil {
+		return err
+	}
+	number := header.Number.Uint64()
+	if number == 0 || number%sb.config.Epoch != 0 || len(sb.config.FixedValidators) > 0 || !sb.config.IsValSetHash(header.Number) {
+		if extra.ValSetHash != (common.Hash{}) {
+			return tendermint.ErrInvalidValSetHash
+		}
+		return nil
+	}
+	if extra.ValSetHash != extra.CalcValSetHash() {
+		return tendermint.ErrMismatchValSet
+	}
+	return nil
+}
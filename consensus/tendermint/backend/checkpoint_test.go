@@ -0,0 +1,142 @@
+package backend
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+)
+
+// parentWithState returns a copy of the genesis header whose state is modified by fn and whose checkpoint validator set
+// is validators
+func parentWithState(t *testing.T, backend *Backend, validators []common.Address, fn func(*state.StateDB)) *types.Header {
+	parent := types.CopyHeader(backend.chain.GetHeaderByNumber(0))
+	stateDB, err := backend.chain.StateAt(parent.Root)
+	require.NoError(t, err)
+	fn(stateDB)
+	parent.Root, err = stateDB.Commit(true)
+	require.NoError(t, err)
+	require.NoError(t, utils.WriteValSet(parent, validators))
+	return parent
+}
+
+func TestGetNextValSet(t *testing.T) {
+	backend, _, _, err := createBlockchainAndBackendFromGenesis(StakingSC)
+	require.NoError(t, err)
+	_, validators := getValidatorAccounts()
+	var (
+		number  = big.NewInt(stakingEpoch)
+		current = validators[:1]
+	)
+
+	t.Run("healthy staking contract", func(t *testing.T) {
+		parent := parentWithState(t, backend, current, func(*state.StateDB) {})
+		next, err := backend.getNextValSet(backend.chain, parent, number)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, validators, next.validators)
+	})
+
+	t.Run("self-destructed staking contract", func(t *testing.T) {
+		parent := parentWithState(t, backend, current, func(stateDB *state.StateDB) {
+			stateDB.Suicide(stakingScAddress)
+		})
+		next, err := backend.getNextValSet(backend.chain, parent, number)
+		require.NoError(t, err)
+		assert.Equal(t, current, next.validators)
+	})
+
+	t.Run("broken staking contract", func(t *testing.T) {
+		backend.config.UseEVMCaller = true
+		defer func() { backend.config.UseEVMCaller = false }()
+		parent := parentWithState(t, backend, current, func(stateDB *state.StateDB) {
+			stateDB.SetCode(stakingScAddress, []byte{0xfe})
+		})
+		next, err := backend.getNextValSet(backend.chain, parent, number)
+		require.NoError(t, err)
+		assert.Equal(t, current, next.validators)
+	})
+
+	t.Run("no validator set to carry forward", func(t *testing.T) {
+		parent := parentWithState(t, backend, nil, func(stateDB *state.StateDB) {
+			stateDB.Suicide(stakingScAddress)
+		})
+		_, err := backend.getNextValSet(backend.chain, parent, number)
+		assert.Equal(t, tendermint.ErrEmptyValSet, err)
+	})
+
+	t.Run("unknown ancestors in the uptime window", func(t *testing.T) {
+		config := backend.chain.Config().Tendermint
+		config.DowntimeJailingBlock = big.NewInt(0)
+		defer func() { config.DowntimeJailingBlock = nil }()
+		parent := parentWithState(t, backend, current, func(*state.StateDB) {})
+		parent.Number = big.NewInt(stakingEpoch - 1)
+		parent.ParentHash = common.HexToHash("0x01")
+		_, err := backend.getNextValSet(backend.chain, parent, number)
+		assert.Equal(t, consensus.ErrUnknownAncestor, err)
+	})
+
+	t.Run("missing state of the parent", func(t *testing.T) {
+		parent := types.CopyHeader(backend.chain.GetHeaderByNumber(0))
+		parent.Root = common.HexToHash("0x01")
+		_, err := backend.getNextValSet(backend.chain, parent, number)
+		assert.Equal(t, tendermint.ErrUnavailableValSet, err)
+	})
+}
+
+func TestVerifyCheckpointValSet(t *testing.T) {
+	backend, _, _, err := createBlockchainAndBackendFromGenesis(StakingSC)
+	require.NoError(t, err)
+	parent := backend.chain.GetHeaderByNumber(0)
+	newCheckpoint := func() *types.Header {
+		header := types.CopyHeader(parent)
+		header.Number = big.NewInt(stakingEpoch)
+		header.ParentHash = parent.Hash()
+		return header
+	}
+
+	header := newCheckpoint()
+	require.NoError(t, backend.addValSetToHeader(backend.chain, header, parent))
+	assert.NoError(t, backend.verifyCheckpointValSet(backend.chain, header, parent))
+
+	_, validators := getValidatorAccounts()
+	header = newCheckpoint()
+	require.NoError(t, utils.WriteValSet(header, validators[:2]))
+	assert.Equal(t, tendermint.ErrMismatchValSet, backend.verifyCheckpointValSet(backend.chain, header, parent))
+}
+
+func TestVerifyValSetHash(t *testing.T) {
+	backend, _, _, err := createBlockchainAndBackendFromGenesis(StakingSC)
+	require.NoError(t, err)
+	backend.config.ValSetHashBlock = big.NewInt(stakingEpoch)
+	defer func() { backend.config.ValSetHashBlock = nil }()
+	parent := backend.chain.GetHeaderByNumber(0)
+
+	header := types.CopyHeader(parent)
+	header.Number = big.NewInt(stakingEpoch)
+	header.ParentHash = parent.Hash()
+	require.NoError(t, backend.addValSetToHeader(backend.chain, header, parent))
+	assert.NoError(t, backend.verifyValSetHash(header))
+	hash := header.Hash()
+
+	// the validator set hash is covered by the hash of the header, the validator set is not
+	_, validators := getValidatorAccounts()
+	require.NoError(t, utils.WriteValSet(header, validators[:1]))
+	assert.Equal(t, hash, header.Hash())
+	assert.Equal(t, tendermint.ErrMismatchValSet, backend.verifyValSetHash(header))
+
+	require.NoError(t, utils.WriteValSetHash(header))
+	assert.NotEqual(t, hash, header.Hash())
+	assert.NoError(t, backend.verifyValSetHash(header))
+
+	// a checkpoint before the fork has no validator set hash
+	backend.config.ValSetHashBlock = big.NewInt(2 * stakingEpoch)
+	assert.Equal(t, tendermint.ErrInvalidValSetHash, backend.verifyValSetHash(header))
+}
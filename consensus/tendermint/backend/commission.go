@@ -0,0 +1,77 @@
+package backend
+
+import (
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+// processCommissionRates stores the commission rates set by the successful transactions of the block to the staking
+// contract.
+// A change must be sent by the candidate or its owner, be at most the maximum commission rate and differ from the
+// current rate by at most the maximum change. A candidate can change its rate once per epoch, the new rate applies
+// to the rewards of the epochs starting after it.
+func (sb *Backend) processCommissionRates(chain consensus.FullChainReader, header *types.Header, stateDB *state.StateDB, txs []*types.Transaction, receipts []*types.Receipt) error {
+	config := chain.Config().Tendermint
+	if config == nil || !config.IsCommission(header.Number) || len(config.FixedValidators) > 0 {
+		return nil
+	}
+	calls, err := sb.stakingCalls(txs, receipts, staking.CommissionRateSelector)
+	if err != nil {
+		return err
+	}
+	var (
+		number = header.Number.Uint64()
+		signer = types.MakeSigner(chain.Config(), header.Number)
+	)
+	for _, tx := range calls {
+		logger := log.New("number", header.Number, "tx", tx.Hash())
+		change, err := staking.DecodeCommissionRateChange(tx.Data())
+		if err != nil {
+			logger.Warn("ignored invalid commission rate change", "err", err)
+			continue
+		}
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			return err
+		}
+		validatorsData, err := sb.getStakingCaller(chain, stateDB, header).GetValidatorsData(sb.stakingContractAddr, []common.Address{change.Candidate})
+		if err != nil {
+			return err
+		}
+		owner := validatorsData[change.Candidate].Owner
+		if (owner == common.Address{}) || (sender != owner && sender != change.Candidate) {
+			logger.Warn("ignored commission rate change from an unauthorized sender", "candidate", change.Candidate, "sender", sender)
+			continue
+		}
+		if change.Rate > maxCommissionRate(config) {
+			logger.Warn("ignored commission rate above the maximum", "candidate", change.Candidate, "rate", change.Rate, "max", maxCommissionRate(config))
+			continue
+		}
+		if changedAt := staking.GetCommissionChangedAt(stateDB, sb.stakingContractAddr, change.Candidate); changedAt != 0 && (changedAt-1)/config.Epoch == (number-1)/config.Epoch {
+			logger.Warn("ignored commission rate changed twice in an epoch", "candidate", change.Candidate, "changedAt", changedAt)
+			continue
+		}
+		current := validatorsData[change.Candidate].CommissionRate
+		if maxChange := config.MaxCommissionRateChange; maxChange > 0 && (change.Rate > current+maxChange || change.Rate+maxChange < current) {
+			logger.Warn("ignored commission rate change above the maximum change", "candidate", change.Candidate,
+				"current", current, "rate", change.Rate, "maxChange", maxChange)
+			continue
+		}
+		staking.SetCommissionRate(stateDB, sb.stakingContractAddr, change.Candidate, change.Rate, number)
+		logger.Info("changed commission rate", "candidate", change.Candidate, "rate", change.Rate)
+	}
+	return nil
+}
+
+// maxCommissionRate returns the maximum commission rate of the candidates of the chain
+func maxCommissionRate(config *params.TendermintConfig) uint64 {
+	if config.MaxCommissionRate == 0 || config.MaxCommissionRate > 100 {
+		return 100
+	}
+	return config.MaxCommissionRate
+}
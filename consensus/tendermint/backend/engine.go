@@ -3,15 +3,14 @@ package backend
 import (
 	"bytes"
 	"errors"
+	"io"
 	"math/big"
-	"reflect"
 	"time"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
-	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
 	"github.com/Evrynetlabs/evrynet-node/core/state"
 	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
@@ -22,9 +21,6 @@ import (
 )
 
 var (
-	validatorRewardPercentage int64 = 50
-	voterRewardPercentage     int64 = 50
-
 	defaultDifficulty = big.NewInt(1)
 	now               = time.Now
 )
@@ -220,7 +216,7 @@ func (sb *Backend) Author(header *types.Header) (common.Address, error) {
 // given engine. Verifying the seal may be done optionally here, or explicitly
 // via the VerifySeal method.
 func (sb *Backend) VerifyHeader(chain consensus.ChainReader, header *types.Header, seal bool) error {
-	return sb.verifyHeader(chain, header, nil)
+	return sb.verifyHeader(chain, header, nil, true)
 }
 
 // VerifyProposalHeader will call be.verifyHeader for checking
@@ -234,28 +230,18 @@ func (sb *Backend) VerifyProposalHeader(header *types.Header) error {
 		if parent == nil {
 			return tendermint.ErrUnknownParent
 		}
-		validators, err := sb.getNextValidatorSet(sb.chain, parent)
-		if err != nil {
-			return err
-		}
-		// get validators's address from the extra-data
-		valSetInHeader, err := utils.GetValSetAddresses(header)
-		if err != nil {
-			log.Info("No validators in the extra-data", err)
+		if err := sb.verifyCheckpointValSet(sb.chain, header, parent); err != nil {
 			return err
 		}
-		if !reflect.DeepEqual(validators, valSetInHeader) {
-			return tendermint.ErrMismatchValSet
-		}
 	}
-	return sb.verifyHeader(sb.chain, header, nil)
+	return sb.verifyHeader(sb.chain, header, nil, true)
 }
 
 // verifyHeader checks whether a header conforms to the consensus rules.The
 // caller may optionally pass in a batch of parents (ascending order) to avoid
 // looking those up from the database. This is useful for concurrently verifying
-// a batch of new headers.
-func (sb *Backend) verifyHeader(chain consensus.ChainReader, header *types.Header, parents []*types.Header) error {
+// a batch of new headers. The proposal and committed seals are only verified if seal is true.
+func (sb *Backend) verifyHeader(chain consensus.ChainReader, header *types.Header, parents []*types.Header, seal bool) error {
 	if header.Number == nil {
 		return tendermint.ErrUnknownBlock
 	}
@@ -269,6 +255,9 @@ func (sb *Backend) verifyHeader(chain consensus.ChainReader, header *types.Heade
 	if _, err := types.ExtractTendermintExtra(header); err != nil {
 		return tendermint.ErrInvalidExtraDataFormat
 	}
+	if err := sb.verifyValSetHash(header); err != nil {
+		return err
+	}
 
 	// Ensure that the mix digest is zero as we don't have fork protection currently
 	if header.MixDigest != types.TendermintDigest {
@@ -279,14 +268,14 @@ func (sb *Backend) verifyHeader(chain consensus.ChainReader, header *types.Heade
 		return tendermint.ErrInvalidDifficulty
 	}
 
-	return sb.verifyCascadingFields(chain, header, parents)
+	return sb.verifyCascadingFields(chain, header, parents, seal)
 }
 
 // verifyCascadingFields verifies all the header fields that are not standalone,
 // rather depend on a batch of previous headers. The caller may optionally pass
 // in a batch of parents (ascending order) to avoid looking those up from the
 // database. This is useful for concurrently verifying a batch of new headers.
-func (sb *Backend) verifyCascadingFields(chain consensus.ChainReader, header *types.Header, parents []*types.Header) error {
+func (sb *Backend) verifyCascadingFields(chain consensus.ChainReader, header *types.Header, parents []*types.Header, seal bool) error {
 	// get block number from header of block
 	blockNumber := header.Number.Uint64()
 	if blockNumber == 0 {
@@ -307,6 +296,13 @@ func (sb *Backend) verifyCascadingFields(chain consensus.ChainReader, header *ty
 		//	return errInvalidTimestamp
 		log.Warn("block time difference is too small", "different in ms", header.Time-sb.config.BlockPeriod)
 	}
+	if err := sb.verifyCheckpoint(chain, header, parent); err != nil {
+		return err
+	}
+	// the seals of a header whose finality is proven by a descendant can be skipped, e.g. when syncing
+	if !seal {
+		return nil
+	}
 	// get val-sets to prepare for the verify proposal and committed seal
 	valSet, err := sb.getValSetFromChain(chain, header, parents)
 	if err != nil {
@@ -315,6 +311,9 @@ func (sb *Backend) verifyCascadingFields(chain consensus.ChainReader, header *ty
 	if err := sb.verifyProposalSeal(header, valSet); err != nil {
 		return err
 	}
+	if err := sb.verifyParentSeals(chain, header, parent, parents); err != nil {
+		return err
+	}
 
 	return sb.verifyCommittedSeals(header, valSet)
 }
@@ -351,11 +350,7 @@ func (sb *Backend) getValSetFromChain(chain consensus.ChainReader, header *types
 			}
 		}
 		if number%sb.config.Epoch == 0 {
-			validators, err := utils.GetValSetAddresses(currentHeader)
-			if err != nil {
-				return nil, err
-			}
-			return validator.NewSet(validators, sb.config.ProposerPolicy, int64(blockNumber)), nil
+			return sb.valSetFromCheckpoint(currentHeader, blockNumber)
 		}
 		number, hash = number-1, currentHeader.ParentHash
 	}
@@ -366,13 +361,14 @@ func (sb *Backend) getValSetFromChain(chain consensus.ChainReader, header *types
 // VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers
 // concurrently. The method returns a quit channel to abort the operations and
 // a results channel to retrieve the async verifications (the order is that of
-// the input slice).
+// the input slice). The seals of a header are skipped if its seals flag is false,
+// the caller must then prove its finality by other means.
 func (sb *Backend) VerifyHeaders(chain consensus.ChainReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
 	abort := make(chan struct{})
 	errorHeaders := make(chan error, len(headers))
 	go func() {
 		for i, header := range headers {
-			err := sb.verifyHeader(chain, header, headers[:i])
+			err := sb.verifyHeader(chain, header, headers[:i], i >= len(seals) || seals[i])
 
 			select {
 			case <-abort:
@@ -433,6 +429,18 @@ func (sb *Backend) Prepare(chain consensus.FullChainReader, header *types.Header
 
 	// prepare extra data without validators
 	header.Extra = sb.prepareExtra(header)
+	if evidences := sb.pendingEvidences(chain, header, parent); len(evidences) > 0 {
+		if err := utils.WriteEvidences(header, evidences); err != nil {
+			log.Error("failed to add evidences to header", "err", err)
+		}
+	}
+
+	if sb.sealsParentCommit(chain, header.Number) {
+		if err := sb.addParentSeals(header, parent); err != nil {
+			log.Error("failed to add parent seals to header", "err", err)
+			return err
+		}
+	}
 
 	// set header's timestamp from parent's timestamp and blockperiod
 	var (
@@ -447,8 +455,10 @@ func (sb *Backend) Prepare(chain consensus.FullChainReader, header *types.Header
 		header.Time = headerTime.Uint64()
 	}
 
+	// refuse to propose a checkpoint block without its validator set
 	if err := sb.addValSetToHeader(chain, header, parent); err != nil {
 		log.Error("failed to add val set to header", "err", err)
+		return err
 	}
 
 	return nil
@@ -459,16 +469,45 @@ func (sb *Backend) Prepare(chain consensus.FullChainReader, header *types.Header
 // Note, the block header and state database might be updated to reflect any
 // consensus rules that happen at finalization (e.g. block rewards).
 func (sb *Backend) Finalize(chain consensus.FullChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction,
-	uncles []*types.Header) error {
+	uncles []*types.Header, receipts []*types.Receipt) error {
+	if parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1); parent != nil {
+		if err := sb.verifyCheckpoint(chain, header, parent); err != nil {
+			log.Error("invalid validator set in checkpoint", "number", header.Number, "err", err)
+			return err
+		}
+	}
+	sb.storeParentEpochRewards(chain, header)
 	// Accumulate any block rewards and commit the final state root
-	if err := sb.accumulateRewards(chain, state, header); err != nil {
+	rewards, err := sb.accumulateRewards(chain, state, header)
+	if err != nil {
 		log.Error("failed to accumulateRewards", "err", err)
 		return err
 	}
+	if err := sb.processUnbondings(chain, header, state, receipts); err != nil {
+		log.Error("failed to record unbonding stakes", "err", err)
+		return err
+	}
+	if err := sb.processEvidences(chain, header, state); err != nil {
+		log.Error("failed to process evidences", "err", err)
+		return err
+	}
+	if err := sb.processBLSRegistrations(chain, header, state, txs, receipts); err != nil {
+		log.Error("failed to process bls key registrations", "err", err)
+		return err
+	}
+	if err := sb.processGovernance(chain, header, state, txs, receipts); err != nil {
+		log.Error("failed to process chain parameter votes", "err", err)
+		return err
+	}
+	if err := sb.processCommissionRates(chain, header, state, txs, receipts); err != nil {
+		log.Error("failed to process commission rate changes", "err", err)
+		return err
+	}
 
 	// Since there is a change in stateDB, its trie must be update
 	// In case block reached EIP158 hash, the state will attempt to delete empty object as EIP158 sepcification
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
+	sb.cacheEpochRewards(header, rewards)
 	return nil
 }
 
@@ -479,20 +518,63 @@ func (sb *Backend) Finalize(chain consensus.FullChainReader, header *types.Heade
 // consensus rules that happen at finalization (e.g. block rewards).
 func (sb *Backend) FinalizeAndAssemble(chain consensus.FullChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction,
 	uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	sb.storeParentEpochRewards(chain, header)
 	// Accumulate any block rewards and commit the final state root
-	if err := sb.accumulateRewards(chain, state, header); err != nil {
+	rewards, err := sb.accumulateRewards(chain, state, header)
+	if err != nil {
 		log.Error("failed to accumulateRewards", "err", err)
 		return nil, err
 	}
+	if err := sb.processUnbondings(chain, header, state, receipts); err != nil {
+		log.Error("failed to record unbonding stakes", "err", err)
+		return nil, err
+	}
+	if err := sb.processEvidences(chain, header, state); err != nil {
+		log.Error("failed to process evidences", "err", err)
+		return nil, err
+	}
+	if err := sb.processBLSRegistrations(chain, header, state, txs, receipts); err != nil {
+		log.Error("failed to process bls key registrations", "err", err)
+		return nil, err
+	}
+	if err := sb.processGovernance(chain, header, state, txs, receipts); err != nil {
+		log.Error("failed to process chain parameter votes", "err", err)
+		return nil, err
+	}
+	if err := sb.processCommissionRates(chain, header, state, txs, receipts); err != nil {
+		log.Error("failed to process commission rate changes", "err", err)
+		return nil, err
+	}
 
 	// No block rewards, so the state remains as is and uncles are dropped
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
 	header.UncleHash = types.CalcUncleHash(nil)
+	sb.cacheEpochRewards(header, rewards)
 
 	// Assemble and return the final block for sealing
 	return types.NewBlock(header, txs, nil, receipts), nil
 }
 
+// stakingCalls returns the transactions of the block calling the staking contract with the selector which succeeded.
+// The node processes these calls itself, a reverted transaction must not change the state.
+func (sb *Backend) stakingCalls(txs []*types.Transaction, receipts []*types.Receipt, selector []byte) ([]*types.Transaction, error) {
+	if len(txs) != len(receipts) {
+		return nil, tendermint.ErrMismatchReceipts
+	}
+	var calls []*types.Transaction
+	for i, tx := range txs {
+		if tx.To() == nil || *tx.To() != sb.stakingContractAddr || !bytes.HasPrefix(tx.Data(), selector) {
+			continue
+		}
+		if receipts[i].Status != types.ReceiptStatusSuccessful {
+			log.Warn("ignored call to the staking contract of a failed transaction", "tx", tx.Hash())
+			continue
+		}
+		calls = append(calls, tx)
+	}
+	return calls, nil
+}
+
 // SealHash returns the hash of a block prior to it being sealed.
 func (sb *Backend) SealHash(header *types.Header) (hash common.Hash) {
 	return utils.SigHash(header)
@@ -517,6 +599,9 @@ func (sb *Backend) APIs(chain consensus.ChainReader) []rpc.API {
 func (sb *Backend) Close() error {
 	close(sb.closingBackgroundThreadsCh)
 
+	if closer, ok := sb.recorder.(io.Closer); ok {
+		return closer.Close()
+	}
 	return nil
 }
 
@@ -546,17 +631,56 @@ func (sb *Backend) verifyCommittedSeals(header *types.Header, valSet tendermint.
 	if err != nil {
 		return err
 	}
+	return verifySeals(header.Hash(), extra.CommittedSeal, extra.AggregatedSeal, valSet)
+}
+
+// verifyParentSeals checks that the parent seals of the header commit its parent, they are required once the uptime
+// of validators is computed from them.
+func (sb *Backend) verifyParentSeals(chain consensus.ChainReader, header *types.Header, parent *types.Header, parents []*types.Header) error {
+	extra, err := types.ExtractTendermintExtra(header)
+	if err != nil {
+		return err
+	}
+	if !sb.sealsParentCommit(chain, header.Number) {
+		if len(extra.ParentCommittedSeal) > 0 || extra.ParentAggregatedSeal != nil {
+			return tendermint.ErrInvalidParentSeals
+		}
+		return nil
+	}
+	if len(parents) > 0 {
+		parents = parents[:len(parents)-1]
+	}
+	valSet, err := sb.getValSetFromChain(chain, parent, parents)
+	if err != nil {
+		return err
+	}
+	if err := verifySeals(parent.Hash(), extra.ParentCommittedSeal, extra.ParentAggregatedSeal, valSet); err != nil {
+		log.Warn("invalid parent seals", "number", header.Number, "err", err)
+		return tendermint.ErrInvalidParentSeals
+	}
+	return nil
+}
+
+// verifySeals checks that the committed seals or the aggregated seal of the block hash are signed by validators
+// having at least the minimum majority of the voting power
+func verifySeals(hash common.Hash, committedSeal [][]byte, aggregatedSeal *types.TendermintAggregatedSeal, valSet tendermint.ValidatorSet) error {
+	if valSet.AggregatesCommits() {
+		return verifyAggregatedSeal(hash, aggregatedSeal, valSet)
+	}
+	if aggregatedSeal != nil {
+		return tendermint.ErrInvalidAggregatedSeal
+	}
 	// The length of Committed seals should be larger than 0
-	if len(extra.CommittedSeal) == 0 {
+	if len(committedSeal) == 0 {
 		return tendermint.ErrEmptyCommittedSeals
 	}
 
 	vals := valSet.Copy()
 	// Check whether the committed seals are generated by parent's validators
 	validSeal := 0
-	proposalSeal := utils.PrepareCommittedSeal(header.Hash())
+	proposalSeal := utils.PrepareCommittedSeal(hash)
 	// 1. Get committed seals from current header
-	for _, seal := range extra.CommittedSeal {
+	for _, seal := range committedSeal {
 		// 2. Get the original address by seal and parent block hash
 		addr, err := utils.GetSignatureAddress(proposalSeal, seal)
 		if err != nil {
@@ -565,14 +689,15 @@ func (sb *Backend) verifyCommittedSeals(header *types.Header, valSet tendermint.
 		}
 		// Every validator can have only one seal. If more than one seals are signed by a
 		// validator, the validator cannot be found and errInvalidCommittedSeals is returned.
-		if vals.RemoveValidator(addr) {
-			validSeal++
+		_, val := vals.GetByAddress(addr)
+		if val != nil && vals.RemoveValidator(addr) {
+			validSeal += int(val.VotingPower())
 		} else {
 			return tendermint.ErrInvalidCommittedSeals
 		}
 	}
 
-	// The length of validSeal should be larger or equal than min majority (num validator - maximum faulty)
+	// The voting power of validSeal should be larger or equal than min majority (total voting power - maximum faulty)
 	if validSeal < valSet.MinMajority() {
 		return tendermint.ErrInvalidCommittedSeals
 	}
@@ -604,22 +729,27 @@ func (sb *Backend) addValSetToHeader(chainReader consensus.FullChainReader, head
 		epoch       = sb.config.Epoch
 	)
 
-	if blockNumber%epoch != 0 {
-		// ignore if this block is not the end of epoch
+	if blockNumber%epoch != 0 || len(sb.config.FixedValidators) > 0 {
+		// ignore if this block is not the end of epoch or the validators are fixed
 		return nil
 	}
 
-	validators, err := sb.getNextValidatorSet(chainReader, parent)
+	next, err := sb.getNextValSet(chainReader, parent, header.Number)
 	if err != nil {
 		return err
 	}
-
 	log.Info("sets the val-set back to extra-data", "number", blockNumber)
-	return utils.WriteValSet(header, validators)
+	if err := next.writeTo(header); err != nil {
+		return err
+	}
+	if sb.config.IsValSetHash(header.Number) {
+		return utils.WriteValSetHash(header)
+	}
+	return nil
 }
 
 func (sb *Backend) getNextValidatorSet(chainReader consensus.FullChainReader, header *types.Header) ([]common.Address, error) {
-	if validators, known := sb.computedValSetCache.Get(header.Number.Uint64()); known {
+	if validators, known := sb.computedValSetCache.Get(header.Hash()); known {
 		if addresses, ok := validators.([]common.Address); ok {
 			return addresses, nil
 		}
@@ -635,7 +765,11 @@ func (sb *Backend) getNextValidatorSet(chainReader consensus.FullChainReader, he
 	if err != nil {
 		return nil, err
 	}
-	sb.computedValSetCache.Add(header.Number.Uint64(), validators)
+	validators, err = sb.excludeOfflineValidators(chainReader, header, validators)
+	if err != nil {
+		return nil, err
+	}
+	sb.computedValSetCache.Add(header.Hash(), validators)
 	log.Info("found new val set", "number", header.Number.Uint64(), "elapsed", common.PrettyDuration(time.Since(start)),
 		"valset", common.PrettyAddresses(validators))
 	return validators, nil
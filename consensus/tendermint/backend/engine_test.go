@@ -276,3 +276,32 @@ func TestBackend_VerifyHeaders(t *testing.T) {
 		require.NoError(t, re)
 	}
 }
+
+func TestStakingCalls(t *testing.T) {
+	var (
+		scAddress = common.HexToAddress("0x1")
+		other     = common.HexToAddress("0x2")
+		selector  = []byte{1, 2, 3, 4}
+		be        = &Backend{stakingContractAddr: scAddress}
+		succeeded = types.NewTransaction(0, scAddress, big.NewInt(0), 0, big.NewInt(0), append(selector, 5))
+		reverted  = types.NewTransaction(1, scAddress, big.NewInt(0), 0, big.NewInt(0), selector)
+		txs       = []*types.Transaction{
+			succeeded,
+			reverted,
+			types.NewTransaction(2, scAddress, big.NewInt(0), 0, big.NewInt(0), []byte{4, 3, 2, 1}),
+			types.NewTransaction(3, other, big.NewInt(0), 0, big.NewInt(0), selector),
+		}
+		receipts = []*types.Receipt{
+			{Status: types.ReceiptStatusSuccessful},
+			{Status: types.ReceiptStatusFailed},
+			{Status: types.ReceiptStatusSuccessful},
+			{Status: types.ReceiptStatusSuccessful},
+		}
+	)
+	calls, err := be.stakingCalls(txs, receipts, selector)
+	require.NoError(t, err)
+	require.Equal(t, []*types.Transaction{succeeded}, calls)
+
+	_, err = be.stakingCalls(txs, receipts[:1], selector)
+	require.Equal(t, tendermint.ErrMismatchReceipts, err)
+}
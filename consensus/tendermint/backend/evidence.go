@@ -0,0 +1,258 @@
+package backend
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/log"
+)
+
+const (
+	// maxEvidencesPerBlock is the maximum number of evidences a proposer includes in a block
+	maxEvidencesPerBlock = 16
+)
+
+// pendingEvidence is an evidence which is not included in the canonical chain yet
+type pendingEvidence struct {
+	evidence *types.TendermintEvidence
+	offense  *tendermintCore.Offense
+}
+
+// evidencePool keeps the evidences reported by core until they are too old to be included in a block.
+// It keeps a single evidence per offense, as any pair of the conflicting votes proves the same offense.
+type evidencePool struct {
+	mu        sync.Mutex
+	evidences map[common.Hash]*pendingEvidence
+}
+
+func newEvidencePool() *evidencePool {
+	return &evidencePool{
+		evidences: make(map[common.Hash]*pendingEvidence),
+	}
+}
+
+// add adds the evidence of the offense to the pool, returns false if the offense is already in the pool
+func (p *evidencePool) add(ev *types.TendermintEvidence, offense *tendermintCore.Offense) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	hash := offense.Hash()
+	if _, ok := p.evidences[hash]; ok {
+		return false
+	}
+	p.evidences[hash] = &pendingEvidence{
+		evidence: ev,
+		offense:  offense,
+	}
+	return true
+}
+
+// pending returns the evidences of blocks from minBlockNumber and removes the older ones
+func (p *evidencePool) pending(minBlockNumber uint64) []*pendingEvidence {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var evidences []*pendingEvidence
+	for hash, pending := range p.evidences {
+		if pending.offense.BlockNumber.Uint64() < minBlockNumber {
+			delete(p.evidences, hash)
+			continue
+		}
+		evidences = append(evidences, pending)
+	}
+	return evidences
+}
+
+// remove removes the evidence of the offense from the pool
+func (p *evidencePool) remove(hash common.Hash) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.evidences, hash)
+}
+
+// punishmentKey returns the key recording that the offender is punished for the votes it signed at the block
+// number of the offense, a validator is slashed and jailed at most once per block number.
+func punishmentKey(offense *tendermintCore.Offense) common.Hash {
+	return crypto.Keccak256Hash(offense.Offender.Bytes(), common.BigToHash(offense.BlockNumber).Bytes())
+}
+
+// ReportEvidence implements tendermint.Backend.ReportEvidence
+func (sb *Backend) ReportEvidence(ev *types.TendermintEvidence) bool {
+	offense, err := tendermintCore.VerifyEvidence(ev)
+	if err != nil {
+		log.Warn("reported evidence is invalid", "err", err)
+		return false
+	}
+	valSet := sb.Validators(offense.BlockNumber)
+	if valSet == nil {
+		return false
+	}
+	if index, _ := valSet.GetByAddress(offense.Offender); index == -1 {
+		log.Warn("offender of the reported evidence is not a validator", "offender", offense.Offender, "number", offense.BlockNumber)
+		return false
+	}
+	return sb.evidencePool.add(ev, offense)
+}
+
+// pendingEvidences returns the evidences to be included in the block of header, at most one per offender and
+// block number. The evidences are removed from the pool once their offenders are punished in the parent state,
+// that is once they are committed.
+func (sb *Backend) pendingEvidences(chain consensus.FullChainReader, header *types.Header, parent *types.Header) []*types.TendermintEvidence {
+	config := chain.Config().Tendermint
+	if config == nil || !config.IsSlashing(header.Number) || len(config.FixedValidators) > 0 {
+		return nil
+	}
+	var minBlockNumber uint64
+	if number := header.Number.Uint64(); number > sb.config.Epoch {
+		minBlockNumber = number - sb.config.Epoch
+	}
+	candidates := sb.evidencePool.pending(minBlockNumber)
+	if len(candidates) == 0 {
+		return nil
+	}
+	stateDB, err := chain.StateAt(parent.Root)
+	if err != nil {
+		log.Error("failed to get parent state to check evidences", "err", err)
+		return nil
+	}
+	var (
+		evidences []*types.TendermintEvidence
+		included  = make(map[common.Hash]bool)
+		slasher   = sb.getStakingSlasher(stateDB)
+	)
+	for _, pending := range candidates {
+		key := punishmentKey(pending.offense)
+		if slasher.IsOffensePunished(sb.stakingContractAddr, key) {
+			sb.evidencePool.remove(pending.offense.Hash())
+			continue
+		}
+		if included[key] {
+			continue
+		}
+		included[key] = true
+		evidences = append(evidences, pending.evidence)
+		if len(evidences) >= maxEvidencesPerBlock {
+			break
+		}
+	}
+	return evidences
+}
+
+// processEvidences slashes and jails the offenders of the evidences included in the header.
+// It returns an error if any evidence is invalid, so that the block is rejected.
+// An offender is punished at most once per block number, whatever the number of its conflicting votes.
+// Evidences are ignored before the slashing fork and when the validators are fixed.
+// The jailed candidates whose jail time is over are released at checkpoint blocks.
+func (sb *Backend) processEvidences(chain consensus.FullChainReader, header *types.Header, stateDB *state.StateDB) error {
+	var (
+		config = chain.Config().Tendermint
+		number = header.Number.Uint64()
+		epoch  = sb.config.Epoch
+	)
+	if config == nil || !config.IsSlashing(header.Number) || len(config.FixedValidators) > 0 {
+		return nil
+	}
+	extra, err := types.ExtractTendermintExtra(header)
+	if err != nil {
+		return err
+	}
+	if len(extra.Evidences) > maxEvidencesPerBlock {
+		return errors.Wrap(tendermint.ErrInvalidEvidence, "too many evidences")
+	}
+
+	slasher := sb.getStakingSlasher(stateDB)
+	if number%epoch == 0 {
+		if released := slasher.ReleaseJailed(sb.stakingContractAddr, number); len(released) > 0 {
+			log.Info("released jailed candidates", "number", number, "candidates", common.PrettyAddresses(released))
+		}
+	}
+
+	for _, ev := range extra.Evidences {
+		offense, err := tendermintCore.VerifyEvidence(ev)
+		if err != nil {
+			return err
+		}
+		evBlockNumber := offense.BlockNumber
+		if evBlockNumber.Uint64() >= number || evBlockNumber.Uint64()+epoch < number {
+			return errors.Wrapf(tendermint.ErrInvalidEvidence, "evidence of block %d is out of range", evBlockNumber.Uint64())
+		}
+		// the offenses punished by the previous evidences of this block are marked in the state too
+		key := punishmentKey(offense)
+		if slasher.IsOffensePunished(sb.stakingContractAddr, key) {
+			return errors.Wrapf(tendermint.ErrInvalidEvidence, "offender %s is already punished for block %d",
+				offense.Offender.Hex(), evBlockNumber.Uint64())
+		}
+		valSet, err := sb.valSetInfo.GetValSet(chain, evBlockNumber)
+		if err != nil {
+			return err
+		}
+		if index, _ := valSet.GetByAddress(offense.Offender); index == -1 {
+			return errors.Wrapf(tendermint.ErrInvalidEvidence, "offender %s is not a validator at block %d", offense.Offender.Hex(), evBlockNumber.Uint64())
+		}
+		slasher.MarkOffensePunished(sb.stakingContractAddr, key, number)
+		slashed := slasher.Slash(sb.stakingContractAddr, offense.Offender, config.SlashRate, number)
+		releaseBlock := utils.GetCheckpointNumber(epoch, number) + (config.JailEpochs+1)*epoch
+		slasher.Jail(sb.stakingContractAddr, offense.Offender, releaseBlock)
+		log.Warn("slashed validator for signing conflicting votes", "offender", offense.Offender, "evidence_block", evBlockNumber,
+			"slashed", slashed, "release_block", releaseBlock)
+	}
+	return nil
+}
+
+// processUnbondings records the candidate of the stakes unvoted by the successful transactions of the block, so that
+// they are only slashed for the offenses of their candidate. They are recorded before the evidences of the block are
+// processed, unvoting in the block of the evidence does not escape the penalty.
+func (sb *Backend) processUnbondings(chain consensus.FullChainReader, header *types.Header, stateDB *state.StateDB, receipts []*types.Receipt) error {
+	config := chain.Config().Tendermint
+	if config == nil || !config.IsSlashing(header.Number) || len(config.FixedValidators) > 0 {
+		return nil
+	}
+	var logs []*types.Log
+	for _, receipt := range receipts {
+		logs = append(logs, receipt.Logs...)
+	}
+	if len(logs) == 0 {
+		return nil
+	}
+	// the stakes before the block are only read when a candidate resigns
+	var parentCaller staking.StakingCaller
+	parentStake := func(candidate, voter common.Address) (*big.Int, error) {
+		if parentCaller == nil {
+			parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+			if parent == nil {
+				return nil, consensus.ErrUnknownAncestor
+			}
+			parentState, err := chain.StateAt(parent.Root)
+			if err != nil {
+				return nil, err
+			}
+			parentCaller = sb.getStakingCaller(chain, parentState, parent)
+		}
+		data, err := parentCaller.GetValidatorsData(sb.stakingContractAddr, []common.Address{candidate})
+		if err != nil {
+			return nil, err
+		}
+		if stake := data[candidate].VoterStakes[voter]; stake != nil {
+			return stake, nil
+		}
+		return new(big.Int), nil
+	}
+	return sb.getStakingSlasher(stateDB).RecordUnbondings(sb.stakingContractAddr, logs, parentStake)
+}
+
+func (sb *Backend) getStakingSlasher(stateDB *state.StateDB) staking.StakingSlasher {
+	indexCfg := sb.config.IndexStateVariables
+	if indexCfg == nil {
+		indexCfg = staking.DefaultConfig
+	}
+	return staking.NewStateDbStakingSlasher(stateDB, indexCfg)
+}
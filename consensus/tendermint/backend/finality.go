@@ -0,0 +1,77 @@
+package backend
+
+import (
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+)
+
+// Epoch returns the number of blocks between two checkpoints
+func (sb *Backend) Epoch() uint64 {
+	return sb.config.Epoch
+}
+
+// VerifyFinality checks that the header is committed by the validator set stored in its checkpoint header.
+// Unlike VerifyHeader, the parent of the header is not required, which lets a light client skip the headers
+// of an epoch: once a checkpoint is final, the validator set it stores can verify any header of the next epoch,
+// including the next checkpoint.
+//
+// The validator set of a checkpoint is only trusted if it matches the validator set hash sealed in the checkpoint,
+// which is the case after the validator set hash fork: the validator set of an earlier checkpoint is not covered by
+// its committed seals.
+func (sb *Backend) VerifyFinality(checkpoint *types.Header, header *types.Header) error {
+	if header.Number == nil || checkpoint.Number == nil || header.Number.Sign() == 0 {
+		return tendermint.ErrUnknownBlock
+	}
+	blockNumber := header.Number.Uint64()
+	if checkpoint.Number.Uint64() != utils.GetCheckpointNumber(sb.config.Epoch, blockNumber) {
+		return tendermint.ErrInvalidCheckpoint
+	}
+	if _, err := types.ExtractTendermintExtra(header); err != nil {
+		return tendermint.ErrInvalidExtraDataFormat
+	}
+	if header.MixDigest != types.TendermintDigest {
+		return tendermint.ErrInvalidMixDigest
+	}
+	if header.Difficulty == nil || header.Difficulty.Cmp(defaultDifficulty) != 0 {
+		return tendermint.ErrInvalidDifficulty
+	}
+	// the header may be the checkpoint of the next epoch
+	if err := sb.verifyValSetHash(header); err != nil {
+		return err
+	}
+	valSet, err := sb.valSetFromCheckpoint(checkpoint, blockNumber)
+	if err != nil {
+		return err
+	}
+	if err := sb.verifyProposalSeal(header, valSet); err != nil {
+		return err
+	}
+	return sb.verifyCommittedSeals(header, valSet)
+}
+
+// valSetFromCheckpoint returns the validator set of the block number from the extra-data of its checkpoint header
+func (sb *Backend) valSetFromCheckpoint(checkpoint *types.Header, blockNumber uint64) (tendermint.ValidatorSet, error) {
+	if len(sb.config.FixedValidators) > 0 {
+		return sb.valSetInfo.GetValSet(nil, new(big.Int).SetUint64(blockNumber))
+	}
+	if err := sb.verifyValSetHash(checkpoint); err != nil {
+		return nil, err
+	}
+	validators, err := utils.GetValSetAddresses(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	votingPowers, err := utils.GetValSetVotingPowers(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	blsPublicKeys, err := utils.GetValSetBLSPublicKeys(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	return validator.NewWeightedSet(validators, votingPowers, blsPublicKeys, sb.config.ProposerPolicy, int64(blockNumber), checkpoint.Number.Int64()), nil
+}
@@ -0,0 +1,129 @@
+package backend
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+// makeFinalityKeys returns n validator keys and their addresses
+func makeFinalityKeys(n int) ([]*ecdsa.PrivateKey, []common.Address) {
+	var (
+		keys  []*ecdsa.PrivateKey
+		addrs []common.Address
+	)
+	for i := 0; i < n; i++ {
+		key := tests_utils.MakeNodeKey()
+		keys = append(keys, key)
+		addrs = append(addrs, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	return keys, addrs
+}
+
+// sealFinality seals the header proposed by the first key and committed by the first committers keys
+func sealFinality(header *types.Header, keys []*ecdsa.PrivateKey, committers int) {
+	header.Coinbase = crypto.PubkeyToAddress(keys[0].PublicKey)
+	tests_utils.AppendSealByPkKey(header, keys[0])
+	tests_utils.AppendCommitedSealByPkKeys(header, keys[:committers])
+}
+
+func TestBackend_VerifyFinality(t *testing.T) {
+	var (
+		oldKeys, oldValidators = makeFinalityKeys(3)
+		newKeys, newValidators = makeFinalityKeys(3)
+		config                 = *tendermint.DefaultConfig
+	)
+	config.Epoch = 4
+	config.FixedValidators = nil
+	engine := New(&config, oldKeys[0]).(*Backend)
+
+	// build the first epoch, its last block is the checkpoint of the next epoch
+	headers := []*types.Header{tests_utils.MakeGenesisHeader(oldValidators)}
+	for i := 1; i <= 5; i++ {
+		header := tests_utils.MakeBlockWithoutSeal(headers[i-1]).Header()
+		if i == 4 {
+			require.NoError(t, utils.WriteValSet(header, newValidators))
+		}
+		if i <= 4 {
+			sealFinality(header, oldKeys, 3)
+		} else {
+			sealFinality(header, newKeys, 3)
+		}
+		headers = append(headers, header)
+	}
+
+	assert.NoError(t, engine.VerifyFinality(headers[0], headers[1]))
+	assert.NoError(t, engine.VerifyFinality(headers[0], headers[4]))
+	assert.NoError(t, engine.VerifyFinality(headers[4], headers[5]))
+
+	// a header must be verified against the checkpoint of its epoch
+	assert.Equal(t, tendermint.ErrInvalidCheckpoint, engine.VerifyFinality(headers[4], headers[1]))
+	assert.Equal(t, tendermint.ErrInvalidCheckpoint, engine.VerifyFinality(headers[0], headers[5]))
+
+	// the validators of the previous epoch can not commit a block of the next epoch
+	header := tests_utils.MakeBlockWithoutSeal(headers[4]).Header()
+	sealFinality(header, oldKeys, 3)
+	assert.Equal(t, tendermint.ErrUnauthorized, engine.VerifyFinality(headers[4], header))
+
+	// 1 of 3 validators is not a majority
+	header = tests_utils.MakeBlockWithoutSeal(headers[4]).Header()
+	sealFinality(header, newKeys, 1)
+	assert.Equal(t, tendermint.ErrInvalidCommittedSeals, engine.VerifyFinality(headers[4], header))
+}
+
+func TestBackend_VerifyFinalitySealedValSet(t *testing.T) {
+	var (
+		oldKeys, oldValidators = makeFinalityKeys(3)
+		newKeys, newValidators = makeFinalityKeys(3)
+		forgedKeys, forged     = makeFinalityKeys(3)
+		config                 = *tendermint.DefaultConfig
+	)
+	config.Epoch = 4
+	config.FixedValidators = nil
+	config.ValSetHashBlock = common.Big1
+	engine := New(&config, oldKeys[0]).(*Backend)
+
+	genesis := tests_utils.MakeGenesisHeader(oldValidators)
+	checkpoint := tests_utils.MakeBlockWithoutSeal(genesis).Header()
+	checkpoint.Number = big.NewInt(4)
+	require.NoError(t, utils.WriteValSet(checkpoint, newValidators))
+	require.NoError(t, utils.WriteValSetHash(checkpoint))
+	sealFinality(checkpoint, oldKeys, 3)
+	header := tests_utils.MakeBlockWithoutSeal(checkpoint).Header()
+	sealFinality(header, newKeys, 3)
+
+	require.NoError(t, engine.VerifyFinality(genesis, checkpoint))
+	require.NoError(t, engine.VerifyFinality(checkpoint, header))
+
+	// a peer replacing the validator set of the committed checkpoint can not prove the finality of its own headers
+	tamper := func(write func(*types.Header) error) *types.Header {
+		tampered := types.CopyHeader(checkpoint)
+		require.NoError(t, write(tampered))
+		require.Equal(t, checkpoint.Hash(), tampered.Hash())
+		return tampered
+	}
+	tampered := tamper(func(h *types.Header) error { return utils.WriteValSet(h, forged) })
+	forgedHeader := tests_utils.MakeBlockWithoutSeal(tampered).Header()
+	sealFinality(forgedHeader, forgedKeys, 3)
+	assert.Equal(t, tendermint.ErrMismatchValSet, engine.VerifyFinality(genesis, tampered))
+	assert.Equal(t, tendermint.ErrMismatchValSet, engine.VerifyFinality(tampered, forgedHeader))
+
+	tampered = tamper(func(h *types.Header) error { return utils.WriteBLSPublicKeys(h, [][]byte{{1}, {2}, {3}}) })
+	assert.Equal(t, tendermint.ErrMismatchValSet, engine.VerifyFinality(genesis, tampered))
+	assert.Equal(t, tendermint.ErrMismatchValSet, engine.VerifyFinality(tampered, header))
+
+	// updating the validator set hash too changes the hash of the checkpoint, its committed seals are invalid
+	tampered = tamper(func(h *types.Header) error { return utils.WriteValSet(h, forged) })
+	require.NoError(t, utils.WriteValSetHash(tampered))
+	assert.Error(t, engine.VerifyFinality(genesis, tampered))
+}
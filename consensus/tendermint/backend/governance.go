@@ -0,0 +1,117 @@
+package backend
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/core/governance"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/log"
+)
+
+// processGovernance records the votes on the chain parameters sent to the staking contract in the successful
+// transactions of the block. At a checkpoint, it activates the values scheduled for it, then schedules the values
+// voted by a majority of the validator set to activate at the next checkpoint.
+func (sb *Backend) processGovernance(chain consensus.FullChainReader, header *types.Header, stateDB *state.StateDB, txs []*types.Transaction, receipts []*types.Receipt) error {
+	if !governance.Enabled(chain.Config(), header.Number) {
+		return nil
+	}
+	calls, err := sb.stakingCalls(txs, receipts, governance.VoteSelector)
+	if err != nil {
+		return err
+	}
+	var (
+		number = header.Number.Uint64()
+		epoch  = sb.config.Epoch
+		signer = types.MakeSigner(chain.Config(), header.Number)
+	)
+	for _, tx := range calls {
+		logger := log.New("number", header.Number, "tx", tx.Hash())
+		vote, err := governance.DecodeVote(tx.Data())
+		if err != nil {
+			logger.Warn("ignored invalid chain parameter vote", "err", err)
+			continue
+		}
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			return err
+		}
+		validatorsData, err := sb.getStakingCaller(chain, stateDB, header).GetValidatorsData(sb.stakingContractAddr, []common.Address{vote.Voter})
+		if err != nil {
+			return err
+		}
+		owner := validatorsData[vote.Voter].Owner
+		if (owner == common.Address{}) || (sender != owner && sender != vote.Voter) {
+			logger.Warn("ignored chain parameter vote from an unauthorized sender", "voter", vote.Voter, "sender", sender)
+			continue
+		}
+		governance.SetVote(stateDB, sb.stakingContractAddr, vote, number)
+		logger.Info("recorded chain parameter vote", "voter", vote.Voter, "param", vote.Param, "value", vote.Value)
+	}
+	if number%epoch != 0 {
+		return nil
+	}
+
+	for _, param := range governance.Activate(stateDB, sb.stakingContractAddr, number) {
+		log.Info("activated chain parameter", "number", number, "param", param,
+			"value", governance.GetParam(stateDB, sb.stakingContractAddr, param))
+	}
+	valSet, err := sb.valSetInfo.GetValSet(chain, header.Number)
+	if err != nil {
+		return err
+	}
+	var (
+		quorum       = int64(valSet.MinMajority())
+		votingPowers = make(map[common.Address]int64, valSet.Size())
+	)
+	for _, val := range valSet.List() {
+		votingPowers[val.Address()] += val.VotingPower()
+	}
+	for _, param := range governance.ChainParams {
+		value := governance.Tally(stateDB, sb.stakingContractAddr, param, votingPowers, quorum)
+		if value == nil {
+			continue
+		}
+		governance.Schedule(stateDB, sb.stakingContractAddr, param, value, number, number+epoch)
+		log.Info("scheduled chain parameter change", "number", number, "param", param, "value", value, "activation", number+epoch)
+	}
+	return nil
+}
+
+// Config implements tendermint.Backend.Config
+func (sb *Backend) Config(blockNumber *big.Int) *tendermint.Config {
+	if sb.chain == nil || blockNumber.Sign() <= 0 || !governance.Enabled(sb.chain.Config(), blockNumber) {
+		return sb.config
+	}
+	parent := sb.chain.GetHeaderByNumber(blockNumber.Uint64() - 1)
+	if parent == nil {
+		return sb.config
+	}
+	stateDB, err := sb.chain.StateAt(parent.Root)
+	if err != nil {
+		log.Warn("failed to get the state of the governed timeouts", "number", blockNumber, "err", err)
+		return sb.config
+	}
+	return governedConfig(sb.chain, sb.config, stateDB)
+}
+
+// governedConfig returns a copy of the config with the timeouts governed in the state
+func governedConfig(chain consensus.ChainReader, config *tendermint.Config, stateDB *state.StateDB) *tendermint.Config {
+	cfg := *config
+	timeouts := map[governance.ChainParam]*time.Duration{
+		governance.ParamTimeoutPropose:   &cfg.TimeoutPropose,
+		governance.ParamTimeoutPrevote:   &cfg.TimeoutPrevote,
+		governance.ParamTimeoutPrecommit: &cfg.TimeoutPrecommit,
+		governance.ParamTimeoutCommit:    &cfg.TimeoutCommit,
+	}
+	for param, timeout := range timeouts {
+		if value, ok := governance.Timeout(chain.Config(), stateDB, param); ok {
+			*timeout = value
+		}
+	}
+	return &cfg
+}
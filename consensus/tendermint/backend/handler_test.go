@@ -1,6 +1,7 @@
 package backend
 
 import (
+	"math/big"
 	"os"
 	"strconv"
 	"sync"
@@ -107,6 +108,18 @@ func (m *mockCore) SetBlockForProposal(block *types.Block) {
 	panic("implement me")
 }
 
+func (m *mockCore) ConsensusState() (*tendermint.ConsensusState, error) {
+	panic("implement me")
+}
+
+func (m *mockCore) RoundVotes(blockNumber *big.Int, round int64) (*tendermint.RoundVotes, error) {
+	panic("implement me")
+}
+
+func (m *mockCore) SubscribeStepEvent(ch chan<- tendermint.StepEvent) event.Subscription {
+	panic("implement me")
+}
+
 // This test case is when user start miner then stop it before core handles all msg in storingMsgs
 func TestBackend_HandleMsg(t *testing.T) {
 	log.Root().SetHandler(log.LvlFilterHandler(log.LvlTrace, log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
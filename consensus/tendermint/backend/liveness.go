@@ -0,0 +1,209 @@
+package backend
+
+import (
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/log"
+)
+
+const (
+	// inMemoryParentSigners is the number of blocks whose parent seal signers are cached
+	inMemoryParentSigners = 4096
+)
+
+// ValidatorUptime is the number of blocks a validator committed in a window of blocks
+type ValidatorUptime struct {
+	Signed   uint64 `json:"signed"`
+	Expected uint64 `json:"expected"`
+}
+
+// Missed returns the number of blocks the validator did not commit
+func (u *ValidatorUptime) Missed() uint64 {
+	return u.Expected - u.Signed
+}
+
+// Percentage returns the percentage of blocks the validator committed, 100 if it is not expected to commit any block
+func (u *ValidatorUptime) Percentage() uint64 {
+	if u.Expected == 0 {
+		return 100
+	}
+	return u.Signed * 100 / u.Expected
+}
+
+// uptimeWindow returns the number of blocks the uptime of validators is computed on
+func (sb *Backend) uptimeWindow(chain consensus.ChainReader) uint64 {
+	if window := chain.Config().Tendermint.UptimeWindow; window > 0 {
+		return window
+	}
+	return sb.config.Epoch
+}
+
+// sealsParentCommit returns whether the block number carries the committed seals of its parent. They are sealed once
+// the uptime of validators matters, except in block 1 whose parent, the genesis block, is not committed.
+func (sb *Backend) sealsParentCommit(chain consensus.ChainReader, number *big.Int) bool {
+	config := chain.Config().Tendermint
+	return config != nil && config.IsDowntimeJailing(number) && number.Cmp(common.Big1) > 0
+}
+
+// addParentSeals writes the committed seals of the parent known by this node to the header
+func (sb *Backend) addParentSeals(header *types.Header, parent *types.Header) error {
+	parentExtra, err := types.ExtractTendermintExtra(parent)
+	if err != nil {
+		return err
+	}
+	return utils.WriteParentSeals(header, parentExtra)
+}
+
+// commitSigners returns the validators of valSet whose committed seals are in the header.
+// The committed seals of a block are not covered by its hash and differ between nodes, they must not be used by consensus.
+func (sb *Backend) commitSigners(header *types.Header, valSet tendermint.ValidatorSet) ([]common.Address, error) {
+	extra, err := types.ExtractTendermintExtra(header)
+	if err != nil {
+		return nil, err
+	}
+	return sealSigners(header.Hash(), extra.CommittedSeal, extra.AggregatedSeal, valSet)
+}
+
+// parentCommitSigners returns the validators of valSet, the validator set of the parent, whose committed seals of the
+// parent are sealed in the header
+func (sb *Backend) parentCommitSigners(header *types.Header, valSet tendermint.ValidatorSet) ([]common.Address, error) {
+	hash := header.Hash()
+	if signers, known := sb.parentSignersCache.Get(hash); known {
+		if addresses, ok := signers.([]common.Address); ok {
+			return addresses, nil
+		}
+	}
+	extra, err := types.ExtractTendermintExtra(header)
+	if err != nil {
+		return nil, err
+	}
+	signers, err := sealSigners(header.ParentHash, extra.ParentCommittedSeal, extra.ParentAggregatedSeal, valSet)
+	if err != nil {
+		return nil, err
+	}
+	sb.parentSignersCache.Add(hash, signers)
+	return signers, nil
+}
+
+// sealSigners returns the validators of valSet whose committed seals of the block hash are either in committedSeal
+// or aggregated in aggregatedSeal
+func sealSigners(hash common.Hash, committedSeal [][]byte, aggregatedSeal *types.TendermintAggregatedSeal, valSet tendermint.ValidatorSet) ([]common.Address, error) {
+	if aggregatedSeal != nil {
+		return aggregatedSealSigners(aggregatedSeal, valSet)
+	}
+	var (
+		proposalSeal = utils.PrepareCommittedSeal(hash)
+		signers      = make([]common.Address, 0, len(committedSeal))
+	)
+	for _, seal := range committedSeal {
+		addr, err := utils.GetSignatureAddress(proposalSeal, seal)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, addr)
+	}
+	return signers, nil
+}
+
+// getUptimes computes the uptime of the validators in the window of blocks whose commits are sealed by the blocks
+// ending at lastHeader (included), i.e. the parents of these blocks.
+//
+// A validator committed a block if its seal is in the parent seals of the child block, so every node counts the same
+// uptime. The proposer of the child chooses the seals it includes as long as they reach the minimum majority: validators
+// colluding against another one can only omit it from the blocks whose child they propose. With colluders proposing a
+// share p of the blocks, the uptime of an online validator is lowered by at most p, e.g. below a third of the voting
+// power they can not lower it under 66%. The minimum uptime must leave that tolerance.
+//
+// The blocks before the uptime fork carry no parent seals and are never part of the window, nor is the genesis block.
+func (sb *Backend) getUptimes(chain consensus.ChainReader, lastHeader *types.Header, window uint64) (map[common.Address]*ValidatorUptime, error) {
+	var (
+		uptimes = make(map[common.Address]*ValidatorUptime)
+		header  = lastHeader
+	)
+	for i := uint64(0); i < window && sb.sealsParentCommit(chain, header.Number); i++ {
+		parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+		if parent == nil {
+			return nil, consensus.ErrUnknownAncestor
+		}
+		valSet, err := sb.valSetInfo.GetValSet(chain, parent.Number)
+		if err != nil {
+			return nil, err
+		}
+		for _, val := range valSet.List() {
+			if _, ok := uptimes[val.Address()]; !ok {
+				uptimes[val.Address()] = &ValidatorUptime{}
+			}
+			uptimes[val.Address()].Expected++
+		}
+		signers, err := sb.parentCommitSigners(header, valSet)
+		if err != nil {
+			return nil, err
+		}
+		for _, signer := range signers {
+			if uptime, ok := uptimes[signer]; ok {
+				uptime.Signed++
+			}
+		}
+		header = parent
+	}
+	return uptimes, nil
+}
+
+// excludeOfflineValidators removes the validators whose uptime in the window ending at header is lower than the
+// minimum uptime from the validators of the next epoch. If all validators are offline, the validators are kept unchanged.
+func (sb *Backend) excludeOfflineValidators(chain consensus.ChainReader, header *types.Header, validators []common.Address) ([]common.Address, error) {
+	config := chain.Config().Tendermint
+	if config == nil || !config.IsDowntimeJailing(new(big.Int).Add(header.Number, common.Big1)) || len(config.FixedValidators) > 0 {
+		return validators, nil
+	}
+	uptimes, err := sb.getUptimes(chain, header, sb.uptimeWindow(chain))
+	if err != nil {
+		return nil, err
+	}
+	var (
+		online  = make([]common.Address, 0, len(validators))
+		offline []common.Address
+	)
+	for _, val := range validators {
+		if uptime, ok := uptimes[val]; ok && uptime.Percentage() < config.MinUptime {
+			offline = append(offline, val)
+			continue
+		}
+		online = append(online, val)
+	}
+	if len(offline) == 0 {
+		return validators, nil
+	}
+	if len(online) == 0 {
+		log.Warn("all validators are offline, keep the validator set unchanged", "number", header.Number)
+		return validators, nil
+	}
+	log.Warn("excluded offline validators from the next validator set", "number", header.Number,
+		"offline", common.PrettyAddresses(offline))
+	return online, nil
+}
+
+// reduceRewardsByUptime scales the rewards of the validators by their uptime in the epoch ending at header's parent
+func (sb *Backend) reduceRewardsByUptime(chain consensus.ChainReader, header *types.Header, rewards map[common.Address]*big.Int) error {
+	config := chain.Config().Tendermint
+	if !config.ReduceRewardByUptime || !config.IsDowntimeJailing(header.Number) {
+		return nil
+	}
+	// header seals the commit of its parent, so the window of its last Epoch-1 parents ends at its parent
+	uptimes, err := sb.getUptimes(chain, header, sb.config.Epoch-1)
+	if err != nil {
+		return err
+	}
+	for addr, reward := range rewards {
+		if uptime, ok := uptimes[addr]; ok {
+			reward.Mul(reward, new(big.Int).SetUint64(uptime.Percentage()))
+			reward.Div(reward, big.NewInt(100))
+		}
+	}
+	return nil
+}
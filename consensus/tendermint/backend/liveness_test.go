@@ -0,0 +1,160 @@
+package backend
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+func TestBackend_GetUptimes(t *testing.T) {
+	var (
+		onlineKey  = tests_utils.MakeNodeKey()
+		offlineKey = tests_utils.MakeNodeKey()
+		online     = crypto.PubkeyToAddress(onlineKey.PublicKey)
+		offline    = crypto.PubkeyToAddress(offlineKey.PublicKey)
+		validators = []common.Address{online, offline}
+		headers    = []*types.Header{tests_utils.MakeGenesisHeader(validators)}
+	)
+	config := *tendermint.DefaultConfig
+	config.FixedValidators = validators
+	be, ok := New(&config, onlineKey).(*Backend)
+	require.True(t, ok)
+
+	// offline validator only commits the first 2 blocks, every block seals the commit of its parent
+	for i := 1; i <= 5; i++ {
+		parent := headers[len(headers)-1]
+		header := tests_utils.MakeBlockWithoutSeal(parent).Header()
+		if i > 1 {
+			parentExtra, err := types.ExtractTendermintExtra(parent)
+			require.NoError(t, err)
+			require.NoError(t, utils.WriteParentSeals(header, parentExtra))
+		}
+		signers := []*ecdsa.PrivateKey{onlineKey}
+		if i <= 2 {
+			signers = append(signers, offlineKey)
+		}
+		require.NoError(t, utils.WriteCommittedSeals(header, commitSeals(t, header, signers)))
+		headers = append(headers, header)
+	}
+	chainConfig := &params.ChainConfig{Tendermint: &params.TendermintConfig{DowntimeJailingBlock: common.Big0}}
+	chain := tests_utils.NewHeadersMockChainReaderWithConfig(chainConfig, headers)
+
+	uptimes, err := be.getUptimes(chain, headers[5], 4)
+	require.NoError(t, err)
+	require.Contains(t, uptimes, online)
+	require.Contains(t, uptimes, offline)
+	assert.Equal(t, uint64(100), uptimes[online].Percentage())
+	assert.Equal(t, uint64(2), uptimes[offline].Missed())
+	assert.Equal(t, uint64(50), uptimes[offline].Percentage())
+
+	// the genesis block is never part of the window
+	uptimes, err = be.getUptimes(chain, headers[3], 10)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), uptimes[offline].Expected)
+	assert.Equal(t, uint64(100), uptimes[offline].Percentage())
+
+	// the seals of the committed block are not counted, only the ones sealed by its child
+	require.NoError(t, utils.WriteCommittedSeals(headers[4], commitSeals(t, headers[4], []*ecdsa.PrivateKey{onlineKey, offlineKey})))
+	uptimes, err = be.getUptimes(chain, headers[5], 1)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), uptimes[offline].Signed)
+
+	// the blocks before the fork carry no parent seals
+	chainConfig.Tendermint.DowntimeJailingBlock = big.NewInt(4)
+	be.parentSignersCache.Purge()
+	uptimes, err = be.getUptimes(chain, headers[5], 4)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), uptimes[offline].Expected)
+	assert.Equal(t, uint64(0), uptimes[offline].Signed)
+}
+
+func TestBackend_VerifyParentSeals(t *testing.T) {
+	var (
+		keys       = []*ecdsa.PrivateKey{tests_utils.MakeNodeKey(), tests_utils.MakeNodeKey()}
+		validators = []common.Address{crypto.PubkeyToAddress(keys[0].PublicKey), crypto.PubkeyToAddress(keys[1].PublicKey)}
+		genesis    = tests_utils.MakeGenesisHeader(validators)
+	)
+	config := *tendermint.DefaultConfig
+	config.FixedValidators = validators
+	be, ok := New(&config, keys[0]).(*Backend)
+	require.True(t, ok)
+
+	parent := tests_utils.MakeBlockWithoutSeal(genesis).Header()
+	require.NoError(t, utils.WriteCommittedSeals(parent, commitSeals(t, parent, keys)))
+	parentExtra, err := types.ExtractTendermintExtra(parent)
+	require.NoError(t, err)
+	chainConfig := &params.ChainConfig{Tendermint: &params.TendermintConfig{DowntimeJailingBlock: common.Big0}}
+	chain := tests_utils.NewHeadersMockChainReaderWithConfig(chainConfig, []*types.Header{genesis, parent})
+
+	header := tests_utils.MakeBlockWithoutSeal(parent).Header()
+	assert.Equal(t, tendermint.ErrInvalidParentSeals, be.verifyParentSeals(chain, header, parent, nil))
+
+	require.NoError(t, utils.WriteParentSeals(header, parentExtra))
+	assert.NoError(t, be.verifyParentSeals(chain, header, parent, nil))
+
+	// the parent seals must reach the minimum majority
+	header = tests_utils.MakeBlockWithoutSeal(parent).Header()
+	require.NoError(t, utils.WriteParentSeals(header, &types.TendermintExtra{CommittedSeal: commitSeals(t, parent, keys[:1])}))
+	assert.Equal(t, tendermint.ErrInvalidParentSeals, be.verifyParentSeals(chain, header, parent, nil))
+
+	// no parent seals before the fork
+	chainConfig.Tendermint.DowntimeJailingBlock = big.NewInt(10)
+	assert.Equal(t, tendermint.ErrInvalidParentSeals, be.verifyParentSeals(chain, header, parent, nil))
+	assert.NoError(t, be.verifyParentSeals(chain, tests_utils.MakeBlockWithoutSeal(parent).Header(), parent, nil))
+}
+
+// commitSeals returns the committed seals of the header signed by the keys
+func commitSeals(t *testing.T, header *types.Header, keys []*ecdsa.PrivateKey) [][]byte {
+	var seals [][]byte
+	for _, key := range keys {
+		seal, err := crypto.Sign(crypto.Keccak256(utils.PrepareCommittedSeal(header.Hash())), key)
+		require.NoError(t, err)
+		seals = append(seals, seal)
+	}
+	return seals
+}
+
+func TestTendermintAPI_GetCommitInfo(t *testing.T) {
+	var (
+		onlineKey  = tests_utils.MakeNodeKey()
+		offlineKey = tests_utils.MakeNodeKey()
+		online     = crypto.PubkeyToAddress(onlineKey.PublicKey)
+		offline    = crypto.PubkeyToAddress(offlineKey.PublicKey)
+		validators = []common.Address{online, offline}
+		genesis    = tests_utils.MakeGenesisHeader(validators)
+	)
+	config := *tendermint.DefaultConfig
+	config.FixedValidators = validators
+	be, ok := New(&config, onlineKey).(*Backend)
+	require.True(t, ok)
+
+	header := tests_utils.MakeBlockWithoutSeal(genesis).Header()
+	tests_utils.AppendSealByPkKey(header, onlineKey)
+	tests_utils.AppendCommitedSealByPkKeys(header, []*ecdsa.PrivateKey{onlineKey})
+	api := &TendermintAPI{chain: tests_utils.NewHeadersMockChainReader([]*types.Header{genesis, header}), be: be}
+
+	number := uint64(1)
+	info, err := api.GetCommitInfo(&number)
+	require.NoError(t, err)
+	assert.Equal(t, header.Hash(), info.Hash)
+	assert.Equal(t, online, info.Proposer)
+	assert.Equal(t, []common.Address{online}, info.Signers)
+	assert.Equal(t, []common.Address{offline}, info.Missing)
+	assert.False(t, info.Aggregated)
+
+	// the genesis block is not committed
+	number = 0
+	_, err = api.GetCommitInfo(&number)
+	assert.Equal(t, tendermint.ErrUnknownBlock, err)
+}
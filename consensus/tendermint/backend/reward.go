@@ -1,13 +1,17 @@
 package backend
 
 import (
+	"bytes"
 	"math/big"
+	"sort"
 	"time"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core/governance"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
 	"github.com/Evrynetlabs/evrynet-node/core/state"
 	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
@@ -15,13 +19,13 @@ import (
 )
 
 // AccumulateRewards credits the coinbase of the given block with the proposing
-// reward.
-func (sb *Backend) accumulateRewards(chainReader consensus.FullChainReader, state *state.StateDB, header *types.Header) error {
+// reward. At a checkpoint, it returns the rewards of the epoch credited to the owners and voters of the validators.
+func (sb *Backend) accumulateRewards(chainReader consensus.FullChainReader, state *state.StateDB, header *types.Header) ([]*types.TendermintReward, error) {
 	// If fixed validators (test) then return
 	if chainReader.Config().Tendermint.FixedValidators != nil {
 		reward := new(big.Int).Set(chainReader.Config().Tendermint.BlockReward)
 		state.AddBalance(header.Coinbase, reward)
-		return nil
+		return nil, nil
 	}
 	var (
 		currentBlock = header.Number.Uint64()
@@ -30,40 +34,49 @@ func (sb *Backend) accumulateRewards(chainReader consensus.FullChainReader, stat
 	)
 
 	if currentBlock == 0 {
-		return tendermint.ErrFinalizeZeroBlock
+		return nil, tendermint.ErrFinalizeZeroBlock
 	}
 
 	if currentBlock%epoch != 0 {
-		return nil
+		return nil, nil
 	}
 
-	validatorsRewards := calculateTotalValidatorsRewards(chainReader, epoch, header)
 	transitionHeader := chainReader.GetHeaderByNumber(currentBlock - epoch)
 	validatorAdds, err := utils.GetValSetAddresses(transitionHeader)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	stateDB, err := chainReader.StateAt(transitionHeader.Root)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	// the chain parameters only change at checkpoints, the ones of the epoch are in the state of its transition block
+	var (
+		config      = chainReader.Config()
+		blockReward = governance.BlockReward(config, stateDB)
+		gasPrice    = governance.GasPrice(config, stateDB)
+	)
+	validatorsRewards := calculateTotalValidatorsRewards(chainReader, epoch, header, blockReward, gasPrice)
+	if err := sb.reduceRewardsByUptime(chainReader, header, validatorsRewards); err != nil {
+		return nil, err
 	}
 	stakingCaller := sb.getStakingCaller(chainReader, stateDB, header)
 	validatorsData, err := stakingCaller.GetValidatorsData(*sb.config.StakingSCAddress, validatorAdds)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	finalReward := calculateReward(validatorsData, validatorsRewards)
-	for addr, value := range finalReward {
-		state.AddBalance(addr, value)
+	rewards := calculateReward(validatorsData, validatorsRewards, maxCommissionRate(chainReader.Config().Tendermint))
+	for _, reward := range rewards {
+		state.AddBalance(reward.Address, reward.Amount)
 	}
 	log.Debug("accumulateRewards", "number", currentBlock, "elapsed", common.PrettyDuration(time.Since(start)))
-	return nil
+	return rewards, nil
 }
 
 // calculateTotalValidatorsRewards gets reward from chainReader and current header (from finalize)
 // reward includes block rewards and tx fee from block number currentBlock - epoch +1
-func calculateTotalValidatorsRewards(chainReader consensus.ChainReader, epoch uint64, header *types.Header) map[common.Address]*big.Int {
+func calculateTotalValidatorsRewards(chainReader consensus.ChainReader, epoch uint64, header *types.Header, blockReward, gasPrice *big.Int) map[common.Address]*big.Int {
 	var currentBlock = header.Number.Uint64()
 	validatorsRewards := make(map[common.Address]*big.Int)
 	for i := currentBlock - epoch + 1; i <= currentBlock; i++ {
@@ -73,8 +86,8 @@ func calculateTotalValidatorsRewards(chainReader consensus.ChainReader, epoch ui
 		} else {
 			currentHeader = header
 		}
-		txFee := new(big.Int).Mul(big.NewInt(int64(currentHeader.GasUsed)), chainReader.Config().GasPrice)
-		reward := new(big.Int).Add(chainReader.Config().Tendermint.BlockReward, txFee)
+		txFee := new(big.Int).Mul(big.NewInt(int64(currentHeader.GasUsed)), gasPrice)
+		reward := new(big.Int).Add(blockReward, txFee)
 		if current, ok := validatorsRewards[currentHeader.Coinbase]; ok {
 			validatorsRewards[currentHeader.Coinbase] = new(big.Int).Add(current, reward)
 		} else {
@@ -84,35 +97,77 @@ func calculateTotalValidatorsRewards(chainReader consensus.ChainReader, epoch ui
 	return validatorsRewards
 }
 
-// calculateReward divides rewards into 50% to owner and 50% among voters
-// rewards for voters is proportional to voters'stake
-func calculateReward(validatorsData map[common.Address]staking.CandidateData, validatorsReward map[common.Address]*big.Int) map[common.Address]*big.Int {
-	finalReward := make(map[common.Address]*big.Int)
-	addReward := func(addr common.Address, value *big.Int) {
-		if current, ok := finalReward[addr]; ok {
-			finalReward[addr] = new(big.Int).Add(current, value)
-		} else {
-			finalReward[addr] = new(big.Int).Set(value)
-		}
-	}
+// calculateReward divides rewards into the commission of the owner and the share of the voters,
+// rewards for voters is proportional to voters'stake. The rewards are sorted by validator then address.
+func calculateReward(validatorsData map[common.Address]staking.CandidateData, validatorsReward map[common.Address]*big.Int, maxRate uint64) []*types.TendermintReward {
+	var rewards []*types.TendermintReward
 	for addr, validatorData := range validatorsData {
 		totalReward, ok := validatorsReward[addr]
 		if !ok {
 			continue
 		}
+		commissionRate := validatorData.CommissionRate
+		if commissionRate > maxRate {
+			commissionRate = maxRate
+		}
 		// remainingReward to ensure the total reward for the voters and owner is equals to the wei validator earns
 		remainingReward := new(big.Int).Set(totalReward)
-		totalVoterReward := new(big.Int).Mul(totalReward, big.NewInt(voterRewardPercentage))
+		totalVoterReward := new(big.Int).Mul(totalReward, new(big.Int).SetUint64(100-commissionRate))
 		totalVoterReward = new(big.Int).Div(totalVoterReward, big.NewInt(100))
 		for voter, voterStake := range validatorData.VoterStakes {
+			if voter == validatorData.Owner {
+				continue
+			}
 			voterReward := new(big.Int).Mul(totalVoterReward, voterStake)
 			voterReward = new(big.Int).Div(voterReward, validatorData.TotalStake)
-			addReward(voter, voterReward)
+			rewards = append(rewards, &types.TendermintReward{Address: voter, Validator: addr, Amount: voterReward})
 			remainingReward.Sub(remainingReward, voterReward)
 		}
-		validatorReward := new(big.Int).Mul(totalReward, big.NewInt(validatorRewardPercentage))
-		validatorReward = new(big.Int).Div(validatorReward, big.NewInt(100))
-		addReward(validatorData.Owner, remainingReward)
+		rewards = append(rewards, &types.TendermintReward{Address: validatorData.Owner, Validator: addr, Amount: remainingReward})
+	}
+	sort.Slice(rewards, func(i, j int) bool {
+		if rewards[i].Validator != rewards[j].Validator {
+			return bytes.Compare(rewards[i].Validator[:], rewards[j].Validator[:]) < 0
+		}
+		return bytes.Compare(rewards[i].Address[:], rewards[j].Address[:]) < 0
+	})
+	return rewards
+}
+
+// cacheEpochRewards keeps the rewards credited at a checkpoint once its state root is known.
+// A checkpoint is finalized for every proposal of its height, the rewards are only persisted once it is committed.
+func (sb *Backend) cacheEpochRewards(header *types.Header, rewards []*types.TendermintReward) {
+	if sb.db == nil || header.Number.Uint64()%sb.config.Epoch != 0 {
+		return
+	}
+	sb.pendingRewardsCache.Add(header.Root, rewards)
+}
+
+// storeParentEpochRewards persists the rewards of the parent of the header if it is a checkpoint,
+// a block is only finalized on top of a committed one.
+func (sb *Backend) storeParentEpochRewards(chainReader consensus.ChainReader, header *types.Header) {
+	number := header.Number.Uint64()
+	if sb.db == nil || number <= 1 || (number-1)%sb.config.Epoch != 0 {
+		return
+	}
+	if parent := chainReader.GetHeader(header.ParentHash, number-1); parent != nil {
+		sb.storeEpochRewards(parent)
+	}
+}
+
+// storeEpochRewards persists the rewards of the committed checkpoint and removes the ones stored for other proposals.
+// It returns false if the rewards of the checkpoint are neither stored nor pending.
+func (sb *Backend) storeEpochRewards(checkpoint *types.Header) bool {
+	number := checkpoint.Number.Uint64()
+	if rawdb.HasEpochRewards(sb.db, number, checkpoint.Root) {
+		return true
+	}
+	rewards, ok := sb.pendingRewardsCache.Get(checkpoint.Root)
+	if !ok {
+		return false
 	}
-	return finalReward
+	rawdb.WriteEpochRewards(sb.db, number, checkpoint.Root, rewards.([]*types.TendermintReward))
+	rawdb.DeleteOrphanEpochRewards(sb.db, number, checkpoint.Root)
+	sb.pendingRewardsCache.Remove(checkpoint.Root)
+	return true
 }
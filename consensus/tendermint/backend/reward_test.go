@@ -12,17 +12,21 @@ import (
 
 	"github.com/Evrynetlabs/evrynet-node/accounts/abi"
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
 	"github.com/Evrynetlabs/evrynet-node/consensus/staking_contracts"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
 	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/log"
 	"github.com/Evrynetlabs/evrynet-node/params"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
 )
 
-//TestBackend_RewardNoTx this is integration test between core.BlockChain and tendermint.Backend
+// TestBackend_RewardNoTx this is integration test between core.BlockChain and tendermint.Backend
 // this test check the reward of validator without any transactions and voters
 func TestBackend_RewardNoTx(t *testing.T) {
 	log.Root().SetHandler(log.LvlFilterHandler(log.LvlTrace, log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
@@ -38,7 +42,7 @@ func TestBackend_RewardNoTx(t *testing.T) {
 	})
 }
 
-//TestBackend_RewardNoTx_WithVoter this is integration test between core.BlockChain and tendermint.Backend
+// TestBackend_RewardNoTx_WithVoter this is integration test between core.BlockChain and tendermint.Backend
 // this test check the reward of validators and voters without any transactions
 func TestBackend_RewardNoTx_WithVoter(t *testing.T) {
 	var (
@@ -47,7 +51,7 @@ func TestBackend_RewardNoTx_WithVoter(t *testing.T) {
 	log.Root().SetHandler(log.LvlFilterHandler(log.LvlTrace, log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
 	_, validatorAddresses := getValidatorAccounts()
 	faucetKeys, faucetAddresses := getFaucetAccounts()
-	testFinalize(t, func(i int, gen *core.BlockGen) {
+	api := testFinalize(t, func(i int, gen *core.BlockGen) {
 		gen.SetCoinbase(validatorAddresses[0])
 		if i == stakingEpoch-1 {
 			valSetData, err := rlp.EncodeToBytes(validatorAddresses)
@@ -96,6 +100,71 @@ func TestBackend_RewardNoTx_WithVoter(t *testing.T) {
 		expectedVoterReward := new(big.Int).Div(new(big.Int).Mul(expectedTotalReward, big.NewInt(25)), big.NewInt(100))
 		require.Equal(t, expectedVoterReward, new(big.Int).Sub(state1.GetBalance(faucetAddresses[1]), state0.GetBalance(faucetAddresses[1])))
 	})
+
+	// the rewards of the second epoch are split between the owner and the voter
+	rewards, err := api.GetEpochRewards(1)
+	require.NoError(t, err)
+	require.Len(t, rewards, 2)
+	voterRewards, err := api.GetRewardsByAddress(faucetAddresses[1], 0, 10)
+	require.NoError(t, err)
+	require.Len(t, voterRewards.Rewards, 1)
+	require.Equal(t, hexutil.Uint64(1), voterRewards.Rewards[0].Epoch)
+	require.Equal(t, validatorAddresses[0], voterRewards.Rewards[0].Validator)
+	require.Equal(t, voterRewards.Total, voterRewards.Rewards[0].Amount)
+	_, err = api.GetEpochRewards(2)
+	require.Equal(t, tendermint.ErrUnknownBlock, err)
+}
+
+// TestBackend_EpochRewardsStoredOnCommit checks that the rewards of a checkpoint are only persisted once it is committed
+// and that the rewards stored for the other proposals of the checkpoint are removed.
+func TestBackend_EpochRewardsStoredOnCommit(t *testing.T) {
+	_, addr := getValidatorAccounts()
+	api := testFinalize(t, func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(addr[0])
+		if i == stakingEpoch-1 {
+			valSetData, err := rlp.EncodeToBytes(addr)
+			require.NoError(t, err)
+			payload, err := rlp.EncodeToBytes(&types.TendermintExtra{ValidatorAdds: valSetData})
+			require.NoError(t, err)
+			gen.SetExtra(append(bytes.Repeat([]byte{0x00}, types.TendermintExtraVanity), payload...))
+		}
+	}, stakingEpoch*2, nil)
+	db := api.be.db
+
+	// the first checkpoint is stored when the block on top of it is finalized
+	checkpoint0 := api.chain.GetHeaderByNumber(stakingEpoch)
+	require.True(t, rawdb.HasEpochRewards(db, stakingEpoch, checkpoint0.Root))
+
+	// a proposal of a checkpoint is not stored when it is finalized
+	proposal := &types.Header{Number: big.NewInt(stakingEpoch * 3), Root: common.Hash{1}}
+	api.be.cacheEpochRewards(proposal, nil)
+	require.False(t, rawdb.HasEpochRewards(db, proposal.Number.Uint64(), proposal.Root))
+
+	// the head checkpoint is stored when its rewards are requested, the other proposals are pruned
+	checkpoint1 := api.chain.GetHeaderByNumber(stakingEpoch * 2)
+	require.False(t, rawdb.HasEpochRewards(db, stakingEpoch*2, checkpoint1.Root))
+	orphan := common.Hash{2}
+	rawdb.WriteEpochRewards(db, stakingEpoch*2, orphan, nil)
+	rewards, err := api.GetEpochRewards(1)
+	require.NoError(t, err)
+	require.Len(t, rewards, 1)
+	require.True(t, rawdb.HasEpochRewards(db, stakingEpoch*2, checkpoint1.Root))
+	require.False(t, rawdb.HasEpochRewards(db, stakingEpoch*2, orphan))
+}
+
+// TestBackend_EpochRewardsUnavailable checks that the rewards of a checkpoint which were not recorded by the node,
+// e.g. when it was fast-synced, are reported as unavailable rather than empty.
+func TestBackend_EpochRewardsUnavailable(t *testing.T) {
+	_, addr := getValidatorAccounts()
+	api := testFinalize(t, func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(addr[0])
+	}, stakingEpoch, nil)
+	api.be.pendingRewardsCache.Purge()
+
+	_, err := api.GetEpochRewards(0)
+	require.Equal(t, ErrUnavailableRewards, err)
+	_, err = api.GetRewardsByAddress(addr[0], 0, 0)
+	require.Equal(t, ErrUnavailableRewards, err)
 }
 
 // TestBackend_RewardWithTx this is integration test between core.BlockChain and tendermint.Backend
@@ -132,9 +201,40 @@ func TestBackend_RewardWithTx(t *testing.T) {
 	})
 }
 
-func testFinalize(t *testing.T, generate func(int, *core.BlockGen), n int, assertFn func(chain *core.BlockChain)) {
+func TestCalculateReward(t *testing.T) {
+	var (
+		validator = common.HexToAddress("0x10")
+		owner     = common.HexToAddress("0x11")
+		voter     = common.HexToAddress("0x12")
+		data      = staking.CandidateData{
+			Owner:       owner,
+			VoterStakes: map[common.Address]*big.Int{owner: big.NewInt(1), voter: big.NewInt(3)},
+			TotalStake:  big.NewInt(4),
+		}
+		rewards = map[common.Address]*big.Int{validator: big.NewInt(1000)}
+	)
+	for _, test := range []struct {
+		rate, maxRate            uint64
+		ownerReward, voterReward int64
+	}{
+		{rate: 50, maxRate: 100, ownerReward: 625, voterReward: 375},
+		{rate: 10, maxRate: 100, ownerReward: 325, voterReward: 675},
+		{rate: 100, maxRate: 100, ownerReward: 1000, voterReward: 0},
+		{rate: 50, maxRate: 20, ownerReward: 400, voterReward: 600},
+	} {
+		data.CommissionRate = test.rate
+		finalReward := calculateReward(map[common.Address]staking.CandidateData{validator: data}, rewards, test.maxRate)
+		require.Equal(t, []*types.TendermintReward{
+			{Address: owner, Validator: validator, Amount: big.NewInt(test.ownerReward)},
+			{Address: voter, Validator: validator, Amount: big.NewInt(test.voterReward)},
+		}, finalReward, "rate %d max %d", test.rate, test.maxRate)
+	}
+}
+
+func testFinalize(t *testing.T, generate func(int, *core.BlockGen), n int, assertFn func(chain *core.BlockChain)) *TendermintAPI {
 	be, chain, db, err := createBlockchainAndBackendFromGenesis(StakingSC)
 	require.NoError(t, err)
+	be.db = db
 	genesis := chain.Genesis()
 	require.NotNil(t, genesis)
 	pks, addrs := getValidatorAccounts()
@@ -162,4 +262,5 @@ func testFinalize(t *testing.T, generate func(int, *core.BlockGen), n int, asser
 	if assertFn != nil {
 		assertFn(chain)
 	}
+	return &TendermintAPI{chain: chain, be: be}
 }
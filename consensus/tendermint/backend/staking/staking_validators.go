@@ -45,5 +45,17 @@ func (v *StakingValidator) GetValSet(chainReader consensus.ChainReader, number *
 		return valSet, err
 	}
 
-	return validator.NewSet(validatorAdds, v.ProposerPolicy, blockNumber), nil
+	votingPowers, err := utils.GetValSetVotingPowers(header)
+	if err != nil {
+		log.Error("can't get the validators's voting powers from extra-data", "number", blockNumber)
+		return valSet, err
+	}
+
+	blsPublicKeys, err := utils.GetValSetBLSPublicKeys(header)
+	if err != nil {
+		log.Error("can't get the validators's BLS public keys from extra-data", "number", blockNumber)
+		return valSet, err
+	}
+
+	return validator.NewWeightedSet(validatorAdds, votingPowers, blsPublicKeys, v.ProposerPolicy, blockNumber, int64(checkPoint)), nil
 }
@@ -0,0 +1,54 @@
+package backend
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+var (
+	// votingPowerUnit is the amount of stake giving a voting power of 1
+	votingPowerUnit = new(big.Int).SetUint64(params.Ether)
+)
+
+// stakeToVotingPower converts the total stake of a validator to its voting power, a validator has at least 1 voting power.
+// The voting power is capped so that the total voting power of the validator set never overflows int64.
+func stakeToVotingPower(stake *big.Int) uint64 {
+	if stake == nil {
+		return 1
+	}
+	power := new(big.Int).Div(stake, votingPowerUnit)
+	if power.Sign() <= 0 {
+		return 1
+	}
+	if !power.IsUint64() || power.Uint64() > math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return power.Uint64()
+}
+
+// getNextVotingPowers returns the voting powers of the validators of the checkpoint block number, computed from the stakes
+// in the state of its parent header. It returns nil if the voting power is not weighted by stake at the block number.
+func (sb *Backend) getNextVotingPowers(chainReader consensus.FullChainReader, parent *types.Header, number *big.Int, validators []common.Address) ([]uint64, error) {
+	config := chainReader.Config().Tendermint
+	if config == nil || !config.IsStakeWeighted(number) || len(config.FixedValidators) > 0 {
+		return nil, nil
+	}
+	stateDB, err := chainReader.StateAt(parent.Root)
+	if err != nil {
+		return nil, err
+	}
+	validatorsData, err := sb.getStakingCaller(chainReader, stateDB, parent).GetValidatorsData(sb.stakingContractAddr, validators)
+	if err != nil {
+		return nil, err
+	}
+	votingPowers := make([]uint64, len(validators))
+	for i, val := range validators {
+		votingPowers[i] = stakeToVotingPower(validatorsData[val].TotalStake)
+	}
+	return votingPowers, nil
+}
@@ -13,6 +13,8 @@ type ProposerPolicy uint64
 const (
 	RoundRobin ProposerPolicy = iota
 	Sticky
+	// WeightedRoundRobin selects the proposer proportionally to the voting power of validators
+	WeightedRoundRobin
 )
 
 //FaultyMode is the config mode to enable fauty node
@@ -46,11 +48,18 @@ type Config struct {
 	TimeoutCommit         time.Duration    //Duration waiting to start round with new height
 	FixedValidators       []common.Address // The fixed validators
 	BlockReward           *big.Int         //BlockReward for accumulating reward
+	ValSetHashBlock       *big.Int         // The block from which checkpoint headers seal the hash of their validator set (nil = no fork)
 
 	FaultyMode uint64 `toml:",omitempty"` // The faulty node indicates the faulty node's behavior
 
 	UseEVMCaller        bool
 	IndexStateVariables *staking.IndexConfigs //The index of state variables has stored in stateDB
+
+	SignerAccount  *common.Address `toml:",omitempty"` // The keystore account signing the consensus messages instead of the node key
+	ExternalSigner string          `toml:",omitempty"` // The endpoint of the external signer holding SignerAccount, e.g. clef
+	BLSKeyFile     string          `toml:",omitempty"` // The file holding the BLS key instead of deriving it from the node key
+
+	RecordFile string `toml:",omitempty"` // The file recording the consensus messages and timeouts, to replay them
 }
 
 var DefaultConfig = &Config{
@@ -112,3 +121,8 @@ func (cfg *Config) PrecommitCatchupTimeout(round int64) time.Duration {
 func (cfg *Config) Commit(t time.Time) time.Time {
 	return t.Add(cfg.TimeoutCommit)
 }
+
+// IsValSetHash returns whether the checkpoint header of the number seals the hash of its validator set
+func (cfg *Config) IsValSetHash(number *big.Int) bool {
+	return cfg.ValSetHashBlock != nil && cfg.ValSetHashBlock.Cmp(number) <= 0
+}
@@ -0,0 +1,32 @@
+package tendermint
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+)
+
+// ConsensusState is a snapshot of the state of the consensus core
+type ConsensusState struct {
+	BlockNumber *big.Int       `json:"blockNumber"`
+	Round       int64          `json:"round"`
+	Step        string         `json:"step"`
+	Proposer    common.Address `json:"proposer"`
+	StartTime   time.Time      `json:"startTime"` // the time the core started the block number at
+	// Proposal is the hash of the block proposed in the round, nil if the proposal is not received yet
+	Proposal    *common.Hash `json:"proposal"`
+	LockedRound int64        `json:"lockedRound"`
+	LockedBlock *common.Hash `json:"lockedBlock"`
+	ValidRound  int64        `json:"validRound"`
+	ValidBlock  *common.Hash `json:"validBlock"`
+}
+
+// RoundVotes is the hash of the block each validator prevoted and precommitted in a round,
+// the empty hash is a vote for no block. The validators which did not vote are not in the maps.
+type RoundVotes struct {
+	BlockNumber *big.Int                       `json:"blockNumber"`
+	Round       int64                          `json:"round"`
+	Prevotes    map[common.Address]common.Hash `json:"prevotes"`
+	Precommits  map[common.Address]common.Hash `json:"precommits"`
+}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto/bls"
+)
+
+// verifyBLSSeal checks the BLS seal of a precommit for a block if the validator set aggregates commits.
+// An invalid BLS seal would make the aggregated seal of the block invalid, so such precommits are rejected.
+func (c *core) verifyBLSSeal(addr common.Address, vote *Vote) error {
+	if !c.valSet.AggregatesCommits() || *vote.BlockHash == emptyBlockHash {
+		return nil
+	}
+	_, val := c.valSet.GetByAddress(addr)
+	if val == nil {
+		return ErrVoteInvalidValidatorAddress
+	}
+	if !bls.Verify(val.BLSPublicKey(), utils.PrepareCommittedSeal(*vote.BlockHash), vote.BLSSeal) {
+		return ErrInvalidBLSSeal
+	}
+	return nil
+}
+
+// aggregateSeals returns the aggregated seal of the BLS seals of the votes of the signers, signers are validator indexes
+func (c *core) aggregateSeals(votes *blockVotes, signers []int) (*types.TendermintAggregatedSeal, error) {
+	blsSeals := make([][]byte, 0, len(signers))
+	for _, i := range signers {
+		blsSeals = append(blsSeals, votes.votes[i].BLSSeal)
+	}
+	signature, err := bls.AggregateSignatures(blsSeals)
+	if err != nil {
+		return nil, err
+	}
+	return &types.TendermintAggregatedSeal{
+		Signers:   utils.SignerBitmap(c.valSet.Size(), signers),
+		Signature: signature,
+	}, nil
+}
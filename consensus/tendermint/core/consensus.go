@@ -59,7 +59,7 @@ func (c *core) enterNewRound(blockNumber *big.Int, round int64) {
 		state.SetProposalReceived(nil)
 	}
 	//Update to RoundStepNewRound
-	state.UpdateRoundStep(round, RoundStepNewRound)
+	c.updateRoundStep(round, RoundStepNewRound)
 	state.setPrecommitWaited(false)
 
 	c.enterPropose(blockNumber, round)
@@ -131,10 +131,10 @@ func (c *core) enterPropose(blockNumber *big.Int, round int64) {
 	}
 
 	logger.Infow("enterPropose")
-	c.proposeStart = time.Now()
+	c.proposeStart = c.now()
 	defer func() {
 		// Done enterPropose:
-		state.UpdateRoundStep(round, RoundStepPropose)
+		c.updateRoundStep(round, RoundStepPropose)
 
 		// If we have the whole proposal + POL, then goto PrevoteTimeout now.
 		// else, we'll enterPrevote when the rest of the proposal is received (in AddProposalBlockPart),
@@ -245,7 +245,7 @@ func (c *core) enterPrevote(blockNumber *big.Int, round int64) {
 	})
 	//eventually we'll enterPrevote
 	defer func() {
-		state.UpdateRoundStep(round, RoundStepPrevote)
+		c.updateRoundStep(round, RoundStepPrevote)
 	}()
 	c.defaultDoPrevote(round)
 }
@@ -276,7 +276,7 @@ func (c *core) enterPrevoteWait(blockNumber *big.Int, round int64) {
 
 	defer func() {
 		// Done enterPrevoteWait:
-		state.UpdateRoundStep(round, RoundStepPrevoteWait)
+		c.updateRoundStep(round, RoundStepPrevoteWait)
 	}()
 
 	//We have to copy blockNumber out since it's pointer, and the use of ScheduleTimeout
@@ -316,7 +316,7 @@ func (c *core) enterPrecommitWait(blockNumber *big.Int, round int64) {
 
 	//after this we setPrecommitWaited to true to make sure that the wait happens only once each round
 	defer func() {
-		state.UpdateRoundStep(round, RoundStepPrecommitWait)
+		c.updateRoundStep(round, RoundStepPrecommitWait)
 		state.setPrecommitWaited(true)
 	}()
 	//We have to copy blockNumber out since it's pointer, and the use of ScheduleTimeout
@@ -362,7 +362,7 @@ func (c *core) enterPrecommit(blockNumber *big.Int, round int64) {
 
 	defer func() {
 		// Done enterPrecommit:
-		state.UpdateRoundStep(round, RoundStepPrecommit)
+		c.updateRoundStep(round, RoundStepPrecommit)
 	}()
 
 	var blockHash = common.Hash{}
@@ -439,9 +439,9 @@ func (c *core) enterCommit(blockNumber *big.Int, commitRound int64) {
 	defer func() {
 		// Done enterCommit:
 		// keep state.Round the same, commitRound points to the right Precommits set.
-		state.UpdateRoundStep(state.Round(), RoundStepCommit)
+		c.updateRoundStep(state.Round(), RoundStepCommit)
 		state.commitRound = commitRound
-		state.commitTime = time.Now()
+		state.commitTime = c.now()
 
 		c.finalizeCommit(blockNumber)
 	}()
@@ -533,7 +533,7 @@ func (c *core) FinalizeBlock(proposal *Proposal) (*types.Block, error) {
 	var (
 		state           = c.currentState
 		round           = state.commitRound
-		totalPrecommits = 0
+		totalPrecommits = 0 // voting power of the precommits whose seals are collected
 		commitSeals     = [][]byte{}
 		header          = proposal.Block.Header()
 		minMajority     = c.valSet.MinMajority()
@@ -551,12 +551,14 @@ func (c *core) FinalizeBlock(proposal *Proposal) (*types.Block, error) {
 		return nil, fmt.Errorf("not enough precommits received expect at least %d received %d", minMajority, totalPrecommits)
 	}
 
-	for _, vote := range votes.votes {
+	var signers []int
+	for i, vote := range votes.votes {
 		if vote == nil {
 			continue
 		}
 		commitSeals = append(commitSeals, vote.Seal)
-		totalPrecommits++
+		signers = append(signers, i)
+		totalPrecommits += int(c.valSet.GetByIndex(int64(i)).VotingPower())
 		//TODO: is it fair to always take the first 2F+1 seals?
 		if totalPrecommits >= minMajority {
 			break
@@ -566,6 +568,16 @@ func (c *core) FinalizeBlock(proposal *Proposal) (*types.Block, error) {
 	if totalPrecommits < minMajority {
 		return nil, fmt.Errorf("not enough precommits received expect at least %d received %d", minMajority, totalPrecommits)
 	}
+	if c.valSet.AggregatesCommits() {
+		aggregatedSeal, err := c.aggregateSeals(votes, signers)
+		if err != nil {
+			return nil, err
+		}
+		if err := utils.WriteAggregatedSeal(header, aggregatedSeal); err != nil {
+			return nil, err
+		}
+		return proposal.Block.WithSeal(header), nil
+	}
 	//writeCommitSeals
 	if err := utils.WriteCommittedSeals(header, commitSeals); err != nil {
 		return nil, err
@@ -594,13 +606,17 @@ func (c *core) startNewRound() {
 		})
 		state.clearPreviousRoundData()
 		c.sentMsgStorage.truncateMsgStored(c.getLogger())
+		if err := c.wal.truncate(state.BlockNumber()); err != nil {
+			c.getLogger().Errorw("failed to truncate WAL", "err", err)
+		}
 		c.valSet = c.backend.Validators(state.BlockNumber())
+		c.updateConfig(state.BlockNumber())
 	}
 
 	//TODO: the timeout must account for the stopped time that core wasn't
 	switch state.Step() {
 	case RoundStepNewHeight:
-		duration = time.Until(state.startTime)
+		duration = state.startTime.Sub(c.now())
 	case RoundStepPropose:
 		duration = c.config.ProposeTimeout(state.Round())
 	case RoundStepPrevote:
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/event"
+)
+
+// ConsensusState implements core.Engine.ConsensusState
+func (c *core) ConsensusState() (*tendermint.ConsensusState, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	state := c.CurrentState()
+	if state == nil || c.valSet == nil {
+		return nil, tendermint.ErrStoppedEngine
+	}
+	consensusState := &tendermint.ConsensusState{
+		BlockNumber: state.CopyBlockNumber(),
+		Round:       state.Round(),
+		Step:        state.Step().String(),
+		StartTime:   state.startTime,
+		LockedRound: state.LockedRound(),
+		LockedBlock: blockHash(state.LockedBlock()),
+		ValidRound:  state.ValidRound(),
+		ValidBlock:  blockHash(state.ValidBlock()),
+	}
+	if proposer := c.valSet.GetProposer(); proposer != nil {
+		consensusState.Proposer = proposer.Address()
+	}
+	if proposal := state.ProposalReceived(); proposal != nil && proposal.Round == state.Round() {
+		consensusState.Proposal = blockHash(proposal.Block)
+	}
+	return consensusState, nil
+}
+
+// RoundVotes implements core.Engine.RoundVotes
+func (c *core) RoundVotes(blockNumber *big.Int, round int64) (*tendermint.RoundVotes, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	state := c.CurrentState()
+	if state == nil {
+		return nil, tendermint.ErrStoppedEngine
+	}
+	if state.BlockNumber().Cmp(blockNumber) != 0 {
+		return nil, tendermint.ErrUnavailableVotes
+	}
+	votes := &tendermint.RoundVotes{
+		BlockNumber: new(big.Int).Set(blockNumber),
+		Round:       round,
+		Prevotes:    make(map[common.Address]common.Hash),
+		Precommits:  make(map[common.Address]common.Hash),
+	}
+	if prevotes, ok := state.GetPrevotesByRound(round); ok {
+		for addr, vote := range prevotes.VotesByAddress() {
+			votes.Prevotes[addr] = *vote.BlockHash
+		}
+	}
+	if precommits, ok := state.GetPrecommitsByRound(round); ok {
+		for addr, vote := range precommits.VotesByAddress() {
+			votes.Precommits[addr] = *vote.BlockHash
+		}
+	}
+	return votes, nil
+}
+
+// SubscribeStepEvent implements core.Engine.SubscribeStepEvent
+func (c *core) SubscribeStepEvent(ch chan<- tendermint.StepEvent) event.Subscription {
+	return c.steps.subscribe(ch)
+}
+
+// updateRoundStep moves the current state to the round and step then notifies the subscribers
+func (c *core) updateRoundStep(round int64, step RoundStepType) {
+	c.CurrentState().UpdateRoundStep(round, step)
+	c.sendStepEvent()
+}
+
+func (c *core) sendStepEvent() {
+	state := c.CurrentState()
+	c.steps.notify(tendermint.StepEvent{
+		BlockNumber: state.CopyBlockNumber(),
+		Round:       state.Round(),
+		Step:        state.Step().String(),
+	})
+}
+
+// stepNotifier notifies the steps of the consensus to the subscribers without ever blocking the consensus,
+// a subscriber whose channel is full misses the steps until it has room for them.
+type stepNotifier struct {
+	mu   sync.Mutex
+	subs map[*stepSubscriber]struct{}
+}
+
+type stepSubscriber struct {
+	ch chan<- tendermint.StepEvent
+}
+
+func (n *stepNotifier) subscribe(ch chan<- tendermint.StepEvent) event.Subscription {
+	sub := &stepSubscriber{ch: ch}
+	n.mu.Lock()
+	if n.subs == nil {
+		n.subs = make(map[*stepSubscriber]struct{})
+	}
+	n.subs[sub] = struct{}{}
+	n.mu.Unlock()
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		n.mu.Lock()
+		delete(n.subs, sub)
+		n.mu.Unlock()
+		return nil
+	})
+}
+
+func (n *stepNotifier) notify(step tendermint.StepEvent) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for sub := range n.subs {
+		select {
+		case sub.ch <- step:
+		default:
+		}
+	}
+}
+
+func blockHash(block *types.Block) *common.Hash {
+	if block == nil {
+		return nil
+	}
+	hash := block.Hash()
+	return &hash
+}
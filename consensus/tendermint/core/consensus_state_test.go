@@ -0,0 +1,105 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+func TestCore_ConsensusStateAndRoundVotes(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		otherKey       = tests_utils.MakeNodeKey()
+		nodeAddr       = crypto.PubkeyToAddress(nodePrivateKey.PublicKey)
+		otherAddr      = crypto.PubkeyToAddress(otherKey.PublicKey)
+		validators     = []common.Address{
+			nodeAddr,
+			otherAddr,
+		}
+		genesisHeader = tests_utils.MakeGenesisHeader(validators)
+	)
+	be, _ := tests_utils.MustCreateAndStartNewBackend(t, nodePrivateKey, genesisHeader, validators)
+	core := newTestCore(be, tendermint.DefaultConfig)
+	_, err := core.ConsensusState()
+	require.Equal(t, tendermint.ErrStoppedEngine, err)
+
+	steps := make(chan tendermint.StepEvent, 16)
+	sub := core.SubscribeStepEvent(steps)
+	defer sub.Unsubscribe()
+	require.NoError(t, core.Start())
+	defer func() {
+		require.NoError(t, core.Stop())
+	}()
+
+	select {
+	case step := <-steps:
+		assert.Equal(t, big.NewInt(1), step.BlockNumber)
+		assert.Equal(t, int64(0), step.Round)
+		assert.Equal(t, RoundStepNewRound.String(), step.Step)
+	case <-time.After(tendermint.DefaultConfig.TimeoutCommit + time.Second):
+		t.Fatal("no step event received")
+	}
+
+	state, err := core.ConsensusState()
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), state.BlockNumber)
+	assert.Contains(t, validators, state.Proposer)
+	assert.Equal(t, int64(-1), state.LockedRound)
+	assert.Nil(t, state.LockedBlock)
+
+	blockHash := common.HexToHash("0x1")
+	require.NoError(t, core.handleMsg(mustMakeSignedVote(t, otherKey, msgPrevote, blockHash, big.NewInt(1), 3)))
+	votes, err := core.RoundVotes(big.NewInt(1), 3)
+	require.NoError(t, err)
+	assert.Equal(t, map[common.Address]common.Hash{otherAddr: blockHash}, votes.Prevotes)
+	assert.Empty(t, votes.Precommits)
+
+	// the votes of other block numbers are not kept
+	_, err = core.RoundVotes(big.NewInt(2), 0)
+	assert.Equal(t, tendermint.ErrUnavailableVotes, err)
+}
+
+// TestCore_StepEventsDoNotBlock assures that a subscriber which does not receive the steps does not stall the consensus
+func TestCore_StepEventsDoNotBlock(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		validators     = []common.Address{
+			crypto.PubkeyToAddress(nodePrivateKey.PublicKey),
+		}
+		genesisHeader = tests_utils.MakeGenesisHeader(validators)
+	)
+	be, _ := tests_utils.MustCreateAndStartNewBackend(t, nodePrivateKey, genesisHeader, validators)
+	core := newTestCore(be, tendermint.DefaultConfig)
+
+	stalled := make(chan tendermint.StepEvent)
+	stalledSub := core.SubscribeStepEvent(stalled)
+	steps := make(chan tendermint.StepEvent, 16)
+	sub := core.SubscribeStepEvent(steps)
+	defer sub.Unsubscribe()
+	require.NoError(t, core.Start())
+	defer func() {
+		require.NoError(t, core.Stop())
+	}()
+	// the stalled subscriber leaves before core is stopped
+	defer stalledSub.Unsubscribe()
+
+	timeout := time.After(tendermint.DefaultConfig.TimeoutCommit + 2*time.Second)
+	for {
+		select {
+		case step := <-steps:
+			if step.Step == RoundStepPropose.String() {
+				return
+			}
+		case <-timeout:
+			t.Fatal("the consensus did not move to the propose step")
+		}
+	}
+}
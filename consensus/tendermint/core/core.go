@@ -13,6 +13,7 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/event"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
 )
 
@@ -26,6 +27,15 @@ func WithoutRebroadcast() Option {
 	}
 }
 
+//WithWAL return an option to persist every signed message and lock change into db before it is sent
+//so that core can resume its state after a restart
+func WithWAL(db evrdb.KeyValueStore) Option {
+	return func(c *core) error {
+		c.wal = newWAL(db)
+		return nil
+	}
+}
+
 // New creates an Tendermint consensus core
 func New(backend tendermint.Backend, config *tendermint.Config, opts ...Option) Engine {
 	c := &core{
@@ -39,6 +49,7 @@ func New(backend tendermint.Backend, config *tendermint.Config, opts ...Option)
 		futureProposals: make(map[int64]message),
 		sentMsgStorage:  NewMsgStorage(),
 		rebroadcast:     true,
+		now:             time.Now,
 	}
 	for _, opt := range opts {
 		if err := opt(c); err != nil {
@@ -83,6 +94,9 @@ type core struct {
 	// a Helper supports to store message before send proposal/ vote for every block
 	sentMsgStorage *msgStorage
 
+	// wal persists the sent messages and lock changes, it is replayed when core is started
+	wal *wal
+
 	//proposeStart mark the time core enter propose. This is purely use for metrics
 	proposeStart time.Time
 
@@ -96,6 +110,15 @@ type core struct {
 	futureProposals map[int64]message
 
 	rebroadcast bool
+
+	// steps notifies the steps the consensus moves to
+	steps stepNotifier
+
+	// recorder records the inbound events of core so that they can be replayed, it is nil if core is not recorded
+	recorder Recorder
+
+	// now returns the current time, the replay of a recording sets it to a virtual clock
+	now func() time.Time
 }
 
 // Start implements core.Engine.Start
@@ -104,10 +127,22 @@ func (c *core) Start() error {
 	// Tests will handle events itself, so we have to make subscribeEvents()
 	// be able to call in test.
 	c.getLogger().Infow("starting Tendermint's core...")
+	var replayedMsgs []*MsgStorageData
 	if c.currentState == nil {
-		c.currentState = c.getInitializedState()
+		state := c.getInitializedState()
+		msgs, err := c.restoreStateFromWAL(state)
+		if err != nil {
+			return err
+		}
+		replayedMsgs = msgs
+		c.currentState = state
 		c.valSet = c.backend.Validators(c.CurrentState().BlockNumber())
+		c.updateConfig(c.CurrentState().BlockNumber())
+		if state.Round() > 0 {
+			c.valSet.CalcProposer(c.valSet.GetProposer().Address(), state.Round())
+		}
 	}
+	c.recordStart()
 	c.subscribeEvents()
 
 	// Tests will handle events itself, so we have to make subscribeEvents()
@@ -116,7 +151,11 @@ func (c *core) Start() error {
 		return err
 	}
 	c.startNewRound()
+	c.handlerWg.Add(1)
 	go c.handleEvents()
+	if len(replayedMsgs) > 0 {
+		go c.repostReplayedMsgs(replayedMsgs)
+	}
 
 	return nil
 }
@@ -124,7 +163,8 @@ func (c *core) Start() error {
 // Stop implements core.Engine.Stop
 // Note: this function is not thread-safe
 func (c *core) Stop() error {
-	c.getLogger().Infow("stopping Tendermint's timeout core...")
+	// the state is only read once the handler returns as it is written by the handler
+	zap.S().Infow("stopping Tendermint's timeout core...")
 	err := c.timeout.Stop()
 	c.unsubscribeEvents()
 	c.handlerWg.Wait()
@@ -132,14 +172,32 @@ func (c *core) Stop() error {
 	return err
 }
 
+//updateConfig updates the timeouts of the core to the ones governed on-chain for the block number
+func (c *core) updateConfig(blockNumber *big.Int) {
+	if config := c.backend.Config(blockNumber); config != nil {
+		c.config = config
+	}
+}
+
 //FinalizeMsg set address, signature and encode msg to bytes
 func (c *core) FinalizeMsg(msg *message) ([]byte, error) {
+	return c.finalizeVote(msg, nil)
+}
+
+//finalizeVote finalizes a msg carrying the vote, which is signed by the backend only if it does not conflict with
+//the votes it signed before. A nil vote finalizes a msg which is not a vote.
+func (c *core) finalizeVote(msg *message, vote *tendermint.VoteStep) ([]byte, error) {
 	msg.Address = c.backend.Address()
 	msgPayLoadWithoutSignature, err := msg.PayLoadWithoutSignature()
 	if err != nil {
 		return nil, err
 	}
-	signature, err := c.backend.Sign(msgPayLoadWithoutSignature)
+	var signature []byte
+	if vote != nil {
+		signature, err = c.backend.SignVote(vote, msgPayLoadWithoutSignature)
+	} else {
+		signature, err = c.backend.Sign(msgPayLoadWithoutSignature)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -147,6 +205,23 @@ func (c *core) FinalizeMsg(msg *message) ([]byte, error) {
 	return rlp.EncodeToBytes(msg)
 }
 
+//storeSentMsg writes the msg along with the current lock to the WAL then keeps it in sentMsgStorage for catching up.
+//The msg must not be sent if it failed to be stored.
+func (c *core) storeSentMsg(step RoundStepType, round int64, payload []byte) error {
+	state := c.CurrentState()
+	lock := &walLock{
+		lockedRound: state.LockedRound(),
+		lockedBlock: state.LockedBlock(),
+		validRound:  state.ValidRound(),
+		validBlock:  state.ValidBlock(),
+	}
+	if err := c.wal.writeSentMsg(state.BlockNumber(), step, round, payload, lock); err != nil {
+		return err
+	}
+	c.sentMsgStorage.storeSentMsg(c.getLogger(), step, round, payload)
+	return nil
+}
+
 //SendPropose will Finalize the Proposal in term of signature and
 //Gossip it to other nodes
 func (c *core) SendPropose(propose *Proposal) {
@@ -158,9 +233,14 @@ func (c *core) SendPropose(propose *Proposal) {
 		logger.Errorw("Failed to encode Proposal to bytes", "error", err)
 		return
 	}
-	payload, err := c.FinalizeMsg(&message{
+	payload, err := c.finalizeVote(&message{
 		Code: msgPropose,
 		Msg:  msgData,
+	}, &tendermint.VoteStep{
+		BlockNumber: propose.Block.Number(),
+		Round:       uint64(propose.Round),
+		Step:        msgPropose,
+		BlockHash:   propose.Block.Hash(),
 	})
 	if err != nil {
 		logger.Errorw("Failed to Finalize Proposal", "error", err)
@@ -168,7 +248,10 @@ func (c *core) SendPropose(propose *Proposal) {
 	}
 
 	// store before send propose msg
-	c.sentMsgStorage.storeSentMsg(c.getLogger(), RoundStepPropose, propose.Round, payload)
+	if err := c.storeSentMsg(RoundStepPropose, propose.Round, payload); err != nil {
+		logger.Errorw("Failed to store Proposal, skip sending it", "error", err)
+		return
+	}
 
 	if err := c.backend.Broadcast(c.valSet, c.currentState.CopyBlockNumber(), propose.Round, msgPropose, payload); err != nil {
 		c.getLogger().Errorw("Failed to Broadcast proposal", "error", err)
@@ -194,44 +277,62 @@ func (c *core) SendVote(voteType uint64, block *types.Block, round int64) {
 	var (
 		blockHash = emptyBlockHash
 		seal      []byte
+		blsSeal   []byte
 	)
+	if block != nil {
+		blockHash = block.Hash()
+	}
+	// the signer refuses to sign a vote conflicting with one it signed before
+	voteStep := &tendermint.VoteStep{
+		BlockNumber: c.CurrentState().BlockNumber(),
+		Round:       uint64(round),
+		Step:        voteType,
+		BlockHash:   blockHash,
+	}
 	if block != nil {
 		var err error
 		commitHash := utils.PrepareCommittedSeal(block.Header().Hash())
-		seal, err = c.backend.Sign(commitHash)
+		seal, err = c.backend.SignVote(voteStep, commitHash)
 		if err != nil {
 			logger.Errorw("failed to sign seal", err, "err")
 			return
 		}
-		blockHash = block.Hash()
+		if voteType == msgPrecommit && c.valSet.AggregatesCommits() {
+			if blsSeal, err = c.backend.SignBLS(commitHash); err != nil {
+				logger.Errorw("failed to sign BLS seal", "error", err)
+				return
+			}
+		}
 	}
 	vote := &Vote{
 		BlockHash:   &blockHash,
 		Round:       round,
 		BlockNumber: c.CurrentState().BlockNumber(),
 		Seal:        seal,
+		BLSSeal:     blsSeal,
 	}
 	msgData, err := rlp.EncodeToBytes(vote)
 	if err != nil {
 		logger.Errorw("Failed to encode Vote to bytes", "error", err)
 		return
 	}
-	payload, err := c.FinalizeMsg(&message{
+	payload, err := c.finalizeVote(&message{
 		Code: voteType,
 		Msg:  msgData,
-	})
+	}, voteStep)
 	if err != nil {
 		logger.Errorw("Failed to Finalize Vote", "error", err)
 		return
 	}
 
-	// store before send propose msg
-	switch voteType {
-	case msgPrevote:
-		c.sentMsgStorage.storeSentMsg(c.getLogger(), RoundStepPrevote, round, payload)
-	case msgPrecommit:
-		c.sentMsgStorage.storeSentMsg(c.getLogger(), RoundStepPrecommit, round, payload)
-	default:
+	// store before send vote msg
+	step := RoundStepPrevote
+	if voteType == msgPrecommit {
+		step = RoundStepPrecommit
+	}
+	if err := c.storeSentMsg(step, round, payload); err != nil {
+		logger.Errorw("Failed to store Vote, skip sending it", "error", err)
+		return
 	}
 
 	if err := c.backend.Broadcast(c.valSet, c.currentState.CopyBlockNumber(), round, voteType, payload); err != nil {
@@ -0,0 +1,176 @@
+package core
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// Offense is the equivocation proven by an evidence: a validator signed conflicting votes of the same type at
+// the same block number and round. Every pair of the conflicting votes proves the same offense.
+type Offense struct {
+	Offender    common.Address
+	BlockNumber *big.Int
+	Round       int64
+	Code        uint64
+}
+
+// Hash returns the key of the offense, which does not depend on the votes proving it
+func (o *Offense) Hash() common.Hash {
+	data, _ := rlp.EncodeToBytes([]interface{}{o.Offender, o.BlockNumber, o.Round, o.Code})
+	return crypto.Keccak256Hash(data)
+}
+
+// newEvidence creates an evidence from 2 conflicting vote messages of the same validator
+func newEvidence(msgA, msgB *message) (*types.TendermintEvidence, error) {
+	voteA, err := rlp.EncodeToBytes(msgA)
+	if err != nil {
+		return nil, err
+	}
+	voteB, err := rlp.EncodeToBytes(msgB)
+	if err != nil {
+		return nil, err
+	}
+	return &types.TendermintEvidence{
+		VoteA: voteA,
+		VoteB: voteB,
+	}, nil
+}
+
+// decodeSignedVote decodes a signed vote message and checks its signature
+func decodeSignedVote(payload []byte) (*message, *Vote, error) {
+	var msg message
+	if err := rlp.DecodeBytes(payload, &msg); err != nil {
+		return nil, nil, err
+	}
+	if msg.Code != msgPrevote && msg.Code != msgPrecommit {
+		return nil, nil, errors.Wrapf(tendermint.ErrInvalidEvidence, "unexpected msg code %d", msg.Code)
+	}
+	// only low-s signatures are accepted so that a signature can not be malleated into another valid one
+	if len(msg.Signature) != types.TendermintExtraSeal ||
+		!crypto.ValidateSignatureValues(msg.Signature[64], new(big.Int).SetBytes(msg.Signature[:32]), new(big.Int).SetBytes(msg.Signature[32:64]), true) {
+		return nil, nil, errors.Wrap(tendermint.ErrInvalidEvidence, "invalid vote signature values")
+	}
+	signer, err := msg.GetAddressFromSignature()
+	if err != nil {
+		return nil, nil, err
+	}
+	if signer != msg.Address {
+		return nil, nil, ErrSignerMessageMissMatch
+	}
+	var vote Vote
+	if err := rlp.DecodeBytes(msg.Msg, &vote); err != nil {
+		return nil, nil, err
+	}
+	if vote.BlockHash == nil || vote.BlockNumber == nil {
+		return nil, nil, errors.Wrap(tendermint.ErrInvalidEvidence, "vote without block hash or block number")
+	}
+	return &msg, &vote, nil
+}
+
+// VerifyEvidence checks that the evidence contains 2 votes of the same type, signed by the same validator
+// at the same block number and round but for different blocks.
+// It returns the offense proven by the evidence.
+// Whether the offender is a validator at that block number must be checked by the caller.
+func VerifyEvidence(ev *types.TendermintEvidence) (*Offense, error) {
+	if ev == nil {
+		return nil, tendermint.ErrInvalidEvidence
+	}
+	msgA, voteA, err := decodeSignedVote(ev.VoteA)
+	if err != nil {
+		return nil, err
+	}
+	msgB, voteB, err := decodeSignedVote(ev.VoteB)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case msgA.Code != msgB.Code:
+		return nil, errors.Wrap(tendermint.ErrInvalidEvidence, "votes are of different types")
+	case msgA.Address != msgB.Address:
+		return nil, errors.Wrap(tendermint.ErrInvalidEvidence, "votes are signed by different validators")
+	case voteA.BlockNumber.Cmp(voteB.BlockNumber) != 0 || voteA.Round != voteB.Round:
+		return nil, errors.Wrap(tendermint.ErrInvalidEvidence, "votes are for different block numbers or rounds")
+	case *voteA.BlockHash == *voteB.BlockHash:
+		return nil, errors.Wrap(tendermint.ErrInvalidEvidence, "votes are for the same block")
+	}
+	return &Offense{
+		Offender:    msgA.Address,
+		BlockNumber: voteA.BlockNumber,
+		Round:       voteA.Round,
+		Code:        msgA.Code,
+	}, nil
+}
+
+// reportConflictingVotes builds an evidence from the vote already in msgSet and the conflicting msg
+// then reports it to the backend
+func (c *core) reportConflictingVotes(msgSet *messageSet, msg message, logger *zap.SugaredLogger) {
+	existing, ok := msgSet.GetMessage(msg.Address)
+	if !ok {
+		return
+	}
+	ev, err := newEvidence(existing, &msg)
+	if err != nil {
+		logger.Errorw("failed to create evidence of conflicting votes", "err", err)
+		return
+	}
+	logger.Warnw("validator signed conflicting votes", "offender", msg.Address.Hex(), "evidence", ev.Hash().Hex())
+	if !c.backend.ReportEvidence(ev) {
+		return
+	}
+	msgData, err := rlp.EncodeToBytes(ev)
+	if err != nil {
+		logger.Errorw("failed to encode evidence", "err", err)
+		return
+	}
+	payload, err := c.FinalizeMsg(&message{
+		Code: msgEvidence,
+		Msg:  msgData,
+	})
+	if err != nil {
+		logger.Errorw("failed to finalize evidence msg", "err", err)
+		return
+	}
+	go c.gossipEvidence(c.valSet.GetNeighbors(c.getAddress()), payload, logger)
+}
+
+// handleEvidence verifies an evidence received from other nodes, reports it to the backend
+// and re-gossips it if it is new
+func (c *core) handleEvidence(msg message) error {
+	var ev types.TendermintEvidence
+	if err := rlp.DecodeBytes(msg.Msg, &ev); err != nil {
+		return err
+	}
+	offense, err := VerifyEvidence(&ev)
+	if err != nil {
+		return err
+	}
+	logger := c.getLogger().With("from", msg.Address.Hex(), "offender", offense.Offender.Hex(), "evidence_block", offense.BlockNumber)
+	if !c.backend.ReportEvidence(&ev) {
+		return nil
+	}
+	logger.Infow("received new evidence of conflicting votes", "evidence", ev.Hash().Hex())
+	if msg.Address == c.getAddress() {
+		return nil
+	}
+	payload, err := rlp.EncodeToBytes(&msg)
+	if err != nil {
+		return err
+	}
+	go c.gossipEvidence(c.valSet.GetNeighbors(c.getAddress()), payload, logger)
+	return nil
+}
+
+// gossipEvidence sends an evidence to the targets, which are resolved by the caller holding the lock of the core
+func (c *core) gossipEvidence(targets map[common.Address]bool, payload []byte, logger *zap.SugaredLogger) {
+	if err := c.backend.Multicast(targets, payload); err != nil {
+		logger.Errorw("failed to gossip evidence", "err", err)
+	}
+}
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+func mustMakeSignedVote(t *testing.T, key *ecdsa.PrivateKey, code uint64, blockHash common.Hash, blockNumber *big.Int, round int64) message {
+	msgData, err := rlp.EncodeToBytes(&Vote{
+		BlockHash:   &blockHash,
+		BlockNumber: blockNumber,
+		Round:       round,
+	})
+	require.NoError(t, err)
+	msg := message{
+		Code:    code,
+		Msg:     msgData,
+		Address: crypto.PubkeyToAddress(key.PublicKey),
+	}
+	payload, err := msg.PayLoadWithoutSignature()
+	require.NoError(t, err)
+	msg.Signature, err = crypto.Sign(crypto.Keccak256(payload), key)
+	require.NoError(t, err)
+	return msg
+}
+
+func mustMakeEvidence(t *testing.T, msgA, msgB message) *types.TendermintEvidence {
+	ev, err := newEvidence(&msgA, &msgB)
+	require.NoError(t, err)
+	return ev
+}
+
+func TestVerifyEvidence(t *testing.T) {
+	var (
+		offenderKey = tests_utils.MakeNodeKey()
+		otherKey    = tests_utils.MakeNodeKey()
+		offender    = crypto.PubkeyToAddress(offenderKey.PublicKey)
+		blockNumber = big.NewInt(10)
+		hashA       = common.HexToHash("0x1")
+		hashB       = common.HexToHash("0x2")
+	)
+	prevoteA := mustMakeSignedVote(t, offenderKey, msgPrevote, hashA, blockNumber, 1)
+	prevoteB := mustMakeSignedVote(t, offenderKey, msgPrevote, hashB, blockNumber, 1)
+
+	offense, err := VerifyEvidence(mustMakeEvidence(t, prevoteA, prevoteB))
+	require.NoError(t, err)
+	assert.Equal(t, offender, offense.Offender)
+	assert.Equal(t, blockNumber, offense.BlockNumber)
+	assert.Equal(t, mustMakeEvidence(t, prevoteA, prevoteB).Hash(), mustMakeEvidence(t, prevoteB, prevoteA).Hash())
+
+	// another pair of conflicting votes proves the same offense
+	prevoteC := mustMakeSignedVote(t, offenderKey, msgPrevote, common.HexToHash("0x3"), blockNumber, 1)
+	other, err := VerifyEvidence(mustMakeEvidence(t, prevoteA, prevoteC))
+	require.NoError(t, err)
+	assert.NotEqual(t, mustMakeEvidence(t, prevoteA, prevoteB).Hash(), mustMakeEvidence(t, prevoteA, prevoteC).Hash())
+	assert.Equal(t, offense.Hash(), other.Hash())
+
+	invalidCases := map[string]message{
+		"same block":       mustMakeSignedVote(t, offenderKey, msgPrevote, hashA, blockNumber, 1),
+		"different round":  mustMakeSignedVote(t, offenderKey, msgPrevote, hashB, blockNumber, 2),
+		"different number": mustMakeSignedVote(t, offenderKey, msgPrevote, hashB, big.NewInt(11), 1),
+		"different type":   mustMakeSignedVote(t, offenderKey, msgPrecommit, hashB, blockNumber, 1),
+		"different signer": mustMakeSignedVote(t, otherKey, msgPrevote, hashB, blockNumber, 1),
+	}
+	for name, msgB := range invalidCases {
+		_, err := VerifyEvidence(mustMakeEvidence(t, prevoteA, msgB))
+		assert.Error(t, err, name)
+	}
+
+	// the signature does not match the address
+	forged := prevoteB
+	forged.Address = crypto.PubkeyToAddress(otherKey.PublicKey)
+	_, err = VerifyEvidence(mustMakeEvidence(t, prevoteA, forged))
+	assert.Error(t, err)
+
+	// the high-s copy of a signature is rejected
+	malleated := prevoteB
+	malleated.Signature = common.CopyBytes(prevoteB.Signature)
+	secp256k1N := crypto.S256().Params().N
+	sValue := new(big.Int).SetBytes(malleated.Signature[32:64])
+	copy(malleated.Signature[32:64], common.LeftPadBytes(new(big.Int).Sub(secp256k1N, sValue).Bytes(), 32))
+	malleated.Signature[64] ^= 1
+	signer, err := malleated.GetAddressFromSignature()
+	require.NoError(t, err)
+	require.Equal(t, offender, signer)
+	_, err = VerifyEvidence(mustMakeEvidence(t, prevoteA, malleated))
+	assert.Error(t, err)
+}
+
+func TestCore_ReportConflictingVotes(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		offenderKey    = tests_utils.MakeNodeKey()
+		nodeAddr       = crypto.PubkeyToAddress(nodePrivateKey.PublicKey)
+		offender       = crypto.PubkeyToAddress(offenderKey.PublicKey)
+		validators     = []common.Address{
+			nodeAddr,
+			offender,
+		}
+		genesisHeader = tests_utils.MakeGenesisHeader(validators)
+	)
+	be, _ := tests_utils.MustCreateAndStartNewBackend(t, nodePrivateKey, genesisHeader, validators)
+	mockBackend, ok := be.(*tests_utils.MockBackend)
+	require.True(t, ok)
+	// the messages are handled by the handler of core, the evidences it reports tell the test it is done
+	evidences := make(chan *types.TendermintEvidence, 1)
+	sub := mockBackend.SubscribeEvidences(evidences)
+	defer sub.Unsubscribe()
+	waitForEvidence := func() *types.TendermintEvidence {
+		select {
+		case ev := <-evidences:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("no evidence reported")
+			return nil
+		}
+	}
+	postMsg := func(msg message) {
+		payload, err := rlp.EncodeToBytes(&msg)
+		require.NoError(t, err)
+		require.NoError(t, be.EventMux().Post(tendermint.MessageEvent{Payload: payload}))
+	}
+
+	core := newTestCore(be, tendermint.DefaultConfig)
+	require.NoError(t, core.Start())
+	defer func() {
+		require.NoError(t, core.Stop())
+	}()
+
+	blockNumber := new(big.Int).Add(be.CurrentHeadBlock().Number(), common.Big1)
+	postMsg(mustMakeSignedVote(t, offenderKey, msgPrecommit, common.HexToHash("0x1"), blockNumber, 5))
+	postMsg(mustMakeSignedVote(t, offenderKey, msgPrecommit, common.HexToHash("0x2"), blockNumber, 5))
+	ev := waitForEvidence()
+	require.Len(t, mockBackend.Evidences, 1)
+	assert.Equal(t, mockBackend.Evidences[0], ev)
+	offense, err := VerifyEvidence(ev)
+	require.NoError(t, err)
+	assert.Equal(t, offender, offense.Offender)
+
+	// the same evidence received from other nodes is not reported again
+	msgData, err := rlp.EncodeToBytes(ev)
+	require.NoError(t, err)
+	evidenceMsg := message{
+		Code:    msgEvidence,
+		Msg:     msgData,
+		Address: offender,
+	}
+	payload, err := evidenceMsg.PayLoadWithoutSignature()
+	require.NoError(t, err)
+	evidenceMsg.Signature, err = crypto.Sign(crypto.Keccak256(payload), offenderKey)
+	require.NoError(t, err)
+	postMsg(evidenceMsg)
+	assert.Equal(t, ev.Hash(), waitForEvidence().Hash())
+	assert.Len(t, mockBackend.Evidences, 1)
+}
@@ -22,6 +22,7 @@ var (
 	ErrEmptyBlockProposal           = errors.New("empty block proposal")
 	ErrSignerMessageMissMatch       = errors.New("deprived signer and address field of msg are miss-match")
 	ErrCatchUpReplyAddressMissMatch = errors.New("address of catch up reply msg and its child are miss match")
+	ErrInvalidBLSSeal               = errors.New("invalid bls seal")
 	emptyBlockHash                  = common.Hash{}
 	catchUpReplyBatchSize           = 3 // send 3 votes as the number of msg to jump to next round
 )
@@ -54,8 +55,6 @@ func (c *core) handleEvents() {
 		c.handlerWg.Done()
 	}()
 
-	c.handlerWg.Add(1)
-
 	for {
 		var logger = c.getLogger()
 		select {
@@ -66,9 +65,11 @@ func (c *core) handleEvents() {
 			// A real event arrived, process interesting content
 			switch ev := event.Data.(type) {
 			case tendermint.NewBlockEvent:
+				c.recordRLP(RecordNewBlock, ev.Block)
 				c.handleNewBlock(ev.Block)
 			case tendermint.MessageEvent:
 				//TODO: Handle ev.Payload, if got error then call c.backend.Gossip()
+				c.record(RecordMessage, ev.Payload)
 				var msg message
 				if err := rlp.DecodeBytes(ev.Payload, &msg); err != nil {
 					logger.Errorw("failed to decode msg", "error", err)
@@ -85,6 +86,7 @@ func (c *core) handleEvents() {
 			if !ok {
 				return
 			}
+			c.recordTimeout(ti)
 			c.handleTimeout(ti)
 		case event, ok := <-c.finalCommitted.Chan():
 			if !ok {
@@ -92,6 +94,7 @@ func (c *core) handleEvents() {
 			}
 			switch ev := event.Data.(type) {
 			case tendermint.FinalCommittedEvent:
+				c.recordRLP(RecordFinalCommitted, ev.BlockNumber)
 				_ = c.handleFinalCommitted(ev.BlockNumber)
 			}
 		}
@@ -115,6 +118,9 @@ func (c *core) handleFinalCommitted(newHeadNumber *big.Int) error {
 
 	c.sentMsgStorage.truncateMsgStored(logger)
 	c.updateStateForNewblock()
+	if err := c.wal.truncate(state.BlockNumber()); err != nil {
+		logger.Errorw("failed to truncate WAL", "err", err)
+	}
 	c.startNewRound()
 	if _, err := c.processFutureMessages(logger); err != nil {
 		logger.Errorw("failed to process future msg", "err", err)
@@ -293,6 +299,10 @@ func (c *core) handlePrevote(msg message) error {
 	}
 	//log.Info("received prevote", "from", msg.Address, "round", vote.Round, "block_hash", vote.BlockHash.Hex())
 	added, err := state.addPrevote(msg, &vote, c.valSet)
+	if err == ErrConflictingVotes {
+		prevotes, _ := state.GetPrevotesByRound(vote.Round)
+		c.reportConflictingVotes(prevotes, msg, logger)
+	}
 	if err != nil {
 		return err
 	}
@@ -380,7 +390,15 @@ func (c *core) handlePrecommit(msg message) error {
 		return nil
 	}
 	//log.Info("received precommit", "from", msg.Address, "round", vote.Round, "block_hash", vote.BlockHash.Hex())
+	if err := c.verifyBLSSeal(msg.Address, &vote); err != nil {
+		logger.Warnw("received precommit with invalid bls seal", "err", err)
+		return err
+	}
 	added, err := state.addPrecommit(msg, &vote, c.valSet)
+	if err == ErrConflictingVotes {
+		precommits, _ := state.GetPrecommitsByRound(vote.Round)
+		c.reportConflictingVotes(precommits, msg, logger)
+	}
 	if err != nil {
 		return err
 	}
@@ -526,6 +544,8 @@ func (c *core) handleMsgLocked(msg message) error {
 		return c.handleCatchupRequest(msg)
 	case msgCatchUpReply:
 		return c.handleCatchUpReply(msg)
+	case msgEvidence:
+		return c.handleEvidence(msg)
 	default:
 		return fmt.Errorf("unknown msg code %d", msg.Code)
 	}
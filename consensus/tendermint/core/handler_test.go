@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/Workiva/go-datastructures/queue"
 	"github.com/stretchr/testify/assert"
@@ -31,6 +32,7 @@ func newTestCore(backend tendermint.Backend, config *tendermint.Config) *core {
 		futureMessages: queue.NewPriorityQueue(0, true),
 		sentMsgStorage: NewMsgStorage(),
 		rebroadcast:    false,
+		now:            time.Now,
 	}
 }
 
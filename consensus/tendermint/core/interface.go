@@ -1,5 +1,12 @@
 package core
 
+import (
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/event"
+)
+
 //Engine abstract the core's functions
 //Note that backend and other packages doesn't care about core's internal logic.
 //It only requires core to start receiving/handling messages
@@ -7,4 +14,10 @@ package core
 type Engine interface {
 	Start() error
 	Stop() error
+	// ConsensusState returns a snapshot of the current state of the consensus
+	ConsensusState() (*tendermint.ConsensusState, error)
+	// RoundVotes returns the votes received in a round of the current block number
+	RoundVotes(blockNumber *big.Int, round int64) (*tendermint.RoundVotes, error)
+	// SubscribeStepEvent subscribes to the steps the consensus moves to, the steps which do not fit in ch are dropped
+	SubscribeStepEvent(ch chan<- tendermint.StepEvent) event.Subscription
 }
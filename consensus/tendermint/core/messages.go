@@ -17,6 +17,8 @@ import (
 var (
 	ErrConflictingVotes = errors.New("vote received from the same validator for different block in the same round")
 	ErrDifferentMsgType = errors.New("message set is not of the same type of the received message")
+	// ErrNotVoteMessage is returned if the step of a message which is not a proposal, a prevote or a precommit is decoded
+	ErrNotVoteMessage = errors.New("not a vote message")
 )
 
 // TODO: More msg codes here if needed
@@ -26,8 +28,79 @@ const (
 	msgPrecommit
 	msgCatchUpRequest
 	msgCatchUpReply
+	msgEvidence
 )
 
+// The codes of the messages, exported for the tools inspecting the traffic of core, e.g. the network simulator
+const (
+	MsgPropose        = msgPropose
+	MsgPrevote        = msgPrevote
+	MsgPrecommit      = msgPrecommit
+	MsgCatchUpRequest = msgCatchUpRequest
+	MsgCatchUpReply   = msgCatchUpReply
+	MsgEvidence       = msgEvidence
+)
+
+// DecodeMessageCode returns the code and the sender of an encoded message, its signature is not verified
+func DecodeMessageCode(payload []byte) (uint64, common.Address, error) {
+	var msg message
+	if err := rlp.DecodeBytes(payload, &msg); err != nil {
+		return 0, common.Address{}, err
+	}
+	return msg.Code, msg.Address, nil
+}
+
+// IsVoteMessage returns whether the payload, with or without its signature, is the message of a proposal, a prevote or
+// a precommit. The signer of such a message must check it against the votes it signed before.
+func IsVoteMessage(payload []byte) bool {
+	code, _, err := DecodeMessageCode(payload)
+	return err == nil && isVoteCode(code)
+}
+
+// DecodeVoteStep returns the step of the vote of a proposal, a prevote or a precommit message, e.g. the payload
+// without signature a signer is asked to sign. It returns ErrNotVoteMessage if the message is not a vote.
+func DecodeVoteStep(payload []byte) (*tendermint.VoteStep, error) {
+	var msg message
+	if err := rlp.DecodeBytes(payload, &msg); err != nil {
+		return nil, err
+	}
+	if !isVoteCode(msg.Code) {
+		return nil, ErrNotVoteMessage
+	}
+	if msg.Code == msgPropose {
+		var proposal Proposal
+		if err := rlp.DecodeBytes(msg.Msg, &proposal); err != nil {
+			return nil, err
+		}
+		if proposal.Block == nil || proposal.Round < 0 {
+			return nil, errors.New("invalid proposal")
+		}
+		return &tendermint.VoteStep{
+			BlockNumber: proposal.Block.Number(),
+			Round:       uint64(proposal.Round),
+			Step:        msgPropose,
+			BlockHash:   proposal.Block.Hash(),
+		}, nil
+	}
+	var vote Vote
+	if err := rlp.DecodeBytes(msg.Msg, &vote); err != nil {
+		return nil, err
+	}
+	if vote.BlockHash == nil || vote.BlockNumber == nil || vote.Round < 0 {
+		return nil, errors.New("invalid vote")
+	}
+	return &tendermint.VoteStep{
+		BlockNumber: vote.BlockNumber,
+		Round:       uint64(vote.Round),
+		Step:        msg.Code,
+		BlockHash:   *vote.BlockHash,
+	}, nil
+}
+
+func isVoteCode(code uint64) bool {
+	return code == msgPropose || code == msgPrevote || code == msgPrecommit
+}
+
 //message is used to store consensus information between steps
 type message struct {
 	Code      uint64
@@ -101,7 +174,7 @@ func (item *msgItem) Compare(other queue.Item) int {
 //blockVotes store the voting received for a particular block
 type blockVotes struct {
 	votes         []*Vote // validatorIndex -> *Vote
-	totalReceived int
+	totalReceived int     // total voting power of the votes
 }
 
 type messageSet struct {
@@ -113,7 +186,7 @@ type messageSet struct {
 	voteByAddress map[common.Address]*Vote
 	voteByBlock   map[common.Hash]*blockVotes
 	maj23         *common.Hash
-	totalReceived int
+	totalReceived int // total voting power of the votes
 	//TODO: Do we have to keep track of which peer has 2/3Majority?
 }
 
@@ -142,6 +215,17 @@ func (ms *messageSet) VotesByAddress() map[common.Address]*Vote {
 	return ret
 }
 
+// GetMessage returns the message added from the given address
+func (ms *messageSet) GetMessage(addr common.Address) (*message, bool) {
+	if ms == nil {
+		return nil, false
+	}
+	ms.messagesMu.Lock()
+	defer ms.messagesMu.Unlock()
+	msg, ok := ms.messages[addr]
+	return msg, ok
+}
+
 func (ms *messageSet) AddVote(msg message, vote *Vote) (bool, error) {
 	ms.messagesMu.Lock()
 	defer ms.messagesMu.Unlock()
@@ -149,7 +233,7 @@ func (ms *messageSet) AddVote(msg message, vote *Vote) (bool, error) {
 	if ms.msgCode != msg.Code {
 		return false, ErrDifferentMsgType
 	}
-	index, _ := ms.valSet.GetByAddress(msg.Address)
+	index, val := ms.valSet.GetByAddress(msg.Address)
 	if index == -1 {
 		return false, errors.Wrapf(ErrVoteInvalidValidatorAddress, "address in vote message:%s ", msg.Address.String())
 	}
@@ -178,8 +262,8 @@ func (ms *messageSet) AddVote(msg message, vote *Vote) (bool, error) {
 
 	ms.messages[msg.Address] = &msg
 	ms.voteByAddress[msg.Address] = vote
-	ms.totalReceived++
-	if err := ms.addVoteToBlockVote(vote, index); err != nil {
+	ms.totalReceived += int(val.VotingPower())
+	if err := ms.addVoteToBlockVote(vote, index, val.VotingPower()); err != nil {
 		return false, err
 	}
 
@@ -192,7 +276,7 @@ func (ms *messageSet) AddVote(msg message, vote *Vote) (bool, error) {
 	return true, nil
 }
 
-func (ms *messageSet) addVoteToBlockVote(vote *Vote, index int) error {
+func (ms *messageSet) addVoteToBlockVote(vote *Vote, index int, votingPower int64) error {
 	bvotes, exist := ms.voteByBlock[*(vote.BlockHash)]
 	if !exist {
 		bvotes = &blockVotes{
@@ -205,7 +289,7 @@ func (ms *messageSet) addVoteToBlockVote(vote *Vote, index int) error {
 		return ErrConflictingVotes
 	}
 	bvotes.votes[index] = vote
-	bvotes.totalReceived++
+	bvotes.totalReceived += int(votingPower)
 	ms.voteByBlock[*(vote.BlockHash)] = bvotes
 	return nil
 }
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+func TestMessageSet_WeightedVotes(t *testing.T) {
+	var (
+		heavyKey    = tests_utils.MakeNodeKey()
+		lightKey    = tests_utils.MakeNodeKey()
+		heavy       = crypto.PubkeyToAddress(heavyKey.PublicKey)
+		light       = crypto.PubkeyToAddress(lightKey.PublicKey)
+		blockNumber = big.NewInt(1)
+		blockHash   = common.HexToHash("0x1")
+		valSet      = validator.NewWeightedSet([]common.Address{heavy, light}, []int64{3, 1}, nil, tendermint.RoundRobin, 1, 0)
+		msgSet      = newMessageSet(valSet, msgPrevote, &tendermint.View{BlockNumber: blockNumber, Round: 0})
+	)
+	// MinMajority = 4 - (ceil(4/3) - 1) = 3
+	require.Equal(t, 3, valSet.MinMajority())
+
+	msg := mustMakeSignedVote(t, lightKey, msgPrevote, blockHash, blockNumber, 0)
+	added, err := msgSet.AddVote(msg, &Vote{BlockHash: &blockHash, BlockNumber: blockNumber, Round: 0})
+	require.NoError(t, err)
+	require.True(t, added)
+	assert.False(t, msgSet.HasTwoThirdAny())
+	assert.False(t, msgSet.HasMajority())
+
+	msg = mustMakeSignedVote(t, heavyKey, msgPrevote, blockHash, blockNumber, 0)
+	added, err = msgSet.AddVote(msg, &Vote{BlockHash: &blockHash, BlockNumber: blockNumber, Round: 0})
+	require.NoError(t, err)
+	require.True(t, added)
+	assert.True(t, msgSet.HasTwoThirdAny())
+	majority, ok := msgSet.TwoThirdMajority()
+	assert.True(t, ok)
+	assert.Equal(t, blockHash, majority)
+}
@@ -0,0 +1,449 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// RecordKind enumerates the kind of data a record entry holds
+type RecordKind uint8
+
+const (
+	// RecordStart is the start of core, its payload is the JSON encoded recordStart
+	RecordStart RecordKind = iota + 1
+	// RecordMessage is an inbound consensus message, its payload is the message as received
+	RecordMessage
+	// RecordNewBlock is a block to propose received from the miner, its payload is the rlp encoded block
+	RecordNewBlock
+	// RecordFinalCommitted is a block inserted in the chain, its payload is the rlp encoded block number
+	RecordFinalCommitted
+	// RecordTimeout is a timeout fired by the timeout ticker, its payload is the rlp encoded recordTimeout
+	RecordTimeout
+	// RecordValSet is a validator set returned by the backend, its payload is the rlp encoded recordValSet
+	RecordValSet
+	// RecordConfig is a config returned by the backend, its payload is the JSON encoded recordConfig
+	RecordConfig
+	// RecordVerification is the result of the verification of a proposed block, its payload is the rlp encoded recordVerification
+	RecordVerification
+	// RecordSent is an outbound consensus message, its payload is the message as sent
+	RecordSent
+)
+
+// String implements fmt.Stringer
+func (k RecordKind) String() string {
+	switch k {
+	case RecordStart:
+		return "start"
+	case RecordMessage:
+		return "message"
+	case RecordNewBlock:
+		return "new_block"
+	case RecordFinalCommitted:
+		return "final_committed"
+	case RecordTimeout:
+		return "timeout"
+	case RecordValSet:
+		return "val_set"
+	case RecordConfig:
+		return "config"
+	case RecordVerification:
+		return "verification"
+	case RecordSent:
+		return "sent"
+	default:
+		return "unknown"
+	}
+}
+
+// RecordEntry is the unit of data of a recording, Time is in nanoseconds since the unix epoch
+type RecordEntry struct {
+	Time    uint64
+	Kind    RecordKind
+	Payload []byte
+}
+
+// Timestamp returns the time the entry was recorded at
+func (e *RecordEntry) Timestamp() time.Time {
+	return time.Unix(0, int64(e.Time))
+}
+
+// Describe returns a short description of the content of the entry
+func (e *RecordEntry) Describe() string {
+	switch e.Kind {
+	case RecordStart:
+		var start recordStart
+		if err := json.Unmarshal(e.Payload, &start); err != nil {
+			return err.Error()
+		}
+		return fmt.Sprintf("address: %s, block: %v, round: %d, step: %s", start.Address.Hex(), start.BlockNumber, start.Round, start.Step)
+	case RecordMessage, RecordSent:
+		var msg message
+		if err := rlp.DecodeBytes(e.Payload, &msg); err != nil {
+			return err.Error()
+		}
+		return describeMessage(&msg)
+	case RecordNewBlock:
+		var block types.Block
+		if err := rlp.DecodeBytes(e.Payload, &block); err != nil {
+			return err.Error()
+		}
+		return fmt.Sprintf("block: %v, hash: %s", block.Number(), block.Hash().Hex())
+	case RecordFinalCommitted:
+		var number big.Int
+		if err := rlp.DecodeBytes(e.Payload, &number); err != nil {
+			return err.Error()
+		}
+		return fmt.Sprintf("block: %v", &number)
+	case RecordTimeout:
+		var rec recordTimeout
+		if err := rlp.DecodeBytes(e.Payload, &rec); err != nil {
+			return err.Error()
+		}
+		return formatTimeout(rec.timeoutInfo())
+	case RecordValSet:
+		var rec recordValSet
+		if err := rlp.DecodeBytes(e.Payload, &rec); err != nil {
+			return err.Error()
+		}
+		return fmt.Sprintf("block: %v, validators: %d", rec.BlockNumber, len(rec.Addresses))
+	case RecordConfig:
+		var rec recordConfig
+		if err := json.Unmarshal(e.Payload, &rec); err != nil {
+			return err.Error()
+		}
+		return fmt.Sprintf("block: %v", rec.BlockNumber)
+	case RecordVerification:
+		var rec recordVerification
+		if err := rlp.DecodeBytes(e.Payload, &rec); err != nil {
+			return err.Error()
+		}
+		return fmt.Sprintf("hash: %s, header: %t, err: %q", rec.BlockHash.Hex(), rec.Header, rec.Err)
+	}
+	return ""
+}
+
+func describeMessage(msg *message) string {
+	switch msg.Code {
+	case msgPropose:
+		var proposal Proposal
+		if err := rlp.DecodeBytes(msg.Msg, &proposal); err != nil || proposal.Block == nil {
+			return fmt.Sprintf("proposal from %s", msg.Address.Hex())
+		}
+		return fmt.Sprintf("proposal from %s, block: %v, round: %d, hash: %s", msg.Address.Hex(),
+			proposal.Block.Number(), proposal.Round, proposal.Block.Hash().Hex())
+	case msgPrevote, msgPrecommit:
+		name := "prevote"
+		if msg.Code == msgPrecommit {
+			name = "precommit"
+		}
+		var vote Vote
+		if err := rlp.DecodeBytes(msg.Msg, &vote); err != nil || vote.BlockHash == nil {
+			return fmt.Sprintf("%s from %s", name, msg.Address.Hex())
+		}
+		return fmt.Sprintf("%s from %s, block: %v, round: %d, hash: %s", name, msg.Address.Hex(),
+			vote.BlockNumber, vote.Round, vote.BlockHash.Hex())
+	case msgCatchUpRequest:
+		return fmt.Sprintf("catch up request from %s", msg.Address.Hex())
+	case msgCatchUpReply:
+		return fmt.Sprintf("catch up reply from %s", msg.Address.Hex())
+	case msgEvidence:
+		return fmt.Sprintf("evidence from %s", msg.Address.Hex())
+	}
+	return fmt.Sprintf("unknown message %d from %s", msg.Code, msg.Address.Hex())
+}
+
+// recordStart is the state core starts at, the round and step may be restored from the WAL
+type recordStart struct {
+	Address     common.Address
+	BlockNumber *big.Int
+	Round       int64
+	Step        RoundStepType
+	Config      *tendermint.Config
+}
+
+// recordTimeout is a timeoutInfo, the round is stored as uint64 since rlp does not support signed integers
+type recordTimeout struct {
+	Duration    uint64
+	BlockNumber *big.Int
+	Round       uint64
+	Step        RoundStepType
+	Retry       uint64
+}
+
+func newRecordTimeout(ti timeoutInfo) *recordTimeout {
+	return &recordTimeout{
+		Duration:    uint64(ti.Duration),
+		BlockNumber: ti.BlockNumber,
+		Round:       uint64(ti.Round),
+		Step:        ti.Step,
+		Retry:       ti.Retry,
+	}
+}
+
+func (t *recordTimeout) timeoutInfo() timeoutInfo {
+	return timeoutInfo{
+		Duration:    time.Duration(t.Duration),
+		BlockNumber: t.BlockNumber,
+		Round:       int64(t.Round),
+		Step:        t.Step,
+		Retry:       t.Retry,
+	}
+}
+
+// recordValSet is the validator set of a block number, Height is the height the proposer is selected at
+type recordValSet struct {
+	BlockNumber   *big.Int
+	Height        uint64
+	Policy        uint64
+	Addresses     []common.Address
+	VotingPowers  []uint64
+	BLSPublicKeys [][]byte
+}
+
+// recordConfig is the config of a block number
+type recordConfig struct {
+	BlockNumber *big.Int
+	Config      *tendermint.Config
+}
+
+// recordVerification is the result of the verification of a proposed block, Err is empty if it is valid
+type recordVerification struct {
+	BlockHash common.Hash
+	Header    bool // whether the header or the block is verified
+	Err       string
+}
+
+// Recorder records the inputs and outputs of core so that its execution can be replayed.
+// It must be safe for concurrent use.
+type Recorder interface {
+	Record(kind RecordKind, payload []byte)
+}
+
+// WithRecorder return an option to record every inbound and outbound message, timeout and backend answer of core.
+// The recording can be replayed with a Replayer.
+func WithRecorder(recorder Recorder) Option {
+	return func(c *core) error {
+		c.recorder = recorder
+		c.backend = &recordingBackend{Backend: c.backend, recorder: recorder}
+		return nil
+	}
+}
+
+// record writes an entry to the recorder of core if any
+func (c *core) record(kind RecordKind, payload []byte) {
+	if c.recorder != nil {
+		c.recorder.Record(kind, payload)
+	}
+}
+
+// recordRLP writes an entry with the rlp encoded value to the recorder of core if any
+func (c *core) recordRLP(kind RecordKind, value interface{}) {
+	if c.recorder == nil {
+		return
+	}
+	payload, err := rlp.EncodeToBytes(value)
+	if err != nil {
+		c.getLogger().Errorw("failed to encode record entry", "kind", kind, "error", err)
+		return
+	}
+	c.recorder.Record(kind, payload)
+}
+
+func (c *core) recordStart() {
+	if c.recorder == nil {
+		return
+	}
+	state := c.CurrentState()
+	payload, err := json.Marshal(&recordStart{
+		Address:     c.backend.Address(),
+		BlockNumber: state.CopyBlockNumber(),
+		Round:       state.Round(),
+		Step:        state.Step(),
+		Config:      c.config,
+	})
+	if err != nil {
+		c.getLogger().Errorw("failed to encode record entry", "kind", RecordStart, "error", err)
+		return
+	}
+	c.recorder.Record(RecordStart, payload)
+}
+
+func (c *core) recordTimeout(ti timeoutInfo) {
+	c.recordRLP(RecordTimeout, newRecordTimeout(ti))
+}
+
+// recordingBackend records the answers of the backend which core depends on and the messages core sends
+type recordingBackend struct {
+	tendermint.Backend
+	recorder Recorder
+}
+
+func (b *recordingBackend) recordRLP(kind RecordKind, value interface{}) {
+	payload, err := rlp.EncodeToBytes(value)
+	if err != nil {
+		return
+	}
+	b.recorder.Record(kind, payload)
+}
+
+// Validators implements tendermint.Backend.Validators
+func (b *recordingBackend) Validators(blockNumber *big.Int) tendermint.ValidatorSet {
+	valSet := b.Backend.Validators(blockNumber)
+	if valSet == nil {
+		return nil
+	}
+	rec := &recordValSet{
+		BlockNumber: new(big.Int).Set(blockNumber),
+		Height:      uint64(valSet.Height()),
+		Policy:      uint64(valSet.Policy()),
+	}
+	for _, val := range valSet.List() {
+		rec.Addresses = append(rec.Addresses, val.Address())
+		rec.VotingPowers = append(rec.VotingPowers, uint64(val.VotingPower()))
+		if valSet.AggregatesCommits() {
+			rec.BLSPublicKeys = append(rec.BLSPublicKeys, val.BLSPublicKey())
+		}
+	}
+	b.recordRLP(RecordValSet, rec)
+	return valSet
+}
+
+// Config implements tendermint.Backend.Config
+func (b *recordingBackend) Config(blockNumber *big.Int) *tendermint.Config {
+	config := b.Backend.Config(blockNumber)
+	if config == nil {
+		return nil
+	}
+	if payload, err := json.Marshal(&recordConfig{BlockNumber: blockNumber, Config: config}); err == nil {
+		b.recorder.Record(RecordConfig, payload)
+	}
+	return config
+}
+
+// VerifyProposalHeader implements tendermint.Backend.VerifyProposalHeader
+func (b *recordingBackend) VerifyProposalHeader(header *types.Header) error {
+	err := b.Backend.VerifyProposalHeader(header)
+	b.recordVerification(header.Hash(), true, err)
+	return err
+}
+
+// VerifyProposalBlock implements tendermint.Backend.VerifyProposalBlock
+func (b *recordingBackend) VerifyProposalBlock(block *types.Block) error {
+	err := b.Backend.VerifyProposalBlock(block)
+	b.recordVerification(block.Hash(), false, err)
+	return err
+}
+
+func (b *recordingBackend) recordVerification(hash common.Hash, header bool, err error) {
+	rec := &recordVerification{BlockHash: hash, Header: header}
+	if err != nil {
+		rec.Err = err.Error()
+	}
+	b.recordRLP(RecordVerification, rec)
+}
+
+// Gossip implements tendermint.Backend.Gossip
+func (b *recordingBackend) Gossip(valSet tendermint.ValidatorSet, blockNumber *big.Int, round int64, msgType uint64, payload []byte) error {
+	b.recorder.Record(RecordSent, payload)
+	return b.Backend.Gossip(valSet, blockNumber, round, msgType, payload)
+}
+
+// Broadcast implements tendermint.Backend.Broadcast
+func (b *recordingBackend) Broadcast(valSet tendermint.ValidatorSet, blockNumber *big.Int, round int64, msgType uint64, payload []byte) error {
+	b.recorder.Record(RecordSent, payload)
+	return b.Backend.Broadcast(valSet, blockNumber, round, msgType, payload)
+}
+
+// Multicast implements tendermint.Backend.Multicast
+func (b *recordingBackend) Multicast(targets map[common.Address]bool, payload []byte) error {
+	b.recorder.Record(RecordSent, payload)
+	return b.Backend.Multicast(targets, payload)
+}
+
+// FileRecorder is a Recorder appending the rlp encoded entries to a file
+type FileRecorder struct {
+	mu   sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+	err  error
+}
+
+// NewFileRecorder returns a FileRecorder appending to the file at path, which is created if it does not exist
+func NewFileRecorder(path string) (*FileRecorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileRecorder{file: file, w: bufio.NewWriter(file)}, nil
+}
+
+// Record implements Recorder.Record.
+// The entry is flushed to the file right away so that the recording survives a crash of the node.
+func (r *FileRecorder) Record(kind RecordKind, payload []byte) {
+	entry := &RecordEntry{Time: uint64(time.Now().UnixNano()), Kind: kind, Payload: payload}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if err := rlp.Encode(r.w, entry); err != nil {
+		r.fail(err)
+		return
+	}
+	if err := r.w.Flush(); err != nil {
+		r.fail(err)
+	}
+}
+
+// fail keeps the first error of the recorder, which records nothing after it
+func (r *FileRecorder) fail(err error) {
+	r.err = err
+	log.Error("Failed to record Tendermint consensus, the recording stops here", "path", r.file.Name(), "err", err)
+}
+
+// Close closes the file, it returns the first error the recorder met if any
+func (r *FileRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.file.Close(); err != nil && r.err == nil {
+		r.err = err
+	}
+	return r.err
+}
+
+// ReadRecording reads the entries of a recording written by a FileRecorder
+func ReadRecording(path string) ([]*RecordEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var (
+		stream  = rlp.NewStream(bufio.NewReader(file), 0)
+		entries []*RecordEntry
+	)
+	for {
+		var entry RecordEntry
+		if err := stream.Decode(&entry); err != nil {
+			if err == io.EOF {
+				return entries, nil
+			}
+			return entries, errors.Wrapf(err, "invalid entry %d", len(entries))
+		}
+		entries = append(entries, &entry)
+	}
+}
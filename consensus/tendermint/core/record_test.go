@@ -0,0 +1,148 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/crypto/bls"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// mockSigner signs like the mock backend, which does not guard against double signing
+type mockSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s *mockSigner) Address() common.Address {
+	return crypto.PubkeyToAddress(s.key.PublicKey)
+}
+
+func (s *mockSigner) Sign(data []byte) ([]byte, error) {
+	return crypto.Sign(crypto.Keccak256(data), s.key)
+}
+
+func (s *mockSigner) SignVote(vote *tendermint.VoteStep, data []byte) ([]byte, error) {
+	return s.Sign(data)
+}
+
+func (s *mockSigner) BLSKey() (*bls.SecretKey, error) {
+	return bls.DeriveSecretKey(crypto.FromECDSA(s.key)), nil
+}
+
+func waitForStep(t *testing.T, steps <-chan tendermint.StepEvent, round int64, step RoundStepType) {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-steps:
+			if ev.Round == round && ev.Step == step.String() {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("core did not reach step %s of round %d", step, round)
+		}
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		otherKey       = tests_utils.MakeNodeKey()
+		validators     = []common.Address{
+			crypto.PubkeyToAddress(nodePrivateKey.PublicKey),
+			crypto.PubkeyToAddress(otherKey.PublicKey),
+		}
+		genesisHeader = tests_utils.MakeGenesisHeader(validators)
+		config        = *tendermint.DefaultConfig
+	)
+	config.TimeoutCommit = 50 * time.Millisecond
+	config.TimeoutPropose = 100 * time.Millisecond
+
+	dir, err := ioutil.TempDir("", "tendermint-record")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "record")
+	recorder, err := NewFileRecorder(path)
+	require.NoError(t, err)
+
+	be, _ := tests_utils.MustCreateAndStartNewBackend(t, nodePrivateKey, genesisHeader, validators)
+	c := New(be, &config, WithoutRebroadcast(), WithRecorder(recorder)).(*core)
+	steps := make(chan tendermint.StepEvent, 64)
+	sub := c.SubscribeStepEvent(steps)
+	defer sub.Unsubscribe()
+	require.NoError(t, c.Start())
+
+	// no block is proposed, the validators prevote and precommit for no block then move to the next round
+	postVote := func(code uint64, round int64) {
+		msg := mustMakeSignedVote(t, otherKey, code, emptyBlockHash, big.NewInt(1), round)
+		payload, err := rlp.EncodeToBytes(&msg)
+		require.NoError(t, err)
+		require.NoError(t, be.EventMux().Post(tendermint.MessageEvent{Payload: payload}))
+	}
+	waitForStep(t, steps, 0, RoundStepPrevote)
+	postVote(msgPrevote, 0)
+	waitForStep(t, steps, 0, RoundStepPrecommit)
+	postVote(msgPrecommit, 0)
+	waitForStep(t, steps, 1, RoundStepPropose)
+	require.NoError(t, c.Stop())
+	require.NoError(t, recorder.Close())
+	recorded, err := c.ConsensusState()
+	require.NoError(t, err)
+
+	entries, err := ReadRecording(path)
+	require.NoError(t, err)
+	replayer, err := NewReplayer(entries, &mockSigner{key: nodePrivateKey})
+	require.NoError(t, err)
+	for !replayer.Done() {
+		step, err := replayer.Step()
+		require.NoError(t, err)
+		assert.Empty(t, step.Divergence, "entry %d: %s %s", step.Index, step.Entry.Kind, step.Entry.Describe())
+	}
+	replayed, err := replayer.Engine().ConsensusState()
+	require.NoError(t, err)
+	assert.Equal(t, recorded.BlockNumber, replayed.BlockNumber)
+	assert.Equal(t, recorded.Round, replayed.Round)
+	assert.Equal(t, recorded.Step, replayed.Step)
+	assert.Equal(t, recorded.Proposer, replayed.Proposer)
+	_, err = replayer.Step()
+	assert.Equal(t, ErrReplayEnded, err)
+
+	// the virtual clock fires the timeout of the propose step once the recording is replayed
+	step := replayer.Advance(time.Minute)
+	require.NotNil(t, step)
+	assert.Equal(t, RoundStepPrevote.String(), step.State.Step)
+	assert.Equal(t, int64(1), step.State.Round)
+}
+
+// TestFileRecorder_WriteError assures that the recorder stops at the first write error and reports it when it is closed
+func TestFileRecorder_WriteError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tendermint-record")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "record")
+	recorder, err := NewFileRecorder(path)
+	require.NoError(t, err)
+
+	recorder.Record(RecordMessage, []byte{0x1})
+	require.NoError(t, recorder.file.Close())
+	recorder.Record(RecordMessage, []byte{0x2})
+	recorder.Record(RecordMessage, []byte{0x3})
+	err = recorder.Close()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), os.ErrClosed.Error())
+
+	entries, err := ReadRecording(path)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,542 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
+	evrynetCore "github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/event"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+var (
+	// ErrNoRecordStart is returned if a recording has no start of core to replay from
+	ErrNoRecordStart = errors.New("recording has no start of core")
+	// ErrReplayEnded is returned if every entry of a recording is replayed
+	ErrReplayEnded = errors.New("end of recording")
+	// errReplayNoSigner is returned when core signs a message during a replay without signer
+	errReplayNoSigner = errors.New("replay has no signer")
+	// errNotRecorded is returned when core asks the backend for an answer which is not recorded
+	errNotRecorded = errors.New("answer of the backend is not recorded")
+)
+
+// ReplayStep is the result of the replay of an entry of a recording
+type ReplayStep struct {
+	Index int          // the index of the entry in the recording, -1 for a timeout fired by the virtual clock
+	Entry *RecordEntry // the entry replayed
+	Time  time.Time    // the virtual time the entry is replayed at
+
+	State *tendermint.ConsensusState // the state of core after the entry is replayed
+	Err   error                      // the error core returned handling the entry
+	// Divergence describes how the replay diverges from the recording at this entry, it is empty if it does not
+	Divergence string
+}
+
+// Replayer re-executes a recording against core deterministically, one entry at a time.
+// The timeouts are driven by a virtual clock set to the time of the entries. The backend answers what it answered
+// when recorded, and the messages core sends are compared to the recorded ones if the replayer has a signer.
+// The locks core may restore from its WAL at start are not recorded, so a replay starting after a restart in the
+// middle of a round may diverge from the recording.
+type Replayer struct {
+	entries []*RecordEntry
+	next    int
+
+	signer  tendermint.Signer
+	core    *core
+	backend *replayBackend
+	ticker  *virtualTimeoutTicker
+	clock   time.Time
+
+	sentIndex int // the index of the next message sent by the replay to compare to the recording
+}
+
+// NewReplayer returns a Replayer of the recorded entries, which starts at the first start of core in the recording.
+// The messages are signed by signer, a nil signer replays as an observer which neither signs nor sends anything.
+func NewReplayer(entries []*RecordEntry, signer tendermint.Signer) (*Replayer, error) {
+	r := &Replayer{
+		entries: entries,
+		signer:  signer,
+		next:    -1,
+	}
+	for i, entry := range entries {
+		if entry.Kind == RecordStart {
+			r.next = i
+			break
+		}
+	}
+	if r.next == -1 {
+		return nil, ErrNoRecordStart
+	}
+	if _, err := r.Step(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Done returns whether every entry of the recording is replayed
+func (r *Replayer) Done() bool {
+	return r.next >= len(r.entries)
+}
+
+// Now returns the virtual time of the replay
+func (r *Replayer) Now() time.Time {
+	return r.clock
+}
+
+// Engine returns the core being replayed, to inspect its state
+func (r *Replayer) Engine() Engine {
+	return r.core
+}
+
+// Committed returns the blocks core committed during the replay
+func (r *Replayer) Committed() []*types.Block {
+	return r.backend.committedBlocks()
+}
+
+// Step replays the next entry of the recording. It returns ErrReplayEnded if every entry is replayed.
+func (r *Replayer) Step() (*ReplayStep, error) {
+	if r.Done() {
+		return nil, ErrReplayEnded
+	}
+	index := r.next
+	entry := r.entries[index]
+	r.next++
+
+	if t := entry.Timestamp(); t.After(r.clock) {
+		r.clock = t
+	}
+	step := &ReplayStep{Index: index, Entry: entry, Time: r.clock}
+	switch entry.Kind {
+	case RecordStart:
+		if err := r.start(entry); err != nil {
+			return nil, errors.Wrapf(err, "invalid start at entry %d", index)
+		}
+	case RecordMessage:
+		var msg message
+		if err := rlp.DecodeBytes(entry.Payload, &msg); err != nil {
+			return nil, errors.Wrapf(err, "invalid message at entry %d", index)
+		}
+		step.Err = r.core.handleMsg(msg)
+	case RecordNewBlock:
+		var block types.Block
+		if err := rlp.DecodeBytes(entry.Payload, &block); err != nil {
+			return nil, errors.Wrapf(err, "invalid block at entry %d", index)
+		}
+		r.core.handleNewBlock(&block)
+	case RecordFinalCommitted:
+		var number big.Int
+		if err := rlp.DecodeBytes(entry.Payload, &number); err != nil {
+			return nil, errors.Wrapf(err, "invalid block number at entry %d", index)
+		}
+		r.backend.setHead(&number)
+		step.Err = r.core.handleFinalCommitted(&number)
+	case RecordTimeout:
+		var rec recordTimeout
+		if err := rlp.DecodeBytes(entry.Payload, &rec); err != nil {
+			return nil, errors.Wrapf(err, "invalid timeout at entry %d", index)
+		}
+		// a timeout may fire right before core schedules a later one, it is then recorded after the later one
+		// is scheduled, which is still pending
+		ti := rec.timeoutInfo()
+		if r.ticker.pending && sameTimeout(r.ticker.current, ti) {
+			r.ticker.fire()
+		} else if !ti.earlierOrEqual(r.ticker.current) {
+			step.Divergence = fmt.Sprintf("timeout %s is recorded but the replay scheduled %s", formatTimeout(ti), formatTimeout(r.ticker.current))
+		}
+		r.core.handleTimeout(ti)
+	case RecordSent:
+		step.Divergence = r.compareSent(entry.Payload)
+	}
+	step.State, _ = r.core.ConsensusState()
+	return step, nil
+}
+
+// Advance moves the virtual clock forward by d once the recording is replayed, to see how core carries on.
+// The clock stops at the deadline of the timeout scheduled by core if it is earlier, the timeout is then fired.
+// It returns nil if no timeout is fired.
+func (r *Replayer) Advance(d time.Duration) *ReplayStep {
+	deadline := r.clock.Add(d)
+	if !r.ticker.pending || r.ticker.deadline.After(deadline) {
+		r.clock = deadline
+		return nil
+	}
+	if r.ticker.deadline.After(r.clock) {
+		r.clock = r.ticker.deadline
+	}
+	ti, _ := r.ticker.fire()
+	r.core.handleTimeout(ti)
+	step := &ReplayStep{Index: -1, Time: r.clock}
+	step.Entry = &RecordEntry{Time: uint64(r.clock.UnixNano()), Kind: RecordTimeout}
+	step.Entry.Payload, _ = rlp.EncodeToBytes(newRecordTimeout(ti))
+	step.State, _ = r.core.ConsensusState()
+	return step
+}
+
+// start (re)creates core at the state it started at, a recording may contain several starts if the node restarted.
+// It mirrors core.Start without subscribing to the events, which are fed by the replayer.
+func (r *Replayer) start(entry *RecordEntry) error {
+	var start recordStart
+	if err := json.Unmarshal(entry.Payload, &start); err != nil {
+		return err
+	}
+	if start.Config == nil || start.BlockNumber == nil {
+		return errors.New("missing config or block number")
+	}
+	backend, err := newReplayBackend(&start, r.entries, r.signer)
+	if err != nil {
+		return err
+	}
+	r.backend = backend
+	r.ticker = newVirtualTimeoutTicker(r.Now)
+	r.sentIndex = 0
+
+	c := New(backend, start.Config, WithoutRebroadcast()).(*core)
+	c.timeout = r.ticker
+	c.now = r.Now
+	state := c.getInitializedState()
+	state.UpdateRoundStep(start.Round, start.Step)
+	c.currentState = state
+	c.valSet = backend.Validators(state.BlockNumber())
+	c.updateConfig(state.BlockNumber())
+	if state.Round() > 0 {
+		c.valSet.CalcProposer(c.valSet.GetProposer().Address(), state.Round())
+	}
+	c.startNewRound()
+	r.core = c
+	return nil
+}
+
+// compareSent compares a recorded message sent by core to the one the replay sent, it returns the divergence if any.
+// The messages relayed for other validators are not compared since the replay does not relay.
+func (r *Replayer) compareSent(payload []byte) string {
+	var msg message
+	if err := rlp.DecodeBytes(payload, &msg); err != nil || r.signer == nil || msg.Address != r.backend.address {
+		return ""
+	}
+	sent := r.backend.sentMsgs()
+	if r.sentIndex >= len(sent) {
+		return fmt.Sprintf("message %d with code %d is not sent by the replay", r.sentIndex, msg.Code)
+	}
+	r.sentIndex++
+	if !bytes.Equal(sent[r.sentIndex-1], payload) {
+		return fmt.Sprintf("message %d with code %d differs from the one sent by the replay", r.sentIndex-1, msg.Code)
+	}
+	return ""
+}
+
+func sameTimeout(a, b timeoutInfo) bool {
+	return a.BlockNumber.Cmp(b.BlockNumber) == 0 && a.Round == b.Round && a.Step == b.Step && a.Retry == b.Retry
+}
+
+func formatTimeout(ti timeoutInfo) string {
+	return fmt.Sprintf("{block: %v, round: %d, step: %s, retry: %d}", ti.BlockNumber, ti.Round, ti.Step, ti.Retry)
+}
+
+// virtualTimeoutTicker implements TimeoutTicker with a virtual clock. Like timeoutTicker it keeps a single timeout,
+// ignoring the ones earlier or equal to it, but the timeout is fired by the replayer instead of a timer.
+type virtualTimeoutTicker struct {
+	now      func() time.Time
+	current  timeoutInfo
+	deadline time.Time
+	pending  bool
+}
+
+func newVirtualTimeoutTicker(now func() time.Time) *virtualTimeoutTicker {
+	return &virtualTimeoutTicker{
+		now:     now,
+		current: timeoutInfo{BlockNumber: big.NewInt(0)},
+	}
+}
+
+// Start implements TimeoutTicker.Start
+func (t *virtualTimeoutTicker) Start() error {
+	return nil
+}
+
+// Stop implements TimeoutTicker.Stop
+func (t *virtualTimeoutTicker) Stop() error {
+	return nil
+}
+
+// Chan implements TimeoutTicker.Chan, nothing is sent on it since the timeouts are fired by the replayer
+func (t *virtualTimeoutTicker) Chan() <-chan timeoutInfo {
+	return nil
+}
+
+// ScheduleTimeout implements TimeoutTicker.ScheduleTimeout
+func (t *virtualTimeoutTicker) ScheduleTimeout(ti timeoutInfo) {
+	if ti.earlierOrEqual(t.current) {
+		return
+	}
+	t.current = ti
+	t.deadline = t.now().Add(ti.Duration)
+	t.pending = true
+}
+
+// fire returns the scheduled timeout if any, it keeps it as the current one as timeoutTicker does
+func (t *virtualTimeoutTicker) fire() (timeoutInfo, bool) {
+	if !t.pending {
+		return timeoutInfo{}, false
+	}
+	t.pending = false
+	return t.current, true
+}
+
+type verificationKey struct {
+	hash   common.Hash
+	header bool
+}
+
+// replayBackend implements tendermint.Backend with the answers recorded from the backend of core
+type replayBackend struct {
+	address common.Address
+	signer  tendermint.Signer
+	epoch   uint64
+	mux     *event.TypeMux
+
+	valSets       map[uint64]*recordValSet
+	configs       map[uint64]*tendermint.Config
+	verifications map[verificationKey]string
+
+	mu        sync.Mutex
+	head      *big.Int
+	sent      [][]byte
+	committed []*types.Block
+	evidences map[common.Hash]bool
+}
+
+func newReplayBackend(start *recordStart, entries []*RecordEntry, signer tendermint.Signer) (*replayBackend, error) {
+	b := &replayBackend{
+		address:       start.Address,
+		epoch:         start.Config.Epoch,
+		mux:           new(event.TypeMux),
+		valSets:       make(map[uint64]*recordValSet),
+		configs:       make(map[uint64]*tendermint.Config),
+		verifications: make(map[verificationKey]string),
+		head:          new(big.Int).Sub(start.BlockNumber, big.NewInt(1)),
+		evidences:     make(map[common.Hash]bool),
+	}
+	if signer != nil {
+		if signer.Address() != start.Address {
+			return nil, fmt.Errorf("signer %s is not the recorded node %s", signer.Address().Hex(), start.Address.Hex())
+		}
+		b.signer = signer
+	}
+	for i, entry := range entries {
+		switch entry.Kind {
+		case RecordValSet:
+			var rec recordValSet
+			if err := rlp.DecodeBytes(entry.Payload, &rec); err != nil {
+				return nil, errors.Wrapf(err, "invalid validator set at entry %d", i)
+			}
+			b.valSets[rec.BlockNumber.Uint64()] = &rec
+		case RecordConfig:
+			var rec recordConfig
+			if err := json.Unmarshal(entry.Payload, &rec); err != nil {
+				return nil, errors.Wrapf(err, "invalid config at entry %d", i)
+			}
+			b.configs[rec.BlockNumber.Uint64()] = rec.Config
+		case RecordVerification:
+			var rec recordVerification
+			if err := rlp.DecodeBytes(entry.Payload, &rec); err != nil {
+				return nil, errors.Wrapf(err, "invalid verification at entry %d", i)
+			}
+			b.verifications[verificationKey{hash: rec.BlockHash, header: rec.Header}] = rec.Err
+		}
+	}
+	return b, nil
+}
+
+// Address implements tendermint.Backend.Address
+func (b *replayBackend) Address() common.Address {
+	return b.address
+}
+
+// EventMux implements tendermint.Backend.EventMux, core does not subscribe to it during a replay
+func (b *replayBackend) EventMux() *event.TypeMux {
+	return b.mux
+}
+
+// Sign implements tendermint.Backend.Sign
+func (b *replayBackend) Sign(data []byte) ([]byte, error) {
+	if b.signer == nil {
+		return nil, errReplayNoSigner
+	}
+	return b.signer.Sign(data)
+}
+
+// SignVote implements tendermint.Backend.SignVote
+func (b *replayBackend) SignVote(vote *tendermint.VoteStep, data []byte) ([]byte, error) {
+	if b.signer == nil {
+		return nil, errReplayNoSigner
+	}
+	return b.signer.SignVote(vote, data)
+}
+
+// SignBLS implements tendermint.Backend.SignBLS
+func (b *replayBackend) SignBLS(data []byte) ([]byte, error) {
+	if b.signer == nil {
+		return nil, errReplayNoSigner
+	}
+	key, err := b.signer.BLSKey()
+	if err != nil {
+		return nil, err
+	}
+	return key.Sign(data), nil
+}
+
+// Gossip implements tendermint.Backend.Gossip
+func (b *replayBackend) Gossip(valSet tendermint.ValidatorSet, blockNumber *big.Int, round int64, msgType uint64, payload []byte) error {
+	b.send(payload)
+	return nil
+}
+
+// Broadcast implements tendermint.Backend.Broadcast, the message is not posted back to core
+// since the recording holds it as an inbound message
+func (b *replayBackend) Broadcast(valSet tendermint.ValidatorSet, blockNumber *big.Int, round int64, msgType uint64, payload []byte) error {
+	b.send(payload)
+	return nil
+}
+
+// Multicast implements tendermint.Backend.Multicast
+func (b *replayBackend) Multicast(targets map[common.Address]bool, payload []byte) error {
+	b.send(payload)
+	return nil
+}
+
+// send keeps the messages signed by the node, the relayed ones are dropped
+func (b *replayBackend) send(payload []byte) {
+	var msg message
+	if err := rlp.DecodeBytes(payload, &msg); err != nil || msg.Address != b.address {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sent = append(b.sent, payload)
+}
+
+func (b *replayBackend) sentMsgs() [][]byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sent
+}
+
+// Validators implements tendermint.Backend.Validators.
+// If the validator set of the block number is not recorded, the closest recorded one before it is used.
+// The checkpoint of the set is not recorded, it is the one of the epoch as the backend reads the set from it.
+func (b *replayBackend) Validators(blockNumber *big.Int) tendermint.ValidatorSet {
+	number := blockNumber.Uint64()
+	rec, ok := b.valSets[number]
+	if !ok {
+		var numbers []uint64
+		for n := range b.valSets {
+			if n < number {
+				numbers = append(numbers, n)
+			}
+		}
+		if len(numbers) == 0 {
+			return validator.NewSet(nil, tendermint.ProposerPolicy(0), blockNumber.Int64())
+		}
+		sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+		rec = b.valSets[numbers[len(numbers)-1]]
+	}
+	votingPowers := make([]int64, len(rec.VotingPowers))
+	for i, power := range rec.VotingPowers {
+		votingPowers[i] = int64(power)
+	}
+	checkpoint := int64(utils.GetCheckpointNumber(b.epoch, number))
+	return validator.NewWeightedSet(rec.Addresses, votingPowers, rec.BLSPublicKeys, tendermint.ProposerPolicy(rec.Policy), int64(rec.Height), checkpoint)
+}
+
+// Config implements tendermint.Backend.Config
+func (b *replayBackend) Config(blockNumber *big.Int) *tendermint.Config {
+	return b.configs[blockNumber.Uint64()]
+}
+
+func (b *replayBackend) setHead(number *big.Int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if number.Cmp(b.head) > 0 {
+		b.head = new(big.Int).Set(number)
+	}
+}
+
+// CurrentHeadBlock implements tendermint.Backend.CurrentHeadBlock, the head block only has its number
+func (b *replayBackend) CurrentHeadBlock() *types.Block {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return types.NewBlockWithHeader(&types.Header{Number: new(big.Int).Set(b.head)})
+}
+
+// FindExistingPeers implements tendermint.Backend.FindExistingPeers, a replay has no peer
+func (b *replayBackend) FindExistingPeers(targets tendermint.ValidatorSet) map[common.Address]consensus.Peer {
+	return make(map[common.Address]consensus.Peer)
+}
+
+// Commit implements tendermint.Backend.Commit, the block is inserted when the recording says it is final committed
+func (b *replayBackend) Commit(block *types.Block) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.committed = append(b.committed, block)
+}
+
+func (b *replayBackend) committedBlocks() []*types.Block {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.committed
+}
+
+// Cancel implements tendermint.Backend.Cancel
+func (b *replayBackend) Cancel(block *types.Block) {}
+
+// VerifyProposalHeader implements tendermint.Backend.VerifyProposalHeader
+func (b *replayBackend) VerifyProposalHeader(header *types.Header) error {
+	return b.verification(header.Hash(), true)
+}
+
+// VerifyProposalBlock implements tendermint.Backend.VerifyProposalBlock
+func (b *replayBackend) VerifyProposalBlock(block *types.Block) error {
+	return b.verification(block.Hash(), false)
+}
+
+// verification returns the recorded result of a verification, the errors core checks for are restored
+func (b *replayBackend) verification(hash common.Hash, header bool) error {
+	result, ok := b.verifications[verificationKey{hash: hash, header: header}]
+	switch {
+	case !ok:
+		return errNotRecorded
+	case result == "":
+		return nil
+	case result == tendermint.ErrEmptyCommittedSeals.Error():
+		return tendermint.ErrEmptyCommittedSeals
+	case result == evrynetCore.ErrKnownBlock.Error():
+		return evrynetCore.ErrKnownBlock
+	default:
+		return errors.New(result)
+	}
+}
+
+// ReportEvidence implements tendermint.Backend.ReportEvidence, an evidence is new the first time it is reported
+func (b *replayBackend) ReportEvidence(ev *types.TendermintEvidence) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	hash := ev.Hash()
+	if b.evidences[hash] {
+		return false
+	}
+	b.evidences[hash] = true
+	return true
+}
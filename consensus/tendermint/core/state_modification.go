@@ -2,7 +2,6 @@ package core
 
 import (
 	"math/big"
-	"time"
 
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
@@ -48,6 +47,44 @@ func (c *core) getInitializedState() *roundState {
 	return rs
 }
 
+//restoreStateFromWAL replays the WAL entries of the state's block number.
+//It restores the lock, the round and step of the last sent message and returns the sent messages.
+func (c *core) restoreStateFromWAL(state *roundState) ([]*MsgStorageData, error) {
+	walState, err := c.wal.replay(state.BlockNumber())
+	if err != nil {
+		return nil, err
+	}
+	if walState == nil {
+		return nil, nil
+	}
+	if lock := walState.lock; lock != nil {
+		state.SetLockedRoundAndBlock(lock.lockedRound, lock.lockedBlock)
+		state.SetValidRoundAndBlock(lock.validRound, lock.validBlock)
+	}
+	for _, msg := range walState.msgs {
+		c.sentMsgStorage.storeSentMsg(c.getLogger(), msg.Step, msg.Round, msg.Data)
+	}
+	if len(walState.msgs) > 0 {
+		last := walState.msgs[len(walState.msgs)-1]
+		state.UpdateRoundStep(last.Round, last.Step)
+	}
+	c.getLogger().Infow("restored state from WAL", "block_number", state.BlockNumber(), "round", state.Round(),
+		"step", state.Step(), "locked_round", state.LockedRound(), "num_msg", len(walState.msgs))
+	return walState.msgs, nil
+}
+
+//repostReplayedMsgs posts the messages replayed from WAL to core itself so that they are counted again
+func (c *core) repostReplayedMsgs(msgs []*MsgStorageData) {
+	for _, msg := range msgs {
+		if err := c.backend.EventMux().Post(tendermint.MessageEvent{
+			Payload: msg.Data,
+		}); err != nil {
+			c.getLogger().Errorw("Failed to re-post msg replayed from WAL", "err", err)
+			return
+		}
+	}
+}
+
 func (c *core) updateStateForNewblock() {
 	var (
 		state  = c.CurrentState()
@@ -74,19 +111,21 @@ func (c *core) updateStateForNewblock() {
 		Round:       0,
 		BlockNumber: height.Add(height, big.NewInt(1)),
 	})
+	c.updateConfig(state.BlockNumber())
 
 	if state.commitTime.IsZero() {
 		// "Now" makes it easier to sync up dev nodes.
 		// We add timeoutCommit to allow transactions
 		// to be gathered for the first block.
 		// And alternative solution that relies on clocks:
-		state.startTime = c.config.Commit(time.Now())
+		state.startTime = c.config.Commit(c.now())
 	} else {
 		state.startTime = c.config.Commit(state.commitTime)
 	}
 
 	state.clearPreviousRoundData()
 	c.currentState = state
+	c.sendStepEvent()
 	c.valSet = c.backend.Validators(c.CurrentState().BlockNumber())
 	c.futureProposals = make(map[int64]message)
 	logger.Infow("updated to new block", "new_block_number", state.BlockNumber())
@@ -55,15 +55,22 @@ type Vote struct {
 	BlockNumber *big.Int
 	Round       int64
 	Seal        []byte
+	// BLSSeal is the BLS signature of the committed seal, it is only set in precommits if the validator set aggregates commits
+	BLSSeal []byte
 }
 
 func (v *Vote) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, []interface{}{
+	fields := []interface{}{
 		v.BlockHash,
 		v.BlockNumber,
 		strconv.FormatInt(v.Round, 10),
 		v.Seal,
-	})
+	}
+	// BLSSeal is only encoded if it is set so the votes of validators which do not aggregate commits are unchanged
+	if len(v.BLSSeal) > 0 {
+		fields = append(fields, v.BLSSeal)
+	}
+	return rlp.Encode(w, fields)
 }
 
 func (v *Vote) DecodeRLP(s *rlp.Stream) error {
@@ -72,10 +79,17 @@ func (v *Vote) DecodeRLP(s *rlp.Stream) error {
 		BlockNumber *big.Int
 		RStr        string
 		Seal        []byte
+		Optional    []rlp.RawValue `rlp:"tail"`
 	}
 	if err := s.Decode(&vs); err != nil {
 		return err
 	}
+	v.BLSSeal = nil
+	if len(vs.Optional) > 0 {
+		if err := rlp.DecodeBytes(vs.Optional[0], &v.BLSSeal); err != nil {
+			return err
+		}
+	}
 	round, err := strconv.ParseInt(vs.RStr, 10, 64)
 	if err != nil {
 		return err
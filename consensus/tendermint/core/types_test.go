@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
 )
 
@@ -40,3 +41,17 @@ func TestCatchUpReplyMsg_DecodeRLP(t *testing.T) {
 	require.Equal(t, payload1, newMsg.Payloads[0])
 	require.Equal(t, payload2, newMsg.Payloads[1])
 }
+
+func TestVote_DecodeRLP(t *testing.T) {
+	blockHash := common.HexToHash("0x1")
+	for _, vote := range []Vote{
+		{BlockHash: &blockHash, BlockNumber: big.NewInt(3), Round: 1, Seal: []byte("seal")},
+		{BlockHash: &blockHash, BlockNumber: big.NewInt(3), Round: 1, Seal: []byte("seal"), BLSSeal: []byte("bls seal")},
+	} {
+		data, err := rlp.EncodeToBytes(&vote)
+		require.NoError(t, err)
+		var decodedVote Vote
+		require.NoError(t, rlp.DecodeBytes(data, &decodedVote))
+		require.Equal(t, vote, decodedVote)
+	}
+}
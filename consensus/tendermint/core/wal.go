@@ -0,0 +1,269 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"sync"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+var (
+	// walPrefix is the database key prefix of every WAL entry: walPrefix + blockNumber (uint64 big endian) + index (uint32 big endian)
+	walPrefix = []byte("tendermint-wal-")
+)
+
+// walEntryType enumerates the kind of data a WAL entry holds
+type walEntryType uint8
+
+const (
+	// walEntrySentMsg is a signed propose/prevote/precommit that this node is about to gossip
+	walEntrySentMsg walEntryType = iota
+	// walEntryLock is a change of locked/valid round and block
+	walEntryLock
+)
+
+// walEntry is the unit of data persisted in the write-ahead log.
+// Rounds are stored as uint64 since rlp does not support signed integers, -1 is encoded as math.MaxUint64.
+type walEntry struct {
+	Type        walEntryType
+	Step        RoundStepType
+	Round       uint64
+	Payload     []byte
+	LockedRound uint64
+	LockedBlock []byte
+	ValidRound  uint64
+	ValidBlock  []byte
+}
+
+// walLock contains the lock info which is restored from the WAL
+type walLock struct {
+	lockedRound int64
+	lockedBlock *types.Block
+	validRound  int64
+	validBlock  *types.Block
+}
+
+// walState is the consensus state of a block number replayed from the WAL
+type walState struct {
+	msgs []*MsgStorageData
+	lock *walLock
+}
+
+// wal is a write-ahead log of the messages signed by this node and of its lock changes.
+// Every entry is written to the database before the message is gossiped, so after a restart
+// core is able to resume at the same block number, round and step with the same lock.
+// A nil wal is valid and drops every write.
+type wal struct {
+	db          evrdb.KeyValueStore
+	mu          sync.Mutex
+	blockNumber uint64
+	nextIndex   uint32
+	lastLock    *walLock
+}
+
+// newWAL returns a wal which stores its entries in the given database
+func newWAL(db evrdb.KeyValueStore) *wal {
+	return &wal{
+		db: db,
+	}
+}
+
+func walKey(blockNumber uint64, index uint32) []byte {
+	key := make([]byte, len(walPrefix)+8+4)
+	copy(key, walPrefix)
+	binary.BigEndian.PutUint64(key[len(walPrefix):], blockNumber)
+	binary.BigEndian.PutUint32(key[len(walPrefix)+8:], index)
+	return key
+}
+
+func walBlockPrefix(blockNumber uint64) []byte {
+	return walKey(blockNumber, 0)[:len(walPrefix)+8]
+}
+
+// writeSentMsg persists the lock (if it is changed since the last write) then the sent message
+func (w *wal) writeSentMsg(blockNumber *big.Int, step RoundStepType, round int64, payload []byte, lock *walLock) error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.switchBlockNumber(blockNumber.Uint64())
+
+	if !w.lastLock.equal(lock) {
+		lockedBlock, err := encodeBlock(lock.lockedBlock)
+		if err != nil {
+			return err
+		}
+		validBlock, err := encodeBlock(lock.validBlock)
+		if err != nil {
+			return err
+		}
+		if err := w.put(&walEntry{
+			Type:        walEntryLock,
+			LockedRound: uint64(lock.lockedRound),
+			LockedBlock: lockedBlock,
+			ValidRound:  uint64(lock.validRound),
+			ValidBlock:  validBlock,
+		}); err != nil {
+			return err
+		}
+		w.lastLock = lock
+	}
+
+	return w.put(&walEntry{
+		Type:    walEntrySentMsg,
+		Step:    step,
+		Round:   uint64(round),
+		Payload: payload,
+	})
+}
+
+// switchBlockNumber resets the write index if the entries are written for a new block number
+func (w *wal) switchBlockNumber(blockNumber uint64) {
+	if w.blockNumber == blockNumber {
+		return
+	}
+	w.blockNumber = blockNumber
+	w.nextIndex = 0
+	w.lastLock = nil
+	// continue after the entries which are already in the db, i.e, the ones replayed after a restart
+	it := w.db.NewIteratorWithPrefix(walBlockPrefix(blockNumber))
+	defer it.Release()
+	for it.Next() {
+		w.nextIndex++
+	}
+}
+
+func (w *wal) put(entry *walEntry) error {
+	data, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		return err
+	}
+	if err := w.db.Put(walKey(w.blockNumber, w.nextIndex), data); err != nil {
+		return err
+	}
+	w.nextIndex++
+	return nil
+}
+
+// replay reads all the entries of the given block number
+// it returns nil if there is no entry for that block number
+func (w *wal) replay(blockNumber *big.Int) (*walState, error) {
+	if w == nil {
+		return nil, nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var (
+		state *walState
+		it    = w.db.NewIteratorWithPrefix(walBlockPrefix(blockNumber.Uint64()))
+	)
+	defer it.Release()
+	for it.Next() {
+		var entry walEntry
+		if err := rlp.DecodeBytes(it.Value(), &entry); err != nil {
+			return nil, err
+		}
+		if state == nil {
+			state = &walState{}
+		}
+		switch entry.Type {
+		case walEntrySentMsg:
+			state.msgs = append(state.msgs, &MsgStorageData{
+				Step:  entry.Step,
+				Round: int64(entry.Round),
+				Data:  entry.Payload,
+			})
+		case walEntryLock:
+			lockedBlock, err := decodeBlock(entry.LockedBlock)
+			if err != nil {
+				return nil, err
+			}
+			validBlock, err := decodeBlock(entry.ValidBlock)
+			if err != nil {
+				return nil, err
+			}
+			state.lock = &walLock{
+				lockedRound: int64(entry.LockedRound),
+				lockedBlock: lockedBlock,
+				validRound:  int64(entry.ValidRound),
+				validBlock:  validBlock,
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	if state != nil {
+		w.lastLock = state.lock
+	}
+	return state, nil
+}
+
+// truncate removes all entries of block numbers lower than the given one
+func (w *wal) truncate(blockNumber *big.Int) error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var (
+		limit = walBlockPrefix(blockNumber.Uint64())
+		it    = w.db.NewIteratorWithPrefix(walPrefix)
+		batch = w.db.NewBatch()
+	)
+	defer it.Release()
+	for it.Next() {
+		if bytes.Compare(it.Key(), limit) >= 0 {
+			break
+		}
+		if err := batch.Delete(common.CopyBytes(it.Key())); err != nil {
+			return err
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+func (l *walLock) equal(other *walLock) bool {
+	if l == nil || other == nil {
+		return l == other
+	}
+	return l.lockedRound == other.lockedRound && l.validRound == other.validRound &&
+		hashOfBlock(l.lockedBlock) == hashOfBlock(other.lockedBlock) &&
+		hashOfBlock(l.validBlock) == hashOfBlock(other.validBlock)
+}
+
+func hashOfBlock(block *types.Block) common.Hash {
+	if block == nil {
+		return common.Hash{}
+	}
+	return block.Hash()
+}
+
+func encodeBlock(block *types.Block) ([]byte, error) {
+	if block == nil {
+		return []byte{}, nil
+	}
+	return rlp.EncodeToBytes(block)
+}
+
+func decodeBlock(data []byte) (*types.Block, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var block types.Block
+	if err := rlp.DecodeBytes(data, &block); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
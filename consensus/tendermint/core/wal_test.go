@@ -0,0 +1,88 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/evrdb/memorydb"
+)
+
+func TestWAL_WriteReplayAndTruncate(t *testing.T) {
+	var (
+		w           = newWAL(memorydb.New())
+		block1      = big.NewInt(1)
+		block2      = big.NewInt(2)
+		lockedBlock = tests_utils.MakeBlockWithoutSeal(tests_utils.MakeGenesisHeader([]common.Address{}))
+		noLock      = &walLock{lockedRound: -1, validRound: -1}
+		lock        = &walLock{lockedRound: 1, lockedBlock: lockedBlock, validRound: 1, validBlock: lockedBlock}
+	)
+	require.NoError(t, w.writeSentMsg(block1, RoundStepPrevote, 0, []byte("prevote-0"), noLock))
+	require.NoError(t, w.writeSentMsg(block1, RoundStepPrecommit, 0, []byte("precommit-0"), noLock))
+	require.NoError(t, w.writeSentMsg(block1, RoundStepPrecommit, 1, []byte("precommit-1"), lock))
+	require.NoError(t, w.writeSentMsg(block2, RoundStepPrevote, 0, []byte("prevote-0"), noLock))
+
+	state, err := w.replay(block1)
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	require.Len(t, state.msgs, 3)
+	assert.Equal(t, RoundStepPrecommit, state.msgs[2].Step)
+	assert.Equal(t, int64(1), state.msgs[2].Round)
+	assert.Equal(t, []byte("precommit-1"), state.msgs[2].Data)
+	require.NotNil(t, state.lock)
+	assert.Equal(t, int64(1), state.lock.lockedRound)
+	assert.Equal(t, lockedBlock.Hash(), state.lock.lockedBlock.Hash())
+
+	state, err = w.replay(block2)
+	require.NoError(t, err)
+	require.Len(t, state.msgs, 1)
+	assert.Equal(t, int64(-1), state.lock.lockedRound)
+	assert.Nil(t, state.lock.lockedBlock)
+
+	require.NoError(t, w.truncate(block2))
+	state, err = w.replay(block1)
+	require.NoError(t, err)
+	assert.Nil(t, state)
+	state, err = w.replay(block2)
+	require.NoError(t, err)
+	assert.NotNil(t, state)
+}
+
+func TestCore_RestoreStateFromWAL(t *testing.T) {
+	zap.ReplaceGlobals(zap.NewExample())
+	var (
+		nodePrivateKey = tests_utils.MakeNodeKey()
+		nodeAddr       = crypto.PubkeyToAddress(nodePrivateKey.PublicKey)
+		validators     = []common.Address{
+			nodeAddr,
+			common.HexToAddress("0x0"),
+		}
+		genesisHeader = tests_utils.MakeGenesisHeader(validators)
+		lockedBlock   = tests_utils.MakeBlockWithoutSeal(genesisHeader)
+		db            = memorydb.New()
+	)
+	be, _ := tests_utils.MustCreateAndStartNewBackend(t, nodePrivateKey, genesisHeader, validators)
+
+	w := newWAL(db)
+	require.NoError(t, w.writeSentMsg(big.NewInt(1), RoundStepPrecommit, 2, []byte("precommit-2"),
+		&walLock{lockedRound: 2, lockedBlock: lockedBlock, validRound: 2, validBlock: lockedBlock}))
+
+	core := newTestCore(be, tendermint.DefaultConfig)
+	core.wal = newWAL(db)
+	state := core.getInitializedState()
+	msgs, err := core.restoreStateFromWAL(state)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, int64(2), state.Round())
+	assert.Equal(t, RoundStepPrecommit, state.Step())
+	assert.Equal(t, int64(2), state.LockedRound())
+	assert.Equal(t, lockedBlock.Hash(), state.LockedBlock().Hash())
+	assert.Equal(t, 0, core.sentMsgStorage.lookup(RoundStepPrecommit, 2))
+}
@@ -7,6 +7,8 @@ var (
 	ErrStartedEngine = errors.New("engine is already started")
 	// ErrStoppedEngine is returned if the engine is stopped
 	ErrStoppedEngine = errors.New("engine is already stopped")
+	// ErrDoubleSign is returned by a signer asked to sign a vote conflicting with one it signed before
+	ErrDoubleSign = errors.New("vote conflicts with a signed vote")
 	// ErrEmptyCommittedSeals is returned if the field of committed seals is zero.
 	ErrEmptyCommittedSeals = errors.New("zero committed seals")
 	// ErrEmptyValSet is returned if the field of validator set is zero.
@@ -47,4 +49,20 @@ var (
 	ErrUnknownParent = errors.New("unknown parent")
 	// ErrFinalizeZeroBlock is returned if node finalize with block number = 0
 	ErrFinalizeZeroBlock = errors.New("finalize zero block")
+	// ErrInvalidEvidence is returned if an evidence does not prove a validator signing conflicting votes
+	ErrInvalidEvidence = errors.New("invalid evidence")
+	// ErrInvalidAggregatedSeal is returned if the aggregated seal of a block is missing or invalid
+	ErrInvalidAggregatedSeal = errors.New("invalid aggregated seal")
+	// ErrInvalidParentSeals is returned if the parent seals of a block are missing or do not commit its parent
+	ErrInvalidParentSeals = errors.New("invalid parent seals")
+	// ErrInvalidValSetHash is returned if a header which is not a checkpoint after the validator set hash fork has a validator set hash
+	ErrInvalidValSetHash = errors.New("unexpected validator set hash")
+	// ErrInvalidCheckpoint is returned if the finality of a header is verified against a header which is not its checkpoint
+	ErrInvalidCheckpoint = errors.New("invalid checkpoint")
+	// ErrUnavailableVotes is returned if the votes of a block number other than the one of the consensus are requested
+	ErrUnavailableVotes = errors.New("votes are only available for the current block number")
+	// ErrUnavailableValSet is returned if the validator set of a checkpoint block can not be computed because the state of its parent is missing
+	ErrUnavailableValSet = errors.New("next validator set is unavailable")
+	// ErrMismatchReceipts is returned if the receipts of a block do not match its transactions
+	ErrMismatchReceipts = errors.New("mismatch between transactions and receipts")
 )
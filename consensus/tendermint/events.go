@@ -24,3 +24,10 @@ type FinalCommittedEvent struct {
 
 // StopCoreEvent is posted when core is stopped
 type StopCoreEvent struct{}
+
+// StepEvent is posted when the core moves to a step of the consensus
+type StepEvent struct {
+	BlockNumber *big.Int `json:"blockNumber"`
+	Round       int64    `json:"round"`
+	Step        string   `json:"step"`
+}
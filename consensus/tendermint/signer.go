@@ -0,0 +1,58 @@
+package tendermint
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/crypto/bls"
+)
+
+// Signer holds the consensus key of a validator. The key signing the messages and the seals of a validator can so
+// be kept apart from its node key, e.g. in a keystore or in an external signer.
+type Signer interface {
+	// Address returns the address of the consensus key
+	Address() common.Address
+
+	// Sign signs the keccak256 hash of data with the consensus key
+	Sign(data []byte) ([]byte, error)
+
+	// SignVote signs the keccak256 hash of data, the message or the seal of a vote, with the consensus key.
+	// It refuses to sign a vote conflicting with the ones signed before, see VoteStep.
+	SignVote(vote *VoteStep, data []byte) ([]byte, error)
+
+	// BLSKey returns the BLS key which signs the aggregated committed seals
+	BLSKey() (*bls.SecretKey, error)
+}
+
+// VoteStep identifies what a validator votes for at a step of a round. A validator double signs if it signs votes
+// for different blocks at the same step, it is then slashed: a signer refuses to sign a vote for another block
+// than the one it signed for the step, or for a step before the last one it signed for.
+type VoteStep struct {
+	BlockNumber *big.Int
+	Round       uint64
+	Step        uint64 // the code of the message: propose, prevote or precommit
+	BlockHash   common.Hash
+}
+
+// Cmp compares the steps of the votes, it returns -1, 0 or +1 if v is before, at, or after the step of o.
+func (v *VoteStep) Cmp(o *VoteStep) int {
+	if c := v.BlockNumber.Cmp(o.BlockNumber); c != 0 {
+		return c
+	}
+	switch {
+	case v.Round < o.Round:
+		return -1
+	case v.Round > o.Round:
+		return 1
+	case v.Step < o.Step:
+		return -1
+	case v.Step > o.Step:
+		return 1
+	}
+	return 0
+}
+
+func (v *VoteStep) String() string {
+	return fmt.Sprintf("{number: %v, round: %d, step: %d, block: %s}", v.BlockNumber, v.Round, v.Step, v.BlockHash.Hex())
+}
@@ -0,0 +1,79 @@
+package signer
+
+import (
+	"github.com/Evrynetlabs/evrynet-node/accounts"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/crypto/bls"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+)
+
+// VoteRequest is the data of a vote an external signer is asked to sign with the accounts.MimetypeTendermintVote
+// content type. The signer checks the vote against its own guard, and signs the keccak256 hash of Data.
+type VoteRequest struct {
+	Vote tendermint.VoteStep
+	Data []byte
+}
+
+// externalSigner is a signer whose consensus key is held by an external signer, e.g. clef. The double-sign
+// protection is enforced by the external signer.
+type externalSigner struct {
+	client  *rpc.Client
+	address common.Address
+	blsKey  *bls.SecretKey
+}
+
+// NewExternalSigner returns a signer with the key of the account of an external signer reachable at the endpoint
+// and the given BLS key
+func NewExternalSigner(endpoint string, address common.Address, blsKey *bls.SecretKey) (tendermint.Signer, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &externalSigner{
+		client:  client,
+		address: address,
+		blsKey:  blsKey,
+	}, nil
+}
+
+// Address implements tendermint.Signer.Address
+func (s *externalSigner) Address() common.Address {
+	return s.address
+}
+
+// Sign implements tendermint.Signer.Sign
+func (s *externalSigner) Sign(data []byte) ([]byte, error) {
+	return s.signData(accounts.MimetypeTendermint, data)
+}
+
+// SignVote implements tendermint.Signer.SignVote
+func (s *externalSigner) SignVote(vote *tendermint.VoteStep, data []byte) ([]byte, error) {
+	req, err := rlp.EncodeToBytes(&VoteRequest{Vote: *vote, Data: data})
+	if err != nil {
+		return nil, err
+	}
+	return s.signData(accounts.MimetypeTendermintVote, req)
+}
+
+// BLSKey implements tendermint.Signer.BLSKey
+func (s *externalSigner) BLSKey() (*bls.SecretKey, error) {
+	return s.blsKey, nil
+}
+
+func (s *externalSigner) signData(mimeType string, data []byte) ([]byte, error) {
+	var (
+		res         hexutil.Bytes
+		signAddress = common.NewMixedcaseAddress(s.address)
+	)
+	if err := s.client.Call(&res, "account_signData", mimeType, &signAddress, hexutil.Encode(data)); err != nil {
+		return nil, err
+	}
+	// Tendermint signatures have their V on the form 0 or 1
+	if len(res) == 65 && (res[64] == 27 || res[64] == 28) {
+		res[64] -= 27
+	}
+	return res, nil
+}
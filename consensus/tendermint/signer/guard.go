@@ -0,0 +1,92 @@
+package signer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/log"
+)
+
+// Guard protects a validator against double signing: it remembers the last vote signed by the validator and
+// refuses to let it sign a conflicting one. The last vote is persisted so that the protection holds across
+// restarts.
+type Guard struct {
+	mu   sync.Mutex
+	path string
+	last *tendermint.VoteStep
+}
+
+// NewGuard creates a guard persisting the last signed vote in the file at path, or in memory only if path is empty
+func NewGuard(path string) (*Guard, error) {
+	g := &Guard{path: path}
+	if path == "" {
+		return g, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return g, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var last tendermint.VoteStep
+	if err := json.Unmarshal(data, &last); err != nil {
+		return nil, err
+	}
+	if last.BlockNumber != nil {
+		g.last = &last
+	}
+	return g, nil
+}
+
+// Check returns tendermint.ErrDoubleSign if the vote conflicts with the last signed vote, otherwise the vote is
+// recorded as signed. A vote for the same block at the same step as the last one does not conflict, so the message
+// and the seal of a vote can be signed separately.
+func (g *Guard) Check(vote *tendermint.VoteStep) error {
+	if vote == nil || vote.BlockNumber == nil {
+		return tendermint.ErrDoubleSign
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.last != nil {
+		switch c := vote.Cmp(g.last); {
+		case c < 0:
+			log.Warn("Refused to sign a vote before the last signed one", "vote", vote, "last", g.last)
+			return tendermint.ErrDoubleSign
+		case c == 0 && vote.BlockHash != g.last.BlockHash:
+			log.Warn("Refused to sign a conflicting vote", "vote", vote, "last", g.last)
+			return tendermint.ErrDoubleSign
+		case c == 0:
+			return nil
+		}
+	}
+	if err := g.persist(vote); err != nil {
+		return err
+	}
+	// the block number is copied as the caller may update it in place
+	cpy := *vote
+	cpy.BlockNumber = new(big.Int).Set(vote.BlockNumber)
+	g.last = &cpy
+	return nil
+}
+
+// persist writes the vote to the guard file, the vote must not be signed if it failed to be persisted
+func (g *Guard) persist(vote *tendermint.VoteStep) error {
+	if g.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(vote)
+	if err != nil {
+		return err
+	}
+	tmp := g.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, g.path)
+}
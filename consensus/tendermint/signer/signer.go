@@ -0,0 +1,116 @@
+// Package signer implements the signers holding the consensus key of a Tendermint validator.
+package signer
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	crand "crypto/rand"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+
+	"github.com/Evrynetlabs/evrynet-node/accounts"
+	"github.com/Evrynetlabs/evrynet-node/accounts/keystore"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/crypto/bls"
+)
+
+// DeriveBLSKey returns the BLS key derived from a private key, e.g. the node key.
+// The BLS key is never derived from a signature of the consensus key, which anyone allowed to request signatures
+// from the signer could obtain.
+func DeriveBLSKey(key *ecdsa.PrivateKey) *bls.SecretKey {
+	return bls.DeriveSecretKey(crypto.FromECDSA(key))
+}
+
+// LoadBLSKey loads the hex encoded BLS key of the file, a new random key is generated and saved to the file if it
+// does not exist.
+func LoadBLSKey(file string) (*bls.SecretKey, error) {
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		seed := make([]byte, 32)
+		if _, err := crand.Read(seed); err != nil {
+			return nil, err
+		}
+		key := bls.DeriveSecretKey(seed)
+		if err := ioutil.WriteFile(file, []byte(hex.EncodeToString(key.Bytes())), 0600); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	b, err := hex.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return nil, err
+	}
+	return bls.NewSecretKey(b)
+}
+
+// hashSigner is a signer which signs votes after checking them against its guard
+type hashSigner struct {
+	address  common.Address
+	signHash func(hash []byte) ([]byte, error)
+	guard    *Guard
+	blsKey   *bls.SecretKey
+}
+
+// NewKeySigner returns a signer with the given private key, e.g. the node key. A nil BLS key is derived from the
+// private key, see DeriveBLSKey. A nil guard protects against double signing until the node stops only.
+func NewKeySigner(key *ecdsa.PrivateKey, blsKey *bls.SecretKey, guard *Guard) tendermint.Signer {
+	if guard == nil {
+		guard, _ = NewGuard("")
+	}
+	if blsKey == nil {
+		blsKey = DeriveBLSKey(key)
+	}
+	return &hashSigner{
+		address: crypto.PubkeyToAddress(key.PublicKey),
+		signHash: func(hash []byte) ([]byte, error) {
+			return crypto.Sign(hash, key)
+		},
+		guard:  guard,
+		blsKey: blsKey,
+	}
+}
+
+// NewKeystoreSigner returns a signer with the key of a keystore account, which must be unlocked to sign, and the
+// given BLS key. A nil guard protects against double signing until the node stops only.
+func NewKeystoreSigner(ks *keystore.KeyStore, account accounts.Account, blsKey *bls.SecretKey, guard *Guard) tendermint.Signer {
+	if guard == nil {
+		guard, _ = NewGuard("")
+	}
+	return &hashSigner{
+		address: account.Address,
+		signHash: func(hash []byte) ([]byte, error) {
+			return ks.SignHash(account, hash)
+		},
+		guard:  guard,
+		blsKey: blsKey,
+	}
+}
+
+// Address implements tendermint.Signer.Address
+func (s *hashSigner) Address() common.Address {
+	return s.address
+}
+
+// Sign implements tendermint.Signer.Sign
+func (s *hashSigner) Sign(data []byte) ([]byte, error) {
+	return s.signHash(crypto.Keccak256(data))
+}
+
+// SignVote implements tendermint.Signer.SignVote
+func (s *hashSigner) SignVote(vote *tendermint.VoteStep, data []byte) ([]byte, error) {
+	if err := s.guard.Check(vote); err != nil {
+		return nil, err
+	}
+	return s.Sign(data)
+}
+
+// BLSKey implements tendermint.Signer.BLSKey
+func (s *hashSigner) BLSKey() (*bls.SecretKey, error) {
+	return s.blsKey, nil
+}
@@ -0,0 +1,104 @@
+package signer
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/crypto/bls"
+)
+
+func newVote(number int64, round, step uint64, hash string) *tendermint.VoteStep {
+	return &tendermint.VoteStep{
+		BlockNumber: big.NewInt(number),
+		Round:       round,
+		Step:        step,
+		BlockHash:   common.HexToHash(hash),
+	}
+}
+
+func TestGuard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tendermint-guard")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "votes.json")
+
+	guard, err := NewGuard(path)
+	require.NoError(t, err)
+	require.NoError(t, guard.Check(newVote(10, 1, 1, "0x01")))
+	// the message and the seal of the same vote are signed separately
+	require.NoError(t, guard.Check(newVote(10, 1, 1, "0x01")))
+	assert.Equal(t, tendermint.ErrDoubleSign, guard.Check(newVote(10, 1, 1, "0x02")))
+	assert.Equal(t, tendermint.ErrDoubleSign, guard.Check(newVote(10, 1, 0, "0x01")))
+	assert.Equal(t, tendermint.ErrDoubleSign, guard.Check(newVote(10, 0, 2, "0x01")))
+	assert.Equal(t, tendermint.ErrDoubleSign, guard.Check(newVote(9, 5, 2, "0x01")))
+	require.NoError(t, guard.Check(newVote(10, 1, 2, "0x02")))
+	require.NoError(t, guard.Check(newVote(11, 0, 0, "0x03")))
+
+	// the last signed vote is kept across restarts
+	guard, err = NewGuard(path)
+	require.NoError(t, err)
+	assert.Equal(t, tendermint.ErrDoubleSign, guard.Check(newVote(11, 0, 0, "0x04")))
+	assert.Equal(t, tendermint.ErrDoubleSign, guard.Check(newVote(10, 1, 2, "0x02")))
+	require.NoError(t, guard.Check(newVote(11, 0, 0, "0x03")))
+
+	// the caller moving its block number forward does not change the last signed vote
+	vote := newVote(12, 0, 2, "0x05")
+	require.NoError(t, guard.Check(vote))
+	vote.BlockNumber.Add(vote.BlockNumber, common.Big1)
+	require.NoError(t, guard.Check(newVote(13, 0, 1, "0x06")))
+}
+
+func TestKeySigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	s := NewKeySigner(key, nil, nil)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), s.Address())
+
+	data := []byte("vote")
+	sig, err := s.SignVote(newVote(1, 0, 1, "0x01"), data)
+	require.NoError(t, err)
+	pubkey, err := crypto.SigToPub(crypto.Keccak256(data), sig)
+	require.NoError(t, err)
+	assert.Equal(t, s.Address(), crypto.PubkeyToAddress(*pubkey))
+
+	_, err = s.SignVote(newVote(1, 0, 1, "0x02"), data)
+	assert.Equal(t, tendermint.ErrDoubleSign, err)
+
+	// the BLS key stays the one derived from the node key
+	blsKey, err := s.BLSKey()
+	require.NoError(t, err)
+	assert.Equal(t, bls.DeriveSecretKey(crypto.FromECDSA(key)).PublicKey(), blsKey.PublicKey())
+
+	// unless another BLS key is given
+	other := bls.DeriveSecretKey([]byte("seed"))
+	blsKey, err = NewKeySigner(key, other, nil).BLSKey()
+	require.NoError(t, err)
+	assert.Equal(t, other.PublicKey(), blsKey.PublicKey())
+}
+
+func TestLoadBLSKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tendermint-bls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "blskey")
+
+	// the key is created the first time
+	key, err := LoadBLSKey(path)
+	require.NoError(t, err)
+	loaded, err := LoadBLSKey(path)
+	require.NoError(t, err)
+	assert.Equal(t, key.PublicKey(), loaded.PublicKey())
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("invalid"), 0600))
+	_, err = LoadBLSKey(path)
+	assert.Error(t, err)
+}
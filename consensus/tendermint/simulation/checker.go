@@ -0,0 +1,64 @@
+package simulation
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+)
+
+// checker checks the invariants of the consensus over the blocks committed by all the nodes
+type checker struct {
+	mu         sync.Mutex
+	committed  map[uint64]common.Hash // the hash of the first block committed at each height
+	violations []string
+	reported   map[common.Hash]bool
+}
+
+func newChecker() *checker {
+	return &checker{
+		committed: make(map[uint64]common.Hash),
+		reported:  make(map[common.Hash]bool),
+	}
+}
+
+// commit checks a block committed by a node against the blocks committed by the other nodes at the same height
+func (c *checker) commit(node *Node, block *types.Block) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	number, hash := block.NumberU64(), block.Hash()
+	committed, ok := c.committed[number]
+	if !ok {
+		c.committed[number] = hash
+		return
+	}
+	if committed != hash {
+		c.violations = append(c.violations, fmt.Sprintf("%s committed block %s at height %d, %s was committed before",
+			node, hash.Hex(), number, committed.Hex()))
+	}
+}
+
+// evidence records an evidence of conflicting votes reported by a node
+func (c *checker) evidence(ev *types.TendermintEvidence) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reported[ev.Hash()] = true
+}
+
+// safety returns an error listing the heights where different blocks were committed
+func (c *checker) safety() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.violations) == 0 {
+		return nil
+	}
+	return fmt.Errorf("safety violated: %s", strings.Join(c.violations, "; "))
+}
+
+func (c *checker) evidences() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.reported)
+}
@@ -0,0 +1,336 @@
+// Package simulation runs a network of Tendermint validators in a single process, over a simulated network which can
+// partition the validators, delay, reorder, drop and duplicate their messages, and run equivocating validators.
+// Checkers verify that the validators never commit different blocks at a height and that they keep committing.
+package simulation
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/log"
+)
+
+var (
+	// ErrNoSuchNode is returned when a node index is out of the nodes of the network
+	ErrNoSuchNode = errors.New("no such node in the network")
+	// ErrHeightNotReached is returned when the nodes do not reach a height before the timeout
+	ErrHeightNotReached = errors.New("height not reached before the timeout")
+)
+
+// Envelope is a message sent by a node to another one through the network.
+// Block is set instead of Payload when the sender syncs a block it committed to the receiver.
+type Envelope struct {
+	From    *Node
+	To      *Node
+	Code    uint64
+	Payload []byte
+	Block   *types.Block
+}
+
+// Filter decides whether an envelope is delivered, it returns false to drop it
+type Filter func(env *Envelope) bool
+
+// Network is the simulated network connecting the nodes
+type Network struct {
+	config     *tendermint.Config
+	validators []common.Address
+	keys       []*ecdsa.PrivateKey
+	genesis    *types.Block
+	checker    *checker
+
+	mu            sync.RWMutex
+	nodes         []*Node
+	rand          *rand.Rand
+	groups        map[*Node]int // the partition group of every node, nil if the network is not partitioned
+	minDelay      time.Duration
+	maxDelay      time.Duration
+	dropRate      float64
+	duplicateRate float64
+	filter        Filter
+}
+
+// NewNetwork creates a network of size validators running the consensus with the config.
+// The faults injected in the network are drawn from a random source seeded with seed.
+func NewNetwork(size int, config *tendermint.Config, seed int64) (*Network, error) {
+	network := &Network{
+		config:  config,
+		checker: newChecker(),
+		rand:    rand.New(rand.NewSource(seed)),
+	}
+	for i := 0; i < size; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		network.keys = append(network.keys, key)
+		network.validators = append(network.validators, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	network.genesis = makeGenesis(network.validators)
+	for i, key := range network.keys {
+		network.nodes = append(network.nodes, newNode(network, i, key, network.genesis))
+	}
+	return network, nil
+}
+
+// AddTwin adds a node running with the key of the validator i, the twin signs its own votes and blocks so the
+// validator equivocates. The twin is not started.
+func (net *Network) AddTwin(i int) (*Node, error) {
+	if i < 0 || i >= len(net.keys) {
+		return nil, ErrNoSuchNode
+	}
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	twin := newNode(net, len(net.nodes), net.keys[i], net.genesis)
+	net.nodes = append(net.nodes, twin)
+	return twin, nil
+}
+
+// Nodes returns the nodes of the network, the validators followed by the twins
+func (net *Network) Nodes() []*Node {
+	net.mu.RLock()
+	defer net.mu.RUnlock()
+	return append([]*Node(nil), net.nodes...)
+}
+
+// Node returns the node at index i
+func (net *Network) Node(i int) (*Node, error) {
+	net.mu.RLock()
+	defer net.mu.RUnlock()
+	if i < 0 || i >= len(net.nodes) {
+		return nil, ErrNoSuchNode
+	}
+	return net.nodes[i], nil
+}
+
+// Start starts all the nodes of the network
+func (net *Network) Start() error {
+	for _, node := range net.Nodes() {
+		if err := node.Start(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop stops the running nodes of the network
+func (net *Network) Stop() {
+	for _, node := range net.Nodes() {
+		if node.Running() {
+			if err := node.Stop(); err != nil {
+				log.Error("failed to stop node", "node", node.Index, "error", err)
+			}
+		}
+	}
+}
+
+// StartNode starts the node at index i, it syncs the blocks it missed from the blocks committed afterward
+func (net *Network) StartNode(i int) error {
+	node, err := net.Node(i)
+	if err != nil {
+		return err
+	}
+	return node.Start()
+}
+
+// StopNode stops the node at index i, as if it crashed
+func (net *Network) StopNode(i int) error {
+	node, err := net.Node(i)
+	if err != nil {
+		return err
+	}
+	return node.Stop()
+}
+
+// Partition splits the network in groups of node indexes, the nodes only receive the messages sent from their group.
+// The nodes which are not in any group are isolated.
+func (net *Network) Partition(groups ...[]int) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	net.groups = make(map[*Node]int)
+	for group, indexes := range groups {
+		for _, i := range indexes {
+			if i >= 0 && i < len(net.nodes) {
+				net.groups[net.nodes[i]] = group
+			}
+		}
+	}
+}
+
+// Heal removes the partition of the network
+func (net *Network) Heal() {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	net.groups = nil
+}
+
+// SetDelay delays every message by a random duration between min and max, so that the messages are reordered
+func (net *Network) SetDelay(min, max time.Duration) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	if max < min {
+		max = min
+	}
+	net.minDelay, net.maxDelay = min, max
+}
+
+// SetDropRate sets the probability of a message to be lost
+func (net *Network) SetDropRate(rate float64) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	net.dropRate = rate
+}
+
+// SetDuplicateRate sets the probability of a message to be delivered twice
+func (net *Network) SetDuplicateRate(rate float64) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	net.duplicateRate = rate
+}
+
+// SetFilter sets a filter deciding which messages are delivered, on top of the partition and the drop rate.
+// A nil filter delivers every message.
+func (net *Network) SetFilter(filter Filter) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	net.filter = filter
+}
+
+// CheckSafety returns an error if the nodes committed different blocks at a height
+func (net *Network) CheckSafety() error {
+	return net.checker.safety()
+}
+
+// Evidences returns the number of distinct evidences of conflicting votes reported by the nodes
+func (net *Network) Evidences() int {
+	return net.checker.evidences()
+}
+
+// WaitForHeight waits until the nodes at the indexes, or all the running nodes if none is given,
+// committed the block number height. It returns ErrHeightNotReached if they do not before the timeout.
+func (net *Network) WaitForHeight(height uint64, timeout time.Duration, indexes ...int) error {
+	var nodes []*Node
+	if len(indexes) == 0 {
+		for _, node := range net.Nodes() {
+			if node.Running() {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	for _, i := range indexes {
+		node, err := net.Node(i)
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, node)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		var lagging *Node
+		for _, node := range nodes {
+			if node.Head().NumberU64() < height {
+				lagging = node
+				break
+			}
+		}
+		if lagging == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%v: %s is at block %d, expected %d", ErrHeightNotReached, lagging, lagging.Head().NumberU64(), height)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// send sends a consensus message to every other node
+func (net *Network) send(from *Node, payload []byte) {
+	code, _, err := tendermintCore.DecodeMessageCode(payload)
+	if err != nil {
+		log.Error("failed to decode sent message", "node", from.Index, "error", err)
+		return
+	}
+	for _, node := range net.Nodes() {
+		if node != from {
+			net.deliver(&Envelope{From: from, To: node, Code: code, Payload: payload})
+		}
+	}
+}
+
+// multicast sends a consensus message to the nodes of the targets
+func (net *Network) multicast(from *Node, targets map[common.Address]bool, payload []byte) {
+	code, _, err := tendermintCore.DecodeMessageCode(payload)
+	if err != nil {
+		log.Error("failed to decode sent message", "node", from.Index, "error", err)
+		return
+	}
+	for _, node := range net.Nodes() {
+		if node != from && targets[node.Address()] {
+			net.deliver(&Envelope{From: from, To: node, Code: code, Payload: payload})
+		}
+	}
+}
+
+// sendBlock sends a block committed by a node to every other node
+func (net *Network) sendBlock(from *Node, block *types.Block) {
+	for _, node := range net.Nodes() {
+		if node != from {
+			net.deliver(&Envelope{From: from, To: node, Block: block})
+		}
+	}
+}
+
+// deliver delivers the envelope to its receiver, unless a fault injected in the network drops it
+func (net *Network) deliver(env *Envelope) {
+	net.mu.Lock()
+	if net.groups != nil {
+		fromGroup, ok := net.groups[env.From]
+		if toGroup, ok2 := net.groups[env.To]; !ok || !ok2 || fromGroup != toGroup {
+			net.mu.Unlock()
+			return
+		}
+	}
+	if net.filter != nil && !net.filter(env) {
+		net.mu.Unlock()
+		return
+	}
+	if net.rand.Float64() < net.dropRate {
+		net.mu.Unlock()
+		return
+	}
+	copies := 1
+	if net.rand.Float64() < net.duplicateRate {
+		copies++
+	}
+	delays := make([]time.Duration, copies)
+	for i := range delays {
+		delays[i] = net.minDelay
+		if net.maxDelay > net.minDelay {
+			delays[i] += time.Duration(net.rand.Int63n(int64(net.maxDelay - net.minDelay)))
+		}
+	}
+	net.mu.Unlock()
+
+	for _, delay := range delays {
+		// the receiver may be handling a message of the sender, so the delivery never blocks the sender
+		time.AfterFunc(delay, func() {
+			if !env.To.Running() {
+				return
+			}
+			if env.Block != nil {
+				env.To.receiveBlock(env.From, env.Block)
+				return
+			}
+			env.To.post(tendermint.MessageEvent{Payload: env.Payload})
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+)
+
+var testConfig = &tendermint.Config{
+	ProposerPolicy:        tendermint.RoundRobin,
+	Epoch:                 30000,
+	BlockPeriod:           1,
+	TimeoutPropose:        100 * time.Millisecond,
+	TimeoutProposeDelta:   50 * time.Millisecond,
+	TimeoutPrevote:        100 * time.Millisecond,
+	TimeoutPrevoteDelta:   50 * time.Millisecond,
+	TimeoutPrecommit:      100 * time.Millisecond,
+	TimeoutPrecommitDelta: 50 * time.Millisecond,
+	TimeoutCommit:         50 * time.Millisecond,
+	FaultyMode:            tendermint.Disabled.Uint64(),
+}
+
+func newTestNetwork(t *testing.T, size int) *Network {
+	network, err := NewNetwork(size, testConfig, 1)
+	require.NoError(t, err)
+	return network
+}
+
+func TestNetworkCommits(t *testing.T) {
+	network := newTestNetwork(t, 4)
+	require.NoError(t, network.Start())
+	defer network.Stop()
+
+	require.NoError(t, network.WaitForHeight(5, 10*time.Second))
+	assert.NoError(t, network.CheckSafety())
+}
+
+func TestNetworkPartition(t *testing.T) {
+	network := newTestNetwork(t, 4)
+	network.Partition([]int{0, 1, 2}, []int{3})
+	require.NoError(t, network.Start())
+	defer network.Stop()
+
+	// the majority keeps committing without the isolated validator
+	require.NoError(t, network.WaitForHeight(3, 10*time.Second, 0, 1, 2))
+	node, err := network.Node(3)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), node.Head().NumberU64())
+
+	// the isolated validator syncs once the partition is healed
+	network.Heal()
+	require.NoError(t, network.WaitForHeight(5, 10*time.Second))
+	assert.NoError(t, network.CheckSafety())
+}
+
+func TestNetworkNoQuorum(t *testing.T) {
+	network := newTestNetwork(t, 4)
+	network.Partition([]int{0, 1}, []int{2, 3})
+	require.NoError(t, network.Start())
+	defer network.Stop()
+
+	// none of the groups has 2/3 of the voting power
+	assert.Error(t, network.WaitForHeight(1, time.Second, 0))
+	for _, node := range network.Nodes() {
+		assert.Equal(t, uint64(0), node.Head().NumberU64())
+	}
+
+	network.Heal()
+	require.NoError(t, network.WaitForHeight(2, 20*time.Second))
+	assert.NoError(t, network.CheckSafety())
+}
+
+func TestNetworkFaultyDelivery(t *testing.T) {
+	network := newTestNetwork(t, 4)
+	network.SetDelay(0, 20*time.Millisecond)
+	network.SetDuplicateRate(0.2)
+	network.SetDropRate(0.05)
+	require.NoError(t, network.Start())
+	defer network.Stop()
+
+	require.NoError(t, network.WaitForHeight(3, 20*time.Second))
+	assert.NoError(t, network.CheckSafety())
+}
+
+func TestNetworkCrashedValidator(t *testing.T) {
+	network := newTestNetwork(t, 4)
+	require.NoError(t, network.Start())
+	defer network.Stop()
+
+	require.NoError(t, network.StopNode(1))
+	require.NoError(t, network.WaitForHeight(3, 10*time.Second))
+	require.NoError(t, network.StartNode(1))
+	require.NoError(t, network.WaitForHeight(5, 10*time.Second))
+	assert.NoError(t, network.CheckSafety())
+}
+
+func TestNetworkEquivocation(t *testing.T) {
+	network := newTestNetwork(t, 4)
+	twin, err := network.AddTwin(0)
+	require.NoError(t, err)
+	// the proposals of the validator 0 and its twin are both delivered, the honest validators see conflicting votes
+	network.SetFilter(func(env *Envelope) bool {
+		return env.Code != tendermintCore.MsgPropose || env.To != twin
+	})
+	require.NoError(t, network.Start())
+	defer network.Stop()
+
+	require.NoError(t, network.WaitForHeight(5, 20*time.Second, 1, 2, 3))
+	assert.NoError(t, network.CheckSafety())
+	assert.NotZero(t, network.Evidences())
+}
@@ -0,0 +1,301 @@
+package simulation
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/signer"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/validator"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/event"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+var (
+	// errUnknownParent is returned when a proposed block does not extend the chain of the node
+	errUnknownParent = errors.New("proposed block does not extend the chain")
+)
+
+// Node is a validator of the simulated network. It implements tendermint.Backend in memory: its messages are sent
+// through the network, and it proposes empty blocks which it inserts in its chain once committed.
+type Node struct {
+	Index int
+
+	address common.Address
+	network *Network
+	signer  tendermint.Signer
+	mux     *event.TypeMux
+	core    tendermintCore.Engine
+
+	mu       sync.RWMutex
+	chain    []*types.Block // the chain of the node, chain[i] is the block number i
+	evidence map[common.Hash]bool
+	running  bool
+}
+
+func newNode(network *Network, index int, key *ecdsa.PrivateKey, genesis *types.Block) *Node {
+	node := &Node{
+		Index:   index,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+		network: network,
+		// each node has its own guard, so the twins of a node sign conflicting votes
+		signer:   signer.NewKeySigner(key, nil, nil),
+		mux:      new(event.TypeMux),
+		chain:    []*types.Block{genesis},
+		evidence: make(map[common.Hash]bool),
+	}
+	node.core = tendermintCore.New(node, network.config, tendermintCore.WithoutRebroadcast())
+	return node
+}
+
+// Start starts the consensus of the node and proposes the next block of its chain
+func (n *Node) Start() error {
+	n.mu.Lock()
+	if n.running {
+		n.mu.Unlock()
+		return tendermint.ErrStartedEngine
+	}
+	n.running = true
+	n.mu.Unlock()
+	if err := n.core.Start(); err != nil {
+		return err
+	}
+	go n.post(n.nextBlockEvent(n.Head()))
+	return nil
+}
+
+// Stop stops the consensus of the node, the messages sent to a stopped node are lost
+func (n *Node) Stop() error {
+	n.mu.Lock()
+	if !n.running {
+		n.mu.Unlock()
+		return tendermint.ErrStoppedEngine
+	}
+	n.running = false
+	n.mu.Unlock()
+	return n.core.Stop()
+}
+
+// Running returns whether the consensus of the node is started
+func (n *Node) Running() bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.running
+}
+
+// Engine returns the consensus core of the node
+func (n *Node) Engine() tendermintCore.Engine {
+	return n.core
+}
+
+// Head returns the last block of the chain of the node
+func (n *Node) Head() *types.Block {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.chain[len(n.chain)-1]
+}
+
+// BlockByNumber returns the block of the chain of the node by number, nil if the node does not have it
+func (n *Node) BlockByNumber(number uint64) *types.Block {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if number >= uint64(len(n.chain)) {
+		return nil
+	}
+	return n.chain[number]
+}
+
+func (n *Node) String() string {
+	return fmt.Sprintf("node %d (%s)", n.Index, n.address.Hex())
+}
+
+// nextBlockEvent returns the event sending the block to propose for the next block number to the core, as the miner
+// does. The index of the node is written in the block so that twins propose different blocks.
+func (n *Node) nextBlockEvent(parent *types.Block) tendermint.NewBlockEvent {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   n.address,
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   parent.GasLimit(),
+		Time:       parent.Time() + 1,
+		Difficulty: big.NewInt(1),
+		MixDigest:  types.TendermintDigest,
+		Extra:      makeExtra([]byte(fmt.Sprintf("node %d", n.Index))),
+	}
+	return tendermint.NewBlockEvent{Block: types.NewBlockWithHeader(header)}
+}
+
+func (n *Node) post(ev interface{}) {
+	if err := n.mux.Post(ev); err != nil {
+		log.Error("failed to post event", "node", n.Index, "error", err)
+	}
+}
+
+// insertBlock appends a committed block to the chain of the node and moves the core to the next block number.
+// It returns false if the block is not the next one of the chain.
+func (n *Node) insertBlock(block *types.Block) bool {
+	n.mu.Lock()
+	head := n.chain[len(n.chain)-1]
+	if block.NumberU64() != head.NumberU64()+1 || block.ParentHash() != head.Hash() {
+		n.mu.Unlock()
+		return false
+	}
+	n.chain = append(n.chain, block)
+	next := n.nextBlockEvent(block)
+	n.mu.Unlock()
+	// the core is called back from its own loop, so the events are posted asynchronously
+	go func() {
+		n.post(tendermint.FinalCommittedEvent{BlockNumber: block.Number()})
+		n.post(next)
+	}()
+	return true
+}
+
+// receiveBlock syncs the chain of the node up to a block committed by another node
+func (n *Node) receiveBlock(from *Node, block *types.Block) {
+	for number := n.Head().NumberU64() + 1; number <= block.NumberU64(); number++ {
+		next := from.BlockByNumber(number)
+		if next == nil || !n.insertBlock(next) {
+			return
+		}
+	}
+}
+
+// Address implements tendermint.Backend.Address
+func (n *Node) Address() common.Address {
+	return n.address
+}
+
+// EventMux implements tendermint.Backend.EventMux
+func (n *Node) EventMux() *event.TypeMux {
+	return n.mux
+}
+
+// Sign implements tendermint.Backend.Sign
+func (n *Node) Sign(data []byte) ([]byte, error) {
+	return n.signer.Sign(data)
+}
+
+// SignVote implements tendermint.Backend.SignVote
+func (n *Node) SignVote(vote *tendermint.VoteStep, data []byte) ([]byte, error) {
+	return n.signer.SignVote(vote, data)
+}
+
+// SignBLS implements tendermint.Backend.SignBLS
+func (n *Node) SignBLS(data []byte) ([]byte, error) {
+	key, err := n.signer.BLSKey()
+	if err != nil {
+		return nil, err
+	}
+	return key.Sign(data), nil
+}
+
+// Gossip implements tendermint.Backend.Gossip
+func (n *Node) Gossip(valSet tendermint.ValidatorSet, blockNumber *big.Int, round int64, msgType uint64, payload []byte) error {
+	n.network.send(n, payload)
+	return nil
+}
+
+// Broadcast implements tendermint.Backend.Broadcast, the message is posted to the node itself right away
+func (n *Node) Broadcast(valSet tendermint.ValidatorSet, blockNumber *big.Int, round int64, msgType uint64, payload []byte) error {
+	n.network.send(n, payload)
+	go n.post(tendermint.MessageEvent{Payload: payload})
+	return nil
+}
+
+// Multicast implements tendermint.Backend.Multicast
+func (n *Node) Multicast(targets map[common.Address]bool, payload []byte) error {
+	n.network.multicast(n, targets, payload)
+	return nil
+}
+
+// Validators implements tendermint.Backend.Validators, every node of the network is a validator
+func (n *Node) Validators(blockNumber *big.Int) tendermint.ValidatorSet {
+	return validator.NewSet(n.network.validators, n.network.config.ProposerPolicy, blockNumber.Int64())
+}
+
+// Config implements tendermint.Backend.Config, the config of the network is not governed
+func (n *Node) Config(blockNumber *big.Int) *tendermint.Config {
+	return nil
+}
+
+// CurrentHeadBlock implements tendermint.Backend.CurrentHeadBlock
+func (n *Node) CurrentHeadBlock() *types.Block {
+	return n.Head()
+}
+
+// FindExistingPeers implements tendermint.Backend.FindExistingPeers, the nodes are not connected through peers
+func (n *Node) FindExistingPeers(targets tendermint.ValidatorSet) map[common.Address]consensus.Peer {
+	return make(map[common.Address]consensus.Peer)
+}
+
+// Commit implements tendermint.Backend.Commit, the block is checked against the blocks committed by the other nodes,
+// inserted in the chain of the node and sent to the other nodes for them to sync
+func (n *Node) Commit(block *types.Block) {
+	n.network.checker.commit(n, block)
+	if n.insertBlock(block) {
+		n.network.sendBlock(n, block)
+	}
+}
+
+// Cancel implements tendermint.Backend.Cancel
+func (n *Node) Cancel(block *types.Block) {}
+
+// VerifyProposalHeader implements tendermint.Backend.VerifyProposalHeader
+func (n *Node) VerifyProposalHeader(header *types.Header) error {
+	if head := n.Head(); header.ParentHash != head.Hash() || header.Number.Uint64() != head.NumberU64()+1 {
+		return errUnknownParent
+	}
+	return nil
+}
+
+// VerifyProposalBlock implements tendermint.Backend.VerifyProposalBlock
+func (n *Node) VerifyProposalBlock(block *types.Block) error {
+	return nil
+}
+
+// ReportEvidence implements tendermint.Backend.ReportEvidence
+func (n *Node) ReportEvidence(ev *types.TendermintEvidence) bool {
+	n.network.checker.evidence(ev)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	hash := ev.Hash()
+	if n.evidence[hash] {
+		return false
+	}
+	n.evidence[hash] = true
+	return true
+}
+
+// makeExtra returns a tendermint extra-data with the vanity
+func makeExtra(vanity []byte) []byte {
+	extra := make([]byte, types.TendermintExtraVanity)
+	copy(extra, vanity)
+	payload, _ := rlp.EncodeToBytes(&types.TendermintExtra{})
+	return append(extra, payload...)
+}
+
+// makeGenesis returns the genesis block of the network
+func makeGenesis(validators []common.Address) *types.Block {
+	var buf bytes.Buffer
+	for _, addr := range validators {
+		buf.Write(addr.Bytes())
+	}
+	return types.NewBlockWithHeader(&types.Header{
+		Number:     big.NewInt(0),
+		GasLimit:   10000000,
+		Difficulty: big.NewInt(1),
+		MixDigest:  types.TendermintDigest,
+		Extra:      makeExtra(crypto.Keccak256(buf.Bytes())),
+	})
+}
@@ -72,10 +72,14 @@ func (c *MockChainReader) State() (*state.StateDB, error) {
 //it serves basic header for testing purposes
 type headersMockChainReader struct {
 	headers []*types.Header
+	config  *params.ChainConfig
 }
 
 func (c *headersMockChainReader) Config() *params.ChainConfig {
-	panic("implement me")
+	if c.config == nil {
+		return &params.ChainConfig{}
+	}
+	return c.config
 }
 
 func (c *headersMockChainReader) CurrentHeader() *types.Header {
@@ -113,3 +117,11 @@ func NewHeadersMockChainReader(headers []*types.Header) consensus.ChainReader {
 		headers: headers,
 	}
 }
+
+// NewHeadersMockChainReaderWithConfig returns a chain reader serving the headers with the given chain config
+func NewHeadersMockChainReaderWithConfig(config *params.ChainConfig, headers []*types.Header) consensus.ChainReader {
+	return &headersMockChainReader{
+		headers: headers,
+		config:  config,
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/core"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/crypto/bls"
 	"github.com/Evrynetlabs/evrynet-node/event"
 	"github.com/Evrynetlabs/evrynet-node/log"
 	"github.com/Evrynetlabs/evrynet-node/params"
@@ -34,6 +35,10 @@ type MockBackend struct {
 	currentBlock func() *types.Block
 	// SendEventMux is used for receiving output msg from core
 	SendEventMux *event.TypeMux
+	// Evidences are the evidences reported by core
+	Evidences []*types.TendermintEvidence
+	// evidenceFeed notifies every evidence core reports, including the ones already reported
+	evidenceFeed event.Feed
 }
 
 //SentMsgEvent represents an action send to an peer
@@ -85,6 +90,21 @@ func (mb *MockBackend) Sign(data []byte) ([]byte, error) {
 	return crypto.Sign(hashData, mb.privateKey)
 }
 
+// SignVote implements tendermint.Backend.SignVote
+func (mb *MockBackend) SignVote(vote *tendermint.VoteStep, data []byte) ([]byte, error) {
+	return mb.Sign(data)
+}
+
+// SignBLS implements tendermint.Backend.SignBLS
+func (mb *MockBackend) SignBLS(data []byte) ([]byte, error) {
+	return bls.DeriveSecretKey(crypto.FromECDSA(mb.privateKey)).Sign(data), nil
+}
+
+// Config implements tendermint.Backend.Config
+func (mb *MockBackend) Config(blockNumber *big.Int) *tendermint.Config {
+	return nil
+}
+
 // Address implements tendermint.Backend.Address
 func (mb *MockBackend) Address() common.Address {
 	return mb.address
@@ -163,6 +183,25 @@ func (mb *MockBackend) Cancel(block *types.Block) {
 	log.Error("not implemented")
 }
 
+// ReportEvidence implements tendermint.Backend.ReportEvidence
+func (mb *MockBackend) ReportEvidence(ev *types.TendermintEvidence) bool {
+	defer mb.evidenceFeed.Send(ev)
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+	for _, reported := range mb.Evidences {
+		if reported.Hash() == ev.Hash() {
+			return false
+		}
+	}
+	mb.Evidences = append(mb.Evidences, ev)
+	return true
+}
+
+// SubscribeEvidences notifies ch of every evidence core reports once the backend handled it
+func (mb *MockBackend) SubscribeEvidences(ch chan<- *types.TendermintEvidence) event.Subscription {
+	return mb.evidenceFeed.Subscribe(ch)
+}
+
 func MustCreateAndStartNewBackend(t *testing.T, nodePrivateKey *ecdsa.PrivateKey, genesisHeader *types.Header, validators []common.Address) (tendermint.Backend, *core.TxPool) {
 	var (
 		address = crypto.PubkeyToAddress(nodePrivateKey.PublicKey)
@@ -3,6 +3,7 @@ package tests_utils
 import (
 	"crypto/ecdsa"
 
+	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
@@ -24,3 +25,27 @@ func AppendCommitedSealByPkKeys(header *types.Header, pks []*ecdsa.PrivateKey) {
 	}
 	_ = utils.WriteCommittedSeals(header, committedSeals)
 }
+
+// MakeCommittedHeaders returns n headers following parent, proposed and committed by the keys. Every epoch blocks, the
+// checkpoint header stores the validators of the keys and the hash of their validator set.
+func MakeCommittedHeaders(parent *types.Header, n int, epoch uint64, keys []*ecdsa.PrivateKey) []*types.Header {
+	validators := make([]common.Address, len(keys))
+	for i, key := range keys {
+		validators[i] = crypto.PubkeyToAddress(key.PublicKey)
+	}
+	headers := make([]*types.Header, 0, n)
+	for i := 0; i < n; i++ {
+		header := makeHeaderFromParent(types.NewBlockWithHeader(parent))
+		header.Time = parent.Time + 1
+		if header.Number.Uint64()%epoch == 0 {
+			_ = utils.WriteValSet(header, validators)
+			_ = utils.WriteValSetHash(header)
+		}
+		header.Coinbase = validators[0]
+		AppendSealByPkKey(header, keys[0])
+		AppendCommitedSealByPkKeys(header, keys)
+		headers = append(headers, header)
+		parent = header
+	}
+	return headers
+}
@@ -10,11 +10,16 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/crypto/bls"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
 )
 
 var (
 	ErrInvalidSealLength = errors.New("seal is expected to be multiplication of 65")
+	// ErrInvalidAggregatedSealLength is returned if the aggregated seal is not a BLS signature
+	ErrInvalidAggregatedSealLength = errors.New("aggregated seal is expected to be 96 bytes")
+	// ErrInvalidSignerBitmap is returned if the signer bitmap of an aggregated seal does not match the validator set
+	ErrInvalidSignerBitmap = errors.New("invalid signer bitmap")
 )
 
 const (
@@ -79,6 +84,74 @@ func WriteValSet(h *types.Header, validators []common.Address) error {
 	return nil
 }
 
+// WriteVotingPowers writes the extra-data field of a block header with the voting powers of its validators.
+func WriteVotingPowers(h *types.Header, votingPowers []uint64) error {
+	tendermintExtra, err := types.ExtractTendermintExtra(h)
+	if err != nil {
+		return err
+	}
+	tendermintExtra.VotingPowers = votingPowers
+
+	payload, err := rlp.EncodeToBytes(&tendermintExtra)
+	if err != nil {
+		return err
+	}
+
+	h.Extra = append(h.Extra[:types.TendermintExtraVanity], payload...)
+	return nil
+}
+
+// WriteBLSPublicKeys writes the extra-data field of a block header with the BLS public keys of its validators.
+func WriteBLSPublicKeys(h *types.Header, publicKeys [][]byte) error {
+	tendermintExtra, err := types.ExtractTendermintExtra(h)
+	if err != nil {
+		return err
+	}
+	tendermintExtra.BLSPublicKeys = publicKeys
+
+	payload, err := rlp.EncodeToBytes(&tendermintExtra)
+	if err != nil {
+		return err
+	}
+
+	h.Extra = append(h.Extra[:types.TendermintExtraVanity], payload...)
+	return nil
+}
+
+// WriteValSetHash writes the extra-data field of a block header with the hash of the validator set it holds.
+func WriteValSetHash(h *types.Header) error {
+	tendermintExtra, err := types.ExtractTendermintExtra(h)
+	if err != nil {
+		return err
+	}
+	tendermintExtra.ValSetHash = tendermintExtra.CalcValSetHash()
+
+	payload, err := rlp.EncodeToBytes(&tendermintExtra)
+	if err != nil {
+		return err
+	}
+
+	h.Extra = append(h.Extra[:types.TendermintExtraVanity], payload...)
+	return nil
+}
+
+// WriteEvidences writes the extra-data field of a block header with given evidences.
+func WriteEvidences(h *types.Header, evidences []*types.TendermintEvidence) error {
+	tendermintExtra, err := types.ExtractTendermintExtra(h)
+	if err != nil {
+		return err
+	}
+	tendermintExtra.Evidences = evidences
+
+	payload, err := rlp.EncodeToBytes(&tendermintExtra)
+	if err != nil {
+		return err
+	}
+
+	h.Extra = append(h.Extra[:types.TendermintExtraVanity], payload...)
+	return nil
+}
+
 // WriteCommittedSeals writes the extra-data field of a block header with given committed seals.
 func WriteCommittedSeals(h *types.Header, committedSeals [][]byte) error {
 	if len(committedSeals) == 0 {
@@ -108,6 +181,50 @@ func WriteCommittedSeals(h *types.Header, committedSeals [][]byte) error {
 	return nil
 }
 
+// WriteAggregatedSeal writes the extra-data field of a block header with the aggregated committed seal.
+func WriteAggregatedSeal(h *types.Header, aggregatedSeal *types.TendermintAggregatedSeal) error {
+	if len(aggregatedSeal.Signature) != bls.SignatureLength {
+		return ErrInvalidAggregatedSealLength
+	}
+
+	tendermintExtra, err := types.ExtractTendermintExtra(h)
+	if err != nil {
+		return err
+	}
+	tendermintExtra.AggregatedSeal = aggregatedSeal
+
+	payload, err := rlp.EncodeToBytes(&tendermintExtra)
+	if err != nil {
+		return err
+	}
+
+	h.Extra = append(h.Extra[:types.TendermintExtraVanity], payload...)
+	return nil
+}
+
+// WriteParentSeals writes the extra-data field of a block header with the committed seals of its parent, either
+// the seals or the aggregated seal of the parent's extra-data.
+func WriteParentSeals(h *types.Header, parentExtra *types.TendermintExtra) error {
+	tendermintExtra, err := types.ExtractTendermintExtra(h)
+	if err != nil {
+		return err
+	}
+	if parentExtra.AggregatedSeal != nil {
+		tendermintExtra.ParentAggregatedSeal = parentExtra.AggregatedSeal
+	} else {
+		tendermintExtra.ParentCommittedSeal = make([][]byte, len(parentExtra.CommittedSeal))
+		copy(tendermintExtra.ParentCommittedSeal, parentExtra.CommittedSeal)
+	}
+
+	payload, err := rlp.EncodeToBytes(&tendermintExtra)
+	if err != nil {
+		return err
+	}
+
+	h.Extra = append(h.Extra[:types.TendermintExtraVanity], payload...)
+	return nil
+}
+
 // GetSignatureAddress gets the signer address from the signature
 func GetSignatureAddress(data []byte, sig []byte) (common.Address, error) {
 	// 1. Keccak data
@@ -128,6 +245,15 @@ func PrepareCommittedSeal(hash common.Hash) []byte {
 	return buf.Bytes()
 }
 
+// CommittedSealHash returns the hash of the block committed by data if it is the data of a committed seal,
+// see PrepareCommittedSeal
+func CommittedSealHash(data []byte) (common.Hash, bool) {
+	if len(data) != common.HashLength+1 || data[common.HashLength] != byte(msgCommit) {
+		return common.Hash{}, false
+	}
+	return common.BytesToHash(data[:common.HashLength]), true
+}
+
 // GetCheckpointNumber returns check-point block where header contains valset of current epoch
 func GetCheckpointNumber(epochDuration uint64, blockNumber uint64) uint64 {
 	if blockNumber == 0 || blockNumber < epochDuration {
@@ -155,3 +281,60 @@ func GetValSetAddresses(h *types.Header) ([]common.Address, error) {
 
 	return validators, nil
 }
+
+// GetValSetVotingPowers returns the voting powers of validators from the extra-data field.
+// It returns nil if the header does not contain voting powers, i.e, every validator has a voting power of 1.
+func GetValSetVotingPowers(h *types.Header) ([]int64, error) {
+	tdmExtra, err := types.ExtractTendermintExtra(h)
+	if err != nil {
+		return nil, err
+	}
+	if len(tdmExtra.VotingPowers) == 0 {
+		return nil, nil
+	}
+	votingPowers := make([]int64, len(tdmExtra.VotingPowers))
+	for i, power := range tdmExtra.VotingPowers {
+		votingPowers[i] = int64(power)
+	}
+	return votingPowers, nil
+}
+
+// GetValSetBLSPublicKeys returns the BLS public keys of validators from the extra-data field.
+// It returns nil if the header does not contain BLS public keys, i.e, the blocks are committed with committed seals.
+func GetValSetBLSPublicKeys(h *types.Header) ([][]byte, error) {
+	tdmExtra, err := types.ExtractTendermintExtra(h)
+	if err != nil {
+		return nil, err
+	}
+	if len(tdmExtra.BLSPublicKeys) == 0 {
+		return nil, nil
+	}
+	return tdmExtra.BLSPublicKeys, nil
+}
+
+// SignerBitmap returns the bitmap where bit i is set for every index i of signers
+func SignerBitmap(size int, signers []int) []byte {
+	bitmap := make([]byte, (size+7)/8)
+	for _, i := range signers {
+		bitmap[i/8] |= 1 << uint(i%8)
+	}
+	return bitmap
+}
+
+// SignerIndexes returns the indexes of the bits set in the bitmap, it returns an error if a bit higher than size is set
+func SignerIndexes(bitmap []byte, size int) ([]int, error) {
+	if len(bitmap) != (size+7)/8 {
+		return nil, ErrInvalidSignerBitmap
+	}
+	var signers []int
+	for i := 0; i < len(bitmap)*8; i++ {
+		if bitmap[i/8]&(1<<uint(i%8)) == 0 {
+			continue
+		}
+		if i >= size {
+			return nil, ErrInvalidSignerBitmap
+		}
+		signers = append(signers, i)
+	}
+	return signers, nil
+}
@@ -1,6 +1,9 @@
 package utils
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestGetCheckpointNumber(t *testing.T) {
 	type args struct {
@@ -45,3 +48,21 @@ func TestGetCheckpointNumber(t *testing.T) {
 		})
 	}
 }
+
+func TestSignerBitmap(t *testing.T) {
+	bitmap := SignerBitmap(10, []int{0, 3, 9})
+	if len(bitmap) != 2 {
+		t.Fatalf("SignerBitmap() length = %v, want 2", len(bitmap))
+	}
+	signers, err := SignerIndexes(bitmap, 10)
+	if err != nil {
+		t.Fatalf("SignerIndexes() error = %v", err)
+	}
+	if !reflect.DeepEqual(signers, []int{0, 3, 9}) {
+		t.Errorf("SignerIndexes() = %v, want [0 3 9]", signers)
+	}
+	// bits higher than the size of the validator set are not allowed
+	if _, err := SignerIndexes(bitmap, 9); err != ErrInvalidSignerBitmap {
+		t.Errorf("SignerIndexes() error = %v, want %v", err, ErrInvalidSignerBitmap)
+	}
+}
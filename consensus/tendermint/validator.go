@@ -11,6 +11,12 @@ type Validator interface {
 	// Address returns address
 	Address() common.Address
 
+	// VotingPower returns the weight of the validator's votes
+	VotingPower() int64
+
+	// BLSPublicKey returns the key the validator signs the aggregated committed seals with, nil if it has none
+	BLSPublicKey() []byte
+
 	// String representation of Validator
 	String() string
 }
@@ -55,11 +61,16 @@ type ValidatorSet interface {
 	RemoveValidator(address common.Address) bool
 	// Copy validator set
 	Copy() ValidatorSet
-	// Get the minimum number of votes for a polka
+	// AggregatesCommits returns true if every validator has a BLS public key,
+	// the blocks are then committed with an aggregated seal instead of the committed seals
+	AggregatesCommits() bool
+	// TotalVotingPower returns the sum of the voting power of all validators
+	TotalVotingPower() int64
+	// Get the minimum voting power of votes for a polka
 	MinMajority() int
 	// Get the minimum number of peers to archive consensus
 	MinPeers() int
-	// Get the maximum number of faulty nodes
+	// Get the maximum voting power of faulty nodes
 	F() int
 	// V get the minimum number of vote nodes
 	V() int
@@ -10,7 +10,9 @@ import (
 )
 
 type defaultValidator struct {
-	address common.Address
+	address      common.Address
+	votingPower  int64
+	blsPublicKey []byte
 }
 
 // Address will return address of defaultValidator
@@ -18,6 +20,16 @@ func (val *defaultValidator) Address() common.Address {
 	return val.address
 }
 
+// VotingPower will return the voting power of defaultValidator
+func (val *defaultValidator) VotingPower() int64 {
+	return val.votingPower
+}
+
+// BLSPublicKey will return the BLS public key of defaultValidator
+func (val *defaultValidator) BLSPublicKey() []byte {
+	return val.blsPublicKey
+}
+
 // String will parse address of defaultValidator to string and return it
 func (val *defaultValidator) String() string {
 	return val.Address().String()
@@ -36,17 +48,28 @@ type defaultSet struct {
 	validatorMu sync.RWMutex
 	selector    tendermint.ProposalSelector
 
-	height int64 // current height when backend init validator set
+	height     int64 // current height when backend init validator set
+	checkpoint int64 // the checkpoint the validator set is read from
+
+	round      int64        // the round of the current proposer, moved forward by CalcProposer
+	proposerMu sync.RWMutex // proposerMu protects proposer and round
 }
 
-func newDefaultSet(addrs []common.Address, policy tendermint.ProposerPolicy, height int64) *defaultSet {
+func newDefaultSet(addrs []common.Address, votingPowers []int64, blsPublicKeys [][]byte, policy tendermint.ProposerPolicy, height, checkpoint int64) *defaultSet {
 	valSet := &defaultSet{}
 
 	valSet.policy = policy
 	// init validators
 	valSet.validators = make([]tendermint.Validator, len(addrs))
 	for i, addr := range addrs {
-		valSet.validators[i] = New(addr)
+		val := &defaultValidator{address: addr, votingPower: 1}
+		if i < len(votingPowers) {
+			val.votingPower = votingPowers[i]
+		}
+		if i < len(blsPublicKeys) {
+			val.blsPublicKey = blsPublicKeys[i]
+		}
+		valSet.validators[i] = val
 	}
 
 	// sort validator
@@ -62,13 +85,18 @@ func newDefaultSet(addrs []common.Address, policy tendermint.ProposerPolicy, hei
 		index := shiftHeight % int64(valSet.Size())
 		valSet.proposer = valSet.GetByIndex(index)
 	}
-	if policy == tendermint.Sticky {
+	switch policy {
+	case tendermint.Sticky:
 		valSet.selector = stickyProposer
-	} else {
+	case tendermint.WeightedRoundRobin:
+		valSet.selector = weightedRoundRobinProposer
+		valSet.proposer = valSet.GetByIndex(int64(weightedProposerIndex(valSet.validators, weightedProposerStep(height, checkpoint, 0))))
+	default:
 		valSet.selector = roundRobinProposer
 	}
 
 	valSet.height = height
+	valSet.checkpoint = checkpoint
 
 	return valSet
 }
@@ -149,6 +177,59 @@ func stickyProposer(valSet tendermint.ValidatorSet, proposer common.Address, rou
 	return valSet.GetByIndex(pick)
 }
 
+// weightedRoundRobinProposer returns the proposer of the round roundDiff rounds after the current round of valSet.
+// Unlike other selectors, the proposer is not derived from the given proposer: it only depends on the height of valSet
+// relative to its checkpoint and on the round, whatever the proposers computed before.
+// It must be called with the proposer lock of valSet held.
+func weightedRoundRobinProposer(valSet tendermint.ValidatorSet, _ common.Address, roundDiff int64) tendermint.Validator {
+	set, ok := valSet.(*defaultSet)
+	if !ok || set.Size() == 0 {
+		return nil
+	}
+	step := weightedProposerStep(set.height, set.checkpoint, set.round+roundDiff)
+	return set.GetByIndex(int64(weightedProposerIndex(set.List(), step)))
+}
+
+// weightedProposerStep returns the step of the weighted round robin sequence of the round at the height.
+// The priorities are accumulated from the checkpoint so the proposer only depends on the checkpoint header.
+func weightedProposerStep(height, checkpoint, round int64) int64 {
+	if height <= checkpoint {
+		return round
+	}
+	return height - checkpoint - 1 + round
+}
+
+// weightedProposerIndex returns the index of the proposer at the given step of the proportional proposer priority
+// sequence: at every step the priority of each validator increases by its voting power, the validator with the highest
+// priority (the lowest index on tie) is chosen and its priority is decreased by the total voting power.
+// The sequence repeats itself every total voting power steps, so only step modulo the total voting power is computed.
+func weightedProposerIndex(validators tendermint.Validators, step int64) int {
+	var total int64
+	for _, val := range validators {
+		total += val.VotingPower()
+	}
+	if total <= 0 {
+		return int(step % int64(len(validators)))
+	}
+	var (
+		priorities = make([]int64, len(validators))
+		picked     int
+	)
+	for i := int64(0); i <= step%total; i++ {
+		for j, val := range validators {
+			priorities[j] += val.VotingPower()
+		}
+		picked = 0
+		for j := range priorities {
+			if priorities[j] > priorities[picked] {
+				picked = j
+			}
+		}
+		priorities[picked] -= total
+	}
+	return picked
+}
+
 // AddValidator will add a validator to validators collection
 func (valSet *defaultSet) AddValidator(address common.Address) bool {
 	valSet.validatorMu.Lock()
@@ -182,24 +263,55 @@ func (valSet *defaultSet) Copy() tendermint.ValidatorSet {
 	defer valSet.validatorMu.RUnlock()
 
 	addresses := make([]common.Address, 0, len(valSet.validators))
+	votingPowers := make([]int64, 0, len(valSet.validators))
+	blsPublicKeys := make([][]byte, 0, len(valSet.validators))
 	for _, v := range valSet.validators {
 		addresses = append(addresses, v.Address())
+		votingPowers = append(votingPowers, v.VotingPower())
+		blsPublicKeys = append(blsPublicKeys, v.BLSPublicKey())
+	}
+	copied := newDefaultSet(addresses, votingPowers, blsPublicKeys, valSet.policy, valSet.height, valSet.checkpoint)
+	if valSet.policy == tendermint.WeightedRoundRobin {
+		valSet.proposerMu.RLock()
+		copied.round = valSet.round
+		copied.proposer = valSet.proposer
+		valSet.proposerMu.RUnlock()
+	}
+	return copied
+}
+
+// AggregatesCommits returns true if every validator has a BLS public key
+func (valSet *defaultSet) AggregatesCommits() bool {
+	validators := valSet.List()
+	for _, v := range validators {
+		if len(v.BLSPublicKey()) == 0 {
+			return false
+		}
+	}
+	return len(validators) > 0
+}
+
+// TotalVotingPower returns the sum of the voting power of all validators
+func (valSet *defaultSet) TotalVotingPower() int64 {
+	var total int64
+	for _, v := range valSet.List() {
+		total += v.VotingPower()
 	}
-	return NewSet(addresses, valSet.policy, valSet.height)
+	return total
 }
 
 // Get the minimum number of peers to archive consensus
 func (valSet *defaultSet) MinPeers() int {
-	return valSet.Size() - valSet.F() - 1
+	return valSet.Size() - (int(math.Ceil(float64(valSet.Size())/3)) - 1) - 1
 }
 
-// Get the minimum number of votes for a polka
+// Get the minimum voting power of votes for a polka
 func (valSet *defaultSet) MinMajority() int {
-	return valSet.Size() - valSet.F()
+	return int(valSet.TotalVotingPower()) - valSet.F()
 }
 
-// F get the maximum number of faulty nodes
-func (valSet *defaultSet) F() int { return int(math.Ceil(float64(valSet.Size())/3)) - 1 }
+// F get the maximum voting power of faulty nodes
+func (valSet *defaultSet) F() int { return int(math.Ceil(float64(valSet.TotalVotingPower())/3)) - 1 }
 
 // V get the minimum number of vote nodes
 func (valSet *defaultSet) V() int { return int(math.Ceil(float64(valSet.Size()) / 2)) }
@@ -232,13 +344,16 @@ func (valSet *defaultSet) GetNeighbors(addr common.Address) map[common.Address]b
 //CalcProposer implement valSet.CalcProposer. Based on the proposer selection scheme,
 //it will set valSet.proposer to the address of the pre-determined round.
 func (valSet *defaultSet) CalcProposer(lastProposer common.Address, roundDiff int64) {
-	valSet.validatorMu.RLock()
-	defer valSet.validatorMu.RUnlock()
+	valSet.proposerMu.Lock()
+	defer valSet.proposerMu.Unlock()
 	valSet.proposer = valSet.selector(valSet, lastProposer, roundDiff)
+	valSet.round += roundDiff
 }
 
 //GetProposer return the current proposer of this valSet
 func (valSet *defaultSet) GetProposer() tendermint.Validator {
+	valSet.proposerMu.RLock()
+	defer valSet.proposerMu.RUnlock()
 	return valSet.proposer
 }
 
@@ -4,6 +4,7 @@ import (
 	"log"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -34,7 +35,7 @@ func TestDefaultSet_GetNeighbor(t *testing.T) {
 			common.HexToAddress("0x2"),
 			common.HexToAddress("0x3"),
 		}
-		valSet      = newDefaultSet(addresses, tendermint.RoundRobin, 0)
+		valSet      = newDefaultSet(addresses, nil, nil, tendermint.RoundRobin, 0, 0)
 		neighbors   = valSet.GetNeighbors(addresses[0])
 		expectedLen = 2
 	)
@@ -44,6 +45,115 @@ func TestDefaultSet_GetNeighbor(t *testing.T) {
 	require.True(t, neighbors[addresses[2]])
 }
 
+func TestDefaultSet_WeightedMajority(t *testing.T) {
+	var (
+		addresses = []common.Address{
+			common.HexToAddress("0x3"),
+			common.HexToAddress("0x1"),
+			common.HexToAddress("0x2"),
+		}
+		votingPowers = []int64{10, 1, 1}
+		valSet       = NewWeightedSet(addresses, votingPowers, nil, tendermint.RoundRobin, 0, 0)
+	)
+	require.Equal(t, int64(12), valSet.TotalVotingPower())
+	// F = ceil(12/3) - 1 = 3
+	require.Equal(t, 3, valSet.F())
+	require.Equal(t, 9, valSet.MinMajority())
+	// MinPeers depends on the number of validators only
+	require.Equal(t, 2, valSet.MinPeers())
+
+	// voting powers follow their validators after sorting and copying
+	for _, valSet := range []tendermint.ValidatorSet{valSet, valSet.Copy()} {
+		_, val := valSet.GetByAddress(common.HexToAddress("0x3"))
+		require.NotNil(t, val)
+		require.Equal(t, int64(10), val.VotingPower())
+		_, val = valSet.GetByAddress(common.HexToAddress("0x1"))
+		require.Equal(t, int64(1), val.VotingPower())
+	}
+}
+
+func TestDefaultSet_WeightedRoundRobin(t *testing.T) {
+	var (
+		heavy     = common.HexToAddress("0x1")
+		light     = common.HexToAddress("0x2")
+		addresses = []common.Address{heavy, light}
+		// the checkpoint is at block 10, so block 11 is the first of the sequence
+		valSet = NewWeightedSet(addresses, []int64{3, 1}, nil, tendermint.WeightedRoundRobin, 11, 10)
+	)
+	require.Equal(t, heavy, valSet.GetProposer().Address())
+
+	// proposers are chosen proportionally to their voting power
+	proposed := map[common.Address]int{valSet.GetProposer().Address(): 1}
+	for i := 1; i < 8; i++ {
+		valSet.CalcProposer(valSet.GetProposer().Address(), 1)
+		proposed[valSet.GetProposer().Address()]++
+	}
+	require.Equal(t, 6, proposed[heavy])
+	require.Equal(t, 2, proposed[light])
+
+	// the proposer of a height only depends on the checkpoint
+	for height := int64(11); height < 19; height++ {
+		expected := NewWeightedSet(addresses, []int64{3, 1}, nil, tendermint.WeightedRoundRobin, 11, 10)
+		expected.CalcProposer(expected.GetProposer().Address(), height-11)
+		valSet := NewWeightedSet(addresses, []int64{3, 1}, nil, tendermint.WeightedRoundRobin, height, 10)
+		require.Equal(t, expected.GetProposer().Address(), valSet.GetProposer().Address())
+
+		// copying keeps the position in the sequence
+		copied := valSet.Copy()
+		require.Equal(t, valSet.GetProposer().Address(), copied.GetProposer().Address())
+		copied.CalcProposer(copied.GetProposer().Address(), 1)
+		valSet.CalcProposer(valSet.GetProposer().Address(), 1)
+		require.Equal(t, valSet.GetProposer().Address(), copied.GetProposer().Address())
+	}
+
+	// the proposer of a round does not depend on the proposers calculated before
+	for round := int64(0); round < 8; round++ {
+		stepped := NewWeightedSet(addresses, []int64{3, 1}, nil, tendermint.WeightedRoundRobin, 13, 10)
+		for i := int64(0); i < round; i++ {
+			stepped.CalcProposer(stepped.GetProposer().Address(), 1)
+		}
+		jumped := NewWeightedSet(addresses, []int64{3, 1}, nil, tendermint.WeightedRoundRobin, 13, 10)
+		jumped.CalcProposer(jumped.GetProposer().Address(), round+2)
+		jumped.CalcProposer(jumped.GetProposer().Address(), -2)
+		require.Equal(t, stepped.GetProposer().Address(), jumped.GetProposer().Address())
+	}
+}
+
+// TestDefaultSet_WeightedRoundRobinConcurrent is meant to be run with -race.
+func TestDefaultSet_WeightedRoundRobinConcurrent(t *testing.T) {
+	var (
+		addresses = []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2")}
+		valSet    = NewWeightedSet(addresses, []int64{3, 1}, nil, tendermint.WeightedRoundRobin, 11, 10)
+		wg        sync.WaitGroup
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				valSet.CalcProposer(common.Address{}, 1)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				require.NotNil(t, valSet.GetProposer())
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				valSet.Copy().CalcProposer(common.Address{}, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	expected := NewWeightedSet(addresses, []int64{3, 1}, nil, tendermint.WeightedRoundRobin, 11, 10)
+	expected.CalcProposer(common.Address{}, 400)
+	require.Equal(t, expected.GetProposer().Address(), valSet.GetProposer().Address())
+}
+
 func testMajorityFormulation(t *testing.T) {
 	var expectedMajority = map[int]int{
 		1: 1, 2: 2, 3: 3, 4: 3, 5: 4, 6: 5, 7: 5,
@@ -55,7 +165,7 @@ func testMajorityFormulation(t *testing.T) {
 			key, _ := crypto.GenerateKey()
 			addresses = append(addresses, crypto.PubkeyToAddress(key.PublicKey))
 		}
-		valSet := NewSet(addresses, tendermint.RoundRobin, int64(0))
+		valSet := NewSet(addresses, tendermint.RoundRobin, 0)
 		require.Equal(t, majority, valSet.MinMajority())
 	}
 }
@@ -75,7 +185,7 @@ func testNewValidatorSet(t *testing.T) {
 	}
 
 	// Create ValidatorSet
-	valSet := NewSet(ExtractValidators(b), tendermint.RoundRobin, int64(0))
+	valSet := NewSet(ExtractValidators(b), tendermint.RoundRobin, 0)
 	if valSet == nil {
 		t.Errorf("the validator byte array cannot be parsed")
 		t.FailNow()
@@ -108,7 +218,7 @@ func testNormalValSet(t *testing.T) {
 	val1 := New(addr1)
 	val2 := New(addr2)
 
-	valSet := newDefaultSet([]common.Address{addr1, addr2}, tendermint.RoundRobin, int64(0))
+	valSet := newDefaultSet([]common.Address{addr1, addr2}, nil, nil, tendermint.RoundRobin, 0, 0)
 	assert.NotNil(t, valSet, "the format of validator set is invalid")
 
 	// check size
@@ -134,7 +244,7 @@ func testNormalValSet(t *testing.T) {
 	}
 
 	blockHeight := 1
-	valSetWilHeight := newDefaultSet([]common.Address{addr1, addr2}, tendermint.RoundRobin, int64(blockHeight))
+	valSetWilHeight := newDefaultSet([]common.Address{addr1, addr2}, nil, nil, tendermint.RoundRobin, int64(blockHeight), 0)
 	assert.NotNil(t, valSet, "the format of validator set is invalid")
 	// test get by first index
 	if val := valSetWilHeight.GetProposer(); !reflect.DeepEqual(val, val1) {
@@ -153,7 +263,7 @@ func testNormalValSet(t *testing.T) {
 }
 
 func testEmptyValSet(t *testing.T) {
-	valSet := NewSet(ExtractValidators([]byte{}), tendermint.RoundRobin, int64(0))
+	valSet := NewSet(ExtractValidators([]byte{}), tendermint.RoundRobin, 0)
 	if valSet == nil {
 		t.Errorf("validator set should not be nil")
 	}
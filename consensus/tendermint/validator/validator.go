@@ -7,16 +7,30 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
 )
 
-// New will create new validator
+// New will create new validator with a voting power of 1
 func New(addr common.Address) tendermint.Validator {
+	return NewWithVotingPower(addr, 1)
+}
+
+// NewWithVotingPower will create new validator with the given voting power
+func NewWithVotingPower(addr common.Address, votingPower int64) tendermint.Validator {
 	return &defaultValidator{
-		address: addr,
+		address:     addr,
+		votingPower: votingPower,
 	}
 }
 
-// NewSet will create new validator set by address list & policy
+// NewSet will create new validator set by address list & policy, every validator has a voting power of 1
 func NewSet(addrs []common.Address, policy tendermint.ProposerPolicy, height int64) tendermint.ValidatorSet {
-	return newDefaultSet(addrs, policy, height)
+	return newDefaultSet(addrs, nil, nil, policy, height, 0)
+}
+
+// NewWeightedSet will create new validator set by address list, voting powers & policy.
+// votingPowers[i] is the voting power of addrs[i], if votingPowers is empty every validator has a voting power of 1.
+// blsPublicKeys[i] is the BLS public key of addrs[i], if blsPublicKeys is empty the blocks are committed with committed seals.
+// checkpoint is the number of the block the validator set is read from.
+func NewWeightedSet(addrs []common.Address, votingPowers []int64, blsPublicKeys [][]byte, policy tendermint.ProposerPolicy, height, checkpoint int64) tendermint.ValidatorSet {
+	return newDefaultSet(addrs, votingPowers, blsPublicKeys, policy, height, checkpoint)
 }
 
 // IsProposer will be checking whether the validator with given address is a proposer
@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/core/governance"
 	"github.com/Evrynetlabs/evrynet-node/core/state"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/params"
@@ -65,9 +66,12 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 		return fmt.Errorf("transaction root hash mismatch: have %x, want %x", hash, header.TxHash)
 	}
 
-	for _, tx := range block.Transactions() {
-		if tx.GasPrice().Cmp(v.config.GasPrice) != 0 {
-			return fmt.Errorf("transaction gas price and chainConfig gas price mismatch: has %s want %s", tx.GasPrice(), v.config.GasPrice)
+	// the governed gas price is checked against the state when the transactions are applied
+	if !governance.Enabled(v.config, block.Number()) {
+		for _, tx := range block.Transactions() {
+			if tx.GasPrice().Cmp(v.config.GasPrice) != 0 {
+				return fmt.Errorf("transaction gas price and chainConfig gas price mismatch: has %s want %s", tx.GasPrice(), v.config.GasPrice)
+			}
 		}
 	}
 	if !v.bc.HasBlockAndState(block.ParentHash(), block.NumberU64()-1) {
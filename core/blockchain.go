@@ -142,6 +142,7 @@ type BlockChain struct {
 	chainHeadFeed event.Feed
 	logsFeed      event.Feed
 	blockProcFeed event.Feed
+	valSetFeed    event.Feed
 	scope         event.SubscriptionScope
 	genesisBlock  *types.Block
 
@@ -1951,6 +1952,9 @@ func (bc *BlockChain) PostChainEvents(events []interface{}, logs []*types.Log) {
 		switch ev := event.(type) {
 		case ChainEvent:
 			bc.chainFeed.Send(ev)
+			if valSetEvent := bc.validatorSetChangedEvent(ev.Block); valSetEvent != nil {
+				bc.valSetFeed.Send(*valSetEvent)
+			}
 
 		case ChainHeadEvent:
 			bc.chainHeadFeed.Send(ev)
@@ -2138,3 +2142,8 @@ func (bc *BlockChain) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscript
 func (bc *BlockChain) SubscribeBlockProcessingEvent(ch chan<- bool) event.Subscription {
 	return bc.scope.Track(bc.blockProcFeed.Subscribe(ch))
 }
+
+// SubscribeValidatorSetChangedEvent registers a subscription of ValidatorSetChangedEvent.
+func (bc *BlockChain) SubscribeValidatorSetChangedEvent(ch chan<- ValidatorSetChangedEvent) event.Subscription {
+	return bc.scope.Track(bc.valSetFeed.Subscribe(ch))
+}
@@ -46,3 +46,14 @@ type ChainSideEvent struct {
 }
 
 type ChainHeadEvent struct{ Block *types.Block }
+
+// ValidatorSetChangedEvent is posted when a checkpoint block of the Tendermint consensus is inserted in the canonical
+// chain. The block writes the validator set of the epoch starting after it, Added and Removed are empty if the set
+// is the same as the one of the previous epoch.
+type ValidatorSetChangedEvent struct {
+	Block      *types.Block
+	Epoch      uint64
+	Validators []common.Address
+	Added      []common.Address
+	Removed    []common.Address
+}
@@ -49,6 +49,7 @@ func NewEVMContext(msg Message, header *types.Header, chain ChainContext, author
 		Transfer:    Transfer,
 		GetHash:     GetHashFn(header, chain),
 		Origin:      msg.From(),
+		GasPayer:    msg.GasPayer(),
 		Coinbase:    beneficiary,
 		BlockNumber: new(big.Int).Set(header.Number),
 		Time:        new(big.Int).SetUint64(header.Time),
@@ -0,0 +1,308 @@
+// Package governance implements the on-chain governance of the chain parameters.
+//
+// The validators vote on the value of a parameter by sending a transaction to the staking contract, which the
+// contract ignores and the consensus engine reads from the successful transactions of the block. At each epoch
+// checkpoint, a value voted by a majority of the voting power of the validator set is scheduled to activate at the
+// next checkpoint. The votes and the parameters are stored in the storage of the
+// staking contract, in slots which are written by the node only.
+package governance
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/accounts/abi"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+// ChainParam identifies a chain parameter governed by the validators
+type ChainParam uint64
+
+const (
+	// ParamGasPrice is the gas price of the transactions, in wei
+	ParamGasPrice ChainParam = iota + 1
+	// ParamBlockReward is the reward of a block, in wei
+	ParamBlockReward
+	// ParamTimeoutPropose is the duration waiting a propose, in milliseconds
+	ParamTimeoutPropose
+	// ParamTimeoutPrevote is the duration waiting for more prevote after 2/3 received, in milliseconds
+	ParamTimeoutPrevote
+	// ParamTimeoutPrecommit is the duration waiting for more precommit after 2/3 received, in milliseconds
+	ParamTimeoutPrecommit
+	// ParamTimeoutCommit is the duration waiting to start round with new height, in milliseconds
+	ParamTimeoutCommit
+)
+
+// ChainParams are all the governed chain parameters
+var ChainParams = []ChainParam{
+	ParamGasPrice,
+	ParamBlockReward,
+	ParamTimeoutPropose,
+	ParamTimeoutPrevote,
+	ParamTimeoutPrecommit,
+	ParamTimeoutCommit,
+}
+
+// maxTimeout is the maximum value of the timeouts, in milliseconds
+const maxTimeout = uint64(10 * time.Minute / time.Millisecond)
+
+var paramNames = map[ChainParam]string{
+	ParamGasPrice:         "gasPrice",
+	ParamBlockReward:      "blockReward",
+	ParamTimeoutPropose:   "timeoutPropose",
+	ParamTimeoutPrevote:   "timeoutPrevote",
+	ParamTimeoutPrecommit: "timeoutPrecommit",
+	ParamTimeoutCommit:    "timeoutCommit",
+}
+
+func (p ChainParam) String() string {
+	if name, ok := paramNames[p]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// validValue returns whether the value can be voted for the parameter
+func (p ChainParam) validValue(value *big.Int) bool {
+	switch p {
+	case ParamGasPrice:
+		return value.Sign() > 0 && value.BitLen() <= 256
+	case ParamBlockReward:
+		return value.Sign() >= 0 && value.BitLen() <= 256
+	case ParamTimeoutPropose, ParamTimeoutPrevote, ParamTimeoutPrecommit, ParamTimeoutCommit:
+		return value.Sign() > 0 && value.IsUint64() && value.Uint64() <= maxTimeout
+	}
+	return false
+}
+
+var (
+	// votesSlot is the slot of mapping(uint256 => mapping(address => uint256)) storing the value voted by the voters
+	votesSlot = crypto.Keccak256Hash([]byte("evrynet.governance.votes"))
+	// votedAtSlot is the slot of mapping(uint256 => mapping(address => uint256)) storing the block the voters voted at
+	votedAtSlot = crypto.Keccak256Hash([]byte("evrynet.governance.votedAt"))
+	// scheduledAtSlot is the slot of mapping(uint256 => uint256) storing the block the last change of a parameter
+	// was scheduled at, the votes before are discarded
+	scheduledAtSlot = crypto.Keccak256Hash([]byte("evrynet.governance.scheduledAt"))
+	// pendingSlot is the slot of mapping(uint256 => uint256) storing the scheduled value of the parameters
+	pendingSlot = crypto.Keccak256Hash([]byte("evrynet.governance.pending"))
+	// pendingAtSlot is the slot of mapping(uint256 => uint256) storing the block the scheduled value activates at
+	pendingAtSlot = crypto.Keccak256Hash([]byte("evrynet.governance.pendingAt"))
+	// paramsSlot is the slot of mapping(uint256 => uint256) storing the active value of the parameters
+	paramsSlot = crypto.Keccak256Hash([]byte("evrynet.governance.params"))
+	// activatedAtSlot is the slot of mapping(uint256 => uint256) storing the block the active value activated at,
+	// zero if the value of the chain config is active
+	activatedAtSlot = crypto.Keccak256Hash([]byte("evrynet.governance.activatedAt"))
+
+	// VoteSelector is the selector of the call voteChainParam(address voter, uint256 param, uint256 value)
+	// to the staking contract. The contract ignores this call, the node reads it from the successful transactions of
+	// the block.
+	VoteSelector = crypto.Keccak256([]byte("voteChainParam(address,uint256,uint256)"))[:4]
+
+	voteArguments abi.Arguments
+
+	// ErrNotVote is returned if the call data is not a vote on a chain parameter
+	ErrNotVote = errors.New("not a chain parameter vote")
+	// ErrInvalidVote is returned if the parameter is unknown or the value is out of its range
+	ErrInvalidVote = errors.New("invalid chain parameter vote")
+)
+
+func init() {
+	addressType, _ := abi.NewType("address", nil)
+	uint256Type, _ := abi.NewType("uint256", nil)
+	voteArguments = abi.Arguments{{Type: addressType}, {Type: uint256Type}, {Type: uint256Type}}
+}
+
+// Vote is the vote of a validator on the value of a chain parameter
+type Vote struct {
+	Voter common.Address // the candidate voting
+	Param ChainParam
+	Value *big.Int
+}
+
+// EncodeVote returns the call data of the vote
+func EncodeVote(vote *Vote) ([]byte, error) {
+	args, err := voteArguments.Pack(vote.Voter, new(big.Int).SetUint64(uint64(vote.Param)), vote.Value)
+	if err != nil {
+		return nil, err
+	}
+	return append(common.CopyBytes(VoteSelector), args...), nil
+}
+
+// DecodeVote decodes the call data of a vote and checks the value is in the range of the parameter
+func DecodeVote(data []byte) (*Vote, error) {
+	if len(data) < len(VoteSelector) || !bytes.Equal(data[:len(VoteSelector)], VoteSelector) {
+		return nil, ErrNotVote
+	}
+	values, err := voteArguments.UnpackValues(data[len(VoteSelector):])
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidVote, err.Error())
+	}
+	param := values[1].(*big.Int)
+	if !param.IsUint64() {
+		return nil, ErrInvalidVote
+	}
+	vote := &Vote{
+		Voter: values[0].(common.Address),
+		Param: ChainParam(param.Uint64()),
+		Value: values[2].(*big.Int),
+	}
+	if !vote.Param.validValue(vote.Value) {
+		return nil, ErrInvalidVote
+	}
+	return vote, nil
+}
+
+// SetVote records the vote cast at the block number, replacing the previous vote of the voter on the parameter
+func SetVote(stateDB *state.StateDB, scAddress common.Address, vote *Vote, number uint64) {
+	stateDB.SetState(scAddress, voteLoc(votesSlot, vote.Param, vote.Voter), common.BigToHash(vote.Value))
+	stateDB.SetState(scAddress, voteLoc(votedAtSlot, vote.Param, vote.Voter), common.BigToHash(new(big.Int).SetUint64(number)))
+}
+
+// GetVote returns the value voted by the voter on the parameter since its last change was scheduled, nil if none
+func GetVote(stateDB *state.StateDB, scAddress common.Address, voter common.Address, param ChainParam) *big.Int {
+	votedAt := stateDB.GetState(scAddress, voteLoc(votedAtSlot, param, voter)).Big()
+	if votedAt.Sign() == 0 || votedAt.Cmp(stateDB.GetState(scAddress, paramLoc(scheduledAtSlot, param)).Big()) <= 0 {
+		return nil
+	}
+	return stateDB.GetState(scAddress, voteLoc(votesSlot, param, voter)).Big()
+}
+
+// Tally returns the value of the parameter voted by at least quorum of the voting powers of the voters, nil if none.
+// If several values reach the quorum, the one with the most voting power is returned, the lowest one on a tie.
+func Tally(stateDB *state.StateDB, scAddress common.Address, param ChainParam, votingPowers map[common.Address]int64, quorum int64) *big.Int {
+	var (
+		powers = make(map[common.Hash]int64)
+		values []*big.Int
+	)
+	for voter, power := range votingPowers {
+		value := GetVote(stateDB, scAddress, voter, param)
+		if value == nil {
+			continue
+		}
+		key := common.BigToHash(value)
+		if _, ok := powers[key]; !ok {
+			values = append(values, value)
+		}
+		powers[key] += power
+	}
+	sort.Slice(values, func(i, j int) bool {
+		pi, pj := powers[common.BigToHash(values[i])], powers[common.BigToHash(values[j])]
+		if pi != pj {
+			return pi > pj
+		}
+		return values[i].Cmp(values[j]) < 0
+	})
+	if len(values) == 0 || powers[common.BigToHash(values[0])] < quorum {
+		return nil
+	}
+	return values[0]
+}
+
+// Schedule schedules the value of the parameter to activate at the activation block, the votes cast until the
+// block number are discarded.
+func Schedule(stateDB *state.StateDB, scAddress common.Address, param ChainParam, value *big.Int, number, activation uint64) {
+	stateDB.SetState(scAddress, paramLoc(scheduledAtSlot, param), common.BigToHash(new(big.Int).SetUint64(number)))
+	stateDB.SetState(scAddress, paramLoc(pendingSlot, param), common.BigToHash(value))
+	stateDB.SetState(scAddress, paramLoc(pendingAtSlot, param), common.BigToHash(new(big.Int).SetUint64(activation)))
+}
+
+// GetPending returns the scheduled value of the parameter and the block it activates at, nil if none
+func GetPending(stateDB *state.StateDB, scAddress common.Address, param ChainParam) (*big.Int, uint64) {
+	pendingAt := stateDB.GetState(scAddress, paramLoc(pendingAtSlot, param)).Big()
+	if pendingAt.Sign() == 0 {
+		return nil, 0
+	}
+	return stateDB.GetState(scAddress, paramLoc(pendingSlot, param)).Big(), pendingAt.Uint64()
+}
+
+// Activate activates the scheduled values of the parameters whose activation block is lower than or equal to
+// the block number, and returns the activated parameters.
+func Activate(stateDB *state.StateDB, scAddress common.Address, number uint64) []ChainParam {
+	var activated []ChainParam
+	for _, param := range ChainParams {
+		value, pendingAt := GetPending(stateDB, scAddress, param)
+		if value == nil || pendingAt > number {
+			continue
+		}
+		stateDB.SetState(scAddress, paramLoc(paramsSlot, param), common.BigToHash(value))
+		stateDB.SetState(scAddress, paramLoc(activatedAtSlot, param), common.BigToHash(new(big.Int).SetUint64(number)))
+		stateDB.SetState(scAddress, paramLoc(pendingSlot, param), common.Hash{})
+		stateDB.SetState(scAddress, paramLoc(pendingAtSlot, param), common.Hash{})
+		activated = append(activated, param)
+	}
+	return activated
+}
+
+// GetParam returns the active value of the parameter, nil if it has never been changed by the governance
+func GetParam(stateDB *state.StateDB, scAddress common.Address, param ChainParam) *big.Int {
+	if stateDB.GetState(scAddress, paramLoc(activatedAtSlot, param)) == (common.Hash{}) {
+		return nil
+	}
+	return stateDB.GetState(scAddress, paramLoc(paramsSlot, param)).Big()
+}
+
+// stakingContract returns the address of the staking contract storing the governance of the chain, false if the
+// chain parameters are not governed
+func stakingContract(config *params.ChainConfig) (common.Address, bool) {
+	if config.Tendermint == nil || config.Tendermint.StakingSCAddress == nil || len(config.Tendermint.FixedValidators) > 0 {
+		return common.Address{}, false
+	}
+	return *config.Tendermint.StakingSCAddress, true
+}
+
+// Enabled returns whether the chain parameters are governed at the block number
+func Enabled(config *params.ChainConfig, number *big.Int) bool {
+	_, ok := stakingContract(config)
+	return ok && config.Tendermint.IsGovernance(number)
+}
+
+// Param returns the value of the parameter in the state, nil if the value of the chain config applies
+func Param(config *params.ChainConfig, stateDB *state.StateDB, param ChainParam) *big.Int {
+	scAddress, ok := stakingContract(config)
+	if !ok || stateDB == nil {
+		return nil
+	}
+	return GetParam(stateDB, scAddress, param)
+}
+
+// GasPrice returns the gas price of the transactions executed on the state
+func GasPrice(config *params.ChainConfig, stateDB *state.StateDB) *big.Int {
+	if value := Param(config, stateDB, ParamGasPrice); value != nil {
+		return value
+	}
+	return config.GasPrice
+}
+
+// BlockReward returns the reward of the blocks executed on the state
+func BlockReward(config *params.ChainConfig, stateDB *state.StateDB) *big.Int {
+	if value := Param(config, stateDB, ParamBlockReward); value != nil {
+		return value
+	}
+	return config.Tendermint.BlockReward
+}
+
+// Timeout returns the value of the timeout parameter in the state, false if the value of the node config applies
+func Timeout(config *params.ChainConfig, stateDB *state.StateDB, param ChainParam) (time.Duration, bool) {
+	value := Param(config, stateDB, param)
+	if value == nil {
+		return 0, false
+	}
+	return time.Duration(value.Uint64()) * time.Millisecond, true
+}
+
+// paramLoc returns the location of the parameter in the mapping at slot
+func paramLoc(slot common.Hash, param ChainParam) common.Hash {
+	return crypto.Keccak256Hash(common.BigToHash(new(big.Int).SetUint64(uint64(param))).Bytes(), slot.Bytes())
+}
+
+// voteLoc returns the location of the vote of the voter on the parameter in the mapping of mappings at slot
+func voteLoc(slot common.Hash, param ChainParam, voter common.Address) common.Hash {
+	return crypto.Keccak256Hash(voter.Hash().Bytes(), paramLoc(slot, param).Bytes())
+}
@@ -0,0 +1,88 @@
+package governance_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/governance"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+func TestVoteEncoding(t *testing.T) {
+	vote := &governance.Vote{
+		Voter: common.HexToAddress("0x560089aB68dc224b250f9588b3DB540D87A66b7a"),
+		Param: governance.ParamGasPrice,
+		Value: big.NewInt(2 * params.GWei),
+	}
+	data, err := governance.EncodeVote(vote)
+	require.NoError(t, err)
+	decoded, err := governance.DecodeVote(data)
+	require.NoError(t, err)
+	assert.Equal(t, vote, decoded)
+
+	// the value must be in the range of the parameter
+	for _, vote := range []*governance.Vote{
+		{Param: governance.ParamGasPrice, Value: big.NewInt(0)},
+		{Param: governance.ParamTimeoutCommit, Value: big.NewInt(3600 * 1000)},
+		{Param: governance.ChainParam(100), Value: big.NewInt(1)},
+	} {
+		data, err := governance.EncodeVote(vote)
+		require.NoError(t, err)
+		_, err = governance.DecodeVote(data)
+		assert.Equal(t, governance.ErrInvalidVote, err, "param %v", vote.Param)
+	}
+	_, err = governance.DecodeVote([]byte{1, 2, 3, 4})
+	assert.Equal(t, governance.ErrNotVote, err)
+}
+
+func TestGovernance(t *testing.T) {
+	var (
+		scAddress  = common.HexToAddress("0x1")
+		validators = []common.Address{common.HexToAddress("0x10"), common.HexToAddress("0x11"), common.HexToAddress("0x12")}
+		powers     = map[common.Address]int64{validators[0]: 1, validators[1]: 1, validators[2]: 1}
+		quorum     = int64(2)
+		newPrice   = big.NewInt(2 * params.GWei)
+		config     = &params.ChainConfig{
+			GasPrice:   big.NewInt(params.GasPriceConfig),
+			Tendermint: &params.TendermintConfig{StakingSCAddress: &scAddress, GovernanceBlock: big.NewInt(0)},
+		}
+	)
+	stateDB, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	require.NoError(t, err)
+	vote := func(voter common.Address, value *big.Int, number uint64) {
+		governance.SetVote(stateDB, scAddress, &governance.Vote{Voter: voter, Param: governance.ParamGasPrice, Value: value}, number)
+	}
+
+	// a value voted by less than the quorum is not adopted
+	vote(validators[0], newPrice, 1)
+	vote(validators[1], big.NewInt(3*params.GWei), 2)
+	assert.Nil(t, governance.Tally(stateDB, scAddress, governance.ParamGasPrice, powers, quorum))
+	vote(validators[1], newPrice, 3)
+	value := governance.Tally(stateDB, scAddress, governance.ParamGasPrice, powers, quorum)
+	require.NotNil(t, value)
+	assert.Equal(t, newPrice, value)
+
+	// the value activates at the scheduled checkpoint and the votes are discarded
+	governance.Schedule(stateDB, scAddress, governance.ParamGasPrice, value, 10, 20)
+	assert.Nil(t, governance.Tally(stateDB, scAddress, governance.ParamGasPrice, powers, quorum))
+	pending, activation := governance.GetPending(stateDB, scAddress, governance.ParamGasPrice)
+	assert.Equal(t, newPrice, pending)
+	assert.Equal(t, uint64(20), activation)
+	assert.Empty(t, governance.Activate(stateDB, scAddress, 19))
+	assert.Equal(t, config.GasPrice, governance.GasPrice(config, stateDB))
+	assert.Equal(t, []governance.ChainParam{governance.ParamGasPrice}, governance.Activate(stateDB, scAddress, 20))
+	assert.Equal(t, newPrice, governance.GasPrice(config, stateDB))
+	pending, _ = governance.GetPending(stateDB, scAddress, governance.ParamGasPrice)
+	assert.Nil(t, pending)
+
+	// the parameters of the chains with fixed validators are not governed
+	config.Tendermint.FixedValidators = validators
+	assert.Equal(t, config.GasPrice, governance.GasPrice(config, stateDB))
+	assert.False(t, governance.Enabled(config, big.NewInt(20)))
+}
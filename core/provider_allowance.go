@@ -0,0 +1,43 @@
+package core
+
+import (
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+)
+
+// checkProviderAllowance returns ErrProviderAllowanceExceeded if the gas is over the remaining gas of one of the
+// allowances of the provider for the sender at the block number.
+func checkProviderAllowance(db vm.StateDB, contract, provider, sender common.Address, number, gas uint64) error {
+	for _, allowance := range db.GetProviderAllowances(contract) {
+		if allowance.AppliesTo(provider, sender) && allowance.Remaining(number) < gas {
+			return ErrProviderAllowanceExceeded
+		}
+	}
+	return nil
+}
+
+// useProviderAllowance accounts the gas paid by the provider for the sender at the block number to its allowances.
+// If refund is set, the gas was paid for but not used and is given back to the allowances instead.
+func useProviderAllowance(db vm.StateDB, contract, provider, sender common.Address, number, gas uint64, refund bool) {
+	prev := db.GetProviderAllowances(contract)
+	var allowances []types.ProviderAllowance
+	for i, allowance := range prev {
+		if !allowance.AppliesTo(provider, sender) {
+			continue
+		}
+		// the allowances are shared with the copies of the state, they are never modified in place
+		if allowances == nil {
+			allowances = make([]types.ProviderAllowance, len(prev))
+			copy(allowances, prev)
+		}
+		if refund {
+			allowances[i] = allowance.Refund(number, gas)
+		} else {
+			allowances[i] = allowance.Use(number, gas)
+		}
+	}
+	if allowances != nil {
+		db.SetProviderAllowances(contract, allowances)
+	}
+}
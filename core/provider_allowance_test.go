@@ -0,0 +1,90 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+// Tests that the gas paid by a provider is limited by its allowances, and that the unused gas is given back to them.
+func TestProviderAllowance(t *testing.T) {
+	var (
+		senderKey, _   = crypto.GenerateKey()
+		providerKey, _ = crypto.GenerateKey()
+		sender         = crypto.PubkeyToAddress(senderKey.PublicKey)
+		provider       = crypto.PubkeyToAddress(providerKey.PublicKey)
+		owner          = common.HexToAddress("0x01")
+		contract       = common.HexToAddress("0xe1")
+		signer         = types.HomesteadSigner{}
+		gasPrice       = big.NewInt(1)
+		config         = *params.TestChainConfig
+	)
+	config.ProviderAllowanceBlock = big.NewInt(5)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	statedb.CreateAccount(contract, types.CreateAccountOption{OwnerAddress: &owner, ProviderAddress: &provider})
+	statedb.SetCode(contract, []byte{byte(vm.STOP)})
+	statedb.AddBalance(provider, big.NewInt(params.Ether))
+	statedb.SetProviderAllowances(contract, []types.ProviderAllowance{
+		{Provider: provider, Period: 10, GasLimit: 50000},
+	})
+
+	apply := func(number int64, gas uint64) error {
+		nonce := statedb.GetNonce(sender)
+		tx, err := types.SignTx(types.NewTransaction(nonce, contract, new(big.Int), gas, gasPrice, nil), signer, senderKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tx, err = types.ProviderSignTx(tx, signer, providerKey); err != nil {
+			t.Fatal(err)
+		}
+		msg, err := tx.AsMessage(signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := &types.Header{Number: big.NewInt(number), GasLimit: 1000000, Difficulty: new(big.Int)}
+		evm := vm.NewEVM(NewEVMContext(msg, header, nil, &common.Address{}), statedb, &config, vm.Config{})
+		_, _, _, err = ApplyMessage(evm, msg, new(GasPool).AddGas(header.GasLimit))
+		return err
+	}
+	used := func(number uint64) uint64 {
+		return statedb.GetProviderAllowances(contract)[0].Used(number)
+	}
+
+	// the allowances do not apply before their fork
+	if err := apply(4, 60000); err != nil {
+		t.Fatalf("failed to apply sponsored message: %v", err)
+	}
+	if have := used(4); have != 0 {
+		t.Fatalf("used allowance mismatch: have %d, want 0", have)
+	}
+	if err := apply(5, 30000); err != nil {
+		t.Fatalf("failed to apply sponsored message: %v", err)
+	}
+	if have, want := used(5), params.TxGas; have != want {
+		t.Fatalf("used allowance mismatch: have %d, want %d", have, want)
+	}
+	// 29000 gas is left in the period
+	if err := apply(6, 30000); err != ErrProviderAllowanceExceeded {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrProviderAllowanceExceeded)
+	}
+	if err := apply(9, 29000); err != nil {
+		t.Fatalf("failed to apply sponsored message: %v", err)
+	}
+	if have, want := used(9), 2*params.TxGas; have != want {
+		t.Fatalf("used allowance mismatch: have %d, want %d", have, want)
+	}
+	// the allowance is renewed by the next period
+	if err := apply(10, 30000); err != nil {
+		t.Fatalf("failed to apply sponsored message: %v", err)
+	}
+	if have, want := used(10), params.TxGas; have != want {
+		t.Fatalf("used allowance mismatch: have %d, want %d", have, want)
+	}
+}
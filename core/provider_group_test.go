@@ -0,0 +1,120 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/event"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+// Tests that a member of a provider group pays for the deployment of a contract which then joins the group.
+func TestProviderGroupDeployment(t *testing.T) {
+	var (
+		senderKey, _   = crypto.GenerateKey()
+		memberKey, _   = crypto.GenerateKey()
+		outsiderKey, _ = crypto.GenerateKey()
+		sender         = crypto.PubkeyToAddress(senderKey.PublicKey)
+		member         = crypto.PubkeyToAddress(memberKey.PublicKey)
+		outsider       = crypto.PubkeyToAddress(outsiderKey.PublicKey)
+		owner          = common.HexToAddress("0x01")
+		group          = vm.ProviderGroupAddress("customers")
+		signer         = types.HomesteadSigner{}
+		gasPrice       = big.NewInt(1)
+		config         = *params.TestChainConfig
+	)
+	config.ProviderGroupBlock = big.NewInt(0)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	statedb.CreateAccount(group, types.CreateAccountOption{OwnerAddress: &owner, ProviderAddress: &member})
+	statedb.SetNonce(group, 1)
+	statedb.AddBalance(member, big.NewInt(params.Ether))
+	statedb.AddBalance(outsider, big.NewInt(params.Ether))
+
+	deploy := func(providerKey *ecdsa.PrivateKey) (common.Address, error) {
+		nonce := statedb.GetNonce(sender)
+		tx := types.NewContractCreation(nonce, new(big.Int), 100000, gasPrice, []byte{byte(vm.STOP)},
+			types.CreateAccountOption{OwnerAddress: &owner, ProviderAddress: &group})
+		tx, err := types.SignTx(tx, signer, senderKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tx, err = types.ProviderSignTx(tx, signer, providerKey); err != nil {
+			t.Fatal(err)
+		}
+		msg, err := tx.AsMessage(signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := &types.Header{Number: big.NewInt(1), GasLimit: 1000000, Difficulty: new(big.Int)}
+		evm := vm.NewEVM(NewEVMContext(msg, header, nil, &common.Address{}), statedb, &config, vm.Config{})
+		_, _, _, err = ApplyMessage(evm, msg, new(GasPool).AddGas(header.GasLimit))
+		return crypto.CreateAddress(sender, nonce), err
+	}
+
+	if _, err := deploy(outsiderKey); err != ErrInvalidProvider {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrInvalidProvider)
+	}
+	balance := statedb.GetBalance(member)
+	contract, err := deploy(memberKey)
+	if err != nil {
+		t.Fatalf("failed to apply sponsored deployment: %v", err)
+	}
+	if statedb.GetBalance(member).Cmp(balance) >= 0 {
+		t.Fatal("the deployment is not paid by the member of the group")
+	}
+	if providers := statedb.GetProviders(contract); len(providers) != 1 || *providers[0] != group {
+		t.Fatalf("providers mismatch: have %v, want [%x]", providers, group)
+	}
+	if !vm.IsContractProvider(statedb, contract, member) {
+		t.Fatal("the member of the group is not a provider of the deployed contract")
+	}
+	if vm.IsContractProvider(statedb, contract, outsider) {
+		t.Fatal("an account outside the group is a provider of the deployed contract")
+	}
+}
+
+// Tests that the tx pool only accepts the calls paid by a member of a provider group listed by the contract from the
+// provider group fork.
+func TestTransactionProviderGroupFork(t *testing.T) {
+	var (
+		memberKey, _ = crypto.GenerateKey()
+		senderKey, _ = crypto.GenerateKey()
+		member       = crypto.PubkeyToAddress(memberKey.PublicKey)
+		owner        = common.HexToAddress("0x01")
+		contract     = common.HexToAddress("0xe1")
+		group        = vm.ProviderGroupAddress("customers")
+		signer       = types.HomesteadSigner{}
+	)
+	tx, _ := types.SignTx(types.NewTransaction(0, contract, new(big.Int), 100000, big.NewInt(params.GasPriceConfig), nil), signer, senderKey)
+	tx, _ = types.ProviderSignTx(tx, signer, memberKey)
+
+	unforked := *params.TestChainConfig
+	unforked.ProviderGroupBlock = big.NewInt(5)
+	for _, test := range []struct {
+		config *params.ChainConfig
+		err    error
+	}{
+		{&unforked, ErrInvalidProvider},
+		{params.TestChainConfig, nil},
+	} {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+		pool := NewTxPool(testTxPoolConfig, test.config, &testBlockChain{statedb, 1000000, new(event.Feed)})
+		pool.currentState.CreateAccount(group, types.CreateAccountOption{OwnerAddress: &owner, ProviderAddress: &member})
+		pool.currentState.SetNonce(group, 1)
+		pool.currentState.CreateAccount(contract, types.CreateAccountOption{OwnerAddress: &owner, ProviderAddress: &group})
+		pool.currentState.SetCode(contract, []byte{byte(vm.STOP)})
+		pool.currentState.AddBalance(member, new(big.Int).Mul(big.NewInt(1000000), big.NewInt(params.GasPriceConfig)))
+
+		if err := pool.AddRemote(tx); err != test.err {
+			t.Errorf("provider group block %v: error mismatch: have %v, want %v", test.config.ProviderGroupBlock, err, test.err)
+		}
+		pool.Stop()
+	}
+}
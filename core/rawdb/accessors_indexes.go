@@ -129,3 +129,30 @@ func WriteBloomBits(db evrdb.KeyValueWriter, bit uint, section uint64, head comm
 		log.Crit("Failed to store bloom bits", "err", err)
 	}
 }
+
+// ReadInternalTxs retrieves the internal transactions executed by the block with
+// the given number and hash, as recorded by the trace indexer.
+func ReadInternalTxs(db evrdb.KeyValueReader, number uint64, hash common.Hash) []*types.InternalTx {
+	data, _ := db.Get(internalTxsKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	var txs []*types.InternalTx
+	if err := rlp.DecodeBytes(data, &txs); err != nil {
+		log.Error("Invalid internal transactions RLP", "number", number, "hash", hash, "err", err)
+		return nil
+	}
+	return txs
+}
+
+// WriteInternalTxs stores the internal transactions executed by the block with
+// the given number and hash.
+func WriteInternalTxs(db evrdb.KeyValueWriter, number uint64, hash common.Hash, txs []*types.InternalTx) {
+	data, err := rlp.EncodeToBytes(txs)
+	if err != nil {
+		log.Crit("Failed to RLP encode internal transactions", "err", err)
+	}
+	if err := db.Put(internalTxsKey(number, hash), data); err != nil {
+		log.Crit("Failed to store internal transactions", "err", err)
+	}
+}
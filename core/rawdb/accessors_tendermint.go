@@ -0,0 +1,65 @@
+package rawdb
+
+import (
+	"bytes"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// ReadEpochRewards retrieves the rewards credited at the checkpoint with the given number and state root.
+// The rewards are keyed by the state root since they are computed when the checkpoint is finalized, before the
+// hash of the block is known.
+func ReadEpochRewards(db evrdb.KeyValueReader, number uint64, root common.Hash) []*types.TendermintReward {
+	data, _ := db.Get(epochRewardsKey(number, root))
+	if len(data) == 0 {
+		return nil
+	}
+	var rewards []*types.TendermintReward
+	if err := rlp.DecodeBytes(data, &rewards); err != nil {
+		log.Error("Invalid epoch rewards RLP", "number", number, "root", root, "err", err)
+		return nil
+	}
+	return rewards
+}
+
+// HasEpochRewards verifies the existence of the rewards credited at the checkpoint with the given number and state root.
+func HasEpochRewards(db evrdb.KeyValueReader, number uint64, root common.Hash) bool {
+	has, err := db.Has(epochRewardsKey(number, root))
+	return err == nil && has
+}
+
+// WriteEpochRewards stores the rewards credited at the checkpoint with the given number and state root.
+func WriteEpochRewards(db evrdb.KeyValueWriter, number uint64, root common.Hash, rewards []*types.TendermintReward) {
+	data, err := rlp.EncodeToBytes(rewards)
+	if err != nil {
+		log.Crit("Failed to RLP encode epoch rewards", "err", err)
+	}
+	if err := db.Put(epochRewardsKey(number, root), data); err != nil {
+		log.Crit("Failed to store epoch rewards", "err", err)
+	}
+}
+
+// DeleteOrphanEpochRewards removes the rewards stored for the checkpoint with the given number under any state root
+// but the one of the committed block.
+func DeleteOrphanEpochRewards(db evrdb.KeyValueStore, number uint64, root common.Hash) {
+	var (
+		keep   = epochRewardsKey(number, root)
+		it     = db.NewIteratorWithPrefix(append(append([]byte{}, epochRewardsPrefix...), encodeBlockNumber(number)...))
+		orphan [][]byte
+	)
+	for it.Next() {
+		if !bytes.Equal(it.Key(), keep) {
+			orphan = append(orphan, common.CopyBytes(it.Key()))
+		}
+	}
+	it.Release()
+	for _, key := range orphan {
+		if err := db.Delete(key); err != nil {
+			log.Crit("Failed to delete orphan epoch rewards", "err", err)
+		}
+	}
+}
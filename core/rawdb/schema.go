@@ -53,13 +53,18 @@ var (
 	txLookupPrefix  = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
 
+	internalTxsPrefix = []byte("I") // internalTxsPrefix + num (uint64 big endian) + hash -> block internal transactions
+
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
 	tendermintPrefix = []byte("tendermint-snapshot-")
 
+	epochRewardsPrefix = []byte("tendermint-rewards-") // epochRewardsPrefix + num (uint64 big endian) + state root -> epoch rewards
+
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
+	TraceIndexPrefix     = []byte("iT") // TraceIndexPrefix is the data table of the trace indexer to track its progress
 
 	preimageCounter    = metrics.NewRegisteredCounter("db/preimage/total", nil)
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
@@ -157,11 +162,21 @@ func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	return key
 }
 
+// internalTxsKey = internalTxsPrefix + num (uint64 big endian) + hash
+func internalTxsKey(number uint64, hash common.Hash) []byte {
+	return append(append(internalTxsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
 // preimageKey = preimagePrefix + hash
 func preimageKey(hash common.Hash) []byte {
 	return append(preimagePrefix, hash.Bytes()...)
 }
 
+// epochRewardsKey = epochRewardsPrefix + num (uint64 big endian) + state root
+func epochRewardsKey(number uint64, root common.Hash) []byte {
+	return append(append(epochRewardsPrefix, encodeBlockNumber(number)...), root.Bytes()...)
+}
+
 // configKey = configPrefix + hash
 func configKey(hash common.Hash) []byte {
 	return append(configPrefix, hash.Bytes()...)
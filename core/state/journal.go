@@ -20,6 +20,7 @@ import (
 	"math/big"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
 )
 
 // journalEntry is a modification entry in the state change journal that can be
@@ -115,6 +116,18 @@ type (
 		account            *common.Address
 		prevcode, prevhash []byte
 	}
+	ownerChange struct {
+		account *common.Address
+		prev    *common.Address
+	}
+	providersChange struct {
+		account *common.Address
+		prev    []*common.Address
+	}
+	providerAllowancesChange struct {
+		account *common.Address
+		prev    []types.ProviderAllowance
+	}
 
 	// Changes to other state values.
 	refundChange struct {
@@ -195,6 +208,30 @@ func (ch codeChange) dirtied() *common.Address {
 	return ch.account
 }
 
+func (ch ownerChange) revert(s *StateDB) {
+	s.getStateObject(*ch.account).setOwner(ch.prev)
+}
+
+func (ch ownerChange) dirtied() *common.Address {
+	return ch.account
+}
+
+func (ch providersChange) revert(s *StateDB) {
+	s.getStateObject(*ch.account).setProviders(ch.prev)
+}
+
+func (ch providersChange) dirtied() *common.Address {
+	return ch.account
+}
+
+func (ch providerAllowancesChange) revert(s *StateDB) {
+	s.getStateObject(*ch.account).setProviderAllowances(ch.prev)
+}
+
+func (ch providerAllowancesChange) dirtied() *common.Address {
+	return ch.account
+}
+
 func (ch storageChange) revert(s *StateDB) {
 	s.getStateObject(*ch.account).setState(ch.key, ch.prevalue)
 }
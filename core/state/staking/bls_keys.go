@@ -0,0 +1,128 @@
+package staking
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/accounts/abi"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/crypto/bls"
+)
+
+var (
+	// blsPublicKeysSlot is the slot of mapping(address => bytes48) storing the BLS public key of the candidates,
+	// a public key takes 2 slots. Like the jailing slots, it is written by the node only.
+	blsPublicKeysSlot = crypto.Keccak256Hash([]byte("evrynet.staking.blsPublicKeys"))
+	// blsKeyCandidatesSlot is the slot of mapping(bytes32 => address) storing the candidate of a BLS public key by its hash
+	blsKeyCandidatesSlot = crypto.Keccak256Hash([]byte("evrynet.staking.blsKeyCandidates"))
+
+	// BLSRegistrationSelector is the selector of the call registerBLSKey(address candidate, bytes publicKey, bytes proof)
+	// to the staking contract. The contract ignores this call, the node reads it from the transactions of the block.
+	// The contract must accept this call, a registration in a reverted transaction is ignored.
+	BLSRegistrationSelector = crypto.Keccak256([]byte("registerBLSKey(address,bytes,bytes)"))[:4]
+
+	blsRegistrationArguments abi.Arguments
+
+	// ErrNotBLSRegistration is returned if the call data is not a registration of a BLS key
+	ErrNotBLSRegistration = errors.New("not a bls key registration")
+	// ErrInvalidBLSRegistration is returned if the call data or the public key is invalid
+	ErrInvalidBLSRegistration = errors.New("invalid bls key registration")
+	// ErrInvalidBLSPossession is returned if the proof of possession of the public key is invalid
+	ErrInvalidBLSPossession = errors.New("invalid proof of possession of the bls key")
+)
+
+func init() {
+	addressType, _ := abi.NewType("address", nil)
+	bytesType, _ := abi.NewType("bytes", nil)
+	blsRegistrationArguments = abi.Arguments{{Type: addressType}, {Type: bytesType}, {Type: bytesType}}
+}
+
+// BLSRegistration is the registration of the BLS public key of a candidate
+type BLSRegistration struct {
+	Candidate common.Address
+	PublicKey []byte
+	Proof     []byte // the proof of possession of the public key for the candidate, see ProveBLSPossession
+}
+
+// ProveBLSPossession returns the proof of possession of the BLS key to register for the candidate.
+// The proof signs the candidate so that a registration cannot be replayed for another candidate.
+func ProveBLSPossession(sk *bls.SecretKey, candidate common.Address) []byte {
+	return sk.ProvePossession(candidate.Bytes())
+}
+
+// VerifyPossession checks that the proof of the registration is made with the secret key of the public key
+// for the candidate of the registration
+func (reg *BLSRegistration) VerifyPossession() error {
+	if !bls.VerifyPossession(reg.PublicKey, reg.Candidate.Bytes(), reg.Proof) {
+		return ErrInvalidBLSPossession
+	}
+	return nil
+}
+
+// EncodeBLSRegistration returns the call data registering the BLS public key of a candidate
+func EncodeBLSRegistration(reg *BLSRegistration) ([]byte, error) {
+	args, err := blsRegistrationArguments.Pack(reg.Candidate, reg.PublicKey, reg.Proof)
+	if err != nil {
+		return nil, err
+	}
+	return append(common.CopyBytes(BLSRegistrationSelector), args...), nil
+}
+
+// DecodeBLSRegistration decodes the call data of a BLS key registration.
+// The proof of possession of the key must be checked with VerifyPossession.
+func DecodeBLSRegistration(data []byte) (*BLSRegistration, error) {
+	if len(data) < len(BLSRegistrationSelector) || !bytes.Equal(data[:len(BLSRegistrationSelector)], BLSRegistrationSelector) {
+		return nil, ErrNotBLSRegistration
+	}
+	values, err := blsRegistrationArguments.UnpackValues(data[len(BLSRegistrationSelector):])
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidBLSRegistration, err.Error())
+	}
+	reg := &BLSRegistration{
+		Candidate: values[0].(common.Address),
+		PublicKey: values[1].([]byte),
+		Proof:     values[2].([]byte),
+	}
+	if len(reg.PublicKey) != bls.PublicKeyLength {
+		return nil, ErrInvalidBLSRegistration
+	}
+	return reg, nil
+}
+
+// GetBLSPublicKey returns the BLS public key registered by the candidate, nil if the candidate has not registered any
+func GetBLSPublicKey(stateDB *state.StateDB, scAddress common.Address, candidate common.Address) []byte {
+	var (
+		loc  = getMappingElementLoc(blsPublicKeysSlot, candidate.Hash())
+		head = stateDB.GetState(scAddress, loc)
+		tail = stateDB.GetState(scAddress, addOffsetToLoc(loc, common.Big1))
+	)
+	if (head == common.Hash{}) && (tail == common.Hash{}) {
+		return nil
+	}
+	return append(head.Bytes(), tail[:bls.PublicKeyLength-common.HashLength]...)
+}
+
+// GetBLSKeyCandidate returns the candidate which registered the BLS public key, the zero address if none did
+func GetBLSKeyCandidate(stateDB *state.StateDB, scAddress common.Address, publicKey []byte) common.Address {
+	loc := getMappingElementLoc(blsKeyCandidatesSlot, crypto.Keccak256Hash(publicKey))
+	return common.HexToAddress(stateDB.GetState(scAddress, loc).Hex())
+}
+
+// SetBLSPublicKey stores the BLS public key of the candidate, replacing the previous one if any.
+// The caller must check that the public key is not registered by another candidate.
+func SetBLSPublicKey(stateDB *state.StateDB, scAddress common.Address, candidate common.Address, publicKey []byte) {
+	var (
+		loc  = getMappingElementLoc(blsPublicKeysSlot, candidate.Hash())
+		tail common.Hash
+	)
+	if previous := GetBLSPublicKey(stateDB, scAddress, candidate); previous != nil {
+		stateDB.SetState(scAddress, getMappingElementLoc(blsKeyCandidatesSlot, crypto.Keccak256Hash(previous)), common.Hash{})
+	}
+	stateDB.SetState(scAddress, getMappingElementLoc(blsKeyCandidatesSlot, crypto.Keccak256Hash(publicKey)), candidate.Hash())
+	copy(tail[:], publicKey[common.HashLength:])
+	stateDB.SetState(scAddress, loc, common.BytesToHash(publicKey[:common.HashLength]))
+	stateDB.SetState(scAddress, addOffsetToLoc(loc, common.Big1), tail)
+}
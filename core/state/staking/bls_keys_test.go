@@ -0,0 +1,61 @@
+package staking_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
+	"github.com/Evrynetlabs/evrynet-node/crypto/bls"
+)
+
+func TestBLSRegistration(t *testing.T) {
+	var (
+		scAddress = common.HexToAddress("0x1")
+		candidate = common.HexToAddress("0x560089aB68dc224b250f9588b3DB540D87A66b7a")
+		sk        = bls.DeriveSecretKey([]byte("seed"))
+	)
+	data, err := staking.EncodeBLSRegistration(&staking.BLSRegistration{
+		Candidate: candidate,
+		PublicKey: sk.PublicKey(),
+		Proof:     staking.ProveBLSPossession(sk, candidate),
+	})
+	require.NoError(t, err)
+	reg, err := staking.DecodeBLSRegistration(data)
+	require.NoError(t, err)
+	assert.Equal(t, candidate, reg.Candidate)
+	assert.Equal(t, sk.PublicKey(), reg.PublicKey)
+	assert.NoError(t, reg.VerifyPossession())
+
+	// the proof must be made with the secret key of the registered public key
+	reg.Proof = staking.ProveBLSPossession(bls.DeriveSecretKey([]byte("another seed")), candidate)
+	assert.Equal(t, staking.ErrInvalidBLSPossession, reg.VerifyPossession())
+	// the proof of another candidate cannot be replayed
+	reg.Proof = staking.ProveBLSPossession(sk, common.HexToAddress("0x2"))
+	assert.Equal(t, staking.ErrInvalidBLSPossession, reg.VerifyPossession())
+
+	data, err = staking.EncodeBLSRegistration(&staking.BLSRegistration{Candidate: candidate, PublicKey: []byte{1, 2, 3}})
+	require.NoError(t, err)
+	_, err = staking.DecodeBLSRegistration(data)
+	assert.Equal(t, staking.ErrInvalidBLSRegistration, err)
+	_, err = staking.DecodeBLSRegistration([]byte{1, 2, 3, 4})
+	assert.Equal(t, staking.ErrNotBLSRegistration, err)
+
+	stateDB, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	require.NoError(t, err)
+	assert.Nil(t, staking.GetBLSPublicKey(stateDB, scAddress, candidate))
+	staking.SetBLSPublicKey(stateDB, scAddress, candidate, sk.PublicKey())
+	assert.Equal(t, sk.PublicKey(), staking.GetBLSPublicKey(stateDB, scAddress, candidate))
+	assert.Equal(t, candidate, staking.GetBLSKeyCandidate(stateDB, scAddress, sk.PublicKey()))
+
+	// replacing the key releases the previous one
+	newKey := bls.DeriveSecretKey([]byte("another seed")).PublicKey()
+	staking.SetBLSPublicKey(stateDB, scAddress, candidate, newKey)
+	assert.Equal(t, newKey, staking.GetBLSPublicKey(stateDB, scAddress, candidate))
+	assert.Equal(t, common.Address{}, staking.GetBLSKeyCandidate(stateDB, scAddress, sk.PublicKey()))
+	assert.Equal(t, candidate, staking.GetBLSKeyCandidate(stateDB, scAddress, newKey))
+}
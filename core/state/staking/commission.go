@@ -0,0 +1,99 @@
+package staking
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/accounts/abi"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+// DefaultCommissionRate is the percentage of the reward of a validator kept by its owner if the candidate has never
+// set its commission rate
+const DefaultCommissionRate uint64 = 50
+
+var (
+	// commissionRatesSlot is the slot of mapping(address => uint256) storing the commission rate of the candidates.
+	// Like the BLS keys slots, it is written by the node only.
+	commissionRatesSlot = crypto.Keccak256Hash([]byte("evrynet.staking.commissionRates"))
+	// commissionChangedAtSlot is the slot of mapping(address => uint256) storing the block number the commission
+	// rate of the candidates was last changed at, zero if it has never been
+	commissionChangedAtSlot = crypto.Keccak256Hash([]byte("evrynet.staking.commissionChangedAt"))
+
+	// CommissionRateSelector is the selector of the call setCommissionRate(address candidate, uint256 rate)
+	// to the staking contract. The contract ignores this call, the node reads it from the successful transactions of
+	// the block.
+	CommissionRateSelector = crypto.Keccak256([]byte("setCommissionRate(address,uint256)"))[:4]
+
+	commissionRateArguments abi.Arguments
+
+	// ErrNotCommissionRate is returned if the call data is not a change of the commission rate
+	ErrNotCommissionRate = errors.New("not a commission rate change")
+	// ErrInvalidCommissionRate is returned if the commission rate is not a percentage
+	ErrInvalidCommissionRate = errors.New("invalid commission rate change")
+)
+
+func init() {
+	addressType, _ := abi.NewType("address", nil)
+	uint256Type, _ := abi.NewType("uint256", nil)
+	commissionRateArguments = abi.Arguments{{Type: addressType}, {Type: uint256Type}}
+}
+
+// CommissionRateChange is the change of the commission rate of a candidate
+type CommissionRateChange struct {
+	Candidate common.Address
+	Rate      uint64 // the percentage of the reward of the validator kept by its owner
+}
+
+// EncodeCommissionRateChange returns the call data changing the commission rate of a candidate
+func EncodeCommissionRateChange(change *CommissionRateChange) ([]byte, error) {
+	args, err := commissionRateArguments.Pack(change.Candidate, new(big.Int).SetUint64(change.Rate))
+	if err != nil {
+		return nil, err
+	}
+	return append(common.CopyBytes(CommissionRateSelector), args...), nil
+}
+
+// DecodeCommissionRateChange decodes the call data of a commission rate change and checks the rate is a percentage
+func DecodeCommissionRateChange(data []byte) (*CommissionRateChange, error) {
+	if len(data) < len(CommissionRateSelector) || !bytes.Equal(data[:len(CommissionRateSelector)], CommissionRateSelector) {
+		return nil, ErrNotCommissionRate
+	}
+	values, err := commissionRateArguments.UnpackValues(data[len(CommissionRateSelector):])
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidCommissionRate, err.Error())
+	}
+	rate := values[1].(*big.Int)
+	if rate.Cmp(big.NewInt(100)) > 0 {
+		return nil, ErrInvalidCommissionRate
+	}
+	return &CommissionRateChange{
+		Candidate: values[0].(common.Address),
+		Rate:      rate.Uint64(),
+	}, nil
+}
+
+// GetCommissionRate returns the commission rate of the candidate, DefaultCommissionRate if it has never set one
+func GetCommissionRate(stateDB *state.StateDB, scAddress common.Address, candidate common.Address) uint64 {
+	if GetCommissionChangedAt(stateDB, scAddress, candidate) == 0 {
+		return DefaultCommissionRate
+	}
+	return stateDB.GetState(scAddress, getMappingElementLoc(commissionRatesSlot, candidate.Hash())).Big().Uint64()
+}
+
+// GetCommissionChangedAt returns the block number the commission rate of the candidate was last changed at,
+// zero if it has never been
+func GetCommissionChangedAt(stateDB *state.StateDB, scAddress common.Address, candidate common.Address) uint64 {
+	return stateDB.GetState(scAddress, getMappingElementLoc(commissionChangedAtSlot, candidate.Hash())).Big().Uint64()
+}
+
+// SetCommissionRate stores the commission rate of the candidate changed at the block number.
+// The caller must check the change is allowed by the chain config.
+func SetCommissionRate(stateDB *state.StateDB, scAddress common.Address, candidate common.Address, rate uint64, number uint64) {
+	stateDB.SetState(scAddress, getMappingElementLoc(commissionRatesSlot, candidate.Hash()), common.BigToHash(new(big.Int).SetUint64(rate)))
+	stateDB.SetState(scAddress, getMappingElementLoc(commissionChangedAtSlot, candidate.Hash()), common.BigToHash(new(big.Int).SetUint64(number)))
+}
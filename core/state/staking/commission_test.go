@@ -0,0 +1,43 @@
+package staking_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
+)
+
+func TestCommissionRate(t *testing.T) {
+	var (
+		scAddress = common.HexToAddress("0x1")
+		candidate = common.HexToAddress("0x560089aB68dc224b250f9588b3DB540D87A66b7a")
+	)
+	data, err := staking.EncodeCommissionRateChange(&staking.CommissionRateChange{Candidate: candidate, Rate: 10})
+	require.NoError(t, err)
+	change, err := staking.DecodeCommissionRateChange(data)
+	require.NoError(t, err)
+	assert.Equal(t, &staking.CommissionRateChange{Candidate: candidate, Rate: 10}, change)
+
+	// the rate must be a percentage
+	data, err = staking.EncodeCommissionRateChange(&staking.CommissionRateChange{Candidate: candidate, Rate: 101})
+	require.NoError(t, err)
+	_, err = staking.DecodeCommissionRateChange(data)
+	assert.Equal(t, staking.ErrInvalidCommissionRate, err)
+	_, err = staking.DecodeCommissionRateChange([]byte{1, 2, 3, 4})
+	assert.Equal(t, staking.ErrNotCommissionRate, err)
+
+	stateDB, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	require.NoError(t, err)
+	assert.Equal(t, staking.DefaultCommissionRate, staking.GetCommissionRate(stateDB, scAddress, candidate))
+	assert.Zero(t, staking.GetCommissionChangedAt(stateDB, scAddress, candidate))
+
+	// a rate of zero is kept as set
+	staking.SetCommissionRate(stateDB, scAddress, candidate, 0, 5)
+	assert.Zero(t, staking.GetCommissionRate(stateDB, scAddress, candidate))
+	assert.Equal(t, uint64(5), staking.GetCommissionChangedAt(stateDB, scAddress, candidate))
+}
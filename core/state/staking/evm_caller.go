@@ -9,6 +9,7 @@ import (
 	"github.com/pkg/errors"
 
 	evrynet "github.com/Evrynetlabs/evrynet-node"
+	"github.com/Evrynetlabs/evrynet-node/accounts/abi/bind"
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/common/math"
 	"github.com/Evrynetlabs/evrynet-node/consensus"
@@ -51,6 +52,9 @@ func (caller *evmStakingCaller) GetValidators(scAddress common.Address) ([]commo
 	// check and remove if owner stake of candidate is greater or equal minValidatorStake
 	minValidatorStake := data.MinValidatorCap
 	for i, candidate := range data.Candidates {
+		if isJailed(caller.stateDB, scAddress, candidate) {
+			continue
+		}
 		owner, err := sc.GetCandidateOwner(nil, candidate)
 		if err != nil {
 			return nil, err
@@ -114,27 +118,65 @@ func (caller *evmStakingCaller) GetValidatorsData(scAddress common.Address, cand
 		for i := range voterStakes {
 			voteStakes[voters[i]] = voterStakes[i]
 		}
+		unbonding, err := caller.getUnbondings(sc, scAddress, candidateData.Owner, candidate)
+		if err != nil {
+			return nil, err
+		}
 		allVoterStake[candidate] = CandidateData{
-			VoterStakes: voteStakes,
-			Owner:       candidateData.Owner,
-			TotalStake:  candidateData.TotalStake,
+			VoterStakes:    voteStakes,
+			Owner:          candidateData.Owner,
+			TotalStake:     candidateData.TotalStake,
+			Unbonding:      unbonding,
+			CommissionRate: GetCommissionRate(caller.stateDB, scAddress, candidate),
 		}
 	}
 	return allVoterStake, nil
 }
 
+// getUnbondings returns the stakes unvoted by the voter from the candidate and not withdrawn yet.
+// The staking contract does not record the candidate of its withdraws, the part of a cap recorded for the candidate
+// by the node is read from the state.
+func (caller *evmStakingCaller) getUnbondings(sc *staking_contracts.StakingContractsCaller, scAddress common.Address, voter common.Address, candidate common.Address) ([]Unbonding, error) {
+	data, err := sc.GetWithdrawEpochsAndCaps(&bind.CallOpts{From: voter})
+	if err != nil {
+		return nil, err
+	}
+	if len(data.Epochs) != len(data.Caps) {
+		return nil, ErrLengthOfEpochsAndCapsMisMatch
+	}
+	var (
+		unbondings []Unbonding
+		seen       = make(map[uint64]bool)
+	)
+	for i, epoch := range data.Epochs {
+		if seen[epoch.Uint64()] {
+			continue
+		}
+		seen[epoch.Uint64()] = true
+		amount := data.Caps[i]
+		if recorded := getRecordedUnbonding(caller.stateDB, scAddress, voter, candidate, epoch.Uint64()); recorded.Cmp(amount) < 0 {
+			amount = recorded
+		}
+		if amount.Sign() == 0 {
+			continue
+		}
+		unbondings = append(unbondings, Unbonding{Candidate: candidate, Epoch: epoch.Uint64(), Amount: amount})
+	}
+	return unbondings, nil
+}
+
 // Deprecated: Using NewStateDbStakingCaller instead of
 // NewBECaller returns staking caller which reads data from staking smart-contract by execute a call from evm
 func NewEVMStakingCaller(stateDB *state.StateDB, chainContext core.ChainContext, header *types.Header,
 	chainConfig *params.ChainConfig, vmConfig vm.Config) StakingCaller {
-	return &evmStakingCaller{
+	return &contractCaller{caller: &evmStakingCaller{
 		stateDB:      stateDB,
 		chainContext: chainContext,
 		blockNumber:  header.Number,
 		header:       header,
 		chainConfig:  chainConfig,
 		vmConfig:     vmConfig,
-	}
+	}}
 }
 
 // CodeAt returns the code of the given account. This is needed to differentiate
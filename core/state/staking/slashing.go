@@ -0,0 +1,212 @@
+package staking
+
+import (
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+var (
+	// the slots below are not used by the staking contract, they are written by the node only.
+	// jailedUntilSlot is the slot of mapping(address => uint256) storing the block a jailed candidate is released at
+	jailedUntilSlot = crypto.Keccak256Hash([]byte("evrynet.staking.jailedUntil"))
+	// jailedCandidatesSlot is the slot of address[] storing the jailed candidates
+	jailedCandidatesSlot = crypto.Keccak256Hash([]byte("evrynet.staking.jailedCandidates"))
+	// punishedOffensesSlot is the slot of mapping(bytes32 => uint256) storing the block an offense is punished at
+	punishedOffensesSlot = crypto.Keccak256Hash([]byte("evrynet.staking.punishedOffenses"))
+)
+
+// StakingSlasher punishes misbehaving candidates by modifying the staking contract's storage directly
+type StakingSlasher interface {
+	// Slash burns rate percent of the candidate owner's stake, including the stake unvoted from the candidate and not
+	// released at blockNumber, and returns the burnt amount
+	Slash(scAddress common.Address, candidate common.Address, rate uint64, blockNumber uint64) *big.Int
+	// Jail excludes the candidate from the validator set until the releaseBlock
+	Jail(scAddress common.Address, candidate common.Address, releaseBlock uint64)
+	// ReleaseJailed releases the candidates whose release block is lower than or equal to blockNumber
+	ReleaseJailed(scAddress common.Address, blockNumber uint64) []common.Address
+	// IsOffensePunished returns true if the offense identified by the key is already punished
+	IsOffensePunished(scAddress common.Address, key common.Hash) bool
+	// MarkOffensePunished records that the offense identified by the key is punished at blockNumber
+	MarkOffensePunished(scAddress common.Address, key common.Hash, blockNumber uint64)
+	// RecordUnbondings records the candidate of the stakes unvoted in a block from its logs, the withdraws of the
+	// staking contract only record the voter. parentStake returns the stake of a voter in a candidate before the block.
+	RecordUnbondings(scAddress common.Address, logs []*types.Log, parentStake func(candidate, voter common.Address) (*big.Int, error)) error
+}
+
+// stateDBStakingSlasher implements StakingSlasher by writing to the stateDB
+type stateDBStakingSlasher struct {
+	stateDB *state.StateDB
+	config  *IndexConfigs
+}
+
+// NewStateDbStakingSlasher returns a StakingSlasher which writes directly to the state DB
+func NewStateDbStakingSlasher(stateDB *state.StateDB, cfg *IndexConfigs) StakingSlasher {
+	return &stateDBStakingSlasher{
+		stateDB: stateDB,
+		config:  cfg,
+	}
+}
+
+// Slash implements StakingSlasher.Slash
+func (s *stateDBStakingSlasher) Slash(scAddress common.Address, candidate common.Address, rate uint64, blockNumber uint64) *big.Int {
+	var (
+		loc           = getMappingElementLoc(s.config.CandidateDataLayout.slotHash(), candidate.Hash())
+		ownerLoc      = addOffsetToLoc(loc, new(big.Int).SetUint64(s.config.CandidateDataStruct.Owner.Slot))
+		totalStakeLoc = addOffsetToLoc(loc, new(big.Int).SetUint64(s.config.CandidateDataStruct.TotalStake.Slot))
+		owner         = common.HexToAddress(s.stateDB.GetState(scAddress, ownerLoc).Hex())
+		ownerStakeLoc = getMappingElementLoc(addOffsetToLoc(loc, new(big.Int).SetUint64(s.config.CandidateDataStruct.VotersStakes.Slot)), owner.Hash())
+		ownerStake    = s.stateDB.GetState(scAddress, ownerStakeLoc).Big()
+		totalStake    = s.stateDB.GetState(scAddress, totalStakeLoc).Big()
+	)
+	amount := slashAmount(ownerStake, rate)
+	if amount.Cmp(totalStake) > 0 {
+		amount.Set(totalStake)
+	}
+	if amount.Sign() > 0 {
+		s.stateDB.SetState(scAddress, ownerStakeLoc, common.BigToHash(new(big.Int).Sub(ownerStake, amount)))
+		s.stateDB.SetState(scAddress, totalStakeLoc, common.BigToHash(new(big.Int).Sub(totalStake, amount)))
+	}
+	// the stake unvoted by the owner from the candidate stays slashable until it is released, so unvoting does not
+	// escape the penalty. The stake unvoted from the other candidates of the owner is not.
+	epoch := currentEpoch(s.stateDB, scAddress, s.config, blockNumber)
+	for _, unbonding := range getUnbondings(s.stateDB, scAddress, s.config, owner, candidate) {
+		if unbonding.Epoch <= epoch {
+			continue
+		}
+		slashed := slashAmount(unbonding.Amount, rate)
+		if slashed.Sign() == 0 {
+			continue
+		}
+		capLoc := withdrawCapLoc(s.config, owner, unbonding.Epoch)
+		s.stateDB.SetState(scAddress, capLoc, common.BigToHash(new(big.Int).Sub(s.stateDB.GetState(scAddress, capLoc).Big(), slashed)))
+		recorded := getRecordedUnbonding(s.stateDB, scAddress, owner, candidate, unbonding.Epoch)
+		setRecordedUnbonding(s.stateDB, scAddress, owner, candidate, unbonding.Epoch, new(big.Int).Sub(recorded, slashed))
+		amount.Add(amount, slashed)
+	}
+	if amount.Sign() == 0 {
+		return amount
+	}
+	// the slashed stake is burnt
+	s.stateDB.SubBalance(scAddress, amount)
+	return amount
+}
+
+// Jail implements StakingSlasher.Jail
+func (s *stateDBStakingSlasher) Jail(scAddress common.Address, candidate common.Address, releaseBlock uint64) {
+	loc := getMappingElementLoc(jailedUntilSlot, candidate.Hash())
+	current := s.stateDB.GetState(scAddress, loc).Big()
+	if current.Sign() == 0 {
+		length := s.stateDB.GetState(scAddress, jailedCandidatesSlot).Big().Uint64()
+		s.stateDB.SetState(scAddress, getElementArrayLoc(jailedCandidatesSlot, length, defaultElementSize), candidate.Hash())
+		s.stateDB.SetState(scAddress, jailedCandidatesSlot, common.BigToHash(new(big.Int).SetUint64(length+1)))
+	}
+	if current.Uint64() < releaseBlock {
+		s.stateDB.SetState(scAddress, loc, common.BigToHash(new(big.Int).SetUint64(releaseBlock)))
+	}
+}
+
+// ReleaseJailed implements StakingSlasher.ReleaseJailed
+func (s *stateDBStakingSlasher) ReleaseJailed(scAddress common.Address, blockNumber uint64) []common.Address {
+	var (
+		released []common.Address
+		length   = s.stateDB.GetState(scAddress, jailedCandidatesSlot).Big().Uint64()
+	)
+	for i := uint64(0); i < length; {
+		candidate := common.HexToAddress(s.stateDB.GetState(scAddress, getElementArrayLoc(jailedCandidatesSlot, i, defaultElementSize)).Hex())
+		loc := getMappingElementLoc(jailedUntilSlot, candidate.Hash())
+		if s.stateDB.GetState(scAddress, loc).Big().Uint64() > blockNumber {
+			i++
+			continue
+		}
+		// remove by moving the last element to position i
+		lastLoc := getElementArrayLoc(jailedCandidatesSlot, length-1, defaultElementSize)
+		s.stateDB.SetState(scAddress, getElementArrayLoc(jailedCandidatesSlot, i, defaultElementSize), s.stateDB.GetState(scAddress, lastLoc))
+		s.stateDB.SetState(scAddress, lastLoc, common.Hash{})
+		s.stateDB.SetState(scAddress, loc, common.Hash{})
+		length--
+		released = append(released, candidate)
+	}
+	s.stateDB.SetState(scAddress, jailedCandidatesSlot, common.BigToHash(new(big.Int).SetUint64(length)))
+	return released
+}
+
+// IsOffensePunished implements StakingSlasher.IsOffensePunished
+func (s *stateDBStakingSlasher) IsOffensePunished(scAddress common.Address, key common.Hash) bool {
+	return s.stateDB.GetState(scAddress, getMappingElementLoc(punishedOffensesSlot, key)) != common.Hash{}
+}
+
+// MarkOffensePunished implements StakingSlasher.MarkOffensePunished
+func (s *stateDBStakingSlasher) MarkOffensePunished(scAddress common.Address, key common.Hash, blockNumber uint64) {
+	s.stateDB.SetState(scAddress, getMappingElementLoc(punishedOffensesSlot, key), common.BigToHash(new(big.Int).SetUint64(blockNumber)))
+}
+
+// RecordUnbondings implements StakingSlasher.RecordUnbondings
+func (s *stateDBStakingSlasher) RecordUnbondings(scAddress common.Address, logs []*types.Log, parentStake func(candidate, voter common.Address) (*big.Int, error)) error {
+	changes, err := decodeStakeChanges(scAddress, logs)
+	if err != nil {
+		return err
+	}
+	// the changes of the stakes in the block by voter and candidate, to know the stake unvoted by a resignation
+	deltas := make(map[[2]common.Address]*big.Int)
+	for _, change := range changes {
+		voter, amount := change.voter, change.amount
+		if change.resigned {
+			ownerLoc := addOffsetToLoc(getMappingElementLoc(s.config.CandidateDataLayout.slotHash(), change.candidate.Hash()),
+				new(big.Int).SetUint64(s.config.CandidateDataStruct.Owner.Slot))
+			voter = common.HexToAddress(s.stateDB.GetState(scAddress, ownerLoc).Hex())
+			stake, err := parentStake(change.candidate, voter)
+			if err != nil {
+				return err
+			}
+			if delta := deltas[[2]common.Address{voter, change.candidate}]; delta != nil {
+				stake = new(big.Int).Add(stake, delta)
+			}
+			amount = new(big.Int).Neg(stake)
+		}
+		key := [2]common.Address{voter, change.candidate}
+		if deltas[key] == nil {
+			deltas[key] = new(big.Int)
+		}
+		deltas[key].Add(deltas[key], amount)
+		if amount.Sign() < 0 {
+			s.recordUnbonding(scAddress, voter, change.candidate, new(big.Int).Neg(amount))
+		}
+	}
+	return nil
+}
+
+// recordUnbonding records the stake unvoted by the voter from the candidate in the current block.
+// The staking contract releases all the stakes unvoted in a block at the same epoch, the latest of the voter's withdraws.
+func (s *stateDBStakingSlasher) recordUnbonding(scAddress common.Address, voter common.Address, candidate common.Address, amount *big.Int) {
+	epochs := getWithdrawEpochs(s.stateDB, scAddress, s.config, voter)
+	if len(epochs) == 0 {
+		return
+	}
+	release := epochs[0]
+	for _, epoch := range epochs[1:] {
+		if epoch > release {
+			release = epoch
+		}
+	}
+	recorded := getRecordedUnbonding(s.stateDB, scAddress, voter, candidate, release)
+	setRecordedUnbonding(s.stateDB, scAddress, voter, candidate, release, new(big.Int).Add(recorded, amount))
+}
+
+// slashAmount returns rate percent of stake, capped to stake
+func slashAmount(stake *big.Int, rate uint64) *big.Int {
+	amount := new(big.Int).Mul(stake, new(big.Int).SetUint64(rate))
+	amount.Div(amount, big.NewInt(100))
+	if amount.Cmp(stake) > 0 {
+		amount.Set(stake)
+	}
+	return amount
+}
+
+// isJailed returns true if the candidate is jailed in the staking contract's storage
+func isJailed(stateDB *state.StateDB, scAddress common.Address, candidate common.Address) bool {
+	return stateDB.GetState(scAddress, getMappingElementLoc(jailedUntilSlot, candidate.Hash())) != common.Hash{}
+}
@@ -0,0 +1,113 @@
+package staking_test
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/accounts/abi/bind"
+	"github.com/Evrynetlabs/evrynet-node/accounts/abi/bind/backends"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/staking_contracts"
+	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+func TestStateDBStakingSlasher(t *testing.T) {
+	var (
+		candidates = []common.Address{
+			common.HexToAddress("0x560089aB68dc224b250f9588b3DB540D87A66b7a"),
+			common.HexToAddress("0x954e4BF2C68F13D97C45db0e02645D145dB6911f"),
+		}
+		epoch             = big.NewInt(300000)
+		startBlock        = common.Big0
+		maxValidatorSize  = big.NewInt(100)
+		minValidatorStake = big.NewInt(20)
+		minVoteCap        = big.NewInt(10)
+		adminAddr         = common.HexToAddress("0x560089aB68dc224b250f9588b3DB540D87A66b7a")
+		newCandidate      = common.HexToAddress("0x377615c604BA7639F37dFd62dC1909357a542DAB")
+		offenseKey        = common.HexToHash("0x1234")
+		blockNumber       = uint64(3)
+	)
+
+	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	require.NoError(t, err)
+	addr := crypto.PubkeyToAddress(*privateKey.Public().(*ecdsa.PublicKey))
+
+	be := backends.NewSimulatedBackend(core.GenesisAlloc{
+		addr: core.GenesisAccount{
+			Balance: big.NewInt(0).Exp(big.NewInt(10), big.NewInt(18), nil),
+		},
+		newCandidate: core.GenesisAccount{
+			Balance: new(big.Int).Mul(big.NewInt(gasLimit), big.NewInt(params.GasPriceConfig)),
+		},
+	}, gasLimit)
+
+	authOpts := bind.NewKeyedTransactor(privateKey)
+	authOpts.Nonce = big.NewInt(0)
+	scAddr, tx, contract, err := staking_contracts.DeployStakingContracts(authOpts, be, candidates, candidates, epoch, startBlock, maxValidatorSize, minValidatorStake, minVoteCap, adminAddr)
+	require.NoError(t, err)
+	be.Commit()
+	assertTxSuccess(t, be, tx.Hash())
+
+	authOpts = bind.NewKeyedTransactor(privateKey)
+	authOpts.Nonce = big.NewInt(1)
+	tx, err = contract.Register(authOpts, newCandidate, newCandidate)
+	require.NoError(t, err)
+	be.Commit()
+	assertTxSuccess(t, be, tx.Hash())
+
+	ownerPk, err := crypto.HexToECDSA(newCandidatePkHex)
+	require.NoError(t, err)
+	authOpts = bind.NewKeyedTransactor(ownerPk)
+	authOpts.Nonce = big.NewInt(0)
+	authOpts.Value = big.NewInt(1000)
+	tx, err = contract.Vote(authOpts, newCandidate)
+	require.NoError(t, err)
+	be.Commit()
+	assertTxSuccess(t, be, tx.Hash())
+
+	stateDB, err := be.CurrentStateDb()
+	require.NoError(t, err)
+	var (
+		caller         = staking.NewStateDbStakingCaller(stateDB, staking.DefaultConfig)
+		slasher        = staking.NewStateDbStakingSlasher(stateDB, staking.DefaultConfig)
+		balanceBefore  = stateDB.GetBalance(scAddr)
+		validators, _  = caller.GetValidators(scAddr)
+		numValidators  = len(validators)
+		expectedSlash  = big.NewInt(100)
+		expectedRemain = big.NewInt(900)
+	)
+	require.Contains(t, validators, newCandidate)
+
+	// slash 10% of the owner's stake
+	slashed := slasher.Slash(scAddr, newCandidate, 10, blockNumber)
+	assert.Equal(t, expectedSlash, slashed)
+	assert.Equal(t, new(big.Int).Sub(balanceBefore, expectedSlash), stateDB.GetBalance(scAddr))
+	data, err := caller.GetValidatorsData(scAddr, []common.Address{newCandidate})
+	require.NoError(t, err)
+	assert.Equal(t, expectedRemain, data[newCandidate].VoterStakes[newCandidate])
+	assert.Equal(t, expectedRemain, data[newCandidate].TotalStake)
+
+	// jailed candidate is excluded from the validators until released
+	slasher.Jail(scAddr, newCandidate, 100)
+	validators, err = caller.GetValidators(scAddr)
+	require.NoError(t, err)
+	assert.Len(t, validators, numValidators-1)
+	assert.NotContains(t, validators, newCandidate)
+
+	assert.Empty(t, slasher.ReleaseJailed(scAddr, 99))
+	assert.Equal(t, []common.Address{newCandidate}, slasher.ReleaseJailed(scAddr, 100))
+	validators, err = caller.GetValidators(scAddr)
+	require.NoError(t, err)
+	assert.Contains(t, validators, newCandidate)
+
+	assert.False(t, slasher.IsOffensePunished(scAddr, offenseKey))
+	slasher.MarkOffensePunished(scAddr, offenseKey, 10)
+	assert.True(t, slasher.IsOffensePunished(scAddr, offenseKey))
+}
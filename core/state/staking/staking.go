@@ -15,19 +15,72 @@ var (
 	ErrLengthOfCandidatesAndStakesMisMatch = errors.New("length of stakes is not equal to length of candidates")
 	// ErrLengthOfVotesAndStakesMisMatch returns when lengths voters and stakes are not match
 	ErrLengthOfVotesAndStakesMisMatch = errors.New("length of voters is not equal to length of stakes")
+	// ErrLengthOfEpochsAndCapsMisMatch returns when lengths of withdraw epochs and caps are not match
+	ErrLengthOfEpochsAndCapsMisMatch = errors.New("length of withdraw epochs is not equal to length of caps")
 
 	maxGasGetValSet uint64 = 500000000
 )
 
+// ContractError is returned by a StakingCaller when the staking contract fails to return the requested data, e.g. it
+// reverts, has no code or its data is malformed. The failure only depends on the state, so every node gets the same.
+type ContractError struct {
+	Err error
+}
+
+func (e *ContractError) Error() string {
+	return "staking contract: " + e.Err.Error()
+}
+
+// IsContractError returns whether the error, possibly wrapped, is a ContractError
+func IsContractError(err error) bool {
+	_, ok := errors.Cause(err).(*ContractError)
+	return ok
+}
+
+// contractCaller wraps the errors of a StakingCaller into ContractError
+type contractCaller struct {
+	caller StakingCaller
+}
+
+func (c *contractCaller) GetValidators(scAddress common.Address) ([]common.Address, error) {
+	validators, err := c.caller.GetValidators(scAddress)
+	if err != nil {
+		return nil, &ContractError{Err: err}
+	}
+	return validators, nil
+}
+
+func (c *contractCaller) GetValidatorsData(scAddress common.Address, candidates []common.Address) (map[common.Address]CandidateData, error) {
+	data, err := c.caller.GetValidatorsData(scAddress, candidates)
+	if err != nil {
+		return nil, &ContractError{Err: err}
+	}
+	return data, nil
+}
+
+// StakingCaller reads the validators from the staking contract, its errors are ContractError
 type StakingCaller interface {
 	// GetValidators returns list of validators, calculate from current stateDB
 	GetValidators(common.Address) ([]common.Address, error)
-	// GetValidatorsData return information of validators including owner, totalStake and voterStakes
+	// GetValidatorsData return information of validators including owner, totalStake, voterStakes and the unbonding
+	// stakes of the owner
 	GetValidatorsData(common.Address, []common.Address) (map[common.Address]CandidateData, error)
 }
 
 type CandidateData struct {
-	Owner       common.Address
+	Owner common.Address
+	// VoterStakes and TotalStake are the bonded stakes, only they are used to select the validators and share the rewards
 	VoterStakes map[common.Address]*big.Int
 	TotalStake  *big.Int
+	// Unbonding is the stake unvoted by the owner from the candidate and not withdrawn yet, it is slashable until its
+	// release epoch
+	Unbonding []Unbonding
+	// CommissionRate is the percentage of the reward of the validator kept by its owner,
+	// the remaining is shared among the voters proportionally to their stake
+	CommissionRate uint64
+}
+
+// UnbondingStake returns the stake unvoted by the owner from the candidate and not withdrawn yet
+func (d CandidateData) UnbondingStake() *big.Int {
+	return totalUnbonding(d.Unbonding)
 }
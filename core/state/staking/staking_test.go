@@ -15,6 +15,8 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus/staking_contracts"
 	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
 	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/params"
@@ -34,6 +36,18 @@ func TestStateDBStakingCaller_GetValidators(t *testing.T) {
 	testGetValidators(t, staking.DefaultConfig)
 }
 
+func TestStateDBStakingCaller_ContractError(t *testing.T) {
+	stateDB, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	require.NoError(t, err)
+	caller := staking.NewStateDbStakingCaller(stateDB, staking.DefaultConfig)
+
+	// there is no staking contract at the address
+	_, err = caller.GetValidators(common.HexToAddress("0x01"))
+	require.True(t, staking.IsContractError(err))
+	assert.Equal(t, bind.ErrNoCode, err.(*staking.ContractError).Err)
+	assert.False(t, staking.IsContractError(bind.ErrNoCode))
+}
+
 func testGetValidators(t *testing.T, indexCfg *staking.IndexConfigs) {
 	var (
 		candidates = []common.Address{
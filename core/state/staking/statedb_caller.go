@@ -25,10 +25,10 @@ type stateDBStakingCaller struct {
 
 // NewStateDbStakingCaller return instance of StakingCaller which reads data directly from state DB
 func NewStateDbStakingCaller(state *state.StateDB, cfg *IndexConfigs) StakingCaller {
-	return &stateDBStakingCaller{
+	return &contractCaller{caller: &stateDBStakingCaller{
 		stateDB: state,
 		config:  cfg,
-	}
+	}}
 }
 
 // GetCandidates returns list candidate's address
@@ -64,6 +64,9 @@ func (c *stateDBStakingCaller) GetValidators(stakingContractAddr common.Address)
 	)
 	minValStake := c.GetMinValidatorStake(stakingContractAddr)
 	for _, candidate := range candidates {
+		if isJailed(c.stateDB, stakingContractAddr, candidate) {
+			continue
+		}
 		stake := c.GetCandidateStake(stakingContractAddr, candidate)
 		if stake.Cmp(minValStake) < 0 {
 			continue
@@ -116,9 +119,11 @@ func (c *stateDBStakingCaller) GetCandidateData(stakingContractAddr common.Addre
 	}
 
 	return CandidateData{
-		Owner:       owner,
-		TotalStake:  totalStake,
-		VoterStakes: voteStakes,
+		Owner:          owner,
+		TotalStake:     totalStake,
+		VoterStakes:    voteStakes,
+		Unbonding:      getUnbondings(c.stateDB, stakingContractAddr, c.config, owner, candidate),
+		CommissionRate: GetCommissionRate(c.stateDB, stakingContractAddr, candidate),
 	}
 }
 
@@ -133,7 +138,12 @@ func (c *stateDBStakingCaller) GetVoters(stakingAddr common.Address, candidate c
 	return voters
 }
 
-// GetCandidateStake returns current stake of a candidate
+// GetUnbondings returns the stakes unvoted by the voter from the candidate and not withdrawn yet
+func (c *stateDBStakingCaller) GetUnbondings(scAddress common.Address, voter common.Address, candidate common.Address) []Unbonding {
+	return getUnbondings(c.stateDB, scAddress, c.config, voter, candidate)
+}
+
+// GetCandidateStake returns current bonded stake of a candidate, the unvoted stake is not included
 func (c *stateDBStakingCaller) GetCandidateStake(scAddress common.Address, candidate common.Address) *big.Int {
 	loc := getMappingElementLoc(c.config.CandidateDataLayout.slotHash(), candidate.Hash())
 	loc = addOffsetToLoc(loc, new(big.Int).SetUint64(c.config.CandidateDataStruct.TotalStake.Slot))
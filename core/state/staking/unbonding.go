@@ -0,0 +1,169 @@
+package staking
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/Evrynetlabs/evrynet-node/accounts/abi"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/staking_contracts"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+var (
+	// unbondingsSlot is the slot of mapping(address => mapping(address => mapping(uint256 => uint256))) storing the
+	// stake a voter unvoted from a candidate by release epoch. The withdraws of the staking contract do not record the
+	// candidate, like the jailing slots, it is written by the node only.
+	unbondingsSlot = crypto.Keccak256Hash([]byte("evrynet.staking.unbondings"))
+
+	stakingABI abi.ABI
+)
+
+func init() {
+	stakingABI, _ = abi.JSON(strings.NewReader(staking_contracts.StakingContractsABI))
+}
+
+// Unbonding is a stake unvoted from a candidate of the staking contract and not withdrawn yet.
+// The stake can be withdrawn from its release epoch Epoch and is slashable for the offenses of the candidate until then.
+type Unbonding struct {
+	Candidate common.Address
+	Epoch     uint64
+	Amount    *big.Int
+}
+
+// totalUnbonding returns the sum of the unbonding stakes
+func totalUnbonding(unbondings []Unbonding) *big.Int {
+	total := new(big.Int)
+	for _, u := range unbondings {
+		total.Add(total, u.Amount)
+	}
+	return total
+}
+
+// getUnbondings returns the stakes the voter unvoted from the candidate, read from the withdrawsState of the staking
+// contract. The part of a withdraw cap recorded for the candidate is capped to the cap as the voter may have withdrawn it.
+func getUnbondings(stateDB *state.StateDB, scAddress common.Address, cfg *IndexConfigs, voter common.Address, candidate common.Address) []Unbonding {
+	var result []Unbonding
+	for _, epoch := range getWithdrawEpochs(stateDB, scAddress, cfg, voter) {
+		amount := stateDB.GetState(scAddress, withdrawCapLoc(cfg, voter, epoch)).Big()
+		if recorded := getRecordedUnbonding(stateDB, scAddress, voter, candidate, epoch); recorded.Cmp(amount) < 0 {
+			amount = recorded
+		}
+		if amount.Sign() == 0 {
+			continue
+		}
+		result = append(result, Unbonding{Candidate: candidate, Epoch: epoch, Amount: amount})
+	}
+	return result
+}
+
+// getWithdrawEpochs returns the release epochs of the withdrawsState of the voter, an epoch is listed once per unvote
+// by the staking contract
+func getWithdrawEpochs(stateDB *state.StateDB, scAddress common.Address, cfg *IndexConfigs, voter common.Address) []uint64 {
+	var (
+		loc       = getMappingElementLoc(cfg.WithdrawsStateLayout.slotHash(), voter.Hash())
+		epochsLoc = addOffsetToLoc(loc, new(big.Int).SetUint64(cfg.WithdrawStateStruct.Epochs.Slot))
+		length    = stateDB.GetState(scAddress, epochsLoc).Big().Uint64()
+		seen      = make(map[uint64]bool)
+		epochs    []uint64
+	)
+	for i := uint64(0); i < length; i++ {
+		epoch := stateDB.GetState(scAddress, getElementArrayLoc(epochsLoc, i, defaultElementSize)).Big().Uint64()
+		if !seen[epoch] {
+			seen[epoch] = true
+			epochs = append(epochs, epoch)
+		}
+	}
+	return epochs
+}
+
+// withdrawCapLoc returns the location of the stake the voter can withdraw from the epoch
+func withdrawCapLoc(cfg *IndexConfigs, voter common.Address, epoch uint64) common.Hash {
+	loc := getMappingElementLoc(cfg.WithdrawsStateLayout.slotHash(), voter.Hash())
+	capsLoc := addOffsetToLoc(loc, new(big.Int).SetUint64(cfg.WithdrawStateStruct.Caps.Slot))
+	return getMappingElementLoc(capsLoc, common.BigToHash(new(big.Int).SetUint64(epoch)))
+}
+
+// recordedUnbondingLoc returns the location of the stake the voter unvoted from the candidate released at the epoch
+func recordedUnbondingLoc(voter common.Address, candidate common.Address, epoch uint64) common.Hash {
+	loc := getMappingElementLoc(getMappingElementLoc(unbondingsSlot, voter.Hash()), candidate.Hash())
+	return getMappingElementLoc(loc, common.BigToHash(new(big.Int).SetUint64(epoch)))
+}
+
+// getRecordedUnbonding returns the stake the voter unvoted from the candidate released at the epoch
+func getRecordedUnbonding(stateDB *state.StateDB, scAddress common.Address, voter common.Address, candidate common.Address, epoch uint64) *big.Int {
+	return stateDB.GetState(scAddress, recordedUnbondingLoc(voter, candidate, epoch)).Big()
+}
+
+// setRecordedUnbonding stores the stake the voter unvoted from the candidate released at the epoch
+func setRecordedUnbonding(stateDB *state.StateDB, scAddress common.Address, voter common.Address, candidate common.Address, epoch uint64, amount *big.Int) {
+	stateDB.SetState(scAddress, recordedUnbondingLoc(voter, candidate, epoch), common.BigToHash(amount))
+}
+
+// currentEpoch returns the epoch of the staking contract at blockNumber
+func currentEpoch(stateDB *state.StateDB, scAddress common.Address, cfg *IndexConfigs, blockNumber uint64) uint64 {
+	var (
+		startBlock  = stateDB.GetState(scAddress, cfg.StartBlockLayout.slotHash()).Big().Uint64()
+		epochPeriod = stateDB.GetState(scAddress, cfg.EpochPeriodLayout.slotHash()).Big().Uint64()
+	)
+	if epochPeriod == 0 || blockNumber < startBlock {
+		return 0
+	}
+	return (blockNumber - startBlock) / epochPeriod
+}
+
+// stakeChange is a change of the stake of a voter in a candidate logged by the staking contract.
+// The resignation of a candidate unvotes the whole stake of its owner, its voter and amount are unknown.
+type stakeChange struct {
+	voter     common.Address
+	candidate common.Address
+	amount    *big.Int // positive for a vote, negative for an unvote
+	resigned  bool
+}
+
+// decodeStakeChanges returns the changes of stakes logged by the staking contract in the logs, in order
+func decodeStakeChanges(scAddress common.Address, logs []*types.Log) ([]stakeChange, error) {
+	var (
+		voted    = stakingABI.Events["Voted"].Id()
+		unvoted  = stakingABI.Events["Unvoted"].Id()
+		resigned = stakingABI.Events["Resigned"].Id()
+		changes  []stakeChange
+	)
+	for _, log := range logs {
+		if log.Address != scAddress || len(log.Topics) == 0 {
+			continue
+		}
+		switch log.Topics[0] {
+		case voted, unvoted:
+			var event struct {
+				Voter     common.Address
+				Candidate common.Address
+				Amount    *big.Int
+			}
+			name := "Voted"
+			if log.Topics[0] == unvoted {
+				name = "Unvoted"
+			}
+			if err := stakingABI.Unpack(&event, name, log.Data); err != nil {
+				return nil, err
+			}
+			amount := event.Amount
+			if log.Topics[0] == unvoted {
+				amount = new(big.Int).Neg(amount)
+			}
+			changes = append(changes, stakeChange{voter: event.Voter, candidate: event.Candidate, amount: amount})
+		case resigned:
+			var event struct {
+				Candidate common.Address
+				Epoch     *big.Int
+			}
+			if err := stakingABI.Unpack(&event, "Resigned", log.Data); err != nil {
+				return nil, err
+			}
+			changes = append(changes, stakeChange{candidate: event.Candidate, resigned: true})
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,226 @@
+package staking_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/accounts/abi/bind"
+	"github.com/Evrynetlabs/evrynet-node/accounts/abi/bind/backends"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/staking_contracts"
+	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/state/staking"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+// deployWithUnbonding deploys a staking contract with an epoch period of 2 blocks and a candidate whose owner voted
+// 1000 and unvoted 400 of them. It returns the current state where the unbonding is recorded like a node does.
+func deployWithUnbonding(t *testing.T) (*backends.SimulatedBackend, *state.StateDB, common.Address, common.Address) {
+	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	require.NoError(t, err)
+	ownerPk, err := crypto.HexToECDSA(newCandidatePkHex)
+	require.NoError(t, err)
+	var (
+		addr      = crypto.PubkeyToAddress(*privateKey.Public().(*ecdsa.PublicKey))
+		candidate = crypto.PubkeyToAddress(ownerPk.PublicKey)
+		balance   = big.NewInt(0).Exp(big.NewInt(10), big.NewInt(18), nil)
+	)
+	be := backends.NewSimulatedBackend(core.GenesisAlloc{
+		addr:      core.GenesisAccount{Balance: balance},
+		candidate: core.GenesisAccount{Balance: balance},
+	}, gasLimit)
+
+	authOpts := bind.NewKeyedTransactor(privateKey)
+	scAddr, tx, contract, err := staking_contracts.DeployStakingContracts(authOpts, be, []common.Address{addr}, []common.Address{addr},
+		big.NewInt(2), common.Big0, big.NewInt(100), big.NewInt(20), big.NewInt(10), addr)
+	require.NoError(t, err)
+	be.Commit()
+	assertTxSuccess(t, be, tx.Hash())
+
+	tx, err = contract.Register(bind.NewKeyedTransactor(privateKey), candidate, candidate)
+	require.NoError(t, err)
+	be.Commit()
+	assertTxSuccess(t, be, tx.Hash())
+
+	authOpts = bind.NewKeyedTransactor(ownerPk)
+	authOpts.Value = big.NewInt(1000)
+	tx, err = contract.Vote(authOpts, candidate)
+	require.NoError(t, err)
+	be.Commit()
+	assertTxSuccess(t, be, tx.Hash())
+
+	tx, err = contract.Unvote(bind.NewKeyedTransactor(ownerPk), candidate, big.NewInt(400))
+	require.NoError(t, err)
+	be.Commit()
+	assertTxSuccess(t, be, tx.Hash())
+
+	stateDB, err := be.CurrentStateDb()
+	require.NoError(t, err)
+	recordUnbondings(t, be, stateDB, scAddr, nil, tx.Hash())
+	return be, stateDB, scAddr, candidate
+}
+
+// recordUnbondings records the unbonding stakes of the transactions of the current block in the state like a node
+// does, parent is the state before the block
+func recordUnbondings(t *testing.T, be *backends.SimulatedBackend, stateDB *state.StateDB, scAddr common.Address, parent *state.StateDB, txHashes ...common.Hash) {
+	var logs []*types.Log
+	for _, hash := range txHashes {
+		receipt, err := be.TransactionReceipt(context.Background(), hash)
+		require.NoError(t, err)
+		logs = append(logs, receipt.Logs...)
+	}
+	parentStake := func(candidate, voter common.Address) (*big.Int, error) {
+		data, err := staking.NewStateDbStakingCaller(parent, staking.DefaultConfig).GetValidatorsData(scAddr, []common.Address{candidate})
+		if err != nil {
+			return nil, err
+		}
+		return data[candidate].VoterStakes[voter], nil
+	}
+	slasher := staking.NewStateDbStakingSlasher(stateDB, staking.DefaultConfig)
+	require.NoError(t, slasher.RecordUnbondings(scAddr, logs, parentStake))
+}
+
+func TestEvmStakingCaller_Unbonding(t *testing.T) {
+	testUnbonding(t, nil)
+}
+
+func TestStateDBStakingCaller_Unbonding(t *testing.T) {
+	testUnbonding(t, staking.DefaultConfig)
+}
+
+func testUnbonding(t *testing.T, indexCfg *staking.IndexConfigs) {
+	be, stateDB, scAddr, candidate := deployWithUnbonding(t)
+	stakingCaller := be.StakingCallerAt(stateDB, indexCfg)
+
+	// the unvoted stake is not used to select the validators
+	validators, err := stakingCaller.GetValidators(scAddr)
+	require.NoError(t, err)
+	assert.Contains(t, validators, candidate)
+
+	data, err := stakingCaller.GetValidatorsData(scAddr, []common.Address{candidate})
+	require.NoError(t, err)
+	require.Contains(t, data, candidate)
+	assert.Equal(t, big.NewInt(600), data[candidate].TotalStake)
+	assert.Equal(t, big.NewInt(600), data[candidate].VoterStakes[candidate])
+	require.Len(t, data[candidate].Unbonding, 1)
+	assert.Equal(t, candidate, data[candidate].Unbonding[0].Candidate)
+	// the stake is unvoted at block 4 of the epoch 2 and is released 2 epochs later
+	assert.Equal(t, uint64(4), data[candidate].Unbonding[0].Epoch)
+	assert.Equal(t, big.NewInt(400), data[candidate].UnbondingStake())
+}
+
+func TestStateDBStakingSlasher_SlashUnbonding(t *testing.T) {
+	_, stateDB, scAddr, candidate := deployWithUnbonding(t)
+	var (
+		caller        = staking.NewStateDbStakingCaller(stateDB, staking.DefaultConfig)
+		slasher       = staking.NewStateDbStakingSlasher(stateDB, staking.DefaultConfig)
+		balanceBefore = stateDB.GetBalance(scAddr)
+	)
+	// 10% of both the bonded and the unbonding stakes are burnt
+	slashed := slasher.Slash(scAddr, candidate, 10, 5)
+	assert.Equal(t, big.NewInt(100), slashed)
+	assert.Equal(t, new(big.Int).Sub(balanceBefore, slashed), stateDB.GetBalance(scAddr))
+	data, err := caller.GetValidatorsData(scAddr, []common.Address{candidate})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(540), data[candidate].TotalStake)
+	assert.Equal(t, big.NewInt(360), data[candidate].UnbondingStake())
+
+	// the released stake is not slashable anymore
+	slashed = slasher.Slash(scAddr, candidate, 10, 8)
+	assert.Equal(t, big.NewInt(54), slashed)
+	data, err = caller.GetValidatorsData(scAddr, []common.Address{candidate})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(360), data[candidate].UnbondingStake())
+}
+
+func TestStateDBStakingSlasher_SlashUnbondingOfCandidate(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	require.NoError(t, err)
+	ownerPk, err := crypto.HexToECDSA(newCandidatePkHex)
+	require.NoError(t, err)
+	otherPk, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	var (
+		addr       = crypto.PubkeyToAddress(privateKey.PublicKey)
+		owner      = crypto.PubkeyToAddress(ownerPk.PublicKey)
+		candidate  = owner
+		other      = crypto.PubkeyToAddress(otherPk.PublicKey)
+		balance    = big.NewInt(0).Exp(big.NewInt(10), big.NewInt(18), nil)
+		commitTxs  []common.Hash
+		ownerStake = map[common.Address]int64{candidate: 1000, other: 500}
+	)
+	be := backends.NewSimulatedBackend(core.GenesisAlloc{
+		addr:  core.GenesisAccount{Balance: balance},
+		owner: core.GenesisAccount{Balance: balance},
+	}, gasLimit)
+	scAddr, tx, contract, err := staking_contracts.DeployStakingContracts(bind.NewKeyedTransactor(privateKey), be, []common.Address{addr}, []common.Address{addr},
+		big.NewInt(2), common.Big0, big.NewInt(100), big.NewInt(20), big.NewInt(10), addr)
+	require.NoError(t, err)
+	be.Commit()
+	assertTxSuccess(t, be, tx.Hash())
+
+	// the owner of both candidates votes for them
+	for _, c := range []common.Address{candidate, other} {
+		tx, err = contract.Register(bind.NewKeyedTransactor(privateKey), c, owner)
+		require.NoError(t, err)
+		commitTxs = append(commitTxs, tx.Hash())
+		authOpts := bind.NewKeyedTransactor(ownerPk)
+		authOpts.Value = big.NewInt(ownerStake[c])
+		tx, err = contract.Vote(authOpts, c)
+		require.NoError(t, err)
+		commitTxs = append(commitTxs, tx.Hash())
+		be.Commit()
+	}
+	for _, hash := range commitTxs {
+		assertTxSuccess(t, be, hash)
+	}
+	parent, err := be.CurrentStateDb()
+	require.NoError(t, err)
+
+	// in the same block, the owner unvotes 400 from the candidate and the other candidate resigns,
+	// both stakes are released at the same epoch of the owner's withdraws
+	unvoteTx, err := contract.Unvote(bind.NewKeyedTransactor(ownerPk), candidate, big.NewInt(400))
+	require.NoError(t, err)
+	resignTx, err := contract.Resign(bind.NewKeyedTransactor(ownerPk), other)
+	require.NoError(t, err)
+	be.Commit()
+	assertTxSuccess(t, be, unvoteTx.Hash())
+	assertTxSuccess(t, be, resignTx.Hash())
+	stateDB, err := be.CurrentStateDb()
+	require.NoError(t, err)
+	recordUnbondings(t, be, stateDB, scAddr, parent, unvoteTx.Hash(), resignTx.Hash())
+
+	var (
+		caller  = staking.NewStateDbStakingCaller(stateDB, staking.DefaultConfig)
+		slasher = staking.NewStateDbStakingSlasher(stateDB, staking.DefaultConfig)
+	)
+	data, err := caller.GetValidatorsData(scAddr, []common.Address{candidate, other})
+	require.NoError(t, err)
+	require.Len(t, data[candidate].Unbonding, 1)
+	require.Len(t, data[other].Unbonding, 1)
+	assert.Equal(t, data[candidate].Unbonding[0].Epoch, data[other].Unbonding[0].Epoch)
+	assert.Equal(t, big.NewInt(400), data[candidate].UnbondingStake())
+	assert.Equal(t, big.NewInt(500), data[other].UnbondingStake())
+
+	// only the stake unvoted from the offending candidate is slashed
+	slashed := slasher.Slash(scAddr, candidate, 10, 7)
+	assert.Equal(t, big.NewInt(100), slashed)
+	data, err = caller.GetValidatorsData(scAddr, []common.Address{candidate, other})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(540), data[candidate].TotalStake)
+	assert.Equal(t, big.NewInt(360), data[candidate].UnbondingStake())
+	assert.Equal(t, big.NewInt(500), data[other].UnbondingStake())
+
+	// the EVM caller reads the same unbonding stakes from the slashed withdraw cap
+	data, err = be.StakingCallerAt(stateDB, nil).GetValidatorsData(scAddr, []common.Address{candidate, other})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(360), data[candidate].UnbondingStake())
+	assert.Equal(t, big.NewInt(500), data[other].UnbondingStake())
+}
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/metrics"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
@@ -104,6 +105,9 @@ type Account struct {
 	CodeHash          []byte
 	OwnerAddress      *common.Address   `rlp:"nil"`
 	ProviderAddresses []*common.Address `rlp:"nil"`
+	// ProviderAllowances is the tail of the account so that the encoding of the accounts without allowance is unchanged,
+	// they can only be set since the provider allowance fork
+	ProviderAllowances []types.ProviderAllowance `rlp:"tail"`
 }
 
 // AccountWithoutProvider represent an account without provider
@@ -431,3 +435,71 @@ func (s *stateObject) OwnerAddress() *common.Address {
 func (s *stateObject) ProviderAddresses() []*common.Address {
 	return s.data.ProviderAddresses
 }
+
+func (s *stateObject) SetOwner(owner common.Address) {
+	s.db.journal.append(ownerChange{
+		account: &s.address,
+		prev:    s.data.OwnerAddress,
+	})
+	s.setOwner(&owner)
+}
+
+func (s *stateObject) setOwner(owner *common.Address) {
+	s.data.OwnerAddress = owner
+}
+
+// AddProvider appends the provider to the providers of the account if it is not one of them yet.
+func (s *stateObject) AddProvider(provider common.Address) {
+	if provider.InList(s.data.ProviderAddresses) {
+		return
+	}
+	// the provider list is shared with the copies of the state object, it is never modified in place
+	providers := make([]*common.Address, 0, len(s.data.ProviderAddresses)+1)
+	providers = append(providers, s.data.ProviderAddresses...)
+	providers = append(providers, &provider)
+	s.SetProviders(providers)
+}
+
+// RemoveProvider removes the provider from the providers of the account if it is one of them.
+func (s *stateObject) RemoveProvider(provider common.Address) {
+	if !provider.InList(s.data.ProviderAddresses) {
+		return
+	}
+	providers := make([]*common.Address, 0, len(s.data.ProviderAddresses)-1)
+	for _, p := range s.data.ProviderAddresses {
+		if *p != provider {
+			providers = append(providers, p)
+		}
+	}
+	s.SetProviders(providers)
+}
+
+func (s *stateObject) SetProviders(providers []*common.Address) {
+	s.db.journal.append(providersChange{
+		account: &s.address,
+		prev:    s.data.ProviderAddresses,
+	})
+	s.setProviders(providers)
+}
+
+func (s *stateObject) setProviders(providers []*common.Address) {
+	s.data.ProviderAddresses = providers
+}
+
+func (s *stateObject) ProviderAllowances() []types.ProviderAllowance {
+	return s.data.ProviderAllowances
+}
+
+// SetProviderAllowances replaces the allowances of the providers, the slice must not be modified afterwards as
+// it is shared with the copies of the state object.
+func (s *stateObject) SetProviderAllowances(allowances []types.ProviderAllowance) {
+	s.db.journal.append(providerAllowancesChange{
+		account: &s.address,
+		prev:    s.data.ProviderAllowances,
+	})
+	s.setProviderAllowances(allowances)
+}
+
+func (s *stateObject) setProviderAllowances(allowances []types.ProviderAllowance) {
+	s.data.ProviderAllowances = allowances
+}
@@ -236,6 +236,14 @@ func (self *StateDB) GetProviders(addr common.Address) []*common.Address {
 	return []*common.Address{}
 }
 
+// GetProviderAllowances returns the allowances of the providers of an enterprise contract
+func (self *StateDB) GetProviderAllowances(addr common.Address) []types.ProviderAllowance {
+	if so := self.getStateObject(addr); so != nil {
+		return so.ProviderAllowances()
+	}
+	return nil
+}
+
 // Retrieve the balance from the given address or 0 if object not found
 func (self *StateDB) GetBalance(addr common.Address) *big.Int {
 	stateObject := self.getStateObject(addr)
@@ -389,6 +397,38 @@ func (self *StateDB) SetNonce(addr common.Address, nonce uint64) {
 	}
 }
 
+// SetOwner transfers the ownership of an enterprise contract
+func (self *StateDB) SetOwner(addr common.Address, owner common.Address) {
+	stateObject := self.GetOrNewStateObject(addr)
+	if stateObject != nil {
+		stateObject.SetOwner(owner)
+	}
+}
+
+// AddProvider adds a provider to the providers of an enterprise contract, it does nothing if it is already one of them
+func (self *StateDB) AddProvider(addr common.Address, provider common.Address) {
+	stateObject := self.GetOrNewStateObject(addr)
+	if stateObject != nil {
+		stateObject.AddProvider(provider)
+	}
+}
+
+// RemoveProvider removes a provider from the providers of an enterprise contract, it does nothing if it is not one of them
+func (self *StateDB) RemoveProvider(addr common.Address, provider common.Address) {
+	stateObject := self.getStateObject(addr)
+	if stateObject != nil {
+		stateObject.RemoveProvider(provider)
+	}
+}
+
+// SetProviderAllowances replaces the allowances of the providers of an enterprise contract
+func (self *StateDB) SetProviderAllowances(addr common.Address, allowances []types.ProviderAllowance) {
+	stateObject := self.GetOrNewStateObject(addr)
+	if stateObject != nil {
+		stateObject.SetProviderAllowances(allowances)
+	}
+}
+
 func (self *StateDB) SetCode(addr common.Address, code []byte) {
 	stateObject := self.GetOrNewStateObject(addr)
 	if stateObject != nil {
@@ -252,6 +252,36 @@ func newTestAction(addr common.Address, r *rand.Rand) testAction {
 			},
 			args: make([]int64, 2),
 		},
+		{
+			name: "SetOwner",
+			fn: func(a testAction, s *StateDB) {
+				s.SetOwner(addr, common.BigToAddress(big.NewInt(a.args[0])))
+			},
+			args: make([]int64, 1),
+		},
+		{
+			name: "AddProvider",
+			fn: func(a testAction, s *StateDB) {
+				s.AddProvider(addr, common.BigToAddress(big.NewInt(a.args[0]%4)))
+			},
+			args: make([]int64, 1),
+		},
+		{
+			name: "RemoveProvider",
+			fn: func(a testAction, s *StateDB) {
+				s.RemoveProvider(addr, common.BigToAddress(big.NewInt(a.args[0]%4)))
+			},
+			args: make([]int64, 1),
+		},
+		{
+			name: "SetProviderAllowances",
+			fn: func(a testAction, s *StateDB) {
+				s.SetProviderAllowances(addr, []types.ProviderAllowance{
+					{Provider: common.BigToAddress(big.NewInt(a.args[0])), Period: 1, GasLimit: uint64(a.args[1])},
+				})
+			},
+			args: make([]int64, 2),
+		},
 		{
 			name: "CreateAccount",
 			fn: func(a testAction, s *StateDB) {
@@ -393,6 +423,9 @@ func (test *snapshotTest) checkEqual(state, checkstate *StateDB) error {
 		checkeq("GetCode", state.GetCode(addr), checkstate.GetCode(addr))
 		checkeq("GetCodeHash", state.GetCodeHash(addr), checkstate.GetCodeHash(addr))
 		checkeq("GetCodeSize", state.GetCodeSize(addr), checkstate.GetCodeSize(addr))
+		checkeq("GetOwner", state.GetOwner(addr), checkstate.GetOwner(addr))
+		checkeq("GetProviders", state.GetProviders(addr), checkstate.GetProviders(addr))
+		checkeq("GetProviderAllowances", state.GetProviderAllowances(addr), checkstate.GetProviderAllowances(addr))
 		// Check storage.
 		if obj := state.getStateObject(addr); obj != nil {
 			state.ForEachStorage(addr, func(key, value common.Hash) bool {
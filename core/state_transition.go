@@ -158,6 +158,17 @@ func (st *StateTransition) buyGas() error {
 	if st.state.GetBalance(st.msg.GasPayer()).Cmp(mgval) < 0 {
 		return errInsufficientBalanceForGas
 	}
+	// since the provider group fork, a deployment is only sponsored by a member of the group the contract joins
+	if st.isSponsoredCreation() && st.evm.ChainConfig().IsProviderGroup(st.evm.BlockNumber) {
+		if st.msg.Provider() == nil || !vm.IsGroupMember(st.state, *st.msg.Provider(), st.msg.GasPayer()) {
+			return ErrInvalidProvider
+		}
+	}
+	if st.hasProviderAllowance() {
+		if err := checkProviderAllowance(st.state, *st.msg.To(), st.msg.GasPayer(), st.msg.From(), st.evm.BlockNumber.Uint64(), st.msg.Gas()); err != nil {
+			return err
+		}
+	}
 	if err := st.gp.SubGas(st.msg.Gas()); err != nil {
 		return err
 	}
@@ -165,9 +176,28 @@ func (st *StateTransition) buyGas() error {
 
 	st.initialGas = st.msg.Gas()
 	st.state.SubBalance(st.msg.GasPayer(), mgval)
+	if st.hasProviderAllowance() {
+		useProviderAllowance(st.state, *st.msg.To(), st.msg.GasPayer(), st.msg.From(), st.evm.BlockNumber.Uint64(), st.msg.Gas(), false)
+	}
 	return nil
 }
 
+// isSponsored returns whether the gas of the message is paid by a provider of the called contract
+func (st *StateTransition) isSponsored() bool {
+	return st.msg.To() != nil && st.msg.GasPayer() != st.msg.From()
+}
+
+// hasProviderAllowance returns whether the gas paid by the provider of the message is limited by its allowances,
+// which is the case of sponsored calls since the provider allowance fork
+func (st *StateTransition) hasProviderAllowance() bool {
+	return st.isSponsored() && st.evm.ChainConfig().IsProviderAllowance(st.evm.BlockNumber)
+}
+
+// isSponsoredCreation returns whether the gas of the contract creation is paid by a provider
+func (st *StateTransition) isSponsoredCreation() bool {
+	return st.msg.To() == nil && st.msg.GasPayer() != st.msg.From()
+}
+
 func (st *StateTransition) preCheck() error {
 	// Make sure this transaction's nonce is correct.
 	if st.msg.CheckNonce() {
@@ -251,6 +281,9 @@ func (st *StateTransition) refundGas() {
 	// Return ETH for remaining gas, exchanged at the original rate.
 	remaining := new(big.Int).Mul(new(big.Int).SetUint64(st.gas), st.gasPrice)
 	st.state.AddBalance(st.msg.GasPayer(), remaining)
+	if st.hasProviderAllowance() {
+		useProviderAllowance(st.state, *st.msg.To(), st.msg.GasPayer(), st.msg.From(), st.evm.BlockNumber.Uint64(), st.gas, true)
+	}
 
 	// Also return remaining gas to the block gas counter so it is
 	// available for the next transaction.
@@ -280,6 +280,13 @@ func (l *txList) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Tran
 		}
 	}
 	// Otherwise overwrite the old transaction with the current one
+	return true, l.Replace(tx)
+}
+
+// Replace inserts a new transaction into the list regardless of the price of the
+// transaction with the same nonce, returning the replaced transaction if any.
+func (l *txList) Replace(tx *types.Transaction) *types.Transaction {
+	old := l.txs.Get(tx.Nonce())
 	l.txs.Put(tx)
 	if cost := tx.Cost(); l.costcap.Cmp(cost) < 0 {
 		l.costcap = cost
@@ -287,7 +294,7 @@ func (l *txList) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Tran
 	if gas := tx.Gas(); l.gascap < gas {
 		l.gascap = gas
 	}
-	return true, old
+	return old
 }
 
 // Forward removes all transactions from the list with a nonce lower than the
@@ -27,8 +27,10 @@ import (
 
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/common/prque"
+	"github.com/Evrynetlabs/evrynet-node/core/governance"
 	"github.com/Evrynetlabs/evrynet-node/core/state"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/event"
 	"github.com/Evrynetlabs/evrynet-node/log"
@@ -103,11 +105,23 @@ var (
 	// ErrTxPoolFull is returned tx pool is full
 	ErrTxPoolFull = errors.New("Tx pool is full")
 
+	// ErrAccountQuotaExceeded is returned if the sender of a remote transaction already
+	// has the maximum number of transactions permitted per account in the pool.
+	ErrAccountQuotaExceeded = errors.New("account quota exceeded")
+
+	// ErrProviderQuotaExceeded is returned if the provider paying for a remote transaction
+	// already pays for the maximum number of transactions permitted per provider in the pool.
+	ErrProviderQuotaExceeded = errors.New("provider quota exceeded")
+
 	// ErrInvalidGasPrice is returned if tx gasPrice is different from gasPrice of the network
 	ErrInvalidGasPrice = errors.New("Tx gasPrice is different from gasPrice of the network")
 
 	// ErrMaxProvider will be returned if the providers are over the limit
 	ErrMaxProvider = errors.New("maximum provider in contract")
+
+	// ErrProviderAllowanceExceeded is returned if the gas of a tx is over the gas its provider can still pay for
+	// the sender, as set by the owner of the contract
+	ErrProviderAllowanceExceeded = errors.New("provider allowance exceeded")
 )
 
 var (
@@ -132,6 +146,14 @@ var (
 	validMeter         = metrics.NewRegisteredMeter("txpool/valid", nil)
 	invalidTxMeter     = metrics.NewRegisteredMeter("txpool/invalid", nil)
 	underpricedTxMeter = metrics.NewRegisteredMeter("txpool/underpriced", nil)
+	accountQuotaMeter  = metrics.NewRegisteredMeter("txpool/quota/account", nil)  // Dropped due to the account quota
+	providerQuotaMeter = metrics.NewRegisteredMeter("txpool/quota/provider", nil) // Dropped due to the provider quota
+	cancelMeter        = metrics.NewRegisteredMeter("txpool/cancel", nil)         // Transactions replaced by a cancellation
+
+	// Metrics on the time transactions spend in the pool
+	pendingAgeGauge  = metrics.NewRegisteredGauge("txpool/pending/age", nil)  // Age of the oldest pending transaction (ms)
+	queuedAgeGauge   = metrics.NewRegisteredGauge("txpool/queued/age", nil)   // Age of the oldest queued transaction (ms)
+	includedAgeTimer = metrics.NewRegisteredTimer("txpool/included/age", nil) // Time from arrival to inclusion in the chain
 
 	pendingCounter = metrics.NewRegisteredCounter("txpool/pending", nil)
 	queuedCounter  = metrics.NewRegisteredCounter("txpool/queued", nil)
@@ -173,6 +195,9 @@ type TxPoolConfig struct {
 	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
+	AccountQuota  uint64 // Maximum number of transactions, executable or not, permitted per remote account
+	ProviderQuota uint64 // Maximum number of remote transactions a single provider is permitted to pay for
+
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
 }
 
@@ -190,6 +215,9 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	AccountQueue: 64,
 	GlobalQueue:  1024,
 
+	AccountQuota:  128,
+	ProviderQuota: 1024,
+
 	Lifetime: 3 * time.Hour,
 }
 
@@ -225,6 +253,14 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool global queue", "provided", conf.GlobalQueue, "updated", DefaultTxPoolConfig.GlobalQueue)
 		conf.GlobalQueue = DefaultTxPoolConfig.GlobalQueue
 	}
+	if conf.AccountQuota < 1 {
+		log.Warn("Sanitizing invalid txpool account quota", "provided", conf.AccountQuota, "updated", DefaultTxPoolConfig.AccountQuota)
+		conf.AccountQuota = DefaultTxPoolConfig.AccountQuota
+	}
+	if conf.ProviderQuota < 1 {
+		log.Warn("Sanitizing invalid txpool provider quota", "provided", conf.ProviderQuota, "updated", DefaultTxPoolConfig.ProviderQuota)
+		conf.ProviderQuota = DefaultTxPoolConfig.ProviderQuota
+	}
 	if conf.Lifetime < 1 {
 		log.Warn("Sanitizing invalid txpool lifetime", "provided", conf.Lifetime, "updated", DefaultTxPoolConfig.Lifetime)
 		conf.Lifetime = DefaultTxPoolConfig.Lifetime
@@ -284,10 +320,10 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		pending:     make(map[common.Address]*txList),
 		queue:       make(map[common.Address]*txList),
 		beats:       make(map[common.Address]time.Time),
-		all:         newTxLookup(),
 		chainHeadCh: make(chan ChainHeadEvent, chainHeadChanSize),
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
 	}
+	pool.all = newTxLookup(pool.signer)
 	pool.locals = newAccountSet(pool.signer)
 	for _, addr := range config.Locals {
 		log.Info("Setting new local account", "address", addr)
@@ -362,6 +398,8 @@ func (pool *TxPool) loop() {
 			pool.mu.RLock()
 			pending, queued := pool.stats()
 			stales := pool.priced.stales
+			pendingAgeGauge.Update(int64(oldestAge(pool.pending) / time.Millisecond))
+			queuedAgeGauge.Update(int64(oldestAge(pool.queue) / time.Millisecond))
 			pool.mu.RUnlock()
 
 			if pending != prevPending || queued != prevQueued || stales != prevStales {
@@ -657,8 +695,8 @@ func (pool *TxPool) ValidateTx(tx *types.Transaction, local bool) error {
 		return ErrInvalidSender
 	}
 
-	//Vlidate gasPrice of tx must be as the same as gasPrice of chainConfig
-	if tx.GasPrice().Cmp(pool.chainconfig.GasPrice) != 0 {
+	//Vlidate gasPrice of tx must be as the same as gasPrice of the network
+	if tx.GasPrice().Cmp(pool.networkGasPrice()) != 0 {
 		return ErrInvalidGasPrice
 	}
 
@@ -666,7 +704,11 @@ func (pool *TxPool) ValidateTx(tx *types.Transaction, local bool) error {
 	// Otherwise, it should not have any provider's signature
 	// TODO: remove the log in production
 	signedProvider, providerRetrieveErr := types.Provider(pool.signer, tx)
-	var isEnterpriseContract = false
+	var (
+		isEnterpriseContract = false
+		// the provider groups can only pay for transactions from the fork
+		providerGroups = signedProvider != nil && pool.chainconfig.IsProviderGroup(new(big.Int).Add(pool.chain.CurrentBlock().Number(), common.Big1))
+	)
 	if tx.To() != nil {
 		to := tx.To()
 		contractHash := pool.currentState.GetCodeHash(*to)
@@ -682,7 +724,8 @@ func (pool *TxPool) ValidateTx(tx *types.Transaction, local bool) error {
 					log.Error("invalid provider address", "provider address is nil")
 					return ErrInvalidProvider
 				}
-				if !signedProvider.InList(expectedProviders) {
+				if (providerGroups && !vm.IsContractProvider(pool.currentState, *to, *signedProvider)) ||
+					(!providerGroups && !signedProvider.InList(expectedProviders)) {
 					log.Error("invalid provider address", "provider address", signedProvider.String())
 					return ErrInvalidProvider
 				}
@@ -696,6 +739,14 @@ func (pool *TxPool) ValidateTx(tx *types.Transaction, local bool) error {
 				return ErrOwnerReqired
 			}
 		}
+		// a member of a provider group can pay for the deployment of a contract joining the group
+		if providerGroups {
+			if tx.Provider() == nil || !vm.IsGroupMember(pool.currentState, *tx.Provider(), *signedProvider) {
+				log.Error("invalid provider address", "provider address", signedProvider.String())
+				return ErrInvalidProvider
+			}
+			isEnterpriseContract = true
+		}
 	}
 	if (signedProvider != nil) && (!isEnterpriseContract) {
 		// this case happens when there is no provider address required but still have provider's signature
@@ -724,6 +775,13 @@ func (pool *TxPool) ValidateTx(tx *types.Transaction, local bool) error {
 		if pool.currentState.GetBalance(*signedProvider).Cmp(tx.TransactionFee()) < 0 {
 			return ErrProviderInsufficientFunds
 		}
+		// Check the gas the provider can still pay for the sender in the next block
+		number := pool.chain.CurrentBlock().NumberU64() + 1
+		if tx.To() != nil {
+			if err := checkProviderAllowance(pool.currentState, *tx.To(), *signedProvider, from, number, tx.Gas()); err != nil {
+				return err
+			}
+		}
 	} else {
 		// Sender pays transaction fee, check sender's balance for tx costs
 		// cost == V + GP * GL
@@ -762,24 +820,51 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		invalidTxMeter.Mark(1)
 		return false, err
 	}
-	// If the transaction pool is full, discard underpriced transactions
-	if uint64(pool.all.Count()) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
-		//discard new transaction when transaction pool is full
-		log.Trace("Discarding new transaction because transaction pool is full", "hash", hash, "price", tx.GasPrice())
-		pendingDiscardMeter.Mark(1)
-		return false, ErrTxPoolFull
-	}
-	// If the transaction has the same nonce with a pending transaction, discard it
 	from, _ := types.Sender(pool.signer, tx) // already validated
-	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
-		// discard new tx, which has the same nonce with old tx
-		pendingDiscardMeter.Mark(1)
-		return false, ErrSameNonce
-	}
-	// New transaction isn't replacing a pending one, push into queue
-	replace, err := pool.enqueueTx(hash, tx)
-	if err != nil {
-		return false, err
+
+	var replace bool
+	switch {
+	case pool.cancels(from, tx, pool.pending[from]):
+		// The transaction cancels a pending one, replace it without a price bump
+		pool.replaceTx(pool.pending[from], tx)
+		pendingReplaceMeter.Mark(1)
+		cancelMeter.Mark(1)
+		go pool.txFeed.Send(NewTxsEvent{types.Transactions{tx}})
+		replace = true
+
+	case pool.cancels(from, tx, pool.queue[from]):
+		// The transaction cancels a queued one, replace it without a price bump
+		pool.replaceTx(pool.queue[from], tx)
+		queuedReplaceMeter.Mark(1)
+		cancelMeter.Mark(1)
+		replace = true
+
+	default:
+		// If the transaction pool is full, discard underpriced transactions
+		if uint64(pool.all.Count()) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
+			//discard new transaction when transaction pool is full
+			log.Trace("Discarding new transaction because transaction pool is full", "hash", hash, "price", tx.GasPrice())
+			pendingDiscardMeter.Mark(1)
+			return false, ErrTxPoolFull
+		}
+		// If the transaction has the same nonce with a pending transaction, discard it
+		if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
+			// discard new tx, which has the same nonce with old tx
+			pendingDiscardMeter.Mark(1)
+			return false, ErrSameNonce
+		}
+		// If a remote transaction takes a new slot over the quota of its sender or provider, discard it
+		if list := pool.queue[from]; !local && !pool.locals.contains(from) && (list == nil || !list.Overlaps(tx)) {
+			if err := pool.checkQuota(from, tx); err != nil {
+				log.Trace("Discarding transaction over quota", "hash", hash, "from", from, "err", err)
+				return false, err
+			}
+		}
+		// New transaction isn't replacing a pending one, push into queue
+		var err error
+		if replace, err = pool.enqueueTx(hash, tx); err != nil {
+			return false, err
+		}
 	}
 	// Mark local addresses and journal local transactions
 	if local {
@@ -797,6 +882,57 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	return replace, nil
 }
 
+// checkQuota returns an error if the sender of the transaction or the provider
+// paying for it already has its maximum number of transactions in the pool.
+func (pool *TxPool) checkQuota(from common.Address, tx *types.Transaction) error {
+	count := 0
+	if list := pool.pending[from]; list != nil {
+		count += list.Len()
+	}
+	if list := pool.queue[from]; list != nil {
+		count += list.Len()
+	}
+	if uint64(count) >= pool.config.AccountQuota {
+		accountQuotaMeter.Mark(1)
+		return ErrAccountQuotaExceeded
+	}
+	if provider, _ := types.Provider(pool.signer, tx); provider != nil && uint64(pool.all.Sponsored(*provider)) >= pool.config.ProviderQuota {
+		providerQuotaMeter.Mark(1)
+		return ErrProviderQuotaExceeded
+	}
+	return nil
+}
+
+// cancels returns whether the transaction cancels the transaction with the same
+// nonce in the list. As the gas price of the network is fixed, a transaction can
+// not be replaced by a higher priced one, instead its sender can cancel it once by
+// replacing it with a transfer of no value to itself.
+func (pool *TxPool) cancels(from common.Address, tx *types.Transaction, list *txList) bool {
+	if list == nil || !isCancellation(from, tx) {
+		return false
+	}
+	old := list.txs.Get(tx.Nonce())
+	return old != nil && !isCancellation(from, old)
+}
+
+// isCancellation returns whether the transaction sent by from is a cancellation,
+// that is a transfer of no value nor data to its own sender.
+func isCancellation(from common.Address, tx *types.Transaction) bool {
+	return tx.To() != nil && *tx.To() == from && tx.Value().Sign() == 0 && len(tx.Data()) == 0
+}
+
+// replaceTx replaces the transaction with the same nonce as tx in the list.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) replaceTx(list *txList, tx *types.Transaction) {
+	old := list.Replace(tx)
+	pool.all.Remove(old.Hash())
+	pool.priced.Removed(1)
+
+	pool.all.Add(tx)
+	pool.priced.Put(tx)
+}
+
 // enqueueTx inserts a new transaction into the non-executable transaction queue.
 //
 // Note, this method assumes the pool lock is held!
@@ -1049,12 +1185,19 @@ func (pool *TxPool) removeTx(hash common.Hash, outofbound bool) {
 	}
 }
 
+// networkGasPrice returns the gas price of the transactions of the pending block, which is set by the chain config
+// or by the governance of the chain parameters.
+func (pool *TxPool) networkGasPrice() *big.Int {
+	return governance.GasPrice(pool.chainconfig, pool.currentState)
+}
+
 // TODO: Write comments about what this function does and returns
 func (pool *TxPool) filterUnpayableTransactions(account common.Address, l *txList) (types.Transactions, types.Transactions) {
 	var (
 		filtereds, invalids types.Transactions
 		providerBalance     *big.Int
 		accountBalance      = pool.currentState.GetBalance(account)
+		gasPrice            = pool.networkGasPrice()
 		nonces              []uint64
 		hasEnoughFunds      bool
 	)
@@ -1069,7 +1212,8 @@ func (pool *TxPool) filterUnpayableTransactions(account common.Address, l *txLis
 			// sender pays for fee + value
 			hasEnoughFunds = tx.Cost().Cmp(accountBalance) <= 0
 		}
-		if !hasEnoughFunds || tx.Gas() > pool.currentMaxGas {
+		// the transactions priced before a change of the network's gas price can no longer be included
+		if !hasEnoughFunds || tx.Gas() > pool.currentMaxGas || tx.GasPrice().Cmp(gasPrice) != 0 {
 			nonces = append(nonces, nonce)
 			filtereds = append(filtereds, tx)
 		}
@@ -1299,6 +1443,7 @@ func (pool *TxPool) demoteUnexecutables() {
 		for _, tx := range olds {
 			hash := tx.Hash()
 			pool.all.Remove(hash)
+			includedAgeTimer.UpdateSince(tx.Time())
 			log.Trace("Removed old pending transaction", "hash", hash)
 		}
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
@@ -1338,6 +1483,20 @@ func (pool *TxPool) demoteUnexecutables() {
 	}
 }
 
+// oldestAge returns the time since the oldest transaction of the lists was first
+// seen.
+func oldestAge(lists map[common.Address]*txList) time.Duration {
+	var oldest time.Duration
+	for _, list := range lists {
+		for _, tx := range list.txs.items {
+			if age := time.Since(tx.Time()); age > oldest {
+				oldest = age
+			}
+		}
+	}
+	return oldest
+}
+
 // addressByHeartbeat is an account address tagged with its last activity timestamp.
 type addressByHeartbeat struct {
 	address   common.Address
@@ -1413,12 +1572,19 @@ func (as *accountSet) flatten() []common.Address {
 type txLookup struct {
 	all  map[common.Hash]*types.Transaction
 	lock sync.RWMutex
+
+	signer    types.Signer                   // Signer to derive the providers paying for the transactions
+	providers map[common.Hash]common.Address // Providers paying for the transactions, if any
+	sponsored map[common.Address]int         // Number of transactions paid for by each provider
 }
 
 // newTxLookup returns a new txLookup structure.
-func newTxLookup() *txLookup {
+func newTxLookup(signer types.Signer) *txLookup {
 	return &txLookup{
-		all: make(map[common.Hash]*types.Transaction),
+		all:       make(map[common.Hash]*types.Transaction),
+		signer:    signer,
+		providers: make(map[common.Hash]common.Address),
+		sponsored: make(map[common.Address]int),
 	}
 }
 
@@ -1450,12 +1616,29 @@ func (t *txLookup) Count() int {
 	return len(t.all)
 }
 
+// Sponsored returns the number of transactions in the lookup paid for by the
+// provider.
+func (t *txLookup) Sponsored(provider common.Address) int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.sponsored[provider]
+}
+
 // Add adds a transaction to the lookup.
 func (t *txLookup) Add(tx *types.Transaction) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
-	t.all[tx.Hash()] = tx
+	hash := tx.Hash()
+	if _, ok := t.all[hash]; ok {
+		return
+	}
+	t.all[hash] = tx
+	if provider, _ := types.Provider(t.signer, tx); provider != nil {
+		t.providers[hash] = *provider
+		t.sponsored[*provider]++
+	}
 }
 
 // Remove removes a transaction from the lookup.
@@ -1464,4 +1647,10 @@ func (t *txLookup) Remove(hash common.Hash) {
 	defer t.lock.Unlock()
 
 	delete(t.all, hash)
+	if provider, ok := t.providers[hash]; ok {
+		delete(t.providers, hash)
+		if t.sponsored[provider]--; t.sponsored[provider] == 0 {
+			delete(t.sponsored, provider)
+		}
+	}
 }
@@ -27,9 +27,11 @@ import (
 	"time"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/governance"
 	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
 	"github.com/Evrynetlabs/evrynet-node/core/state"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/event"
 	"github.com/Evrynetlabs/evrynet-node/params"
@@ -77,6 +79,13 @@ func pricedTransaction(nonce uint64, gaslimit uint64, gasprice *big.Int, key *ec
 	return tx
 }
 
+// cancellation returns a transaction cancelling the pooled transaction of the
+// sender with the given nonce.
+func cancellation(nonce uint64, key *ecdsa.PrivateKey) *types.Transaction {
+	tx, _ := types.SignTx(types.NewTransaction(nonce, crypto.PubkeyToAddress(key.PublicKey), new(big.Int), params.TxGas, big.NewInt(params.GasPriceConfig), nil), types.HomesteadSigner{}, key)
+	return tx
+}
+
 func setupTxPool() (*TxPool, *ecdsa.PrivateKey) {
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
 	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
@@ -258,6 +267,47 @@ func TestInvalidTransactions(t *testing.T) {
 	}
 }
 
+// Tests that the transactions are priced with the gas price governed on-chain, and that the ones priced before
+// a change of the gas price are dropped.
+func TestGovernedGasPrice(t *testing.T) {
+	t.Parallel()
+
+	var (
+		scAddress = common.HexToAddress("0x1")
+		config    = *params.TendermintTestChainConfig
+		newPrice  = big.NewInt(2 * params.GasPriceConfig)
+	)
+	config.Tendermint = &params.TendermintConfig{StakingSCAddress: &scAddress, GovernanceBlock: big.NewInt(0)}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+	pool := NewTxPool(testTxPoolConfig, &config, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	statedb.AddBalance(from, big.NewInt(params.Ether))
+	pool.lockedReset(nil, nil)
+	if err := pool.AddRemote(transaction(0, 100000, key)); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+
+	governance.Schedule(statedb, scAddress, governance.ParamGasPrice, newPrice, 10, 20)
+	governance.Activate(statedb, scAddress, 20)
+	pool.lockedReset(nil, nil)
+	if pending, queued := pool.Stats(); pending != 0 || queued != 0 {
+		t.Fatalf("transactions mismatch: have %d pending and %d queued, want none", pending, queued)
+	}
+	if err := pool.AddRemote(transaction(0, 100000, key)); err != ErrInvalidGasPrice {
+		t.Error("expected", ErrInvalidGasPrice, "got", err)
+	}
+	if err := pool.AddRemote(pricedTransaction(0, 100000, newPrice, key)); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 func TestTransactionQueue(t *testing.T) {
 	t.Parallel()
 
@@ -1114,6 +1164,169 @@ func TestTransactionPendingMinimumAllowance(t *testing.T) {
 	}
 }
 
+// Tests that an account can't take more remote transaction slots than its quota,
+// while its local transactions and cancellations are still accepted.
+func TestTransactionAccountQuota(t *testing.T) {
+	t.Parallel()
+
+	// Create the pool to test the quota enforcement with
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.AccountQuota = 4
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(addr, new(big.Int).Mul(big.NewInt(1000000), big.NewInt(params.GasPriceConfig)))
+
+	// Fill the quota with executable and queued transactions
+	for _, nonce := range []uint64{0, 1, 5, 6} {
+		if err := pool.AddRemote(transaction(nonce, 100000, key)); err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", nonce, err)
+		}
+	}
+	if err := pool.AddRemote(transaction(2, 100000, key)); err != ErrAccountQuotaExceeded {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrAccountQuotaExceeded)
+	}
+	// Cancellations don't take a new slot
+	if err := pool.AddRemote(cancellation(1, key)); err != nil {
+		t.Fatalf("failed to add cancellation: %v", err)
+	}
+	// Local transactions are exempt from the quota
+	if err := pool.AddLocal(transaction(2, 100000, key)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 3 || queued != 2 {
+		t.Fatalf("pool size mismatch: have %d pending %d queued, want 3 pending 2 queued", pending, queued)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that a provider can't pay for more remote transactions than its quota.
+func TestTransactionProviderQuota(t *testing.T) {
+	t.Parallel()
+
+	// Create the pool to test the quota enforcement with
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.ProviderQuota = 2
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	// Deploy an enterprise contract paid for by the provider
+	var (
+		providerKey, _ = crypto.GenerateKey()
+		provider       = crypto.PubkeyToAddress(providerKey.PublicKey)
+		owner          = common.HexToAddress("0x01")
+		contract       = common.HexToAddress("0xe1")
+		signer         = types.HomesteadSigner{}
+	)
+	pool.currentState.CreateAccount(contract, types.CreateAccountOption{OwnerAddress: &owner, ProviderAddress: &provider})
+	pool.currentState.SetCode(contract, []byte{byte(vm.STOP)})
+	pool.currentState.AddBalance(provider, new(big.Int).Mul(big.NewInt(1000000), big.NewInt(params.GasPriceConfig)))
+
+	sponsored := func(key *ecdsa.PrivateKey) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(0, contract, new(big.Int), 100000, big.NewInt(params.GasPriceConfig), nil), signer, key)
+		tx, _ = types.ProviderSignTx(tx, signer, providerKey)
+		return tx
+	}
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	for i, key := range keys[:2] {
+		if err := pool.AddRemote(sponsored(key)); err != nil {
+			t.Fatalf("tx %d: failed to add sponsored transaction: %v", i, err)
+		}
+	}
+	if have := pool.all.Sponsored(provider); have != 2 {
+		t.Fatalf("sponsored transaction count mismatch: have %d, want %d", have, 2)
+	}
+	if err := pool.AddRemote(sponsored(keys[2])); err != ErrProviderQuotaExceeded {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrProviderQuotaExceeded)
+	}
+	// Free a slot of the provider and retry
+	pool.mu.Lock()
+	pool.removeTx(pool.pending[crypto.PubkeyToAddress(keys[0].PublicKey)].Flatten()[0].Hash(), true)
+	pool.mu.Unlock()
+
+	if err := pool.AddRemote(sponsored(keys[2])); err != nil {
+		t.Fatalf("failed to add sponsored transaction: %v", err)
+	}
+	if have := pool.all.Sponsored(provider); have != 2 {
+		t.Fatalf("sponsored transaction count mismatch: have %d, want %d", have, 2)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that a pooled transaction can be replaced once by a cancellation from its
+// sender at the same gas price, and only by a cancellation.
+func TestTransactionCancellation(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(addr, new(big.Int).Mul(big.NewInt(1000000), big.NewInt(params.GasPriceConfig)))
+
+	events := make(chan NewTxsEvent, 32)
+	sub := pool.txFeed.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	// Add a pending and a queued transaction
+	if err := pool.AddRemotes([]*types.Transaction{transaction(0, 100000, key), transaction(2, 100000, key)}); err[0] != nil || err[1] != nil {
+		t.Fatalf("failed to add transactions: %v", err)
+	}
+	if err := validateEvents(events, 1); err != nil {
+		t.Fatalf("original event firing failed: %v", err)
+	}
+	// Same nonce transactions other than cancellations are rejected
+	if err := pool.AddRemote(transaction(0, 200000, key)); err != ErrSameNonce {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrSameNonce)
+	}
+	// Cancel the pending transaction and ensure it can't be replaced any more
+	cancel := cancellation(0, key)
+	if err := pool.AddRemote(cancel); err != nil {
+		t.Fatalf("failed to cancel pending transaction: %v", err)
+	}
+	if tx := pool.pending[addr].txs.Get(0); tx.Hash() != cancel.Hash() {
+		t.Fatalf("pending transaction mismatch: have %x, want %x", tx.Hash(), cancel.Hash())
+	}
+	if err := validateEvents(events, 1); err != nil {
+		t.Fatalf("cancellation event firing failed: %v", err)
+	}
+	recancel, _ := types.SignTx(types.NewTransaction(0, addr, new(big.Int), params.TxGas+1, big.NewInt(params.GasPriceConfig), nil), types.HomesteadSigner{}, key)
+	if err := pool.AddRemote(recancel); err != ErrSameNonce {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrSameNonce)
+	}
+	// Cancel the queued transaction
+	cancel = cancellation(2, key)
+	if err := pool.AddRemote(cancel); err != nil {
+		t.Fatalf("failed to cancel queued transaction: %v", err)
+	}
+	if tx := pool.queue[addr].txs.Get(2); tx.Hash() != cancel.Hash() {
+		t.Fatalf("queued transaction mismatch: have %x, want %x", tx.Hash(), cancel.Hash())
+	}
+	if pending, queued := pool.Stats(); pending != 1 || queued != 1 {
+		t.Fatalf("pool size mismatch: have %d pending %d queued, want 1 pending 1 queued", pending, queued)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that setting the transaction pool gas price to a higher value correctly
 // discards everything cheaper than that and moves any gapped transactions back
 // from the pending pool to the queue.
@@ -0,0 +1,20 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+)
+
+// InternalTx is a call, contract creation or self destruct executed by a transaction, the outermost call of the
+// transaction included. It is recorded by the trace indexer to serve the internal transaction history from disk.
+type InternalTx struct {
+	TxHash       common.Hash
+	TxIndex      uint
+	Type         string // The opcode executing the internal transaction: CALL, CREATE, SELFDESTRUCT, ...
+	From         common.Address
+	To           common.Address // The created contract or the beneficiary of a self destruct
+	Value        *big.Int
+	TraceAddress []uint64 // The indexes of the internal transaction and of its ancestors amongst their siblings
+	Error        string   // The error of the internal transaction or of a failed caller, its value transfer is reverted if any
+}
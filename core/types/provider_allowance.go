@@ -0,0 +1,64 @@
+package types
+
+import (
+	"github.com/Evrynetlabs/evrynet-node/common"
+)
+
+// ProviderAllowance limits the gas a provider of an enterprise contract pays for in a period of blocks.
+// It is configured by the owner of the contract and stored in its account.
+type ProviderAllowance struct {
+	Provider    common.Address
+	Sender      common.Address // The sender the gas is paid for, the zero address limits the gas paid for all senders
+	Period      uint64         // The number of blocks after which the allowance is renewed, 1 renews it every block
+	GasLimit    uint64         // The gas the provider pays for in a period
+	GasUsed     uint64         // The gas the provider has paid for since PeriodStart
+	PeriodStart uint64         // The first block of the period GasUsed is accounted in
+}
+
+// AppliesTo returns whether the allowance limits the gas the provider pays for the sender
+func (a ProviderAllowance) AppliesTo(provider, sender common.Address) bool {
+	return a.Provider == provider && (a.Sender == sender || a.Sender == common.Address{})
+}
+
+// periodStart returns the first block of the period of the block number
+func (a ProviderAllowance) periodStart(number uint64) uint64 {
+	if a.Period == 0 {
+		return 0
+	}
+	return number - number%a.Period
+}
+
+// Used returns the gas the provider has paid for in the period of the block number
+func (a ProviderAllowance) Used(number uint64) uint64 {
+	if a.periodStart(number) != a.PeriodStart {
+		return 0
+	}
+	return a.GasUsed
+}
+
+// Remaining returns the gas the provider can still pay for in the period of the block number
+func (a ProviderAllowance) Remaining(number uint64) uint64 {
+	used := a.Used(number)
+	if used >= a.GasLimit {
+		return 0
+	}
+	return a.GasLimit - used
+}
+
+// Use returns a copy of the allowance with gas paid for at the block number
+func (a ProviderAllowance) Use(number uint64, gas uint64) ProviderAllowance {
+	a.GasUsed = a.Used(number) + gas
+	a.PeriodStart = a.periodStart(number)
+	return a
+}
+
+// Refund returns a copy of the allowance with gas which was paid for at the block number but not used
+func (a ProviderAllowance) Refund(number uint64, gas uint64) ProviderAllowance {
+	used := a.Used(number)
+	if gas > used {
+		gas = used
+	}
+	a.GasUsed = used - gas
+	a.PeriodStart = a.periodStart(number)
+	return a
+}
@@ -16,8 +16,10 @@
 package types
 
 import (
+	"bytes"
 	"errors"
 	"io"
+	"math/big"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
@@ -40,6 +42,37 @@ var (
 	ErrInvalidTendermintHeaderExtra = errors.New("invalid tendermint header extra-data")
 )
 
+// TendermintEvidence proves that a validator signed two different votes at the same block number, round and step.
+// VoteA and VoteB are the rlp encoded signed consensus messages.
+type TendermintEvidence struct {
+	VoteA []byte
+	VoteB []byte
+}
+
+// Hash returns the hash of the evidence, it does not depend on the order of the votes.
+func (ev *TendermintEvidence) Hash() common.Hash {
+	if bytes.Compare(ev.VoteA, ev.VoteB) > 0 {
+		return rlpHash([]interface{}{ev.VoteB, ev.VoteA})
+	}
+	return rlpHash([]interface{}{ev.VoteA, ev.VoteB})
+}
+
+// TendermintAggregatedSeal is the BLS aggregated signature of the validators that committed the block
+type TendermintAggregatedSeal struct {
+	// Signers is the bitmap of the committing validators, bit i is set if the i-th validator of the set signed
+	Signers []byte
+	// Signature is the aggregate of the BLS signatures of the committed seal by the signers
+	Signature []byte
+}
+
+// TendermintReward is the part of the reward of a validator for an epoch credited to an address at the checkpoint
+// ending the epoch, the address is the owner of the validator or one of its voters
+type TendermintReward struct {
+	Address   common.Address
+	Validator common.Address
+	Amount    *big.Int
+}
+
 // TendermintExtra extra data for Tendermint consensus
 type TendermintExtra struct {
 	Seal []byte
@@ -47,15 +80,65 @@ type TendermintExtra struct {
 	CommittedSeal [][]byte
 	// Set of authorized validators at this moment
 	ValidatorAdds []byte
+	// Evidences of validators signing conflicting votes, they are processed when the block is finalized
+	Evidences []*TendermintEvidence
+	// VotingPowers of the validators in ValidatorAdds, in the same order. Empty means every validator has 1 vote.
+	VotingPowers []uint64
+	// BLSPublicKeys of the validators in ValidatorAdds, in the same order.
+	// Empty means the blocks of the epoch are committed with CommittedSeal instead of AggregatedSeal.
+	BLSPublicKeys [][]byte
+	// AggregatedSeal replaces CommittedSeal if the validators have BLS public keys
+	AggregatedSeal *TendermintAggregatedSeal
+	// ParentCommittedSeal is the CommittedSeal of the parent block chosen by the proposer. Unlike CommittedSeal it
+	// is covered by the hash, so every node counts the same validators as having committed the parent.
+	ParentCommittedSeal [][]byte
+	// ParentAggregatedSeal is the AggregatedSeal of the parent block chosen by the proposer, it replaces
+	// ParentCommittedSeal if the validators of the parent have BLS public keys
+	ParentAggregatedSeal *TendermintAggregatedSeal
+	// ValSetHash is the hash of ValidatorAdds, VotingPowers and BLSPublicKeys in a checkpoint header. Unlike the
+	// validator set it is covered by the hash, so the validator set of a committed checkpoint can be trusted.
+	ValSetHash common.Hash
+}
+
+// CalcValSetHash returns the hash of the validator set in the extra-data
+func (te *TendermintExtra) CalcValSetHash() common.Hash {
+	return rlpHash([]interface{}{te.ValidatorAdds, te.VotingPowers, te.BLSPublicKeys})
 }
 
 // EncodeRLP serializes ist into the Evrynet RLP format.
+// The optional fields are only encoded up to the last one which is set so the extra of blocks without them is unchanged.
 func (te *TendermintExtra) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, []interface{}{
+	fields := []interface{}{
 		te.Seal,
 		te.CommittedSeal,
 		te.ValidatorAdds,
-	})
+	}
+	optional := []interface{}{
+		te.Evidences,
+		te.VotingPowers,
+		te.BLSPublicKeys,
+		te.AggregatedSeal,
+		te.ParentCommittedSeal,
+		te.ParentAggregatedSeal,
+		te.ValSetHash,
+	}
+	switch {
+	case te.ValSetHash != (common.Hash{}):
+		fields = append(fields, optional[:7]...)
+	case te.ParentAggregatedSeal != nil:
+		fields = append(fields, optional[:6]...)
+	case len(te.ParentCommittedSeal) > 0:
+		fields = append(fields, optional[:5]...)
+	case te.AggregatedSeal != nil:
+		fields = append(fields, optional[:4]...)
+	case len(te.BLSPublicKeys) > 0:
+		fields = append(fields, optional[:3]...)
+	case len(te.VotingPowers) > 0:
+		fields = append(fields, optional[:2]...)
+	case len(te.Evidences) > 0:
+		fields = append(fields, optional[:1]...)
+	}
+	return rlp.Encode(w, fields)
 }
 
 // DecodeRLP implements rlp.Decoder, and load the tendermint fields from a RLP stream.
@@ -64,11 +147,35 @@ func (te *TendermintExtra) DecodeRLP(s *rlp.Stream) error {
 		Seal          []byte
 		CommittedSeal [][]byte
 		ValidatorAdds []byte
+		Optional      []rlp.RawValue `rlp:"tail"`
 	}
 	if err := s.Decode(&tendermintExtra); err != nil {
 		return err
 	}
 	te.Seal, te.CommittedSeal, te.ValidatorAdds = tendermintExtra.Seal, tendermintExtra.CommittedSeal, tendermintExtra.ValidatorAdds
+	te.Evidences, te.VotingPowers, te.BLSPublicKeys, te.AggregatedSeal = nil, nil, nil, nil
+	te.ParentCommittedSeal, te.ParentAggregatedSeal, te.ValSetHash = nil, nil, common.Hash{}
+	optional := []interface{}{
+		&te.Evidences,
+		&te.VotingPowers,
+		&te.BLSPublicKeys,
+		&te.AggregatedSeal,
+		&te.ParentCommittedSeal,
+		&te.ParentAggregatedSeal,
+		&te.ValSetHash,
+	}
+	for i, raw := range tendermintExtra.Optional {
+		if i >= len(optional) {
+			break
+		}
+		// an unset aggregated seal followed by a set field is encoded as an empty list, it stays nil
+		if bytes.Equal(raw, rlp.EmptyList) {
+			continue
+		}
+		if err := rlp.DecodeBytes(raw, optional[i]); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -103,6 +210,9 @@ func TendermintFilteredHeader(h *Header, keepSeal bool) *Header {
 	}
 	tendermintExtra.CommittedSeal = [][]byte{}
 	tendermintExtra.ValidatorAdds = []byte{}
+	tendermintExtra.VotingPowers = nil
+	tendermintExtra.BLSPublicKeys = nil
+	tendermintExtra.AggregatedSeal = nil
 
 	payload, err := rlp.EncodeToBytes(&tendermintExtra)
 	if err != nil {
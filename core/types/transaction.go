@@ -22,6 +22,7 @@ import (
 	"io"
 	"math/big"
 	"sync/atomic"
+	"time"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
@@ -43,6 +44,7 @@ type CreateAccountOption struct {
 
 type Transaction struct {
 	data txdata
+	time time.Time // Time first seen locally, for fair ordering of equally priced transactions
 	// caches
 	hash atomic.Value
 	size atomic.Value
@@ -157,7 +159,7 @@ func newTransaction(nonce uint64, to *common.Address, amount *big.Int, gasLimit
 		d.Price.Set(gasPrice)
 	}
 
-	return &Transaction{data: d}
+	return &Transaction{data: d, time: time.Now()}
 }
 
 // ChainId returns which chain id this transaction was signed for (if at all)
@@ -205,7 +207,7 @@ func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
 	err = rlp.DecodeBytes(raw, &dataWithProvider)
 
 	if err == nil {
-		tx.data = dataWithProvider
+		tx.data, tx.time = dataWithProvider, time.Now()
 		tx.size.Store(common.StorageSize(rlp.ListSize(lenStream)))
 		return nil
 	}
@@ -213,7 +215,7 @@ func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
 	var dataNormal txdataNormal
 	err = rlp.DecodeBytes(raw, &dataNormal)
 	if err == nil {
-		tx.data = dataNormal.toTxData()
+		tx.data, tx.time = dataNormal.toTxData(), time.Now()
 		// add storage for providerAddr, pv, pr, ps
 		tx.size.Store(common.StorageSize(rlp.ListSize(lenStream + 32)))
 		return nil
@@ -251,7 +253,7 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 		}
 	}
 
-	*tx = Transaction{data: dec}
+	*tx = Transaction{data: dec, time: time.Now()}
 	return nil
 }
 
@@ -262,6 +264,10 @@ func (tx *Transaction) Value() *big.Int    { return new(big.Int).Set(tx.data.Amo
 func (tx *Transaction) Nonce() uint64      { return tx.data.AccountNonce }
 func (tx *Transaction) CheckNonce() bool   { return true }
 
+// Time returns the time the transaction was first seen locally, that is the time
+// it was created or decoded.
+func (tx *Transaction) Time() time.Time { return tx.time }
+
 // To returns the recipient address of the transaction.
 // It returns nil if the transaction is a contract creation.
 func (tx *Transaction) To() *common.Address {
@@ -340,7 +346,7 @@ func (tx *Transaction) WithProviderSignature(signer Signer, sig []byte) (*Transa
 	if err != nil {
 		return nil, err
 	}
-	cpy := &Transaction{data: tx.data}
+	cpy := &Transaction{data: tx.data, time: tx.time}
 	cpy.data.PR, cpy.data.PS, cpy.data.PV = r, s, v
 	return cpy, nil
 }
@@ -381,7 +387,7 @@ func (tx *Transaction) WithSignature(signer Signer, sig []byte) (*Transaction, e
 	if err != nil {
 		return nil, err
 	}
-	cpy := &Transaction{data: tx.data}
+	cpy := &Transaction{data: tx.data, time: tx.time}
 	cpy.data.R, cpy.data.S, cpy.data.V = r, s, v
 	return cpy, nil
 }
@@ -459,11 +465,19 @@ func (s TxByNonce) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 
 // TxByPrice implements both the sort and the heap interface, making it useful
 // for all at once sorting as well as individually adding and removing elements.
+// Equally priced transactions are sorted by the time they were first seen.
 type TxByPrice Transactions
 
-func (s TxByPrice) Len() int           { return len(s) }
-func (s TxByPrice) Less(i, j int) bool { return s[i].data.Price.Cmp(s[j].data.Price) > 0 }
-func (s TxByPrice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s TxByPrice) Len() int { return len(s) }
+func (s TxByPrice) Less(i, j int) bool {
+	// If the prices are equal, the transaction seen first goes first so that
+	// transactions at the fixed gas price of the network are ordered by arrival
+	if cmp := s[i].data.Price.Cmp(s[j].data.Price); cmp != 0 {
+		return cmp > 0
+	}
+	return s[i].time.Before(s[j].time)
+}
+func (s TxByPrice) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 
 func (s *TxByPrice) Push(x interface{}) {
 	*s = append(*s, x.(*Transaction))
@@ -478,8 +492,9 @@ func (s *TxByPrice) Pop() interface{} {
 }
 
 // TransactionsByPriceAndNonce represents a set of transactions that can return
-// transactions in a profit-maximizing sorted order, while supporting removing
-// entire batches of transactions for non-executable accounts.
+// transactions in a profit-maximizing sorted order, first come first served
+// between equally priced ones, while supporting removing entire batches of
+// transactions for non-executable accounts.
 type TransactionsByPriceAndNonce struct {
 	txs    map[common.Address]Transactions // Per account nonce-sorted list of transactions
 	heads  TxByPrice                       // Next transaction for each unique account (price heap)
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/log"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+// validatorSetChangedEvent returns the event of the validator set written by the block, nil if the block is not a
+// checkpoint of the Tendermint consensus or the validators are fixed, as their checkpoints carry no validator set
+func (bc *BlockChain) validatorSetChangedEvent(block *types.Block) *ValidatorSetChangedEvent {
+	config := bc.chainConfig.Tendermint
+	if config == nil || config.Epoch == 0 || len(config.FixedValidators) > 0 {
+		return nil
+	}
+	number := block.NumberU64()
+	if number == 0 || number%config.Epoch != 0 {
+		return nil
+	}
+	validators, err := headerValidators(block.Header())
+	if err != nil || len(validators) == 0 {
+		log.Warn("Checkpoint block without validator set", "number", number, "hash", block.Hash(), "err", err)
+		return nil
+	}
+	var previous []common.Address
+	if parent := bc.GetHeaderByNumber(number - config.Epoch); parent != nil {
+		if previous, err = headerValidators(parent); err != nil {
+			log.Warn("Failed to read the previous validator set", "number", parent.Number, "err", err)
+		}
+	}
+	return &ValidatorSetChangedEvent{
+		Block:      block,
+		Epoch:      number / config.Epoch,
+		Validators: validators,
+		Added:      addressesDiff(validators, previous),
+		Removed:    addressesDiff(previous, validators),
+	}
+}
+
+// headerValidators returns the validator set written in the tendermint extra-data of the header
+func headerValidators(header *types.Header) ([]common.Address, error) {
+	extra, err := types.ExtractTendermintExtra(header)
+	if err != nil {
+		return nil, err
+	}
+	if len(extra.ValidatorAdds) == 0 {
+		return nil, nil
+	}
+	var validators []common.Address
+	if err := rlp.DecodeBytes(extra.ValidatorAdds, &validators); err != nil {
+		return nil, err
+	}
+	return validators, nil
+}
+
+// addressesDiff returns the addresses of a which are not in b, in the order of a
+func addressesDiff(a, b []common.Address) []common.Address {
+	in := make(map[common.Address]bool, len(b))
+	for _, addr := range b {
+		in[addr] = true
+	}
+	var diff []common.Address
+	for _, addr := range a {
+		if !in[addr] {
+			diff = append(diff, addr)
+		}
+	}
+	return diff
+}
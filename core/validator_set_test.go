@@ -0,0 +1,85 @@
+package core
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/ethash"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/params"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+func tendermintExtra(t *testing.T, validators []common.Address) []byte {
+	valSet, err := rlp.EncodeToBytes(validators)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := rlp.EncodeToBytes(&types.TendermintExtra{ValidatorAdds: valSet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(make([]byte, types.TendermintExtraVanity), payload...)
+}
+
+func TestValidatorSetChangedEvent(t *testing.T) {
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		config = *params.TestChainConfig
+		a, b   = common.Address{1}, common.Address{2}
+		c      = common.Address{3}
+	)
+	config.Tendermint = &params.TendermintConfig{Epoch: 2}
+	gspec := &Genesis{Config: &config, ExtraData: tendermintExtra(t, []common.Address{a, b})}
+	genesis := gspec.MustCommit(db)
+	blockchain, err := NewBlockChain(db, nil, &config, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blockchain.Stop()
+
+	events := make(chan ValidatorSetChangedEvent, 2)
+	sub := blockchain.SubscribeValidatorSetChangedEvent(events)
+	defer sub.Unsubscribe()
+
+	newBlock := func(number int64, validators []common.Address) *types.Block {
+		return types.NewBlockWithHeader(&types.Header{
+			ParentHash: genesis.Hash(),
+			Number:     big.NewInt(number),
+			Extra:      tendermintExtra(t, validators),
+		})
+	}
+	// only the checkpoint blocks post an event, the validator set is compared with the one of the genesis
+	blockchain.PostChainEvents([]interface{}{
+		ChainEvent{Block: newBlock(1, nil)},
+		ChainEvent{Block: newBlock(2, []common.Address{b, c})},
+	}, nil)
+
+	select {
+	case ev := <-events:
+		if ev.Block.NumberU64() != 2 || ev.Epoch != 1 {
+			t.Errorf("got block %d of epoch %d, want block 2 of epoch 1", ev.Block.NumberU64(), ev.Epoch)
+		}
+		if want := []common.Address{b, c}; !reflect.DeepEqual(ev.Validators, want) {
+			t.Errorf("validators mismatch: got %v, want %v", ev.Validators, want)
+		}
+		if want := []common.Address{c}; !reflect.DeepEqual(ev.Added, want) {
+			t.Errorf("added validators mismatch: got %v, want %v", ev.Added, want)
+		}
+		if want := []common.Address{a}; !reflect.DeepEqual(ev.Removed, want) {
+			t.Errorf("removed validators mismatch: got %v, want %v", ev.Removed, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("validator set changed event not posted")
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected event for block %d", ev.Block.NumberU64())
+	default:
+	}
+}
@@ -28,4 +28,5 @@ var (
 	ErrContractAddressCollision = errors.New("contract address collision")
 	ErrNoCompatibleInterpreter  = errors.New("no compatible interpreter")
 	ErrMaxProvider              = errors.New("maximum provider in contract")
+	ErrMaxProviderAllowance     = errors.New("maximum provider allowance in contract")
 )
@@ -51,6 +51,13 @@ func run(evm *EVM, contract *Contract, input []byte, readOnly bool) ([]byte, err
 		if p := precompiles[*contract.CodeAddr]; p != nil {
 			return RunPrecompiledContract(p, input, contract)
 		}
+		if p := evm.statefulPrecompile(*contract.CodeAddr); p != nil {
+			// a call from a static context is read only even if the call itself is not static
+			if in, ok := evm.interpreter.(*EVMInterpreter); ok && in.readOnly {
+				readOnly = true
+			}
+			return RunStatefulPrecompiledContract(p, evm, contract, input, readOnly)
+		}
 	}
 	for _, interpreter := range evm.interpreters {
 		if interpreter.CanRun(contract.Code) {
@@ -82,6 +89,7 @@ type Context struct {
 	// Message information
 	Origin   common.Address // Provides information for ORIGIN
 	GasPrice *big.Int       // Provides information for GASPRICE
+	GasPayer common.Address // Provides the gas payer of the transaction, its provider if sponsored or its origin
 
 	// Block information
 	Coinbase    common.Address // Provides information for COINBASE
@@ -202,7 +210,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 		if evm.ChainConfig().IsByzantium(evm.BlockNumber) {
 			precompiles = PrecompiledContractsByzantium
 		}
-		if precompiles[addr] == nil && evm.ChainConfig().IsEIP158(evm.BlockNumber) && value.Sign() == 0 {
+		if precompiles[addr] == nil && evm.statefulPrecompile(addr) == nil && evm.ChainConfig().IsEIP158(evm.BlockNumber) && value.Sign() == 0 {
 			// Calling a non existing account, don't do anything, but ping the tracer
 			if evm.vmConfig.Debug && evm.depth == 0 {
 				evm.vmConfig.Tracer.CaptureStart(caller.Address(), addr, false, input, gas, value)
@@ -468,3 +476,12 @@ func (evm *EVM) Create2(caller ContractRef, code []byte, gas uint64, endowment *
 
 // ChainConfig returns the environment's chain configuration
 func (evm *EVM) ChainConfig() *params.ChainConfig { return evm.chainConfig }
+
+// statefulPrecompile returns the stateful precompiled contract at the address, or nil if there is none at the
+// current block.
+func (evm *EVM) statefulPrecompile(addr common.Address) StatefulPrecompiledContract {
+	if !evm.ChainConfig().IsProviderManagement(evm.BlockNumber) {
+		return nil
+	}
+	return StatefulPrecompiledContractsProviderManagement[addr]
+}
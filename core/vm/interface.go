@@ -39,6 +39,14 @@ type StateDB interface {
 	SetCode(common.Address, []byte)
 	GetCodeSize(common.Address) int
 
+	GetOwner(common.Address) *common.Address
+	SetOwner(common.Address, common.Address)
+	GetProviders(common.Address) []*common.Address
+	AddProvider(common.Address, common.Address)
+	RemoveProvider(common.Address, common.Address)
+	GetProviderAllowances(common.Address) []types.ProviderAllowance
+	SetProviderAllowances(common.Address, []types.ProviderAllowance)
+
 	AddRefund(uint64)
 	SubRefund(uint64)
 	GetRefund() uint64
@@ -0,0 +1,72 @@
+package vm
+
+import (
+	"errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+// A provider group is a named set of providers shared by enterprise contracts. It is stored as an account without
+// code at an address derived from its name, whose owner manages the group and whose providers are its members.
+// A contract references a group by listing its address in its providers, the members of the group can then pay for
+// the calls to the contract, and deploy new contracts joining the group.
+
+var (
+	errEmptyGroupName            = errors.New("empty provider group name")
+	errProviderGroupAlreadyExist = errors.New("provider group already exists")
+)
+
+// ProviderGroupAddress returns the address of the provider group named name.
+func ProviderGroupAddress(name string) common.Address {
+	return common.BytesToAddress(crypto.Keccak256([]byte("evrynet.providerGroup"), []byte(name))[12:])
+}
+
+// IsProviderGroup returns whether the account at addr is a provider group.
+func IsProviderGroup(db StateDB, addr common.Address) bool {
+	owner := db.GetOwner(addr)
+	return owner != nil && (*owner != common.Address{}) && db.GetCodeSize(addr) == 0
+}
+
+// IsGroupMember returns whether the provider is a member of the provider group.
+func IsGroupMember(db StateDB, group, provider common.Address) bool {
+	return IsProviderGroup(db, group) && provider.InList(db.GetProviders(group))
+}
+
+// IsContractProvider returns whether the provider can pay for the calls to the enterprise contract, either listed in
+// its providers or member of one of its provider groups.
+func IsContractProvider(db StateDB, contract, provider common.Address) bool {
+	_, ok := ContractProvider(db, contract, provider)
+	return ok
+}
+
+// ContractProvider returns the provider listed by the enterprise contract through which the provider pays for its
+// calls, the provider itself if it is listed or else the provider group it is a member of.
+func ContractProvider(db StateDB, contract, provider common.Address) (common.Address, bool) {
+	providers := db.GetProviders(contract)
+	if provider.InList(providers) {
+		return provider, true
+	}
+	for _, p := range providers {
+		if IsGroupMember(db, *p, provider) {
+			return *p, true
+		}
+	}
+	return common.Address{}, false
+}
+
+// createProviderGroup creates the provider group named name managed by owner.
+func createProviderGroup(db StateDB, name string, owner common.Address) (common.Address, error) {
+	if name == "" {
+		return common.Address{}, errEmptyGroupName
+	}
+	group := ProviderGroupAddress(name)
+	if db.GetOwner(group) != nil || db.GetNonce(group) != 0 || db.GetCodeSize(group) != 0 {
+		return common.Address{}, errProviderGroupAlreadyExist
+	}
+	db.CreateAccount(group, types.CreateAccountOption{OwnerAddress: &owner})
+	// a non zero nonce keeps the account from being deleted as empty
+	db.SetNonce(group, 1)
+	return group, nil
+}
@@ -0,0 +1,249 @@
+package vm
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Evrynetlabs/evrynet-node/accounts/abi"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+// ProviderManagementAddress is the reserved address of the precompiled contract managing the owner and the
+// providers of enterprise contracts.
+var ProviderManagementAddress = common.HexToAddress("0x0000000000000000000000000000000000000100")
+
+// ProviderManagementABI is the ABI of the provider management contract.
+const ProviderManagementABI = `[
+	{"type":"function","name":"getOwner","constant":true,"inputs":[{"name":"contractAddr","type":"address"}],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"getProviders","constant":true,"inputs":[{"name":"contractAddr","type":"address"}],"outputs":[{"name":"","type":"address[]"}]},
+	{"type":"function","name":"addProvider","constant":false,"inputs":[{"name":"contractAddr","type":"address"},{"name":"provider","type":"address"}],"outputs":[]},
+	{"type":"function","name":"removeProvider","constant":false,"inputs":[{"name":"contractAddr","type":"address"},{"name":"provider","type":"address"}],"outputs":[]},
+	{"type":"function","name":"transferOwnership","constant":false,"inputs":[{"name":"contractAddr","type":"address"},{"name":"newOwner","type":"address"}],"outputs":[]},
+	{"type":"function","name":"getProviderAllowance","constant":true,"inputs":[{"name":"contractAddr","type":"address"},{"name":"provider","type":"address"},{"name":"sender","type":"address"}],"outputs":[{"name":"period","type":"uint64"},{"name":"gasLimit","type":"uint64"},{"name":"gasUsed","type":"uint64"}]},
+	{"type":"function","name":"setProviderAllowance","constant":false,"inputs":[{"name":"contractAddr","type":"address"},{"name":"provider","type":"address"},{"name":"sender","type":"address"},{"name":"period","type":"uint64"},{"name":"gasLimit","type":"uint64"}],"outputs":[]},
+	{"type":"function","name":"getTxGasPayer","constant":true,"inputs":[],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"getTxProvider","constant":true,"inputs":[],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"getProviderGroup","constant":true,"inputs":[{"name":"name","type":"string"}],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"createProviderGroup","constant":false,"inputs":[{"name":"name","type":"string"}],"outputs":[{"name":"","type":"address"}]}
+]`
+
+var (
+	errUnknownMethod          = errors.New("unknown provider management method")
+	errNotEnterpriseContract  = errors.New("not an enterprise contract")
+	errNotContractOwner       = errors.New("caller is not the owner of the contract")
+	errProviderAlreadyExists  = errors.New("provider already exists")
+	errProviderNotFound       = errors.New("provider not found")
+	errInvalidOwner           = errors.New("invalid owner address")
+	errDelegatedOwnerCall     = errors.New("provider management can not be delegated")
+	errValueToProviderManager = errors.New("provider management does not accept value")
+
+	providerManagementABI abi.ABI
+)
+
+func init() {
+	var err error
+	if providerManagementABI, err = abi.JSON(strings.NewReader(ProviderManagementABI)); err != nil {
+		panic(err)
+	}
+}
+
+// StatefulPrecompiledContract is a native Go contract which, unlike PrecompiledContract, has access to the state
+// and to the context of the call.
+type StatefulPrecompiledContract interface {
+	RequiredGas(input []byte) uint64                                               // RequiredGas calculates the contract gas use
+	Run(evm *EVM, contract *Contract, input []byte, readOnly bool) ([]byte, error) // Run runs the precompiled contract
+}
+
+// StatefulPrecompiledContractsProviderManagement contains the stateful pre-compiled contracts used since the
+// provider management fork.
+var StatefulPrecompiledContractsProviderManagement = map[common.Address]StatefulPrecompiledContract{
+	ProviderManagementAddress: &providerManagement{},
+}
+
+// RunStatefulPrecompiledContract runs and evaluates the output of a stateful precompiled contract.
+func RunStatefulPrecompiledContract(p StatefulPrecompiledContract, evm *EVM, contract *Contract, input []byte, readOnly bool) (ret []byte, err error) {
+	gas := p.RequiredGas(input)
+	if contract.UseGas(gas) {
+		return p.Run(evm, contract, input, readOnly)
+	}
+	return nil, ErrOutOfGas
+}
+
+// providerManagement lets the owner of an enterprise contract add and remove its providers and transfer its
+// ownership after the contract has been deployed.
+// Since the provider allowance fork, it also limits the gas the providers pay for.
+// Since the provider context fork, it also returns the gas payer and the provider of the current transaction so that
+// contracts can restrict their methods to sponsored calls.
+// Since the provider group fork, it creates provider groups, whose members are managed like the providers of a contract.
+type providerManagement struct{}
+
+func (c *providerManagement) RequiredGas(input []byte) uint64 {
+	if len(input) < 4 {
+		return params.ProviderManagementReadGas
+	}
+	method, err := providerManagementABI.MethodById(input[:4])
+	if err != nil || method.Const {
+		return params.ProviderManagementReadGas
+	}
+	return params.ProviderManagementWriteGas
+}
+
+func (c *providerManagement) Run(evm *EVM, contract *Contract, input []byte, readOnly bool) ([]byte, error) {
+	if len(input) < 4 {
+		return nil, errUnknownMethod
+	}
+	method, err := providerManagementABI.MethodById(input[:4])
+	if err != nil {
+		return nil, errUnknownMethod
+	}
+	switch method.Name {
+	case "getTxGasPayer", "getTxProvider":
+		if !evm.ChainConfig().IsProviderContext(evm.BlockNumber) {
+			return nil, errUnknownMethod
+		}
+		return method.Outputs.Pack(txProvider(evm, method.Name == "getTxProvider"))
+	case "getProviderAllowance", "setProviderAllowance":
+		if !evm.ChainConfig().IsProviderAllowance(evm.BlockNumber) {
+			return nil, errUnknownMethod
+		}
+	}
+	args, err := method.Inputs.UnpackValues(input[4:])
+	if err != nil {
+		return nil, err
+	}
+	switch method.Name {
+	case "getProviderGroup", "createProviderGroup":
+		if !evm.ChainConfig().IsProviderGroup(evm.BlockNumber) {
+			return nil, errUnknownMethod
+		}
+		name := args[0].(string)
+		if method.Const {
+			return method.Outputs.Pack(ProviderGroupAddress(name))
+		}
+		if err := checkManagementCall(contract, readOnly); err != nil {
+			return nil, err
+		}
+		group, err := createProviderGroup(evm.StateDB, name, contract.Caller())
+		if err != nil {
+			return nil, err
+		}
+		return method.Outputs.Pack(group)
+	}
+	contractAddr := args[0].(common.Address)
+	owner := evm.StateDB.GetOwner(contractAddr)
+	if method.Const {
+		switch method.Name {
+		case "getOwner":
+			if owner == nil {
+				return method.Outputs.Pack(common.Address{})
+			}
+			return method.Outputs.Pack(*owner)
+		case "getProviderAllowance":
+			provider, sender := args[1].(common.Address), args[2].(common.Address)
+			for _, allowance := range evm.StateDB.GetProviderAllowances(contractAddr) {
+				if allowance.Provider == provider && allowance.Sender == sender {
+					return method.Outputs.Pack(allowance.Period, allowance.GasLimit, allowance.Used(evm.BlockNumber.Uint64()))
+				}
+			}
+			return method.Outputs.Pack(uint64(0), uint64(0), uint64(0))
+		default:
+			providers := evm.StateDB.GetProviders(contractAddr)
+			addrs := make([]common.Address, len(providers))
+			for i, provider := range providers {
+				addrs[i] = *provider
+			}
+			return method.Outputs.Pack(addrs)
+		}
+	}
+
+	if err := checkManagementCall(contract, readOnly); err != nil {
+		return nil, err
+	}
+	if owner == nil || (*owner == common.Address{}) {
+		return nil, errNotEnterpriseContract
+	}
+	if contract.Caller() != *owner {
+		return nil, errNotContractOwner
+	}
+	addr := args[1].(common.Address)
+	switch method.Name {
+	case "addProvider":
+		providers := evm.StateDB.GetProviders(contractAddr)
+		if addr.InList(providers) {
+			return nil, errProviderAlreadyExists
+		}
+		if len(providers) >= common.MaxProvider {
+			return nil, ErrMaxProvider
+		}
+		evm.StateDB.AddProvider(contractAddr, addr)
+	case "removeProvider":
+		if !addr.InList(evm.StateDB.GetProviders(contractAddr)) {
+			return nil, errProviderNotFound
+		}
+		evm.StateDB.RemoveProvider(contractAddr, addr)
+	case "transferOwnership":
+		if (addr == common.Address{}) {
+			return nil, errInvalidOwner
+		}
+		evm.StateDB.SetOwner(contractAddr, addr)
+	case "setProviderAllowance":
+		allowance := types.ProviderAllowance{
+			Provider: addr,
+			Sender:   args[2].(common.Address),
+			Period:   args[3].(uint64),
+			GasLimit: args[4].(uint64),
+		}
+		return nil, setProviderAllowance(evm.StateDB, contractAddr, allowance)
+	}
+	return nil, nil
+}
+
+// checkManagementCall returns an error if the call to the provider management contract can not modify the state
+func checkManagementCall(contract *Contract, readOnly bool) error {
+	if readOnly {
+		return errWriteProtection
+	}
+	// the caller of a delegated call is not the one of the call to the precompiled contract
+	if contract.CodeAddr == nil || *contract.CodeAddr != contract.Address() {
+		return errDelegatedOwnerCall
+	}
+	if contract.Value().Sign() != 0 {
+		return errValueToProviderManager
+	}
+	return nil
+}
+
+// txProvider returns the gas payer of the transaction, or if sponsoredOnly its provider which is the zero address if
+// the origin pays for its gas.
+func txProvider(evm *EVM, sponsoredOnly bool) common.Address {
+	if sponsoredOnly && evm.GasPayer == evm.Origin {
+		return common.Address{}
+	}
+	return evm.GasPayer
+}
+
+// setProviderAllowance replaces the allowance of the provider for the sender, a zero period removes it. The gas the
+// provider has paid for in the current period is kept unless the period changes.
+func setProviderAllowance(db StateDB, contractAddr common.Address, allowance types.ProviderAllowance) error {
+	var (
+		prev       = db.GetProviderAllowances(contractAddr)
+		allowances = make([]types.ProviderAllowance, 0, len(prev)+1)
+	)
+	for _, a := range prev {
+		if a.Provider != allowance.Provider || a.Sender != allowance.Sender {
+			allowances = append(allowances, a)
+		} else if a.Period == allowance.Period {
+			allowance.GasUsed, allowance.PeriodStart = a.GasUsed, a.PeriodStart
+		}
+	}
+	if allowance.Period != 0 {
+		if len(allowances) >= common.MaxProviderAllowance {
+			return ErrMaxProviderAllowance
+		}
+		allowances = append(allowances, allowance)
+	}
+	db.SetProviderAllowances(contractAddr, allowances)
+	return nil
+}
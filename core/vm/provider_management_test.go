@@ -0,0 +1,327 @@
+package vm
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/state"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/params"
+)
+
+func newProviderManagementEVM(statedb StateDB, number int64) *EVM {
+	ctx := Context{
+		CanTransfer: func(db StateDB, addr common.Address, amount *big.Int) bool {
+			return db.GetBalance(addr).Cmp(amount) >= 0
+		},
+		Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+		BlockNumber: big.NewInt(number),
+	}
+	return NewEVM(ctx, statedb, params.TestChainConfig, Config{})
+}
+
+func TestProviderManagement(t *testing.T) {
+	var (
+		enterprise = common.HexToAddress("0xe1")
+		owner      = common.HexToAddress("0x01")
+		newOwner   = common.HexToAddress("0x02")
+		provider   = common.HexToAddress("0x03")
+		provider2  = common.HexToAddress("0x04")
+		gas        = uint64(1000000)
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	statedb.CreateAccount(enterprise, types.CreateAccountOption{OwnerAddress: &owner, ProviderAddress: &provider})
+	statedb.SetCode(enterprise, []byte{byte(STOP)})
+
+	evm := newProviderManagementEVM(statedb, 0)
+	call := func(caller common.Address, method string, args ...interface{}) ([]byte, error) {
+		input, err := providerManagementABI.Pack(method, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret, _, err := evm.Call(AccountRef(caller), ProviderManagementAddress, input, gas, new(big.Int))
+		return ret, err
+	}
+	checkProviders := func(want ...common.Address) {
+		ret, err := call(owner, "getProviders", enterprise)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var providers []common.Address
+		if err := providerManagementABI.Unpack(&providers, "getProviders", ret); err != nil {
+			t.Fatal(err)
+		}
+		if len(want) == 0 && len(providers) == 0 {
+			return
+		}
+		if !reflect.DeepEqual(providers, want) {
+			t.Fatalf("providers mismatch: have %v, want %v", providers, want)
+		}
+	}
+
+	if _, err := call(owner, "addProvider", enterprise, provider2); err != nil {
+		t.Fatalf("failed to add provider: %v", err)
+	}
+	checkProviders(provider, provider2)
+	if _, err := call(owner, "addProvider", enterprise, provider2); err != errProviderAlreadyExists {
+		t.Fatalf("error mismatch: have %v, want %v", err, errProviderAlreadyExists)
+	}
+	if _, err := call(provider, "removeProvider", enterprise, provider); err != errNotContractOwner {
+		t.Fatalf("error mismatch: have %v, want %v", err, errNotContractOwner)
+	}
+	if _, err := call(owner, "removeProvider", enterprise, provider); err != nil {
+		t.Fatalf("failed to remove provider: %v", err)
+	}
+	checkProviders(provider2)
+	if _, err := call(owner, "removeProvider", enterprise, provider); err != errProviderNotFound {
+		t.Fatalf("error mismatch: have %v, want %v", err, errProviderNotFound)
+	}
+
+	// a non enterprise contract can not be managed
+	if _, err := call(owner, "addProvider", newOwner, provider); err != errNotEnterpriseContract {
+		t.Fatalf("error mismatch: have %v, want %v", err, errNotEnterpriseContract)
+	}
+
+	// the ownership can not be changed by a static call
+	input, _ := providerManagementABI.Pack("transferOwnership", enterprise, newOwner)
+	if _, _, err := evm.StaticCall(AccountRef(owner), ProviderManagementAddress, input, gas); err != errWriteProtection {
+		t.Fatalf("error mismatch: have %v, want %v", err, errWriteProtection)
+	}
+	if _, err := call(owner, "transferOwnership", enterprise, common.Address{}); err != errInvalidOwner {
+		t.Fatalf("error mismatch: have %v, want %v", err, errInvalidOwner)
+	}
+	if _, err := call(owner, "transferOwnership", enterprise, newOwner); err != nil {
+		t.Fatalf("failed to transfer ownership: %v", err)
+	}
+	ret, err := call(owner, "getOwner", enterprise)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := common.BytesToAddress(ret); have != newOwner {
+		t.Fatalf("owner mismatch: have %x, want %x", have, newOwner)
+	}
+	if _, err := call(owner, "addProvider", enterprise, provider); err != errNotContractOwner {
+		t.Fatalf("error mismatch: have %v, want %v", err, errNotContractOwner)
+	}
+
+	// the providers of an enterprise contract are limited
+	for i := 1; i < common.MaxProvider; i++ {
+		if _, err := call(newOwner, "addProvider", enterprise, common.BigToAddress(big.NewInt(int64(0x100+i)))); err != nil {
+			t.Fatalf("failed to add provider %d: %v", i, err)
+		}
+	}
+	if _, err := call(newOwner, "addProvider", enterprise, provider); err != ErrMaxProvider {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrMaxProvider)
+	}
+}
+
+func TestProviderManagementFork(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	config := *params.TestChainConfig
+	config.ProviderManagementBlock = big.NewInt(10)
+
+	evm := NewEVM(Context{BlockNumber: big.NewInt(9)}, statedb, &config, Config{})
+	if p := evm.statefulPrecompile(ProviderManagementAddress); p != nil {
+		t.Fatal("provider management is enabled before its fork")
+	}
+	evm = NewEVM(Context{BlockNumber: big.NewInt(10)}, statedb, &config, Config{})
+	if p := evm.statefulPrecompile(ProviderManagementAddress); p == nil {
+		t.Fatal("provider management is disabled after its fork")
+	}
+}
+
+func TestProviderManagementAllowance(t *testing.T) {
+	var (
+		enterprise = common.HexToAddress("0xe1")
+		owner      = common.HexToAddress("0x01")
+		provider   = common.HexToAddress("0x03")
+		sender     = common.HexToAddress("0x05")
+		gas        = uint64(1000000)
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	statedb.CreateAccount(enterprise, types.CreateAccountOption{OwnerAddress: &owner, ProviderAddress: &provider})
+
+	evm := newProviderManagementEVM(statedb, 12)
+	call := func(caller common.Address, method string, args ...interface{}) ([]byte, error) {
+		input, err := providerManagementABI.Pack(method, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret, _, err := evm.Call(AccountRef(caller), ProviderManagementAddress, input, gas, new(big.Int))
+		return ret, err
+	}
+	checkAllowance := func(period, gasLimit, gasUsed uint64) {
+		ret, err := call(owner, "getProviderAllowance", enterprise, provider, sender)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var allowance struct {
+			Period   uint64
+			GasLimit uint64
+			GasUsed  uint64
+		}
+		if err := providerManagementABI.Unpack(&allowance, "getProviderAllowance", ret); err != nil {
+			t.Fatal(err)
+		}
+		if allowance.Period != period || allowance.GasLimit != gasLimit || allowance.GasUsed != gasUsed {
+			t.Fatalf("allowance mismatch: have %+v, want {%d %d %d}", allowance, period, gasLimit, gasUsed)
+		}
+	}
+
+	if _, err := call(provider, "setProviderAllowance", enterprise, provider, sender, uint64(10), uint64(50000)); err != errNotContractOwner {
+		t.Fatalf("error mismatch: have %v, want %v", err, errNotContractOwner)
+	}
+	if _, err := call(owner, "setProviderAllowance", enterprise, provider, sender, uint64(10), uint64(50000)); err != nil {
+		t.Fatalf("failed to set allowance: %v", err)
+	}
+	checkAllowance(10, 50000, 0)
+
+	// the gas used in the period is kept when the limit changes, and reset when the period changes
+	allowances := statedb.GetProviderAllowances(enterprise)
+	statedb.SetProviderAllowances(enterprise, []types.ProviderAllowance{allowances[0].Use(12, 21000)})
+	if _, err := call(owner, "setProviderAllowance", enterprise, provider, sender, uint64(10), uint64(100000)); err != nil {
+		t.Fatalf("failed to set allowance: %v", err)
+	}
+	checkAllowance(10, 100000, 21000)
+	if _, err := call(owner, "setProviderAllowance", enterprise, provider, sender, uint64(1), uint64(100000)); err != nil {
+		t.Fatalf("failed to set allowance: %v", err)
+	}
+	checkAllowance(1, 100000, 0)
+
+	// a zero period removes the allowance
+	if _, err := call(owner, "setProviderAllowance", enterprise, provider, sender, uint64(0), uint64(0)); err != nil {
+		t.Fatalf("failed to remove allowance: %v", err)
+	}
+	checkAllowance(0, 0, 0)
+	if n := len(statedb.GetProviderAllowances(enterprise)); n != 0 {
+		t.Fatalf("allowance count mismatch: have %d, want 0", n)
+	}
+
+	// the methods are unknown before the provider allowance fork
+	config := *params.TestChainConfig
+	config.ProviderAllowanceBlock = big.NewInt(13)
+	evm = NewEVM(evm.Context, statedb, &config, Config{})
+	if _, err := call(owner, "setProviderAllowance", enterprise, provider, sender, uint64(10), uint64(50000)); err != errUnknownMethod {
+		t.Fatalf("error mismatch: have %v, want %v", err, errUnknownMethod)
+	}
+	if _, err := call(owner, "getProviderAllowance", enterprise, provider, sender); err != errUnknownMethod {
+		t.Fatalf("error mismatch: have %v, want %v", err, errUnknownMethod)
+	}
+}
+
+func TestProviderManagementTxContext(t *testing.T) {
+	var (
+		origin   = common.HexToAddress("0x01")
+		provider = common.HexToAddress("0x03")
+		gas      = uint64(1000000)
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	config := *params.TestChainConfig
+	config.ProviderContextBlock = big.NewInt(10)
+
+	call := func(ctx Context, method string) (common.Address, error) {
+		input, err := providerManagementABI.Pack(method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		evm := NewEVM(ctx, statedb, &config, Config{})
+		ret, _, err := evm.StaticCall(AccountRef(origin), ProviderManagementAddress, input, gas)
+		return common.BytesToAddress(ret), err
+	}
+	tests := []struct {
+		gasPayer     common.Address
+		wantGasPayer common.Address
+		wantProvider common.Address
+	}{
+		{gasPayer: origin, wantGasPayer: origin, wantProvider: common.Address{}}, // the origin pays for its gas
+		{gasPayer: provider, wantGasPayer: provider, wantProvider: provider},     // sponsored by the provider
+	}
+	for i, tt := range tests {
+		ctx := Context{BlockNumber: big.NewInt(10), Origin: origin, GasPayer: tt.gasPayer}
+		if have, err := call(ctx, "getTxGasPayer"); err != nil || have != tt.wantGasPayer {
+			t.Errorf("test %d: gas payer mismatch: have %x (%v), want %x", i, have, err, tt.wantGasPayer)
+		}
+		if have, err := call(ctx, "getTxProvider"); err != nil || have != tt.wantProvider {
+			t.Errorf("test %d: provider mismatch: have %x (%v), want %x", i, have, err, tt.wantProvider)
+		}
+	}
+
+	// the methods are unknown before the provider context fork
+	ctx := Context{BlockNumber: big.NewInt(9), Origin: origin, GasPayer: provider}
+	if _, err := call(ctx, "getTxGasPayer"); err != errUnknownMethod {
+		t.Fatalf("error mismatch: have %v, want %v", err, errUnknownMethod)
+	}
+}
+
+func TestProviderManagementGroup(t *testing.T) {
+	var (
+		enterprise = common.HexToAddress("0xe1")
+		owner      = common.HexToAddress("0x01")
+		member     = common.HexToAddress("0x03")
+		gas        = uint64(1000000)
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	config := *params.TestChainConfig
+	config.ProviderGroupBlock = big.NewInt(10)
+
+	call := func(number int64, caller common.Address, method string, args ...interface{}) ([]byte, error) {
+		input, err := providerManagementABI.Pack(method, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := newProviderManagementEVM(statedb, number).Context
+		evm := NewEVM(ctx, statedb, &config, Config{})
+		ret, _, err := evm.Call(AccountRef(caller), ProviderManagementAddress, input, gas, new(big.Int))
+		return ret, err
+	}
+
+	// the methods are unknown before the provider group fork
+	if _, err := call(9, owner, "createProviderGroup", "customers"); err != errUnknownMethod {
+		t.Fatalf("error mismatch: have %v, want %v", err, errUnknownMethod)
+	}
+	ret, err := call(10, owner, "createProviderGroup", "customers")
+	if err != nil {
+		t.Fatalf("failed to create provider group: %v", err)
+	}
+	group := common.BytesToAddress(ret)
+	if group != ProviderGroupAddress("customers") {
+		t.Fatalf("group address mismatch: have %x, want %x", group, ProviderGroupAddress("customers"))
+	}
+	if ret, err = call(10, member, "getProviderGroup", "customers"); err != nil || common.BytesToAddress(ret) != group {
+		t.Fatalf("group address mismatch: have %x (%v), want %x", ret, err, group)
+	}
+	if have := statedb.GetOwner(group); have == nil || *have != owner {
+		t.Fatalf("group owner mismatch: have %v, want %x", have, owner)
+	}
+	if _, err := call(10, member, "createProviderGroup", "customers"); err != errProviderGroupAlreadyExist {
+		t.Fatalf("error mismatch: have %v, want %v", err, errProviderGroupAlreadyExist)
+	}
+	if _, err := call(10, member, "createProviderGroup", ""); err != errEmptyGroupName {
+		t.Fatalf("error mismatch: have %v, want %v", err, errEmptyGroupName)
+	}
+
+	// the members of the group are providers of the contracts listing it
+	statedb.CreateAccount(enterprise, types.CreateAccountOption{OwnerAddress: &owner, ProviderAddress: &group})
+	statedb.SetCode(enterprise, []byte{byte(STOP)})
+	if IsContractProvider(statedb, enterprise, member) {
+		t.Fatal("provider of the contract before joining the group")
+	}
+	if _, err := call(10, member, "addProvider", group, member); err != errNotContractOwner {
+		t.Fatalf("error mismatch: have %v, want %v", err, errNotContractOwner)
+	}
+	if _, err := call(10, owner, "addProvider", group, member); err != nil {
+		t.Fatalf("failed to add group member: %v", err)
+	}
+	if !IsContractProvider(statedb, enterprise, member) {
+		t.Fatal("member of the group is not a provider of the contract")
+	}
+	if _, err := call(10, owner, "removeProvider", group, member); err != nil {
+		t.Fatalf("failed to remove group member: %v", err)
+	}
+	if IsContractProvider(statedb, enterprise, member) {
+		t.Fatal("provider of the contract after leaving the group")
+	}
+}
@@ -29,6 +29,7 @@ func NewEnv(cfg *Config) *vm.EVM {
 		GetHash:     func(uint64) common.Hash { return common.Hash{} },
 
 		Origin:      cfg.Origin,
+		GasPayer:    cfg.Origin,
 		Coinbase:    cfg.Coinbase,
 		BlockNumber: cfg.BlockNumber,
 		Time:        cfg.Time,
@@ -0,0 +1,188 @@
+// Package bls implements BLS signatures on the BLS12-381 curve.
+//
+// Public keys are points of G1 (48 bytes compressed) and signatures are points of G2 (96 bytes compressed),
+// so that signatures of the same message can be aggregated into a single signature verified against the
+// aggregate of the signers' public keys. To prevent rogue key attacks, a public key must come with a proof of
+// possession of its secret key before it is used in an aggregate.
+package bls
+
+import (
+	"errors"
+	"math/big"
+
+	bls12381 "github.com/kilic/bls12-381"
+
+	"github.com/Evrynetlabs/evrynet-node/common/math"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+const (
+	// PublicKeyLength is the length of a compressed public key
+	PublicKeyLength = 48
+	// SignatureLength is the length of a compressed signature
+	SignatureLength = 96
+)
+
+var (
+	// signatureDST is the domain separation tag used to hash messages to G2
+	signatureDST = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+	// possessionDST is the domain separation tag used to hash public keys to G2 for the proofs of possession
+	possessionDST = []byte("BLS_POP_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+
+	// curveOrder is the order of the groups G1 and G2
+	curveOrder, _ = new(big.Int).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", 16)
+
+	// ErrInvalidSecretKey is returned if the secret key is zero or not lower than the curve order
+	ErrInvalidSecretKey = errors.New("invalid bls secret key")
+	// ErrInvalidPublicKey is returned if the public key is not a valid point of G1
+	ErrInvalidPublicKey = errors.New("invalid bls public key")
+	// ErrInvalidSignature is returned if the signature is not a valid point of G2
+	ErrInvalidSignature = errors.New("invalid bls signature")
+	// ErrEmptyAggregate is returned when aggregating an empty list of signatures or public keys
+	ErrEmptyAggregate = errors.New("nothing to aggregate")
+)
+
+// SecretKey is a BLS secret key
+type SecretKey struct {
+	scalar *big.Int
+}
+
+// NewSecretKey returns the secret key of the given 32 bytes big endian scalar
+func NewSecretKey(b []byte) (*SecretKey, error) {
+	scalar := new(big.Int).SetBytes(b)
+	if scalar.Sign() == 0 || scalar.Cmp(curveOrder) >= 0 {
+		return nil, ErrInvalidSecretKey
+	}
+	return &SecretKey{scalar: scalar}, nil
+}
+
+// DeriveSecretKey deterministically derives a secret key from the given seed, e.g. the node key,
+// so that no extra key has to be stored by the node.
+func DeriveSecretKey(seed []byte) *SecretKey {
+	var (
+		scalar  = new(big.Int)
+		counter = []byte{0}
+	)
+	for scalar.Sign() == 0 {
+		scalar.SetBytes(crypto.Keccak256([]byte("evrynet.bls.key"), seed, counter))
+		scalar.Mod(scalar, curveOrder)
+		counter[0]++
+	}
+	return &SecretKey{scalar: scalar}
+}
+
+// Bytes returns the 32 bytes big endian encoding of the secret key
+func (sk *SecretKey) Bytes() []byte {
+	return math.PaddedBigBytes(sk.scalar, 32)
+}
+
+// PublicKey returns the compressed public key of the secret key
+func (sk *SecretKey) PublicKey() []byte {
+	g1 := bls12381.NewG1()
+	return g1.ToCompressed(g1.MulScalarBig(g1.New(), g1.One(), sk.scalar))
+}
+
+// Sign returns the compressed signature of msg
+func (sk *SecretKey) Sign(msg []byte) []byte {
+	return sk.sign(msg, signatureDST)
+}
+
+// ProvePossession returns the proof that the holder of the public key knows the secret key.
+// The proof also signs the context, e.g. the account registering the key, so that it cannot be replayed in another one.
+func (sk *SecretKey) ProvePossession(context []byte) []byte {
+	return sk.sign(possessionMessage(sk.PublicKey(), context), possessionDST)
+}
+
+func (sk *SecretKey) sign(msg, dst []byte) []byte {
+	g2 := bls12381.NewG2()
+	point, err := g2.HashToCurve(msg, dst)
+	if err != nil {
+		// hashing only fails with an empty or too long domain separation tag
+		panic(err)
+	}
+	return g2.ToCompressed(g2.MulScalarBig(g2.New(), point, sk.scalar))
+}
+
+// Verify checks that sig is the signature of msg by the holder of the public key
+func Verify(publicKey, msg, sig []byte) bool {
+	return verify(publicKey, msg, sig, signatureDST)
+}
+
+// VerifyPossession checks the proof of possession of the public key in the context
+func VerifyPossession(publicKey, context, proof []byte) bool {
+	return verify(publicKey, possessionMessage(publicKey, context), proof, possessionDST)
+}
+
+func possessionMessage(publicKey, context []byte) []byte {
+	msg := make([]byte, 0, len(publicKey)+len(context))
+	return append(append(msg, publicKey...), context...)
+}
+
+// VerifyAggregate checks that sig is the aggregate of the signatures of msg by the holders of all the public keys.
+// The public keys must have been checked with VerifyPossession beforehand.
+func VerifyAggregate(publicKeys [][]byte, msg, sig []byte) bool {
+	publicKey, err := AggregatePublicKeys(publicKeys)
+	if err != nil {
+		return false
+	}
+	return Verify(publicKey, msg, sig)
+}
+
+func verify(publicKey, msg, sig, dst []byte) bool {
+	var (
+		g1 = bls12381.NewG1()
+		g2 = bls12381.NewG2()
+	)
+	pk, err := g1.FromCompressed(publicKey)
+	if err != nil || g1.IsZero(pk) {
+		return false
+	}
+	s, err := g2.FromCompressed(sig)
+	if err != nil {
+		return false
+	}
+	point, err := g2.HashToCurve(msg, dst)
+	if err != nil {
+		return false
+	}
+	// e(pk, H(msg)) == e(g1, sig)
+	return bls12381.NewEngine().AddPair(pk, point).AddPairInv(g1.One(), s).Check()
+}
+
+// AggregatePublicKeys returns the compressed sum of the public keys
+func AggregatePublicKeys(publicKeys [][]byte) ([]byte, error) {
+	if len(publicKeys) == 0 {
+		return nil, ErrEmptyAggregate
+	}
+	var (
+		g1  = bls12381.NewG1()
+		sum = g1.Zero()
+	)
+	for _, publicKey := range publicKeys {
+		pk, err := g1.FromCompressed(publicKey)
+		if err != nil {
+			return nil, ErrInvalidPublicKey
+		}
+		g1.Add(sum, sum, pk)
+	}
+	return g1.ToCompressed(sum), nil
+}
+
+// AggregateSignatures returns the compressed sum of the signatures
+func AggregateSignatures(sigs [][]byte) ([]byte, error) {
+	if len(sigs) == 0 {
+		return nil, ErrEmptyAggregate
+	}
+	var (
+		g2  = bls12381.NewG2()
+		sum = g2.Zero()
+	)
+	for _, sig := range sigs {
+		s, err := g2.FromCompressed(sig)
+		if err != nil {
+			return nil, ErrInvalidSignature
+		}
+		g2.Add(sum, sum, s)
+	}
+	return g2.ToCompressed(sum), nil
+}
@@ -0,0 +1,65 @@
+package bls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	var (
+		sk  = DeriveSecretKey([]byte("seed"))
+		msg = []byte("message")
+		sig = sk.Sign(msg)
+	)
+	require.Len(t, sk.PublicKey(), PublicKeyLength)
+	require.Len(t, sig, SignatureLength)
+	assert.True(t, Verify(sk.PublicKey(), msg, sig))
+	assert.False(t, Verify(sk.PublicKey(), []byte("another message"), sig))
+	assert.False(t, Verify(DeriveSecretKey([]byte("another seed")).PublicKey(), msg, sig))
+
+	// the secret key can be restored from its bytes
+	restored, err := NewSecretKey(sk.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, sk.PublicKey(), restored.PublicKey())
+	_, err = NewSecretKey(make([]byte, 32))
+	assert.Equal(t, ErrInvalidSecretKey, err)
+}
+
+func TestProvePossession(t *testing.T) {
+	var (
+		sk    = DeriveSecretKey([]byte("seed"))
+		other = DeriveSecretKey([]byte("another seed"))
+		proof = sk.ProvePossession([]byte("context"))
+	)
+	assert.True(t, VerifyPossession(sk.PublicKey(), []byte("context"), proof))
+	assert.False(t, VerifyPossession(other.PublicKey(), []byte("context"), proof))
+	assert.False(t, VerifyPossession(sk.PublicKey(), []byte("another context"), proof))
+	// a proof of possession is not a valid signature of the public key
+	assert.False(t, Verify(sk.PublicKey(), append(sk.PublicKey(), []byte("context")...), proof))
+}
+
+func TestVerifyAggregate(t *testing.T) {
+	var (
+		msg        = []byte("message")
+		publicKeys [][]byte
+		sigs       [][]byte
+	)
+	for _, seed := range []string{"a", "b", "c"} {
+		sk := DeriveSecretKey([]byte(seed))
+		publicKeys = append(publicKeys, sk.PublicKey())
+		sigs = append(sigs, sk.Sign(msg))
+	}
+	aggregate, err := AggregateSignatures(sigs)
+	require.NoError(t, err)
+	require.Len(t, aggregate, SignatureLength)
+	assert.True(t, VerifyAggregate(publicKeys, msg, aggregate))
+	assert.False(t, VerifyAggregate(publicKeys[:2], msg, aggregate))
+	assert.False(t, VerifyAggregate(publicKeys, []byte("another message"), aggregate))
+
+	_, err = AggregateSignatures(nil)
+	assert.Equal(t, ErrEmptyAggregate, err)
+	_, err = AggregatePublicKeys([][]byte{make([]byte, PublicKeyLength)})
+	assert.Equal(t, ErrInvalidPublicKey, err)
+}
@@ -147,6 +147,10 @@ func (b *EvrAPIBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Sub
 	return b.evr.BlockChain().SubscribeChainEvent(ch)
 }
 
+func (b *EvrAPIBackend) SubscribeValidatorSetChangedEvent(ch chan<- core.ValidatorSetChangedEvent) event.Subscription {
+	return b.evr.BlockChain().SubscribeValidatorSetChangedEvent(ch)
+}
+
 func (b *EvrAPIBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
 	return b.evr.BlockChain().SubscribeChainHeadEvent(ch)
 }
@@ -0,0 +1,168 @@
+package evr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+)
+
+// maxTraceFilterBlocks is the maximum number of blocks searched by a single
+// trace_filter request.
+const maxTraceFilterBlocks = 10000
+
+// TraceFilterArgs represents the arguments of a trace_filter request.
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`
+	FromAddress []common.Address `json:"fromAddress"` // Senders of the internal transactions, any sender if empty
+	ToAddress   []common.Address `json:"toAddress"`   // Recipients of the internal transactions, any recipient if empty
+}
+
+// RPCInternalTx is an internal transaction returned by the trace API.
+type RPCInternalTx struct {
+	Type                string         `json:"type"`
+	From                common.Address `json:"from"`
+	To                  common.Address `json:"to"`
+	Value               *hexutil.Big   `json:"value"`
+	Error               string         `json:"error,omitempty"`
+	TraceAddress        []uint64       `json:"traceAddress"`
+	BlockHash           common.Hash    `json:"blockHash"`
+	BlockNumber         hexutil.Uint64 `json:"blockNumber"`
+	TransactionHash     common.Hash    `json:"transactionHash"`
+	TransactionPosition hexutil.Uint   `json:"transactionPosition"`
+}
+
+// PrivateTraceAPI serves the internal transactions recorded by the trace indexer.
+type PrivateTraceAPI struct {
+	evr *Evrynet
+}
+
+// NewPrivateTraceAPI creates a new API definition for the trace methods of the
+// Evrynet service.
+func NewPrivateTraceAPI(evr *Evrynet) *PrivateTraceAPI {
+	return &PrivateTraceAPI{evr: evr}
+}
+
+// Filter returns the internal transactions executed by the blocks from fromBlock to
+// toBlock, sent by one of fromAddress and received by one of toAddress.
+func (api *PrivateTraceAPI) Filter(ctx context.Context, args TraceFilterArgs) ([]*RPCInternalTx, error) {
+	head := api.evr.blockchain.CurrentBlock().NumberU64()
+	from, to := head, head
+	if args.FromBlock != nil {
+		from = api.blockNumber(*args.FromBlock)
+	}
+	if args.ToBlock != nil {
+		to = api.blockNumber(*args.ToBlock)
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid block range #%d-#%d", from, to)
+	}
+	if to-from >= maxTraceFilterBlocks {
+		return nil, fmt.Errorf("block range #%d-#%d exceeds %d blocks", from, to, maxTraceFilterBlocks)
+	}
+	if err := api.checkIndexed(to); err != nil {
+		return nil, err
+	}
+	result := []*RPCInternalTx{}
+	for number := from; number <= to; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		txs, err := api.blockInternalTxs(number)
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range txs {
+			if matchAddress(tx.From, args.FromAddress) && matchAddress(tx.To, args.ToAddress) {
+				result = append(result, tx)
+			}
+		}
+	}
+	return result, nil
+}
+
+// Block returns the internal transactions executed by the block.
+func (api *PrivateTraceAPI) Block(ctx context.Context, number rpc.BlockNumber) ([]*RPCInternalTx, error) {
+	n := api.blockNumber(number)
+	if err := api.checkIndexed(n); err != nil {
+		return nil, err
+	}
+	return api.blockInternalTxs(n)
+}
+
+// blockNumber resolves the number of the block, the pending block being the
+// current one.
+func (api *PrivateTraceAPI) blockNumber(number rpc.BlockNumber) uint64 {
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		return api.evr.blockchain.CurrentBlock().NumberU64()
+	}
+	return uint64(number.Int64())
+}
+
+// checkIndexed returns an error if the block with the given number is not indexed.
+func (api *PrivateTraceAPI) checkIndexed(number uint64) error {
+	if api.evr.traceIndexer == nil {
+		return errors.New("trace index is disabled")
+	}
+	sections, _, _ := api.evr.traceIndexer.Sections()
+	if number >= sections*traceIndexSection {
+		return fmt.Errorf("block #%d is not indexed yet", number)
+	}
+	return nil
+}
+
+// blockInternalTxs returns the internal transactions recorded for the canonical
+// block with the given number.
+func (api *PrivateTraceAPI) blockInternalTxs(number uint64) ([]*RPCInternalTx, error) {
+	hash := rawdb.ReadCanonicalHash(api.evr.chainDb, number)
+	if hash == (common.Hash{}) {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	txs := rawdb.ReadInternalTxs(api.evr.chainDb, number, hash)
+	result := make([]*RPCInternalTx, len(txs))
+	for i, tx := range txs {
+		result[i] = newRPCInternalTx(tx, hash, number)
+	}
+	return result, nil
+}
+
+// newRPCInternalTx returns the internal transaction executed by the given block
+// in its RPC representation.
+func newRPCInternalTx(tx *types.InternalTx, blockHash common.Hash, blockNumber uint64) *RPCInternalTx {
+	traceAddress := tx.TraceAddress
+	if traceAddress == nil {
+		traceAddress = []uint64{}
+	}
+	return &RPCInternalTx{
+		Type:                tx.Type,
+		From:                tx.From,
+		To:                  tx.To,
+		Value:               (*hexutil.Big)(tx.Value),
+		Error:               tx.Error,
+		TraceAddress:        traceAddress,
+		BlockHash:           blockHash,
+		BlockNumber:         hexutil.Uint64(blockNumber),
+		TransactionHash:     tx.TxHash,
+		TransactionPosition: hexutil.Uint(tx.TxIndex),
+	}
+}
+
+// matchAddress returns whether addr is one of addresses, any address matching an
+// empty list.
+func matchAddress(addr common.Address, addresses []common.Address) bool {
+	if len(addresses) == 0 {
+		return true
+	}
+	for _, a := range addresses {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
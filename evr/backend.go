@@ -26,6 +26,7 @@ import (
 	"sync/atomic"
 
 	"github.com/Evrynetlabs/evrynet-node/accounts"
+	"github.com/Evrynetlabs/evrynet-node/accounts/keystore"
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
 	"github.com/Evrynetlabs/evrynet-node/consensus"
@@ -33,6 +34,8 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/consensus/ethash"
 	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
 	tendermintBackend "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/backend"
+	tendermintCore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	tmsigner "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/signer"
 	"github.com/Evrynetlabs/evrynet-node/core"
 	"github.com/Evrynetlabs/evrynet-node/core/bloombits"
 	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
@@ -83,6 +86,7 @@ type Evrynet struct {
 
 	bloomRequests chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
+	traceIndexer  *core.ChainIndexer             // Trace indexer recording the internal transactions, nil if disabled
 
 	APIBackend *EvrAPIBackend
 
@@ -111,6 +115,9 @@ func New(ctx *node.ServiceContext, config *Config) (*Evrynet, error) {
 	if !config.SyncMode.IsValid() {
 		return nil, fmt.Errorf("invalid sync mode %d", config.SyncMode)
 	}
+	if config.TraceIndex && (!config.NoPruning || config.SyncMode != downloader.FullSync) {
+		return nil, errors.New("the trace index requires an archive node (--gcmode=archive) in full sync mode")
+	}
 	if config.NoPruning && config.TrieDirtyCache > 0 {
 		config.TrieCleanCache += config.TrieDirtyCache
 		config.TrieDirtyCache = 0
@@ -182,6 +189,10 @@ func New(ctx *node.ServiceContext, config *Config) (*Evrynet, error) {
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
 	evr.bloomIndexer.Start(evr.blockchain)
+	if config.TraceIndex {
+		evr.traceIndexer = NewTraceIndexer(evr)
+		evr.traceIndexer.Start(evr.blockchain)
+	}
 
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
@@ -220,6 +231,48 @@ func makeExtraData(extra []byte) []byte {
 	return extra
 }
 
+// tendermintVotesFile is the file in the data directory recording the last vote signed by the node
+const tendermintVotesFile = "tendermint-votes.json"
+
+// createTendermintSigner creates the signer of the Tendermint consensus messages. It signs with the configured
+// keystore account or external signer, and defaults to the node key. The votes signed by the node are recorded
+// in its data directory, so that it refuses to sign conflicting votes even after a restart.
+// Whatever the signer, the BLS key is the one of the configured BLS key file, or is derived from the node key.
+func createTendermintSigner(ctx *node.ServiceContext, config *tendermint.Config) (tendermint.Signer, error) {
+	blsKey := tmsigner.DeriveBLSKey(ctx.NodeKey())
+	if config.BLSKeyFile != "" {
+		var err error
+		if blsKey, err = tmsigner.LoadBLSKey(ctx.ResolvePath(config.BLSKeyFile)); err != nil {
+			return nil, err
+		}
+	}
+	if config.ExternalSigner != "" {
+		if config.SignerAccount == nil {
+			return nil, errors.New("external signer requires a signer account")
+		}
+		log.Info("Using external Tendermint signer", "endpoint", config.ExternalSigner, "account", *config.SignerAccount)
+		return tmsigner.NewExternalSigner(config.ExternalSigner, *config.SignerAccount, blsKey)
+	}
+	guard, err := tmsigner.NewGuard(ctx.ResolvePath(tendermintVotesFile))
+	if err != nil {
+		return nil, err
+	}
+	if config.SignerAccount == nil {
+		return tmsigner.NewKeySigner(ctx.NodeKey(), blsKey, guard), nil
+	}
+	backends := ctx.AccountManager.Backends(keystore.KeyStoreType)
+	if len(backends) == 0 {
+		return nil, errors.New("keystore is not available")
+	}
+	ks := backends[0].(*keystore.KeyStore)
+	account, err := ks.Find(accounts.Account{Address: *config.SignerAccount})
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Using keystore Tendermint signer", "account", account.Address)
+	return tmsigner.NewKeystoreSigner(ks, account, blsKey, guard), nil
+}
+
 // CreateConsensusEngine creates the required type of consensus engine instance for an Evrynet service
 func CreateConsensusEngine(ctx *node.ServiceContext, chainConfig *params.ChainConfig, config *Config, notify []string, noverify bool, db evrdb.Database) consensus.Engine {
 	// If proof-of-authority is requested, set it up
@@ -233,8 +286,23 @@ func CreateConsensusEngine(ctx *node.ServiceContext, chainConfig *params.ChainCo
 		config.Tendermint.StakingSCAddress = chainConfig.Tendermint.StakingSCAddress
 		config.Tendermint.FixedValidators = chainConfig.Tendermint.FixedValidators
 		config.Tendermint.BlockReward = chainConfig.Tendermint.BlockReward
+		config.Tendermint.ValSetHashBlock = chainConfig.Tendermint.ValSetHashBlock
 		log.Info("Create Tendermint consensus engine")
-		return tendermintBackend.New(&config.Tendermint, ctx.NodeKey())
+		signer, err := createTendermintSigner(ctx, &config.Tendermint)
+		if err != nil {
+			log.Crit("Failed to create Tendermint signer", "err", err)
+		}
+		opts := []tendermintBackend.Option{tendermintBackend.WithDB(db), tendermintBackend.WithSigner(signer)}
+		if config.Tendermint.RecordFile != "" {
+			path := ctx.ResolvePath(config.Tendermint.RecordFile)
+			recorder, err := tendermintCore.NewFileRecorder(path)
+			if err != nil {
+				log.Crit("Failed to open Tendermint record file", "path", path, "err", err)
+			}
+			log.Info("Recording Tendermint consensus", "path", path)
+			opts = append(opts, tendermintBackend.WithRecorder(recorder))
+		}
+		return tendermintBackend.New(&config.Tendermint, ctx.NodeKey(), opts...)
 	}
 
 	// Otherwise assume proof-of-work
@@ -274,6 +342,14 @@ func (s *Evrynet) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
+	// Append the trace API if the internal transactions are indexed
+	if s.traceIndexer != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "trace",
+			Version:   "1.0",
+			Service:   NewPrivateTraceAPI(s),
+		})
+	}
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
 		{
@@ -544,8 +620,13 @@ func (s *Evrynet) GetPm() *ProtocolManager {
 // Evrynet protocol.
 func (s *Evrynet) Stop() error {
 	s.bloomIndexer.Close()
+	if s.traceIndexer != nil {
+		s.traceIndexer.Close()
+	}
 	s.blockchain.Stop()
-	s.engine.Close()
+	if err := s.engine.Close(); err != nil {
+		log.Error("Failed to close the consensus engine", "err", err)
+	}
 	s.protocolManager.Stop()
 	if s.lesServer != nil {
 		s.lesServer.Stop()
@@ -137,6 +137,10 @@ type Config struct {
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// Enables recording the internal transactions of the chain for the trace API,
+	// which requires an archive node synced in full sync mode
+	TraceIndex bool
+
 	// Tendermint options
 	Tendermint tendermint.Config
 
@@ -27,6 +27,7 @@ import (
 
 	ethereum "github.com/Evrynetlabs/evrynet-node"
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
 	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/event"
@@ -108,6 +109,9 @@ type Downloader struct {
 	queue      *queue   // Scheduler for selecting the hashes to download
 	peers      *peerSet // Set of active peers from which download can proceed
 
+	finality    consensus.FinalityVerifier // Verifier of the finality of the headers if blocks are final once committed
+	checkpoints map[uint64]*types.Header   // Checkpoint headers of the sync cycle whose finality is verified
+
 	stateDB    evrdb.Database  // Database to state sync into (and deduplicate via)
 	stateBloom *trie.SyncBloom // Bloom filter for fast trie node existence checks
 
@@ -210,7 +214,8 @@ type BlockChain interface {
 }
 
 // New creates a new downloader to fetch hashes and blocks from remote peers.
-func New(checkpoint uint64, stateDb evrdb.Database, stateBloom *trie.SyncBloom, mux *event.TypeMux, chain BlockChain, lightchain LightChain, dropPeer peerDropFn) *Downloader {
+// If the consensus engine has instant finality, the downloader syncs from checkpoint to checkpoint with the finality verifier.
+func New(checkpoint uint64, stateDb evrdb.Database, stateBloom *trie.SyncBloom, mux *event.TypeMux, chain BlockChain, lightchain LightChain, finality consensus.FinalityVerifier, dropPeer peerDropFn) *Downloader {
 	if lightchain == nil {
 		lightchain = chain
 	}
@@ -219,6 +224,7 @@ func New(checkpoint uint64, stateDb evrdb.Database, stateBloom *trie.SyncBloom,
 		stateBloom:     stateBloom,
 		mux:            mux,
 		checkpoint:     checkpoint,
+		finality:       finality,
 		queue:          newQueue(),
 		peers:          newPeerSet(),
 		rttEstimate:    uint64(rttMaxEstimate),
@@ -440,7 +446,12 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 	}
 	height := latest.Number.Uint64()
 
-	origin, err := d.findAncestor(p, latest)
+	var origin uint64
+	if d.finality != nil {
+		origin, err = d.findFinalAncestor(p, latest)
+	} else {
+		origin, err = d.findAncestor(p, latest)
+	}
 	if err != nil {
 		return err
 	}
@@ -458,6 +469,10 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 			origin = 0
 		} else {
 			pivot = height - uint64(fsMinFullBlocks)
+			// Anchor the state sync on the latest checkpoint, its finality is verified by the previous checkpoints
+			if d.finality != nil && pivot >= d.finality.Epoch() {
+				pivot -= pivot % d.finality.Epoch()
+			}
 			if pivot <= origin {
 				origin = pivot - 1
 			}
@@ -505,6 +520,12 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 			d.lightchain.Rollback(hashes)
 		}
 	}
+	// Jump the validator set boundaries up to the target block if blocks are final once committed
+	if d.finality != nil {
+		if err := d.fetchCheckpoints(p, origin, height); err != nil {
+			return err
+		}
+	}
 	// Initiate the sync using a concurrent header and content retrieval algorithm
 	d.queue.Prepare(origin+1, d.mode)
 	if d.syncInitHook != nil {
@@ -1466,6 +1487,18 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 					if chunk[len(chunk)-1].Number.Uint64()+uint64(fsHeaderForceVerify) > pivot {
 						frequency = 1
 					}
+					// If the finality of the chunk is proven by its checkpoint, skip the verification of the seals
+					if d.finality != nil {
+						final, err := d.verifyChunkFinality(chunk)
+						if err != nil {
+							return err
+						}
+						if final {
+							frequency = 0
+						} else {
+							frequency = 1
+						}
+					}
 					if n, err := d.lightchain.InsertHeaderChain(chunk, frequency); err != nil {
 						// If some headers were inserted, add them too to the rollback list
 						if n > 0 {
@@ -17,6 +17,7 @@
 package downloader
 
 import (
+	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"math/big"
@@ -28,8 +29,14 @@ import (
 
 	ethereum "github.com/Evrynetlabs/evrynet-node"
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	tendermintBackend "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/backend"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
 	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/event"
 	"github.com/Evrynetlabs/evrynet-node/evrdb"
 	"github.com/Evrynetlabs/evrynet-node/trie"
@@ -67,26 +74,31 @@ type downloadTester struct {
 
 // newTester creates a new downloader test mocker.
 func newTester() *downloadTester {
+	return newTesterWithGenesis(testGenesis)
+}
+
+// newTesterWithGenesis creates a new downloader test mocker whose local chain starts at the given genesis block.
+func newTesterWithGenesis(genesis *types.Block) *downloadTester {
 	tester := &downloadTester{
-		genesis:     testGenesis,
+		genesis:     genesis,
 		peerDb:      testDB,
 		peers:       make(map[string]*downloadTesterPeer),
-		ownHashes:   []common.Hash{testGenesis.Hash()},
-		ownHeaders:  map[common.Hash]*types.Header{testGenesis.Hash(): testGenesis.Header()},
-		ownBlocks:   map[common.Hash]*types.Block{testGenesis.Hash(): testGenesis},
-		ownReceipts: map[common.Hash]types.Receipts{testGenesis.Hash(): nil},
-		ownChainTd:  map[common.Hash]*big.Int{testGenesis.Hash(): testGenesis.Difficulty()},
+		ownHashes:   []common.Hash{genesis.Hash()},
+		ownHeaders:  map[common.Hash]*types.Header{genesis.Hash(): genesis.Header()},
+		ownBlocks:   map[common.Hash]*types.Block{genesis.Hash(): genesis},
+		ownReceipts: map[common.Hash]types.Receipts{genesis.Hash(): nil},
+		ownChainTd:  map[common.Hash]*big.Int{genesis.Hash(): genesis.Difficulty()},
 
 		// Initialize ancient store with test genesis block
-		ancientHeaders:  map[common.Hash]*types.Header{testGenesis.Hash(): testGenesis.Header()},
-		ancientBlocks:   map[common.Hash]*types.Block{testGenesis.Hash(): testGenesis},
-		ancientReceipts: map[common.Hash]types.Receipts{testGenesis.Hash(): nil},
-		ancientChainTd:  map[common.Hash]*big.Int{testGenesis.Hash(): testGenesis.Difficulty()},
+		ancientHeaders:  map[common.Hash]*types.Header{genesis.Hash(): genesis.Header()},
+		ancientBlocks:   map[common.Hash]*types.Block{genesis.Hash(): genesis},
+		ancientReceipts: map[common.Hash]types.Receipts{genesis.Hash(): nil},
+		ancientChainTd:  map[common.Hash]*big.Int{genesis.Hash(): genesis.Difficulty()},
 	}
 	tester.stateDb = rawdb.NewMemoryDatabase()
-	tester.stateDb.Put(testGenesis.Root().Bytes(), []byte{0x00})
+	tester.stateDb.Put(genesis.Root().Bytes(), []byte{0x00})
 
-	tester.downloader = New(0, tester.stateDb, trie.NewSyncBloom(1, tester.stateDb), new(event.TypeMux), tester, nil, tester.dropPeer)
+	tester.downloader = New(0, tester.stateDb, trie.NewSyncBloom(1, tester.stateDb), new(event.TypeMux), tester, nil, nil, tester.dropPeer)
 	return tester
 }
 
@@ -1656,3 +1668,155 @@ func testCheckpointEnforcement(t *testing.T, protocol int, mode SyncMode) {
 		assertOwnChain(t, tester, chain.len())
 	}
 }
+
+// testFinalityVerifier considers final the headers of a given chain
+type testFinalityVerifier struct {
+	epoch uint64
+	final *testChain
+
+	lock     sync.Mutex
+	verified int // Number of headers whose finality was verified
+}
+
+func (v *testFinalityVerifier) Epoch() uint64 {
+	return v.epoch
+}
+
+func (v *testFinalityVerifier) VerifyFinality(checkpoint *types.Header, header *types.Header) error {
+	v.lock.Lock()
+	v.verified++
+	v.lock.Unlock()
+
+	if number := header.Number.Uint64(); checkpoint.Number.Uint64() != (number-1)-(number-1)%v.epoch {
+		return errors.New("invalid checkpoint")
+	}
+	if _, ok := v.final.headerm[header.Hash()]; !ok {
+		return errors.New("not final")
+	}
+	return nil
+}
+
+// Tests that if blocks are final once committed, the downloader jumps from checkpoint to checkpoint
+// and only verifies the finality of a few headers.
+func TestFinalitySynchronisation64Full(t *testing.T)  { testFinalitySynchronisation(t, 64, FullSync) }
+func TestFinalitySynchronisation64Fast(t *testing.T)  { testFinalitySynchronisation(t, 64, FastSync) }
+func TestFinalitySynchronisation64Light(t *testing.T) { testFinalitySynchronisation(t, 64, LightSync) }
+
+func testFinalitySynchronisation(t *testing.T, protocol int, mode SyncMode) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	chain := testChainBase.shorten(blockCacheItems - 15)
+	verifier := &testFinalityVerifier{epoch: 16, final: chain}
+	tester.downloader.finality = verifier
+	tester.newPeer("peer", protocol, chain)
+
+	if err := tester.sync("peer", nil, mode); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, chain.len())
+
+	// Every checkpoint is verified, then the last header of every imported chunk
+	checkpoints := (chain.len() - 2) / int(verifier.epoch)
+	if mode == FullSync {
+		if verifier.verified != checkpoints {
+			t.Fatalf("verified headers mismatch: have %d, want %d", verifier.verified, checkpoints)
+		}
+	} else if verifier.verified <= checkpoints || verifier.verified >= chain.len()/2 {
+		t.Fatalf("verified headers mismatch: have %d, want between %d and %d", verifier.verified, checkpoints, chain.len()/2)
+	}
+	// Syncing again from the local head does not look for the common ancestor
+	chain = testChainBase.shorten(blockCacheItems)
+	verifier.final = chain
+	tester.newPeer("longer", protocol, chain)
+	if err := tester.sync("longer", nil, mode); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, chain.len())
+}
+
+// Tests that a peer serving headers which are not final is rejected.
+func TestFinalityConflictingChain64Fast(t *testing.T)  { testFinalityConflictingChain(t, 64, FastSync) }
+func TestFinalityConflictingChain64Light(t *testing.T) { testFinalityConflictingChain(t, 64, LightSync) }
+
+func testFinalityConflictingChain(t *testing.T, protocol int, mode SyncMode) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	tester.downloader.finality = &testFinalityVerifier{epoch: 16, final: testChainForkLightA}
+	tester.newPeer("fork", protocol, testChainForkLightB)
+
+	if err := tester.sync("fork", nil, mode); err != errInvalidChain {
+		t.Fatalf("block sync error mismatch: have %v, want %v", err, errInvalidChain)
+	}
+}
+
+// Tests that a peer replacing the validator set of a checkpoint, which is not covered by the hash of the header, can
+// not make the downloader accept headers committed by other validators.
+func TestFinalityTamperedValSet64Light(t *testing.T) { testFinalityTamperedValSet(t, 64, LightSync) }
+
+func testFinalityTamperedValSet(t *testing.T, protocol int, mode SyncMode) {
+	t.Parallel()
+
+	const epoch = 4
+	var (
+		keys       = []*ecdsa.PrivateKey{tests_utils.MakeNodeKey(), tests_utils.MakeNodeKey(), tests_utils.MakeNodeKey()}
+		forgedKeys = []*ecdsa.PrivateKey{tests_utils.MakeNodeKey(), tests_utils.MakeNodeKey(), tests_utils.MakeNodeKey()}
+		validators []common.Address
+		forged     []common.Address
+		config     = *tendermint.DefaultConfig
+	)
+	for i := range keys {
+		validators = append(validators, crypto.PubkeyToAddress(keys[i].PublicKey))
+		forged = append(forged, crypto.PubkeyToAddress(forgedKeys[i].PublicKey))
+	}
+	config.Epoch = epoch
+	config.ValSetHashBlock = common.Big1
+	genesis := types.NewBlockWithHeader(tests_utils.MakeGenesisHeader(validators))
+	headers := tests_utils.MakeCommittedHeaders(genesis.Header(), 3*epoch-2, epoch, keys)
+
+	tamper := func(write func(*types.Header) error) []*types.Header {
+		tampered := make([]*types.Header, len(headers))
+		copy(tampered, headers)
+		checkpoint := types.CopyHeader(headers[epoch-1])
+		if err := write(checkpoint); err != nil {
+			t.Fatal(err)
+		}
+		if checkpoint.Hash() != headers[epoch-1].Hash() {
+			t.Fatal("tampered checkpoint hash mismatch")
+		}
+		tampered[epoch-1] = checkpoint
+		return tampered
+	}
+	withValidators := tamper(func(h *types.Header) error { return utils.WriteValSet(h, forged) })
+	withValidators = append(withValidators[:epoch], tests_utils.MakeCommittedHeaders(withValidators[epoch-1], 2*epoch-2, epoch, forgedKeys)...)
+	withBLSKeys := tamper(func(h *types.Header) error { return utils.WriteBLSPublicKeys(h, [][]byte{{1}, {2}, {3}}) })
+
+	sync := func(headers []*types.Header) (*downloadTester, error) {
+		tester := newTesterWithGenesis(genesis)
+		tester.downloader.finality = tendermintBackend.New(&config, keys[0]).(consensus.FinalityVerifier)
+		tester.newPeer("peer", protocol, newHeaderTestChain(genesis, headers))
+		return tester, tester.sync("peer", nil, mode)
+	}
+	tester, err := sync(headers)
+	if err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, len(headers)+1)
+	tester.terminate()
+
+	for name, headers := range map[string][]*types.Header{"validators": withValidators, "bls public keys": withBLSKeys} {
+		tester, err := sync(headers)
+		if err != errInvalidChain {
+			t.Fatalf("%s: block sync error mismatch: have %v, want %v", name, err, errInvalidChain)
+		}
+		if head := tester.CurrentHeader().Number.Uint64(); head >= epoch {
+			t.Fatalf("%s: imported headers beyond the tampered checkpoint: head #%d", name, head)
+		}
+		tester.terminate()
+	}
+}
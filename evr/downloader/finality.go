@@ -0,0 +1,174 @@
+package downloader
+
+import (
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/log"
+)
+
+// requestHeadersByNumber requests count headers from the peer starting at from, skipping skip headers between each,
+// and waits for the reply
+func (d *Downloader) requestHeadersByNumber(p *peerConnection, from uint64, count, skip int) ([]*types.Header, error) {
+	go p.peer.RequestHeadersByNumber(from, count, skip, false)
+
+	ttl := d.requestTTL()
+	timeout := time.After(ttl)
+	for {
+		select {
+		case <-d.cancelCh:
+			return nil, errCanceled
+
+		case packet := <-d.headerCh:
+			// Discard anything not from the origin peer
+			if packet.PeerId() != p.id {
+				p.log.Debug("Received headers from incorrect peer", "peer", packet.PeerId())
+				break
+			}
+			// Make sure the peer's reply conforms to the request
+			headers := packet.(*headerPack).headers
+			if len(headers) == 0 || len(headers) > count {
+				p.log.Warn("Invalid number of headers", "requested", count, "received", len(headers))
+				return nil, errBadPeer
+			}
+			for i, header := range headers {
+				expectNumber := from + uint64(i)*uint64(skip+1)
+				if number := header.Number.Uint64(); number != expectNumber {
+					p.log.Warn("Headers broke chain ordering", "index", i, "requested", expectNumber, "received", number)
+					return nil, errInvalidChain
+				}
+			}
+			return headers, nil
+
+		case <-timeout:
+			p.log.Debug("Waiting for headers timed out", "elapsed", ttl)
+			return nil, errTimeout
+
+		case <-d.bodyCh:
+		case <-d.receiptCh:
+			// Out of bounds delivery, ignore
+		}
+	}
+}
+
+// findFinalAncestor is findAncestor for chains with instant finality. As committed blocks can not be reorganised,
+// the common ancestor is the local head, or the remote head if it is lower, as long as the peer has the same block.
+func (d *Downloader) findFinalAncestor(p *peerConnection, remoteHeader *types.Header) (uint64, error) {
+	var localHeight uint64
+	switch d.mode {
+	case FullSync:
+		localHeight = d.blockchain.CurrentBlock().NumberU64()
+	case FastSync:
+		localHeight = d.blockchain.CurrentFastBlock().NumberU64()
+	default:
+		localHeight = d.lightchain.CurrentHeader().Number.Uint64()
+	}
+	if remoteHeight := remoteHeader.Number.Uint64(); remoteHeight < localHeight {
+		localHeight = remoteHeight
+	}
+	// the genesis block is checked by the handshake
+	if localHeight == 0 {
+		return 0, nil
+	}
+	p.log.Debug("Checking final common ancestor", "number", localHeight)
+
+	headers, err := d.requestHeadersByNumber(p, localHeight, 1, 0)
+	if err != nil {
+		return 0, err
+	}
+	h, n := headers[0].Hash(), headers[0].Number.Uint64()
+
+	var known bool
+	switch d.mode {
+	case FullSync:
+		known = d.blockchain.HasBlock(h, n)
+	case FastSync:
+		known = d.blockchain.HasFastBlock(h, n)
+	default:
+		known = d.lightchain.HasHeader(h, n)
+	}
+	if !known {
+		p.log.Warn("Peer is on a conflicting final chain", "number", n, "hash", h)
+		return 0, errInvalidAncestor
+	}
+	p.log.Debug("Found common ancestor", "number", n, "hash", h)
+	return n, nil
+}
+
+// fetchCheckpoints retrieves the checkpoint headers from the one of the origin up to the one of the height, and
+// verifies the finality of each one against the validator set stored in the previous one. The checkpoint of the
+// origin is known locally, so the verified checkpoints can prove the finality of any header up to the height.
+// The validator set stored in a checkpoint is not covered by its hash, the engine checks it against the validator set
+// hash which is.
+func (d *Downloader) fetchCheckpoints(p *peerConnection, origin, height uint64) error {
+	d.checkpoints = make(map[uint64]*types.Header)
+	if height == 0 {
+		return nil
+	}
+	var (
+		epoch = d.finality.Epoch()
+		from  = origin - origin%epoch
+		last  = height - 1 - (height-1)%epoch // the checkpoint of the height
+	)
+	if last < from {
+		return nil
+	}
+	p.log.Debug("Retrieving checkpoint headers", "from", from, "to", last)
+
+	var checkpoint *types.Header
+	for from <= last {
+		count := int((last-from)/epoch) + 1
+		if count > MaxHeaderFetch {
+			count = MaxHeaderFetch
+		}
+		headers, err := d.requestHeadersByNumber(p, from, count, int(epoch-1))
+		if err != nil {
+			return err
+		}
+		for _, header := range headers {
+			if checkpoint == nil {
+				// the first checkpoint is the anchor of the sync, it must be known
+				if !d.lightchain.HasHeader(header.Hash(), header.Number.Uint64()) {
+					p.log.Warn("Unknown anchor checkpoint", "number", header.Number, "hash", header.Hash())
+					return errInvalidAncestor
+				}
+			} else if err := d.finality.VerifyFinality(checkpoint, header); err != nil {
+				p.log.Warn("Invalid checkpoint", "number", header.Number, "hash", header.Hash(), "err", err)
+				return errInvalidChain
+			}
+			checkpoint = header
+			d.checkpoints[header.Number.Uint64()] = header
+		}
+		from += uint64(len(headers)) * epoch
+	}
+	p.log.Debug("Verified checkpoint headers", "count", len(d.checkpoints))
+	return nil
+}
+
+// verifyChunkFinality checks that a contiguous chunk of headers is final by verifying that its last header is committed
+// by the validators of its checkpoint: as every header commits to its ancestors, the seals of the other headers need
+// no verification. It returns false if the checkpoint of the chunk has not been retrieved, the chunk must then be fully
+// verified.
+func (d *Downloader) verifyChunkFinality(chunk []*types.Header) (bool, error) {
+	last := chunk[len(chunk)-1]
+	number := last.Number.Uint64()
+	if number == 0 {
+		return false, nil
+	}
+	epoch := d.finality.Epoch()
+	checkpoint, ok := d.checkpoints[number-1-(number-1)%epoch]
+	if !ok {
+		return false, nil
+	}
+	for _, header := range chunk {
+		if known, ok := d.checkpoints[header.Number.Uint64()]; ok && known.Hash() != header.Hash() {
+			log.Debug("Header conflicts with a final checkpoint", "number", header.Number, "hash", header.Hash())
+			return false, errInvalidChain
+		}
+	}
+	if err := d.finality.VerifyFinality(checkpoint, last); err != nil {
+		log.Debug("Invalid finality proof", "number", last.Number, "hash", last.Hash(), "err", err)
+		return false, errInvalidChain
+	}
+	return true, nil
+}
@@ -75,6 +75,27 @@ func newTestChain(length int, genesis *types.Block) *testChain {
 	return tc
 }
 
+// newHeaderTestChain creates a test chain of the given headers following genesis, with empty blocks.
+func newHeaderTestChain(genesis *types.Block, headers []*types.Header) *testChain {
+	tc := new(testChain).copy(len(headers) + 1)
+	tc.genesis = genesis
+	tc.chain = append(tc.chain, genesis.Hash())
+	tc.headerm[genesis.Hash()] = genesis.Header()
+	tc.tdm[genesis.Hash()] = genesis.Difficulty()
+	tc.blockm[genesis.Hash()] = genesis
+
+	td := new(big.Int).Set(genesis.Difficulty())
+	for _, header := range headers {
+		td.Add(td, header.Difficulty)
+		hash := header.Hash()
+		tc.chain = append(tc.chain, hash)
+		tc.blockm[hash] = types.NewBlockWithHeader(header)
+		tc.headerm[hash] = header
+		tc.tdm[hash] = new(big.Int).Set(td)
+	}
+	return tc
+}
+
 // makeFork creates a fork on top of the test chain.
 func (tc *testChain) makeFork(length int, heavy bool, seed byte) *testChain {
 	fork := tc.copy(tc.len() + length)
@@ -28,6 +28,7 @@ import (
 	ethereum "github.com/Evrynetlabs/evrynet-node"
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/core"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/event"
 	"github.com/Evrynetlabs/evrynet-node/evrdb"
@@ -233,6 +234,63 @@ func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, er
 	return rpcSub, nil
 }
 
+// ValidatorSetChange is the notification of the validatorSetChanges subscription, the validator set written by a
+// checkpoint block for the epoch starting after it
+type ValidatorSetChange struct {
+	BlockHash   common.Hash      `json:"blockHash"`
+	BlockNumber hexutil.Uint64   `json:"blockNumber"`
+	Epoch       hexutil.Uint64   `json:"epoch"`
+	Validators  []common.Address `json:"validators"`
+	Added       []common.Address `json:"added"`
+	Removed     []common.Address `json:"removed"`
+}
+
+// ValidatorSetChanges send a notification each time a checkpoint block writing the validator set of the next epoch
+// is appended to the chain, with the validators added and removed since the previous epoch.
+func (api *PublicFilterAPI) ValidatorSetChanges(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		valSets := make(chan core.ValidatorSetChangedEvent)
+		valSetsSub := api.events.SubscribeValidatorSetChanges(valSets)
+
+		for {
+			select {
+			case ev := <-valSets:
+				notifier.Notify(rpcSub.ID, &ValidatorSetChange{
+					BlockHash:   ev.Block.Hash(),
+					BlockNumber: hexutil.Uint64(ev.Block.NumberU64()),
+					Epoch:       hexutil.Uint64(ev.Epoch),
+					Validators:  ev.Validators,
+					Added:       nonNilAddresses(ev.Added),
+					Removed:     nonNilAddresses(ev.Removed),
+				})
+			case <-rpcSub.Err():
+				valSetsSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				valSetsSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// nonNilAddresses returns an empty list instead of nil, so that it is encoded as [] rather than null
+func nonNilAddresses(addresses []common.Address) []common.Address {
+	if addresses == nil {
+		return []common.Address{}
+	}
+	return addresses
+}
+
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
 func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
@@ -53,6 +53,8 @@ const (
 	PendingTransactionsSubscription
 	// BlocksSubscription queries hashes for blocks that are imported
 	BlocksSubscription
+	// ValidatorSetChangesSubscription queries the validator sets written by the checkpoint blocks
+	ValidatorSetChangesSubscription
 	// LastSubscription keeps track of the last index
 	LastIndexSubscription
 )
@@ -68,6 +70,8 @@ const (
 	logsChanSize = 10
 	// chainEvChanSize is the size of channel listening to ChainEvent.
 	chainEvChanSize = 10
+	// valSetChanSize is the size of channel listening to ValidatorSetChangedEvent.
+	valSetChanSize = 10
 )
 
 var (
@@ -82,6 +86,7 @@ type subscription struct {
 	logs      chan []*types.Log
 	hashes    chan []common.Hash
 	headers   chan *types.Header
+	valSets   chan core.ValidatorSetChangedEvent
 	installed chan struct{} // closed when the filter is installed
 	err       chan error    // closed when the filter is uninstalled
 }
@@ -99,15 +104,17 @@ type EventSystem struct {
 	logsSub       event.Subscription         // Subscription for new log event
 	rmLogsSub     event.Subscription         // Subscription for removed log event
 	chainSub      event.Subscription         // Subscription for new chain event
+	valSetSub     event.Subscription         // Subscription for validator set changed event
 	pendingLogSub *event.TypeMuxSubscription // Subscription for pending log event
 
 	// Channels
-	install   chan *subscription         // install filter for event notification
-	uninstall chan *subscription         // remove filter for event notification
-	txsCh     chan core.NewTxsEvent      // Channel to receive new transactions event
-	logsCh    chan []*types.Log          // Channel to receive new log event
-	rmLogsCh  chan core.RemovedLogsEvent // Channel to receive removed log event
-	chainCh   chan core.ChainEvent       // Channel to receive new chain event
+	install   chan *subscription                 // install filter for event notification
+	uninstall chan *subscription                 // remove filter for event notification
+	txsCh     chan core.NewTxsEvent              // Channel to receive new transactions event
+	logsCh    chan []*types.Log                  // Channel to receive new log event
+	rmLogsCh  chan core.RemovedLogsEvent         // Channel to receive removed log event
+	chainCh   chan core.ChainEvent               // Channel to receive new chain event
+	valSetCh  chan core.ValidatorSetChangedEvent // Channel to receive validator set changed event
 }
 
 // NewEventSystem creates a new manager that listens for event on the given mux,
@@ -127,6 +134,7 @@ func NewEventSystem(mux *event.TypeMux, backend Backend, lightMode bool) *EventS
 		logsCh:    make(chan []*types.Log, logsChanSize),
 		rmLogsCh:  make(chan core.RemovedLogsEvent, rmLogsChanSize),
 		chainCh:   make(chan core.ChainEvent, chainEvChanSize),
+		valSetCh:  make(chan core.ValidatorSetChangedEvent, valSetChanSize),
 	}
 
 	// Subscribe events
@@ -134,12 +142,13 @@ func NewEventSystem(mux *event.TypeMux, backend Backend, lightMode bool) *EventS
 	m.logsSub = m.backend.SubscribeLogsEvent(m.logsCh)
 	m.rmLogsSub = m.backend.SubscribeRemovedLogsEvent(m.rmLogsCh)
 	m.chainSub = m.backend.SubscribeChainEvent(m.chainCh)
+	m.valSetSub = m.backend.SubscribeValidatorSetChangedEvent(m.valSetCh)
 	// TODO(rjl493456442): use feed to subscribe pending log event
 	m.pendingLogSub = m.mux.Subscribe(core.PendingLogsEvent{})
 
 	// Make sure none of the subscriptions are empty
 	if m.txsSub == nil || m.logsSub == nil || m.rmLogsSub == nil || m.chainSub == nil ||
-		m.pendingLogSub.Closed() {
+		m.valSetSub == nil || m.pendingLogSub.Closed() {
 		log.Crit("Subscribe for event system failed")
 	}
 
@@ -175,6 +184,7 @@ func (sub *Subscription) Unsubscribe() {
 			case <-sub.f.logs:
 			case <-sub.f.hashes:
 			case <-sub.f.headers:
+			case <-sub.f.valSets:
 			}
 		}
 
@@ -316,6 +326,23 @@ func (es *EventSystem) SubscribePendingTxs(hashes chan []common.Hash) *Subscript
 
 type filterIndex map[Type]map[rpc.ID]*subscription
 
+// SubscribeValidatorSetChanges creates a subscription that writes the validator sets written by the checkpoint
+// blocks inserted in the chain.
+func (es *EventSystem) SubscribeValidatorSetChanges(valSets chan core.ValidatorSetChangedEvent) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       ValidatorSetChangesSubscription,
+		created:   time.Now(),
+		logs:      make(chan []*types.Log),
+		hashes:    make(chan []common.Hash),
+		headers:   make(chan *types.Header),
+		valSets:   valSets,
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
 // broadcast event to filters that match criteria.
 func (es *EventSystem) broadcast(filters filterIndex, ev interface{}) {
 	if ev == nil {
@@ -368,6 +395,10 @@ func (es *EventSystem) broadcast(filters filterIndex, ev interface{}) {
 				}
 			})
 		}
+	case core.ValidatorSetChangedEvent:
+		for _, f := range filters[ValidatorSetChangesSubscription] {
+			f.valSets <- e
+		}
 	}
 }
 
@@ -453,6 +484,7 @@ func (es *EventSystem) eventLoop() {
 		es.logsSub.Unsubscribe()
 		es.rmLogsSub.Unsubscribe()
 		es.chainSub.Unsubscribe()
+		es.valSetSub.Unsubscribe()
 	}()
 
 	index := make(filterIndex)
@@ -471,6 +503,8 @@ func (es *EventSystem) eventLoop() {
 			es.broadcast(index, ev)
 		case ev := <-es.chainCh:
 			es.broadcast(index, ev)
+		case ev := <-es.valSetCh:
+			es.broadcast(index, ev)
 		case ev, active := <-es.pendingLogSub.Chan():
 			if !active { // system stopped
 				return
@@ -506,6 +540,8 @@ func (es *EventSystem) eventLoop() {
 			return
 		case <-es.chainSub.Err():
 			return
+		case <-es.valSetSub.Err():
+			return
 		}
 	}
 }
@@ -23,6 +23,7 @@ import (
 	"sync"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/governance"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/internal/evrapi"
 	"github.com/Evrynetlabs/evrynet-node/params"
@@ -71,9 +72,14 @@ func NewOracle(backend evrapi.Backend, params Config) *Oracle {
 	}
 }
 
-// SuggestPrice returns the recommended gas price.
+// SuggestPrice returns the recommended gas price, which is the gas price of the network set by the chain config
+// or by the governance of the chain parameters.
 func (gpo *Oracle) SuggestPrice(ctx context.Context) (*big.Int, error) {
-	return gpo.fixedGasPrice, nil
+	state, _, err := gpo.backend.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if state == nil || err != nil {
+		return gpo.fixedGasPrice, nil
+	}
+	return governance.GasPrice(gpo.backend.ChainConfig(), state), nil
 }
 
 type getBlockPricesResult struct {
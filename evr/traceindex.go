@@ -0,0 +1,119 @@
+package evr
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/evr/tracers"
+	"github.com/Evrynetlabs/evrynet-node/evrdb"
+)
+
+const (
+	// traceIndexSection is the number of blocks in a section of the trace index.
+	// Every block is its own section so it is served as soon as it is indexed.
+	traceIndexSection = 1
+
+	// traceIndexConfirms is the number of confirmation blocks before a block is
+	// indexed, reorgs are rolled back by the chain indexer.
+	traceIndexConfirms = 0
+
+	// traceIndexThrottling is the time to wait between indexing two consecutive
+	// blocks.
+	traceIndexThrottling = time.Duration(0)
+)
+
+// TraceIndexer implements a core.ChainIndexer, recording the internal transactions
+// executed by the blocks of the canonical chain to serve the trace API from disk.
+// The blocks are executed on the state of their parent, which is only available
+// for every block of an archive node synced in full sync mode.
+type TraceIndexer struct {
+	evr   *Evrynet
+	batch evrdb.Batch // Batch of the internal transactions of the section being processed
+}
+
+// NewTraceIndexer returns a chain indexer that records the internal transactions
+// of the canonical chain.
+func NewTraceIndexer(evr *Evrynet) *core.ChainIndexer {
+	backend := &TraceIndexer{
+		evr: evr,
+	}
+	table := rawdb.NewTable(evr.chainDb, string(rawdb.TraceIndexPrefix))
+
+	return core.NewChainIndexer(evr.chainDb, table, backend, traceIndexSection, traceIndexConfirms, traceIndexThrottling, "traces")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new trace index section.
+func (t *TraceIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	t.batch = t.evr.chainDb.NewBatch()
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, executing a new block to record its
+// internal transactions.
+func (t *TraceIndexer) Process(ctx context.Context, header *types.Header) error {
+	number, hash := header.Number.Uint64(), header.Hash()
+	block := t.evr.blockchain.GetBlock(hash, number)
+	if block == nil {
+		return fmt.Errorf("block #%d [%x…] not found", number, hash[:4])
+	}
+	txs, err := t.internalTxs(block)
+	if err != nil {
+		return err
+	}
+	rawdb.WriteInternalTxs(t.batch, number, hash, txs)
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, writing the internal transactions of
+// the section out into the database.
+func (t *TraceIndexer) Commit() error {
+	return t.batch.Write()
+}
+
+// internalTxs executes the transactions of the block on top of the state of its
+// parent and returns the internal transactions they executed.
+func (t *TraceIndexer) internalTxs(block *types.Block) ([]*types.InternalTx, error) {
+	if len(block.Transactions()) == 0 {
+		return nil, nil
+	}
+	parent := t.evr.blockchain.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %#x not found", block.ParentHash())
+	}
+	statedb, err := t.evr.blockchain.StateAt(parent.Root)
+	if err != nil {
+		return nil, fmt.Errorf("state of parent %#x not available: %v", block.ParentHash(), err)
+	}
+	var (
+		config = t.evr.blockchain.Config()
+		signer = types.MakeSigner(config, block.Number())
+		result []*types.InternalTx
+	)
+	for i, tx := range block.Transactions() {
+		msg, err := tx.AsMessage(signer)
+		if err != nil {
+			return nil, err
+		}
+		vmctx := core.NewEVMContext(msg, block.Header(), t.evr.blockchain, nil)
+		tracer := tracers.NewCallTracer(vmctx, statedb)
+		vmenv := vm.NewEVM(vmctx, statedb, config, vm.Config{Debug: true, Tracer: tracer})
+		if _, _, _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas())); err != nil {
+			return nil, fmt.Errorf("tracing transaction %#x failed: %v", tx.Hash(), err)
+		}
+		// Only delete empty objects if EIP158/161 (a.k.a Spurious Dragon) is in effect
+		statedb.Finalise(config.IsEIP158(block.Number()))
+
+		txs, err := tracer.InternalTxs(tx.Hash(), uint(i))
+		if err != nil {
+			return nil, fmt.Errorf("tracing transaction %#x failed: %v", tx.Hash(), err)
+		}
+		result = append(result, txs...)
+	}
+	return result, nil
+}
@@ -0,0 +1,155 @@
+package evr
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/consensus/ethash"
+	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+)
+
+// forwardCode returns the code of a wallet forwarding the value it receives to
+// recipient, reverting afterwards if revert is set.
+func forwardCode(recipient common.Address, revert bool) []byte {
+	code := []byte{
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.CALLVALUE), byte(vm.PUSH20),
+	}
+	code = append(code, recipient.Bytes()...)
+	code = append(code, byte(vm.GAS), byte(vm.CALL))
+	if revert {
+		return append(code, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT))
+	}
+	return append(code, byte(vm.STOP))
+}
+
+// Tests that the trace indexer records the internal transactions of the chain and
+// serves them through the trace API.
+func TestTraceIndexer(t *testing.T) {
+	var (
+		key, _    = crypto.GenerateKey()
+		sender    = crypto.PubkeyToAddress(key.PublicKey)
+		exchange  = common.HexToAddress("0xe0")
+		wallet    = common.HexToAddress("0xa1")
+		reverting = common.HexToAddress("0xa2")
+		destruct  = common.HexToAddress("0xa3")
+		signer    = types.HomesteadSigner{}
+		db        = rawdb.NewMemoryDatabase()
+		gspec     = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				sender:    {Balance: big.NewInt(params.Ether)},
+				wallet:    {Balance: new(big.Int), Code: forwardCode(exchange, false)},
+				reverting: {Balance: new(big.Int), Code: forwardCode(exchange, true)},
+				destruct:  {Balance: big.NewInt(500), Code: append([]byte{byte(vm.PUSH20)}, append(exchange.Bytes(), byte(vm.SELFDESTRUCT))...)},
+			},
+		}
+		genesis = gspec.MustCommit(db)
+	)
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 3, func(i int, gen *core.BlockGen) {
+		if i != 0 {
+			return
+		}
+		for _, to := range []common.Address{wallet, reverting, destruct} {
+			tx, err := types.SignTx(types.NewTransaction(gen.TxNonce(sender), to, big.NewInt(1000), 100000, gspec.Config.GasPrice, nil), signer, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gen.AddTx(tx)
+		}
+	})
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	evr := &Evrynet{chainDb: db, blockchain: chain}
+	api := NewPrivateTraceAPI(evr)
+	if _, err := api.Block(context.Background(), 1); err == nil {
+		t.Fatal("trace API served with the trace index disabled")
+	}
+	evr.traceIndexer = NewTraceIndexer(evr)
+	evr.traceIndexer.Start(chain)
+	defer evr.traceIndexer.Close()
+
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if sections, _, _ := evr.traceIndexer.Sections(); sections == uint64(len(blocks)+1) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("blocks not indexed in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	txs, err := api.Block(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("failed to retrieve internal transactions: %v", err)
+	}
+	want := []struct {
+		typ          string
+		from, to     common.Address
+		value        int64
+		traceAddress []uint64
+		failed       bool
+	}{
+		{vm.CALL.String(), sender, wallet, 1000, []uint64{}, false},
+		{vm.CALL.String(), wallet, exchange, 1000, []uint64{0}, false},
+		{vm.CALL.String(), sender, reverting, 1000, []uint64{}, true},
+		{vm.CALL.String(), reverting, exchange, 1000, []uint64{0}, true},
+		{vm.CALL.String(), sender, destruct, 1000, []uint64{}, false},
+		{vm.OpCode(vm.SELFDESTRUCT).String(), destruct, exchange, 1500, []uint64{0}, false},
+	}
+	if len(txs) != len(want) {
+		t.Fatalf("internal transaction count mismatch: have %d, want %d", len(txs), len(want))
+	}
+	for i, tx := range txs {
+		w := want[i]
+		if tx.Type != w.typ || tx.From != w.from || tx.To != w.to || tx.Value.ToInt().Int64() != w.value || (tx.Error != "") != w.failed {
+			t.Errorf("internal transaction %d mismatch: have %s %x->%x %v (%q), want %s %x->%x %d (failed %v)",
+				i, tx.Type, tx.From, tx.To, tx.Value, tx.Error, w.typ, w.from, w.to, w.value, w.failed)
+		}
+		if len(tx.TraceAddress) != len(w.traceAddress) {
+			t.Errorf("internal transaction %d trace address mismatch: have %v, want %v", i, tx.TraceAddress, w.traceAddress)
+		}
+		if tx.BlockHash != blocks[0].Hash() || tx.TransactionHash != blocks[0].Transactions()[tx.TransactionPosition].Hash() {
+			t.Errorf("internal transaction %d location mismatch", i)
+		}
+	}
+
+	// Filter the deposits to the exchange made by smart contract wallets
+	from, to := rpc.BlockNumber(0), rpc.LatestBlockNumber
+	deposits, err := api.Filter(context.Background(), TraceFilterArgs{FromBlock: &from, ToBlock: &to, ToAddress: []common.Address{exchange}})
+	if err != nil {
+		t.Fatalf("failed to filter internal transactions: %v", err)
+	}
+	if len(deposits) != 3 || deposits[0].From != wallet || deposits[1].From != reverting || deposits[2].From != destruct {
+		t.Fatalf("deposits mismatch: have %v", deposits)
+	}
+	// the deposit of the reverted wallet is reverted along with it
+	if deposits[0].Error != "" || deposits[1].Error == "" || deposits[2].Error != "" {
+		t.Fatalf("deposit errors mismatch: have %q, %q and %q", deposits[0].Error, deposits[1].Error, deposits[2].Error)
+	}
+	deposits, err = api.Filter(context.Background(), TraceFilterArgs{FromBlock: &from, ToBlock: &to, FromAddress: []common.Address{wallet}, ToAddress: []common.Address{exchange}})
+	if err != nil {
+		t.Fatalf("failed to filter internal transactions: %v", err)
+	}
+	if len(deposits) != 1 || deposits[0].From != wallet {
+		t.Fatalf("deposits mismatch: have %v", deposits)
+	}
+	beyond := rpc.BlockNumber(len(blocks) + 1)
+	if _, err := api.Filter(context.Background(), TraceFilterArgs{FromBlock: &from, ToBlock: &beyond}); err == nil {
+		t.Fatal("served internal transactions of blocks not indexed")
+	}
+}
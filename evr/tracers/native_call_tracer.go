@@ -0,0 +1,376 @@
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+)
+
+// callFrame is a call reported by the call tracers, its fields are ordered and
+// formatted as in the result of the JavaScript callTracer.
+type callFrame struct {
+	Type     string       `json:"type"`
+	From     string       `json:"from,omitempty"`
+	To       string       `json:"to,omitempty"`
+	GasPayer string       `json:"gasPayer,omitempty"`
+	Provider string       `json:"provider,omitempty"`
+	Value    string       `json:"value,omitempty"`
+	Gas      string       `json:"gas,omitempty"`
+	GasUsed  string       `json:"gasUsed,omitempty"`
+	Input    string       `json:"input,omitempty"`
+	Output   string       `json:"output,omitempty"`
+	Error    string       `json:"error,omitempty"`
+	Time     string       `json:"time,omitempty"`
+	Calls    []*callFrame `json:"calls,omitempty"`
+
+	gasIn   uint64 // Gas available to the calling opcode
+	gasCost uint64 // Cost of the calling opcode
+	gas     uint64 // Gas available within the call
+	hasGas  bool   // Flag whether the gas available within the call is known
+	outOff  uint64 // Memory offset of the call output
+	outLen  uint64 // Memory size of the call output
+
+	// The accounts and value of the call, which are also known for the self
+	// destructs unlike in the result of the JavaScript callTracer
+	from  common.Address
+	to    common.Address
+	value *big.Int // Value transferred by the call, nil if it transfers none
+}
+
+// CallTracer is the native implementation of the JavaScript callTracer, it
+// reports all the internal calls made by a transaction. Transactions paid by a
+// provider are annotated with their gas payer and the provider listed by the
+// contract, which is the provider group of the gas payer if it pays through one.
+type CallTracer struct {
+	ctx     vm.Context
+	statedb vm.StateDB
+
+	callstack []*callFrame // Current recursive call stack of the EVM execution
+	descended bool         // Flag whether we've just descended into an inner call
+
+	create  bool
+	from    common.Address
+	to      common.Address
+	input   []byte
+	gas     uint64
+	value   *big.Int
+	output  []byte
+	gasUsed uint64
+	time    time.Duration
+	callErr error
+
+	gasPayer common.Address // Provider paying for the transaction, zero if the origin pays
+	provider common.Address // Provider listed by the contract the gas payer pays through
+
+	err       error  // Error, if one has occurred
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+func newCallTracer(ctx vm.Context, statedb vm.StateDB) TxTracer {
+	return NewCallTracer(ctx, statedb)
+}
+
+// NewCallTracer creates a native call tracer for a transaction executed within
+// the given EVM context on top of statedb.
+func NewCallTracer(ctx vm.Context, statedb vm.StateDB) *CallTracer {
+	return &CallTracer{ctx: ctx, statedb: statedb, callstack: []*callFrame{{}}}
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *CallTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (t *CallTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.create, t.from, t.to, t.input, t.gas, t.value = create, from, to, input, gas, value
+
+	// The providers of a created contract are already set when the tracing starts
+	if t.ctx.GasPayer != (common.Address{}) && t.ctx.GasPayer != t.ctx.Origin {
+		t.gasPayer = t.ctx.GasPayer
+		if provider, ok := vm.ContractProvider(t.statedb, to, t.gasPayer); ok {
+			t.provider = provider
+		}
+	}
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM execution.
+func (t *CallTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.err != nil {
+		return nil
+	}
+	// If tracing was interrupted, set the error and stop
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		t.err = t.reason
+		return nil
+	}
+	if err != nil {
+		t.fault(err)
+		return nil
+	}
+	switch op {
+	case vm.CREATE, vm.CREATE2:
+		// If a new contract is being created, add to the call stack
+		inOff := stack.Back(1).Uint64()
+		t.callstack = append(t.callstack, &callFrame{
+			Type:    op.String(),
+			From:    addressHex(contract.Address()),
+			Input:   hexutil.Encode(memorySlice(memory, inOff, inOff+stack.Back(2).Uint64())),
+			Value:   hexutil.EncodeBig(stack.Back(0)),
+			gasIn:   gas,
+			gasCost: cost,
+			from:    contract.Address(),
+			value:   new(big.Int).Set(stack.Back(0)),
+		})
+		t.descended = true
+		return nil
+
+	case vm.SELFDESTRUCT:
+		// If a contract is being self destructed, gather that as a subcall too
+		top := t.callstack[len(t.callstack)-1]
+		top.Calls = append(top.Calls, &callFrame{
+			Type:  op.String(),
+			from:  contract.Address(),
+			to:    common.BigToAddress(stack.Back(0)),
+			value: new(big.Int).Set(env.StateDB.GetBalance(contract.Address())),
+		})
+		return nil
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		// Skip any pre-compile invocations, those are just fancy opcodes
+		to := common.BigToAddress(stack.Back(1))
+		if _, ok := vm.PrecompiledContractsByzantium[to]; ok {
+			return nil
+		}
+		off := 1
+		if op == vm.DELEGATECALL || op == vm.STATICCALL {
+			off = 0
+		}
+		inOff := stack.Back(2 + off).Uint64()
+		call := &callFrame{
+			Type:    op.String(),
+			From:    addressHex(contract.Address()),
+			To:      addressHex(to),
+			Input:   hexutil.Encode(memorySlice(memory, inOff, inOff+stack.Back(3+off).Uint64())),
+			gasIn:   gas,
+			gasCost: cost,
+			outOff:  stack.Back(4 + off).Uint64(),
+			outLen:  stack.Back(5 + off).Uint64(),
+			from:    contract.Address(),
+			to:      to,
+		}
+		if off == 1 {
+			call.Value = hexutil.EncodeBig(stack.Back(2))
+			call.value = new(big.Int).Set(stack.Back(2))
+		}
+		t.callstack = append(t.callstack, call)
+		t.descended = true
+		return nil
+	}
+	// If we've just descended into an inner call, retrieve its true allowance. The
+	// gas available within a call to a plain account is unknown and skipped.
+	if t.descended {
+		if depth >= len(t.callstack) {
+			t.callstack[len(t.callstack)-1].gas = gas
+			t.callstack[len(t.callstack)-1].hasGas = true
+		}
+		t.descended = false
+	}
+	if op == vm.REVERT {
+		t.callstack[len(t.callstack)-1].Error = "execution reverted"
+		return nil
+	}
+	if depth != len(t.callstack)-1 {
+		return nil
+	}
+	// An inner call is returning, pop it off the call stack and get its results
+	call := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+
+	if call.Type == vm.CREATE.String() || call.Type == vm.CREATE2.String() {
+		call.GasUsed = hexutil.EncodeUint64(call.gasIn - call.gasCost - gas)
+
+		if ret := stack.Back(0); ret.Sign() != 0 {
+			call.to = common.BigToAddress(ret)
+			call.To = addressHex(call.to)
+			call.Output = hexutil.Encode(env.StateDB.GetCode(common.BigToAddress(ret)))
+		} else if call.Error == "" {
+			call.Error = "internal failure"
+		}
+	} else if call.hasGas {
+		call.GasUsed = hexutil.EncodeUint64(call.gasIn - call.gasCost + call.gas - gas)
+
+		if ret := stack.Back(0); ret.Sign() != 0 {
+			call.Output = hexutil.Encode(memorySlice(memory, call.outOff, call.outOff+call.outLen))
+		} else if call.Error == "" {
+			call.Error = "internal failure"
+		}
+	}
+	if call.hasGas {
+		call.Gas = hexutil.EncodeUint64(call.gas)
+	}
+	t.pushCall(call)
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *CallTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.err == nil {
+		t.fault(err)
+	}
+	return nil
+}
+
+// fault pops off the call failing with err and flattens it into its parent.
+func (t *CallTracer) fault(err error) {
+	// If the topmost call already reverted, don't handle the additional fault again
+	if t.callstack[len(t.callstack)-1].Error != "" {
+		return
+	}
+	call := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+	call.Error = err.Error()
+
+	// Consume all available gas
+	if call.hasGas {
+		call.Gas = hexutil.EncodeUint64(call.gas)
+		call.GasUsed = call.Gas
+	}
+	if len(t.callstack) > 0 {
+		t.pushCall(call)
+		return
+	}
+	// Last call failed too, leave it in the stack
+	t.callstack = append(t.callstack, call)
+}
+
+// pushCall injects a finished call into its parent.
+func (t *CallTracer) pushCall(call *callFrame) {
+	parent := t.callstack[len(t.callstack)-1]
+	parent.Calls = append(parent.Calls, call)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *CallTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	t.output, t.gasUsed, t.time, t.callErr = output, gasUsed, d, err
+	return nil
+}
+
+// GetResult returns the outermost call with all its inner calls, or any
+// accumulated error.
+func (t *CallTracer) GetResult() (json.RawMessage, error) {
+	result := &callFrame{
+		Type:    vm.CALL.String(),
+		From:    addressHex(t.from),
+		To:      addressHex(t.to),
+		Value:   hexutil.EncodeBig(t.value),
+		Gas:     hexutil.EncodeUint64(t.gas),
+		GasUsed: hexutil.EncodeUint64(t.gasUsed),
+		Input:   hexutil.Encode(t.input),
+		Output:  hexutil.Encode(t.output),
+		Time:    t.time.String(),
+		Calls:   t.callstack[0].Calls,
+	}
+	if t.create {
+		result.Type = vm.CREATE.String()
+	}
+	if t.gasPayer != (common.Address{}) {
+		result.GasPayer = addressHex(t.gasPayer)
+	}
+	if t.provider != (common.Address{}) {
+		result.Provider = addressHex(t.provider)
+	}
+	if result.Error = t.outermostError(); result.Error != "" {
+		result.Output = ""
+	}
+	res, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.err
+}
+
+// InternalTxs returns the calls, contract creations and self destructs executed
+// by the transaction in execution order, the outermost call first, or any
+// accumulated error. The internal transactions of a failed call carry its error
+// as their effects are reverted with it.
+func (t *CallTracer) InternalTxs(txHash common.Hash, txIndex uint) ([]*types.InternalTx, error) {
+	outermost := &types.InternalTx{
+		TxHash:       txHash,
+		TxIndex:      txIndex,
+		Type:         vm.CALL.String(),
+		From:         t.from,
+		To:           t.to,
+		Value:        new(big.Int),
+		TraceAddress: []uint64{},
+		Error:        t.outermostError(),
+	}
+	if t.create {
+		outermost.Type = vm.CREATE.String()
+	}
+	if t.value != nil {
+		outermost.Value.Set(t.value)
+	}
+	txs := []*types.InternalTx{outermost}
+
+	var collect func(parent *types.InternalTx, calls []*callFrame)
+	collect = func(parent *types.InternalTx, calls []*callFrame) {
+		for i, call := range calls {
+			tx := &types.InternalTx{
+				TxHash:       txHash,
+				TxIndex:      txIndex,
+				Type:         call.Type,
+				From:         call.from,
+				To:           call.to,
+				Value:        new(big.Int),
+				TraceAddress: append(append([]uint64{}, parent.TraceAddress...), uint64(i)),
+				Error:        call.Error,
+			}
+			if tx.Error == "" {
+				tx.Error = parent.Error
+			}
+			if call.value != nil {
+				tx.Value.Set(call.value)
+			}
+			txs = append(txs, tx)
+			collect(tx, call.Calls)
+		}
+	}
+	collect(outermost, t.callstack[0].Calls)
+	return txs, t.err
+}
+
+// outermostError returns the error of the outermost call, empty if it succeeded.
+func (t *CallTracer) outermostError() string {
+	if t.callstack[0].Error != "" {
+		return t.callstack[0].Error
+	}
+	if t.callErr != nil {
+		return t.callErr.Error()
+	}
+	return ""
+}
+
+// addressHex returns the lower case hex encoding of addr.
+func addressHex(addr common.Address) string {
+	return hexutil.Encode(addr[:])
+}
+
+// memorySlice returns a copy of the memory between begin and end, or nil if it
+// is out of bound.
+func memorySlice(memory *vm.Memory, begin, end uint64) []byte {
+	if uint64(memory.Len()) < end || begin > end {
+		return nil
+	}
+	return memory.Get(int64(begin), int64(end-begin))
+}
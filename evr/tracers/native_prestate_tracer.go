@@ -0,0 +1,167 @@
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+// prestateAccount is an account reported by the prestate tracers, its fields are
+// ordered and formatted as in the result of the JavaScript prestateTracer.
+type prestateAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   uint64                      `json:"nonce"`
+	Code    hexutil.Bytes               `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// prestateTracer is the native implementation of the JavaScript prestateTracer,
+// it outputs sufficient information to create a local execution of the
+// transaction from a custom assembled genesis block. The prestate of transactions
+// paid by a provider also contains the account of their gas payer.
+type prestateTracer struct {
+	ctx     vm.Context
+	statedb vm.StateDB
+
+	prestate map[common.Address]*prestateAccount // Genesis that we're building
+	started  bool                                // Flag whether the first step was traced
+
+	create bool
+	from   common.Address
+	to     common.Address
+	value  *big.Int
+
+	err       error  // Error, if one has occurred
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+func newPrestateTracer(ctx vm.Context, statedb vm.StateDB) TxTracer {
+	return &prestateTracer{
+		ctx:      ctx,
+		statedb:  statedb,
+		prestate: make(map[common.Address]*prestateAccount),
+	}
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *prestateTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// lookupAccount injects the specified account into the prestate.
+func (t *prestateTracer) lookupAccount(addr common.Address) {
+	if _, ok := t.prestate[addr]; ok {
+		return
+	}
+	t.prestate[addr] = &prestateAccount{
+		Balance: (*hexutil.Big)(new(big.Int).Set(t.statedb.GetBalance(addr))),
+		Nonce:   t.statedb.GetNonce(addr),
+		Code:    common.CopyBytes(t.statedb.GetCode(addr)),
+		Storage: make(map[common.Hash]common.Hash),
+	}
+}
+
+// lookupStorage injects the specified storage entry of the given account into
+// the prestate.
+func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	t.lookupAccount(addr)
+	if _, ok := t.prestate[addr].Storage[key]; ok {
+		return
+	}
+	t.prestate[addr].Storage[key] = t.statedb.GetState(addr, key)
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (t *prestateTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.create, t.from, t.to, t.value = create, from, to, value
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM execution.
+func (t *prestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.err != nil {
+		return nil
+	}
+	// If tracing was interrupted, set the error and stop
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		t.err = t.reason
+		return nil
+	}
+	// Add the current account if we just started tracing. Balance will potentially
+	// be wrong here, since this will include the value sent along with the message.
+	// We fix that in GetResult.
+	if !t.started {
+		t.lookupAccount(contract.Address())
+		t.started = true
+	}
+	// Whenever new state is accessed, add it to the prestate
+	switch op {
+	case vm.EXTCODECOPY, vm.EXTCODESIZE, vm.BALANCE:
+		t.lookupAccount(common.BigToAddress(stack.Back(0)))
+
+	case vm.CREATE:
+		from := contract.Address()
+		t.lookupAccount(crypto.CreateAddress(from, t.statedb.GetNonce(from)))
+
+	case vm.CREATE2:
+		offset := stack.Back(1).Uint64()
+		code := memorySlice(memory, offset, offset+stack.Back(2).Uint64())
+		t.lookupAccount(crypto.CreateAddress2(contract.Address(), common.BigToHash(stack.Back(3)), crypto.Keccak256(code)))
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		t.lookupAccount(common.BigToAddress(stack.Back(1)))
+
+	case vm.SSTORE, vm.SLOAD:
+		t.lookupStorage(contract.Address(), common.BigToHash(stack.Back(0)))
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *prestateTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+// GetResult returns the assembled prestate, or any accumulated error.
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	// At this point, we need to deduct the value from the outer transaction, and
+	// move it back to the origin
+	t.lookupAccount(t.from)
+	t.lookupAccount(t.to)
+	if t.ctx.GasPayer != (common.Address{}) && t.ctx.GasPayer != t.ctx.Origin {
+		t.lookupAccount(t.ctx.GasPayer)
+	}
+	var (
+		fromBalance = new(big.Int).Add(t.prestate[t.from].Balance.ToInt(), t.value)
+		toBalance   = new(big.Int).Sub(t.prestate[t.to].Balance.ToInt(), t.value)
+	)
+	t.prestate[t.to].Balance = (*hexutil.Big)(toBalance)
+	t.prestate[t.from].Balance = (*hexutil.Big)(fromBalance)
+
+	// Decrement the caller's nonce, and remove empty create targets. We can blindly
+	// delete the contract prestate, as any existing state would have caused the
+	// transaction to be rejected as invalid in the first place.
+	t.prestate[t.from].Nonce--
+	if t.create {
+		delete(t.prestate, t.to)
+	}
+	res, err := json.Marshal(t.prestate)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.err
+}
@@ -0,0 +1,262 @@
+package tracers
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+	"github.com/Evrynetlabs/evrynet-node/tests"
+)
+
+// newTestTracer creates the native or the JavaScript tracer named name.
+func newTestTracer(name string, ctx vm.Context, statedb vm.StateDB) (TxTracer, error) {
+	if tracer, ok := NewNative(name, ctx, statedb); ok {
+		return tracer, nil
+	}
+	return New(name)
+}
+
+// runTracerTest executes tx with the tracer named name on top of the given
+// prestate and returns the unmarshalled result of the trace.
+func runTracerTest(t *testing.T, name string, tx *types.Transaction, signer types.Signer, context vm.Context, config *params.ChainConfig, alloc core.GenesisAlloc) map[string]interface{} {
+	statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc)
+
+	// Create the tracer, the EVM environment and run it
+	tracer, err := newTestTracer(name, context, statedb)
+	if err != nil {
+		t.Fatalf("failed to create %s: %v", name, err)
+	}
+	evm := vm.NewEVM(context, statedb, config, vm.Config{Debug: true, Tracer: tracer})
+
+	msg, err := tx.AsMessage(signer)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if _, _, _, err = st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	// Retrieve the trace result
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	ret := make(map[string]interface{})
+	if err := json.Unmarshal(res, &ret); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	return ret
+}
+
+// Tests that the native prestate tracer reports the same prestate as the
+// JavaScript one, including the accounts created by CREATE2.
+func TestNativePrestateTracerCreate2(t *testing.T) {
+	unsignedTx := types.NewTransaction(1, common.HexToAddress("0x00000000000000000000000000000000deadbeef"),
+		new(big.Int), 5000000, big.NewInt(1), []byte{})
+
+	privateKeyECDSA, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	signer := types.NewEIP155Signer(big.NewInt(1))
+	tx, err := types.SignTx(unsignedTx, signer, privateKeyECDSA)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	origin, _ := signer.Sender(tx)
+	context := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Origin:      origin,
+		GasPayer:    origin,
+		Coinbase:    common.Address{},
+		BlockNumber: new(big.Int).SetUint64(8000000),
+		Time:        new(big.Int).SetUint64(5),
+		Difficulty:  big.NewInt(0x30000),
+		GasLimit:    uint64(6000000),
+		GasPrice:    big.NewInt(1),
+	}
+	alloc := core.GenesisAlloc{
+		common.HexToAddress("0x00000000000000000000000000000000deadbeef"): core.GenesisAccount{
+			Nonce:   1,
+			Code:    hexutil.MustDecode("0x63deadbeef60005263cafebabe6004601c6000F560005260206000F3"),
+			Balance: big.NewInt(1),
+		},
+		origin: core.GenesisAccount{
+			Nonce:   1,
+			Code:    []byte{},
+			Balance: big.NewInt(500000000000000),
+		},
+	}
+	have := runTracerTest(t, "nativePrestateTracer", tx, signer, context, params.MainnetChainConfig, alloc)
+	if _, has := have["0x60f3f640a8508fc6a86d45df051962668e1e8ac7"]; !has {
+		t.Fatalf("Expected 0x60f3f640a8508fc6a86d45df051962668e1e8ac7 in result")
+	}
+	want := runTracerTest(t, "prestateTracer", tx, signer, context, params.MainnetChainConfig, alloc)
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("prestate mismatch: \nhave %+v\nwant %+v", have, want)
+	}
+}
+
+// Iterates over all the input-output datasets in the tracer test harness and
+// runs the native call tracer against them, checking it agrees with the
+// JavaScript one.
+func TestNativeCallTracer(t *testing.T) {
+	files, err := ioutil.ReadDir("testdata")
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), "call_tracer_") {
+			continue
+		}
+		file := file // capture range variable
+		t.Run(camel(strings.TrimSuffix(strings.TrimPrefix(file.Name(), "call_tracer_"), ".json")), func(t *testing.T) {
+			t.Parallel()
+
+			// Call tracer test found, read if from disk
+			blob, err := ioutil.ReadFile(filepath.Join("testdata", file.Name()))
+			if err != nil {
+				t.Fatalf("failed to read testcase: %v", err)
+			}
+			test := new(callTracerTest)
+			if err := json.Unmarshal(blob, test); err != nil {
+				t.Fatalf("failed to parse testcase: %v", err)
+			}
+			// Configure a blockchain with the given prestate
+			tx := new(types.Transaction)
+			if err := rlp.DecodeBytes(common.FromHex(test.Input), tx); err != nil {
+				t.Fatalf("failed to parse testcase input: %v", err)
+			}
+			signer := types.MakeSigner(test.Genesis.Config, new(big.Int).SetUint64(uint64(test.Context.Number)))
+			origin, _ := signer.Sender(tx)
+
+			context := vm.Context{
+				CanTransfer: core.CanTransfer,
+				Transfer:    core.Transfer,
+				Origin:      origin,
+				GasPayer:    origin,
+				Coinbase:    test.Context.Miner,
+				BlockNumber: new(big.Int).SetUint64(uint64(test.Context.Number)),
+				Time:        new(big.Int).SetUint64(uint64(test.Context.Time)),
+				Difficulty:  (*big.Int)(test.Context.Difficulty),
+				GasLimit:    uint64(test.Context.GasLimit),
+				GasPrice:    tx.GasPrice(),
+			}
+			have := runTracerTest(t, "nativeCallTracer", tx, signer, context, test.Genesis.Config, test.Genesis.Alloc)
+
+			// Compare against the etalon and the whole JSON of the JavaScript
+			// tracer apart from the execution time
+			blob, err = json.Marshal(have)
+			if err != nil {
+				t.Fatalf("failed to marshal trace result: %v", err)
+			}
+			ret := new(callTrace)
+			if err := json.Unmarshal(blob, ret); err != nil {
+				t.Fatalf("failed to unmarshal trace result: %v", err)
+			}
+			if !reflect.DeepEqual(ret, test.Result) {
+				t.Fatalf("trace mismatch: \nhave %+v\nwant %+v", ret, test.Result)
+			}
+			want := runTracerTest(t, "callTracer", tx, signer, context, test.Genesis.Config, test.Genesis.Alloc)
+			delete(have, "time")
+			delete(want, "time")
+			if !reflect.DeepEqual(have, want) {
+				t.Fatalf("JavaScript trace mismatch: \nhave %+v\nwant %+v", have, want)
+			}
+		})
+	}
+}
+
+// Tests that the native tracers annotate the transactions paid by a provider
+// with their gas payer and the provider listed by the contract.
+func TestNativeTracerProvider(t *testing.T) {
+	var (
+		sender   = common.HexToAddress("0x01")
+		provider = common.HexToAddress("0x02")
+		owner    = common.HexToAddress("0x03")
+		group    = vm.ProviderGroupAddress("customers")
+		contract = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+		alloc    = core.GenesisAlloc{
+			sender:   core.GenesisAccount{Nonce: 1, Balance: big.NewInt(1)},
+			provider: core.GenesisAccount{Balance: big.NewInt(500000000000000)},
+		}
+	)
+	trace := func(name string, gasPayer, listed common.Address) map[string]interface{} {
+		context := vm.Context{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			Origin:      sender,
+			GasPayer:    gasPayer,
+			BlockNumber: new(big.Int).SetUint64(8000000),
+			Time:        new(big.Int).SetUint64(5),
+			Difficulty:  big.NewInt(0x30000),
+			GasLimit:    uint64(6000000),
+			GasPrice:    big.NewInt(1),
+		}
+		statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc)
+		statedb.CreateAccount(group, types.CreateAccountOption{OwnerAddress: &owner, ProviderAddress: &provider})
+		statedb.SetNonce(group, 1)
+		statedb.CreateAccount(contract, types.CreateAccountOption{OwnerAddress: &owner, ProviderAddress: &listed})
+		statedb.SetCode(contract, []byte{byte(vm.STOP)})
+
+		tracer, ok := NewNative(name, context, statedb)
+		if !ok {
+			t.Fatalf("native tracer %s not found", name)
+		}
+		evm := vm.NewEVM(context, statedb, params.MainnetChainConfig, vm.Config{Debug: true, Tracer: tracer})
+		if _, _, err := evm.Call(vm.AccountRef(sender), contract, nil, 100000, new(big.Int)); err != nil {
+			t.Fatalf("failed to execute call: %v", err)
+		}
+		res, err := tracer.GetResult()
+		if err != nil {
+			t.Fatalf("failed to retrieve trace result: %v", err)
+		}
+		ret := make(map[string]interface{})
+		if err := json.Unmarshal(res, &ret); err != nil {
+			t.Fatalf("failed to unmarshal trace result: %v", err)
+		}
+		return ret
+	}
+	var (
+		providerHex = hexutil.Encode(provider[:])
+		groupHex    = hexutil.Encode(group[:])
+	)
+	call := trace("nativeCallTracer", provider, provider)
+	if call["gasPayer"] != providerHex || call["provider"] != providerHex {
+		t.Fatalf("annotation mismatch: have gas payer %v and provider %v, want %s", call["gasPayer"], call["provider"], providerHex)
+	}
+	// the provider pays through the provider group listed by the contract
+	call = trace("nativeCallTracer", provider, group)
+	if call["gasPayer"] != providerHex || call["provider"] != groupHex {
+		t.Fatalf("annotation mismatch: have gas payer %v and provider %v, want %s and %s", call["gasPayer"], call["provider"], providerHex, groupHex)
+	}
+	call = trace("nativeCallTracer", sender, provider)
+	if _, has := call["gasPayer"]; has {
+		t.Fatalf("unexpected gas payer annotation: %v", call["gasPayer"])
+	}
+	if _, has := call["provider"]; has {
+		t.Fatalf("unexpected provider annotation: %v", call["provider"])
+	}
+	if _, has := trace("nativePrestateTracer", provider, provider)[providerHex]; !has {
+		t.Fatalf("expected %s in prestate", providerHex)
+	}
+	if _, has := trace("nativePrestateTracer", sender, provider)[providerHex]; has {
+		t.Fatalf("unexpected %s in prestate", providerHex)
+	}
+}
@@ -14,19 +14,42 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
-// Package tracers is a collection of JavaScript transaction tracers.
+// Package tracers is a collection of JavaScript and native Go transaction tracers.
 package tracers
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
 	"unicode"
 
+	"github.com/Evrynetlabs/evrynet-node/core/vm"
 	"github.com/Evrynetlabs/evrynet-node/evr/tracers/internal/tracers"
 )
 
+// TxTracer is a transaction tracer returning its result as JSON. It is
+// implemented by the JavaScript Tracer as well as by the native tracers, which
+// assemble their result in Go without any interpreter in between.
+type TxTracer interface {
+	vm.Tracer
+
+	// GetResult returns the JSON result of the trace, or any accumulated error.
+	GetResult() (json.RawMessage, error)
+
+	// Stop terminates execution of the tracer at the first opportune moment.
+	Stop(err error)
+}
+
+// TxTracerCtor creates a native tracer for a transaction executed within the
+// given EVM context on top of statedb.
+type TxTracerCtor func(ctx vm.Context, statedb vm.StateDB) TxTracer
+
 // all contains all the built in JavaScript tracers by name.
 var all = make(map[string]string)
 
+// natives contains all the registered native tracers by name.
+var natives = make(map[string]TxTracerCtor)
+
 // camel converts a snake cased input string into a camel cased output.
 func camel(str string) string {
 	pieces := strings.Split(str, "_")
@@ -42,6 +65,29 @@ func init() {
 		name := camel(strings.TrimSuffix(file, ".js"))
 		all[name] = string(tracers.MustAsset(file))
 	}
+	RegisterNativeTracer("nativeCallTracer", newCallTracer)
+	RegisterNativeTracer("nativePrestateTracer", newPrestateTracer)
+}
+
+// RegisterNativeTracer makes a native tracer available by name. It panics if the
+// name is already taken by another tracer.
+func RegisterNativeTracer(name string, ctor TxTracerCtor) {
+	if _, ok := all[name]; ok {
+		panic(fmt.Sprintf("tracer %s already exists", name))
+	}
+	if _, ok := natives[name]; ok {
+		panic(fmt.Sprintf("tracer %s already exists", name))
+	}
+	natives[name] = ctor
+}
+
+// NewNative creates the native tracer registered by name for a transaction
+// executed within the given EVM context on top of statedb.
+func NewNative(name string, ctx vm.Context, statedb vm.StateDB) (TxTracer, bool) {
+	if ctor, ok := natives[name]; ok {
+		return ctor(ctx, statedb), true
+	}
+	return nil, false
 }
 
 // tracer retrieves a specific JavaScript tracer by name.
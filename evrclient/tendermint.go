@@ -0,0 +1,56 @@
+package evrclient
+
+import (
+	"context"
+
+	ethereum "github.com/Evrynetlabs/evrynet-node"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+)
+
+// EpochReward is the part of the reward of a validator for an epoch credited to its owner or one of its voters
+type EpochReward struct {
+	Epoch     hexutil.Uint64 `json:"epoch"`
+	Address   common.Address `json:"address"`
+	Validator common.Address `json:"validator"`
+	Amount    *hexutil.Big   `json:"amount"`
+}
+
+// AddressRewards is the rewards credited to an address in a range of epochs
+type AddressRewards struct {
+	Address common.Address `json:"address"`
+	Total   *hexutil.Big   `json:"total"`
+	Rewards []*EpochReward `json:"rewards"`
+}
+
+// ValidatorSetChange is the validator set written by a checkpoint block for the epoch starting after it,
+// with the validators added and removed since the previous epoch
+type ValidatorSetChange struct {
+	BlockHash   common.Hash      `json:"blockHash"`
+	BlockNumber hexutil.Uint64   `json:"blockNumber"`
+	Epoch       hexutil.Uint64   `json:"epoch"`
+	Validators  []common.Address `json:"validators"`
+	Added       []common.Address `json:"added"`
+	Removed     []common.Address `json:"removed"`
+}
+
+// EpochRewards returns the rewards of the epoch credited at its last block.
+// Epoch 0 is made of the blocks 1 to the epoch length of the chain.
+func (ec *Client) EpochRewards(ctx context.Context, epoch uint64) ([]*EpochReward, error) {
+	var rewards []*EpochReward
+	err := ec.c.CallContext(ctx, &rewards, "tendermint_getEpochRewards", epoch)
+	return rewards, err
+}
+
+// RewardsByAddress returns the rewards credited to the address from the epoch fromEpoch to the epoch toEpoch included
+func (ec *Client) RewardsByAddress(ctx context.Context, address common.Address, fromEpoch, toEpoch uint64) (*AddressRewards, error) {
+	var rewards *AddressRewards
+	err := ec.c.CallContext(ctx, &rewards, "tendermint_getRewardsByAddress", address, fromEpoch, toEpoch)
+	return rewards, err
+}
+
+// SubscribeValidatorSetChanges subscribes to notifications about the validator sets written by the checkpoint blocks
+// appended to the chain on the given channel.
+func (ec *Client) SubscribeValidatorSetChanges(ctx context.Context, ch chan<- *ValidatorSetChange) (ethereum.Subscription, error) {
+	return ec.c.EthSubscribe(ctx, ch, "validatorSetChanges")
+}
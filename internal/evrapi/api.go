@@ -756,6 +756,33 @@ func (s *PublicBlockChainAPI) GetCode(ctx context.Context, address common.Addres
 	return code, state.Error()
 }
 
+// GetOwner returns the owner of the enterprise contract at the given address in the state for the given block
+// number, or nil if the contract has no owner. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block
+// numbers are also allowed.
+func (s *PublicBlockChainAPI) GetOwner(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (*common.Address, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	owner := state.GetOwner(address)
+	return owner, state.Error()
+}
+
+// GetProviders returns the providers of the enterprise contract at the given address in the state for the given
+// block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block numbers are also allowed.
+func (s *PublicBlockChainAPI) GetProviders(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) ([]common.Address, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	providerAddrs := state.GetProviders(address)
+	providers := make([]common.Address, len(providerAddrs))
+	for i, provider := range providerAddrs {
+		providers[i] = *provider
+	}
+	return providers, state.Error()
+}
+
 // GetStorageAt returns the storage from the state at the given address, key and
 // block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block
 // numbers are also allowed.
@@ -1071,6 +1098,13 @@ func RPCMarshalExtraData(b *types.Block) (map[string]interface{}, error) {
 	}
 
 	fields["commitSigners"] = commitSigner
+	if extra.AggregatedSeal != nil {
+		// the signers of an aggregated seal are indexes in the validator set of the block
+		fields["aggregatedSeal"] = map[string]interface{}{
+			"signers":   hexutil.Bytes(extra.AggregatedSeal.Signers),
+			"signature": hexutil.Bytes(extra.AggregatedSeal.Signature),
+		}
+	}
 
 	return fields, nil
 }
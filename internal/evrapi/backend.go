@@ -111,6 +111,11 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicAccountAPI(apiBackend.AccountManager()),
 			Public:    true,
+		}, {
+			Namespace: "evr",
+			Version:   "1.0",
+			Service:   NewPublicEnterpriseAPI(apiBackend),
+			Public:    true,
 		}, {
 			Namespace: "personal",
 			Version:   "1.0",
@@ -0,0 +1,53 @@
+package evrapi
+
+import (
+	"context"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+)
+
+// PublicEnterpriseAPI provides an API to access the settings of the enterprise contracts.
+type PublicEnterpriseAPI struct {
+	b Backend
+}
+
+// NewPublicEnterpriseAPI creates a new enterprise contract API.
+func NewPublicEnterpriseAPI(b Backend) *PublicEnterpriseAPI {
+	return &PublicEnterpriseAPI{b}
+}
+
+// ProviderAllowance is the gas a provider of an enterprise contract pays for a sender in a period of blocks.
+type ProviderAllowance struct {
+	Provider  common.Address `json:"provider"`
+	Sender    common.Address `json:"sender"`
+	Period    hexutil.Uint64 `json:"period"`
+	GasLimit  hexutil.Uint64 `json:"gasLimit"`
+	GasUsed   hexutil.Uint64 `json:"gasUsed"`
+	Remaining hexutil.Uint64 `json:"remaining"`
+}
+
+// GetProviderAllowance returns the allowance of the provider for the sender set by the owner of the enterprise
+// contract at the given address, in the state and the period of the given block number. The zero sender address
+// returns the allowance for all senders. It returns nil if the gas paid by the provider is not limited.
+func (s *PublicEnterpriseAPI) GetProviderAllowance(ctx context.Context, address, provider, sender common.Address, blockNr rpc.BlockNumber) (*ProviderAllowance, error) {
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	number := header.Number.Uint64()
+	for _, allowance := range state.GetProviderAllowances(address) {
+		if allowance.Provider == provider && allowance.Sender == sender {
+			return &ProviderAllowance{
+				Provider:  allowance.Provider,
+				Sender:    allowance.Sender,
+				Period:    hexutil.Uint64(allowance.Period),
+				GasLimit:  hexutil.Uint64(allowance.GasLimit),
+				GasUsed:   hexutil.Uint64(allowance.Used(number)),
+				Remaining: hexutil.Uint64(allowance.Remaining(number)),
+			}, state.Error()
+		}
+	}
+	return nil, state.Error()
+}
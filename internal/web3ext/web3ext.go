@@ -25,6 +25,7 @@ var Modules = map[string]string{
 	"ethash":     EthashJs,
 	"debug":      DebugJs,
 	"eth":        EvrJs,
+	"evr":        EnterpriseJs,
 	"miner":      MinerJs,
 	"net":        NetJs,
 	"personal":   PersonalJs,
@@ -33,6 +34,7 @@ var Modules = map[string]string{
 	"swarmfs":    SwarmfsJs,
 	"txpool":     TxpoolJs,
 	"tendermint": TendermintJs,
+	"trace":      TraceJs,
 }
 
 const ChequebookJs = `
@@ -450,6 +452,20 @@ web3._extend({
 });
 `
 
+const EnterpriseJs = `
+web3._extend({
+	property: 'evr',
+	methods: [
+		new web3._extend.Method({
+			name: 'getProviderAllowance',
+			call: 'evr_getProviderAllowance',
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+	]
+});
+`
+
 const EvrJs = `
 web3._extend({
 	property: 'eth',
@@ -514,6 +530,18 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.utils.toHex]
 		}),
+		new web3._extend.Method({
+			name: 'getOwner',
+			call: 'eth_getOwner',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getProviders',
+			call: 'eth_getProviders',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'getProof',
 			call: 'eth_getProof',
@@ -796,6 +824,64 @@ web3._extend({
 			params: 1,
 			inputFormatter:[null]
 		}),
+		new web3._extend.Method({
+			name: 'getBLSRegistration',
+			call: 'tendermint_getBLSRegistration',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getChainParams',
+			call: 'tendermint_getChainParams',
+			params: 1,
+			inputFormatter:[null]
+		}),
+		new web3._extend.Method({
+			name: 'getConsensusState',
+			call: 'tendermint_getConsensusState',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getRoundVotes',
+			call: 'tendermint_getRoundVotes',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'getCommitInfo',
+			call: 'tendermint_getCommitInfo',
+			params: 1,
+			inputFormatter:[null]
+		}),
+		new web3._extend.Method({
+			name: 'getEpochRewards',
+			call: 'tendermint_getEpochRewards',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getRewardsByAddress',
+			call: 'tendermint_getRewardsByAddress',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, null]
+		}),
+	],
+	properties: []
+});
+`
+
+const TraceJs = `
+web3._extend({
+	property: 'trace',
+	methods: [
+		new web3._extend.Method({
+			name: 'filter',
+			call: 'trace_filter',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'block',
+			call: 'trace_block',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 	],
 	properties: []
 });
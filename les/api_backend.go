@@ -172,6 +172,10 @@ func (b *LesApiBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEven
 	return b.evr.blockchain.SubscribeRemovedLogsEvent(ch)
 }
 
+func (b *LesApiBackend) SubscribeValidatorSetChangedEvent(ch chan<- core.ValidatorSetChangedEvent) event.Subscription {
+	return b.evr.blockchain.SubscribeValidatorSetChangedEvent(ch)
+}
+
 func (b *LesApiBackend) Downloader() *downloader.Downloader {
 	return b.evr.Downloader()
 }
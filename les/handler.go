@@ -62,6 +62,7 @@ const (
 	MaxHelperTrieProofsFetch = 64  // Amount of merkle proofs to be fetched per retrieval request
 	MaxTxSend                = 64  // Amount of transactions to be send per request
 	MaxTxStatus              = 256 // Amount of transactions to queried per request
+	MaxCheckpointHeaderFetch = 64  // Amount of checkpoint headers to be fetched per retrieval request
 
 	disableClientRemovePeer = false
 )
@@ -186,7 +187,8 @@ func NewProtocolManager(
 		if cht, ok := params.TrustedCheckpoints[blockchain.Genesis().Hash()]; ok {
 			checkpoint = (cht.SectionIndex+1)*params.CHTFrequency - 1
 		}
-		manager.downloader = downloader.New(checkpoint, chainDb, nil, manager.eventMux, nil, blockchain, removePeer)
+		finality, _ := engine.(consensus.FinalityVerifier)
+		manager.downloader = downloader.New(checkpoint, chainDb, nil, manager.eventMux, nil, blockchain, finality, removePeer)
 		manager.peers.notify((*downloaderPeerNotify)(manager))
 		manager.fetcher = newLightFetcher(manager)
 	}
@@ -1117,6 +1119,56 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			Obj:     resp.Status,
 		}
 
+	case GetCheckpointHeadersMsg:
+		p.Log().Trace("Received checkpoint headers request")
+		// Decode the retrieval message
+		var req struct {
+			ReqID   uint64
+			Numbers []uint64
+		}
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		reqCnt := len(req.Numbers)
+		if accept(req.ReqID, uint64(reqCnt), MaxCheckpointHeaderFetch) {
+			go func() {
+				headers := make([]*types.Header, 0, reqCnt)
+				for i, number := range req.Numbers {
+					if i != 0 && !task.waitOrStop() {
+						sendResponse(req.ReqID, 0, nil, task.servingTime)
+						return
+					}
+					header := pm.blockchain.GetHeaderByNumber(number)
+					if header == nil {
+						break
+					}
+					headers = append(headers, header)
+				}
+				sendResponse(req.ReqID, uint64(reqCnt), p.ReplyCheckpointHeaders(req.ReqID, headers), task.done())
+			}()
+		}
+
+	case CheckpointHeadersMsg:
+		if pm.odr == nil {
+			return errResp(ErrUnexpectedResponse, "")
+		}
+
+		p.Log().Trace("Received checkpoint headers response")
+		var resp struct {
+			ReqID, BV uint64
+			Headers   []*types.Header
+		}
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+
+		p.fcServer.ReceivedReply(resp.ReqID, resp.BV)
+		deliverMsg = &Msg{
+			MsgType: MsgCheckpointHeaders,
+			ReqID:   resp.ReqID,
+			Obj:     resp.Headers,
+		}
+
 	case StopMsg:
 		if pm.odr == nil {
 			return errResp(ErrUnexpectedResponse, "")
@@ -87,6 +87,7 @@ const (
 	MsgProofsV2
 	MsgHelperTrieProofs
 	MsgTxStatus
+	MsgCheckpointHeaders
 )
 
 // Msg encodes a LES message that delivers reply data for a request
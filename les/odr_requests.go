@@ -33,16 +33,17 @@ import (
 )
 
 var (
-	errInvalidMessageType  = errors.New("invalid message type")
-	errInvalidEntryCount   = errors.New("invalid number of response entries")
-	errHeaderUnavailable   = errors.New("header unavailable")
-	errTxHashMismatch      = errors.New("transaction hash mismatch")
-	errUncleHashMismatch   = errors.New("uncle hash mismatch")
-	errReceiptHashMismatch = errors.New("receipt hash mismatch")
-	errDataHashMismatch    = errors.New("data hash mismatch")
-	errCHTHashMismatch     = errors.New("cht hash mismatch")
-	errCHTNumberMismatch   = errors.New("cht number mismatch")
-	errUselessNodes        = errors.New("useless nodes in merkle proof nodeset")
+	errInvalidMessageType   = errors.New("invalid message type")
+	errInvalidEntryCount    = errors.New("invalid number of response entries")
+	errHeaderUnavailable    = errors.New("header unavailable")
+	errTxHashMismatch       = errors.New("transaction hash mismatch")
+	errUncleHashMismatch    = errors.New("uncle hash mismatch")
+	errReceiptHashMismatch  = errors.New("receipt hash mismatch")
+	errDataHashMismatch     = errors.New("data hash mismatch")
+	errCHTHashMismatch      = errors.New("cht hash mismatch")
+	errCHTNumberMismatch    = errors.New("cht number mismatch")
+	errHeaderNumberMismatch = errors.New("header number mismatch")
+	errUselessNodes         = errors.New("useless nodes in merkle proof nodeset")
 )
 
 type LesOdrRequest interface {
@@ -68,6 +69,8 @@ func LesRequest(req light.OdrRequest) LesOdrRequest {
 		return (*BloomRequest)(r)
 	case *light.TxStatusRequest:
 		return (*TxStatusRequest)(r)
+	case *light.CheckpointHeadersRequest:
+		return (*CheckpointHeadersRequest)(r)
 	default:
 		return nil
 	}
@@ -512,6 +515,53 @@ func (r *TxStatusRequest) Validate(db evrdb.Database, msg *Msg) error {
 	return nil
 }
 
+// CheckpointHeadersRequest is the ODR request type for checkpoint headers
+type CheckpointHeadersRequest light.CheckpointHeadersRequest
+
+// GetCost returns the cost of the given ODR request according to the serving
+// peer's cost table (implementation of LesOdrRequest)
+func (r *CheckpointHeadersRequest) GetCost(peer *peer) uint64 {
+	return peer.GetRequestCost(GetCheckpointHeadersMsg, len(r.Numbers))
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request
+func (r *CheckpointHeadersRequest) CanSend(peer *peer) bool {
+	if peer.version < lpv3 || len(r.Numbers) == 0 {
+		return false
+	}
+	return peer.headBlockInfo().Number >= r.Numbers[len(r.Numbers)-1]
+}
+
+// Request sends an ODR request to the LES network (implementation of LesOdrRequest)
+func (r *CheckpointHeadersRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Requesting checkpoint headers", "count", len(r.Numbers))
+	return peer.RequestCheckpointHeaders(reqID, r.GetCost(peer), r.Numbers)
+}
+
+// Valid processes an ODR request reply message from the LES network
+// returns true and stores results in memory if the message was a valid reply
+// to the request (implementation of LesOdrRequest)
+func (r *CheckpointHeadersRequest) Validate(db evrdb.Database, msg *Msg) error {
+	log.Debug("Validating checkpoint headers", "count", len(r.Numbers))
+
+	// Ensure we have a correct message with a header for every number,
+	// their finality is verified by the light chain
+	if msg.MsgType != MsgCheckpointHeaders {
+		return errInvalidMessageType
+	}
+	headers := msg.Obj.([]*types.Header)
+	if len(headers) != len(r.Numbers) {
+		return errInvalidEntryCount
+	}
+	for i, header := range headers {
+		if header.Number == nil || header.Number.Uint64() != r.Numbers[i] {
+			return errHeaderNumberMismatch
+		}
+	}
+	r.Headers = headers
+	return nil
+}
+
 // readTraceDB stores the keys of database reads. We use this to check that received node
 // sets contain only the trie nodes necessary to make proofs pass.
 type readTraceDB struct {
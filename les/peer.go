@@ -409,6 +409,12 @@ func (p *peer) ReplyTxStatus(reqID uint64, stats []light.TxStatus) *reply {
 	return &reply{p.rw, TxStatusMsg, reqID, data}
 }
 
+// ReplyCheckpointHeaders creates a reply with a batch of canonical headers, corresponding to the numbers requested.
+func (p *peer) ReplyCheckpointHeaders(reqID uint64, headers []*types.Header) *reply {
+	data, _ := rlp.EncodeToBytes(headers)
+	return &reply{p.rw, CheckpointHeadersMsg, reqID, data}
+}
+
 // RequestHeadersByHash fetches a batch of blocks' headers corresponding to the
 // specified header query, based on the hash of an origin block.
 func (p *peer) RequestHeadersByHash(reqID, cost uint64, origin common.Hash, amount int, skip int, reverse bool) error {
@@ -461,6 +467,12 @@ func (p *peer) RequestTxStatus(reqID, cost uint64, txHashes []common.Hash) error
 	return sendRequest(p.rw, GetTxStatusMsg, reqID, cost, txHashes)
 }
 
+// RequestCheckpointHeaders fetches a batch of canonical headers by number from a remote node.
+func (p *peer) RequestCheckpointHeaders(reqID, cost uint64, numbers []uint64) error {
+	p.Log().Debug("Fetching batch of checkpoint headers", "count", len(numbers))
+	return sendRequest(p.rw, GetCheckpointHeadersMsg, reqID, cost, numbers)
+}
+
 // SendTxStatus creates a reply with a batch of transactions to be added to the remote transaction pool.
 func (p *peer) SendTxs(reqID, cost uint64, txs rlp.RawValue) error {
 	p.Log().Debug("Sending batch of transactions", "size", len(txs))
@@ -43,7 +43,7 @@ var (
 )
 
 // Number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = map[uint]uint64{lpv2: 22, lpv3: 24}
+var ProtocolLengths = map[uint]uint64{lpv2: 22, lpv3: 26}
 
 const (
 	NetworkId          = 1
@@ -72,8 +72,10 @@ const (
 	GetTxStatusMsg         = 0x14
 	TxStatusMsg            = 0x15
 	// Protocol messages introduced in LPV3
-	StopMsg   = 0x16
-	ResumeMsg = 0x17
+	StopMsg                 = 0x16
+	ResumeMsg               = 0x17
+	GetCheckpointHeadersMsg = 0x18
+	CheckpointHeadersMsg    = 0x19
 )
 
 type requestInfo struct {
@@ -82,14 +84,15 @@ type requestInfo struct {
 }
 
 var requests = map[uint64]requestInfo{
-	GetBlockHeadersMsg:     {"GetBlockHeaders", MaxHeaderFetch},
-	GetBlockBodiesMsg:      {"GetBlockBodies", MaxBodyFetch},
-	GetReceiptsMsg:         {"GetReceipts", MaxReceiptFetch},
-	GetCodeMsg:             {"GetCode", MaxCodeFetch},
-	GetProofsV2Msg:         {"GetProofsV2", MaxProofsFetch},
-	GetHelperTrieProofsMsg: {"GetHelperTrieProofs", MaxHelperTrieProofsFetch},
-	SendTxV2Msg:            {"SendTxV2", MaxTxSend},
-	GetTxStatusMsg:         {"GetTxStatus", MaxTxStatus},
+	GetBlockHeadersMsg:      {"GetBlockHeaders", MaxHeaderFetch},
+	GetBlockBodiesMsg:       {"GetBlockBodies", MaxBodyFetch},
+	GetReceiptsMsg:          {"GetReceipts", MaxReceiptFetch},
+	GetCodeMsg:              {"GetCode", MaxCodeFetch},
+	GetProofsV2Msg:          {"GetProofsV2", MaxProofsFetch},
+	GetHelperTrieProofsMsg:  {"GetHelperTrieProofs", MaxHelperTrieProofsFetch},
+	SendTxV2Msg:             {"SendTxV2", MaxTxSend},
+	GetTxStatusMsg:          {"GetTxStatus", MaxTxStatus},
+	GetCheckpointHeadersMsg: {"GetCheckpointHeaders", MaxCheckpointHeaderFetch},
 }
 
 type errCode int
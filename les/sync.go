@@ -74,6 +74,8 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
-	pm.blockchain.(*light.LightChain).SyncCht(ctx)
+	if !pm.blockchain.(*light.LightChain).SyncFinality(ctx, peer.headBlockInfo().Number) {
+		pm.blockchain.(*light.LightChain).SyncCht(ctx)
+	}
 	pm.downloader.Synchronise(peer.id, peer.Head(), peer.Td(), downloader.LightSync)
 }
@@ -43,6 +43,8 @@ import (
 var (
 	bodyCacheLimit  = 256
 	blockCacheLimit = 256
+
+	maxCheckpointHeadersFetch = 64 // Amount of checkpoint headers to be fetched per retrieval request
 )
 
 // LightChain represents a canonical chain that by default only handles block
@@ -495,6 +497,89 @@ func (lc *LightChain) SyncCht(ctx context.Context) bool {
 	return false
 }
 
+// SyncFinality moves the head of the chain up to the given remote head number by following the checkpoint headers
+// of a consensus engine with instant finality. Every checkpoint header is verified against the validator set stored
+// in the previous one, then the remote head is verified against the last checkpoint, so the headers in between are
+// neither downloaded nor verified. The engine only trusts the validator set of a checkpoint if it matches the hash
+// covered by the committed seals, a peer can not replace it.
+func (lc *LightChain) SyncFinality(ctx context.Context, head uint64) bool {
+	verifier, ok := lc.engine.(consensus.FinalityVerifier)
+	if !ok || verifier.Epoch() == 0 {
+		return false
+	}
+	epoch := verifier.Epoch()
+	current := lc.CurrentHeader().Number.Uint64()
+	if head <= current {
+		return false
+	}
+	// The checkpoint of the current header is always known locally, whether the chain was synced header by header
+	// or from checkpoint to checkpoint
+	checkpoint := lc.GetHeaderByNumber(current - current%epoch)
+	if checkpoint == nil {
+		return false
+	}
+	var numbers []uint64
+	for number := checkpoint.Number.Uint64() + epoch; number < head; number += epoch {
+		numbers = append(numbers, number)
+	}
+	numbers = append(numbers, head)
+
+	for len(numbers) > 0 {
+		batch := numbers
+		if len(batch) > maxCheckpointHeadersFetch {
+			batch = batch[:maxCheckpointHeadersFetch]
+		}
+		numbers = numbers[len(batch):]
+
+		headers, err := GetCheckpointHeaders(ctx, lc.odr, batch)
+		if err != nil {
+			log.Debug("Failed to retrieve checkpoint headers", "err", err)
+			return false
+		}
+		for _, header := range headers {
+			if err := verifier.VerifyFinality(checkpoint, header); err != nil {
+				log.Warn("Invalid finality proof", "number", header.Number, "hash", header.Hash(), "err", err)
+				return false
+			}
+			if !lc.writeFinalHeader(checkpoint, header) {
+				return false
+			}
+			if header.Number.Uint64()%epoch == 0 {
+				checkpoint = header
+			}
+		}
+	}
+	return true
+}
+
+// writeFinalHeader stores a header whose finality is verified against its checkpoint and makes it the head of the chain.
+// As the difficulty of every block is the same, the total difficulty is derived from the one of the checkpoint.
+func (lc *LightChain) writeFinalHeader(checkpoint, header *types.Header) bool {
+	lc.chainmu.Lock()
+	defer lc.chainmu.Unlock()
+
+	// Ensure the chain didn't move past the header while retrieving it
+	if lc.hc.CurrentHeader().Number.Uint64() >= header.Number.Uint64() {
+		return false
+	}
+	ptd := lc.hc.GetTd(checkpoint.Hash(), checkpoint.Number.Uint64())
+	if ptd == nil {
+		return false
+	}
+	var (
+		hash, number = header.Hash(), header.Number.Uint64()
+		distance     = new(big.Int).Sub(header.Number, checkpoint.Number)
+		td           = new(big.Int).Add(ptd, distance.Mul(distance, header.Difficulty))
+	)
+	rawdb.WriteHeader(lc.chainDb, header)
+	rawdb.WriteTd(lc.chainDb, hash, number, td)
+	rawdb.WriteCanonicalHash(lc.chainDb, hash, number)
+
+	log.Info("Updated latest header based on finality proof", "number", number, "hash", hash, "age", common.PrettyAge(time.Unix(int64(header.Time), 0)))
+	lc.hc.SetCurrentHeader(header)
+	return true
+}
+
 // LockChain locks the chain mutex for reading so that multiple canonical hashes can be
 // retrieved while it is guaranteed that they belong to the same version of the chain
 func (lc *LightChain) LockChain() {
@@ -533,6 +618,12 @@ func (lc *LightChain) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent)
 	return lc.scope.Track(new(event.Feed).Subscribe(ch))
 }
 
+// SubscribeValidatorSetChangedEvent implements the interface of filters.Backend
+// LightChain does not send core.ValidatorSetChangedEvent, so return an empty subscription.
+func (lc *LightChain) SubscribeValidatorSetChangedEvent(ch chan<- core.ValidatorSetChangedEvent) event.Subscription {
+	return lc.scope.Track(new(event.Feed).Subscribe(ch))
+}
+
 // DisableCheckFreq disables header validation. This is used for ultralight mode.
 func (lc *LightChain) DisableCheckFreq() {
 	atomic.StoreInt32(&lc.disableCheckFreq, 1)
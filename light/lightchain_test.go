@@ -18,14 +18,20 @@ package light
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"math/big"
 	"testing"
 
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/consensus/ethash"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	tendermintBackend "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/backend"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/tests_utils"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
 	"github.com/Evrynetlabs/evrynet-node/core"
 	"github.com/Evrynetlabs/evrynet-node/core/rawdb"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/evrdb"
 	"github.com/Evrynetlabs/evrynet-node/params"
 )
@@ -352,3 +358,102 @@ func TestReorgBadHeaderHashes(t *testing.T) {
 		t.Errorf("last header hash mismatch: have: %x, want %x", ncm.CurrentHeader().Hash(), headers[2].Hash())
 	}
 }
+
+// checkpointOdr serves the headers of a chain by number without any proof
+type checkpointOdr struct {
+	dummyOdr
+	headers map[uint64]*types.Header
+}
+
+func (odr *checkpointOdr) Retrieve(ctx context.Context, req OdrRequest) error {
+	if req, ok := req.(*CheckpointHeadersRequest); ok {
+		for _, number := range req.Numbers {
+			req.Headers = append(req.Headers, odr.headers[number])
+		}
+	}
+	return nil
+}
+
+// Tests that the light chain follows the checkpoint headers up to the remote head, and that a peer replacing the
+// validator set of a checkpoint can not make it accept headers committed by other validators.
+func TestSyncFinality(t *testing.T) {
+	const epoch = 4
+	makeKeys := func() []*ecdsa.PrivateKey {
+		return []*ecdsa.PrivateKey{tests_utils.MakeNodeKey(), tests_utils.MakeNodeKey(), tests_utils.MakeNodeKey()}
+	}
+	addresses := func(keys []*ecdsa.PrivateKey) []common.Address {
+		var addrs []common.Address
+		for _, key := range keys {
+			addrs = append(addrs, crypto.PubkeyToAddress(key.PublicKey))
+		}
+		return addrs
+	}
+	var (
+		keys       = makeKeys()
+		forgedKeys = makeKeys()
+		gspec      = &core.Genesis{
+			Config:     params.TestChainConfig,
+			ExtraData:  tests_utils.MakeGenesisHeader(addresses(keys)).Extra,
+			Difficulty: big.NewInt(1),
+			GasLimit:   params.GenesisGasLimit,
+		}
+		config = *tendermint.DefaultConfig
+	)
+	config.Epoch = epoch
+	config.ValSetHashBlock = common.Big1
+
+	newLightChain := func(headers []*types.Header) *LightChain {
+		db := rawdb.NewMemoryDatabase()
+		gspec.MustCommit(db)
+		odr := &checkpointOdr{dummyOdr: dummyOdr{db: db}, headers: make(map[uint64]*types.Header)}
+		for _, header := range headers {
+			odr.headers[header.Number.Uint64()] = header
+		}
+		lc, err := NewLightChain(odr, gspec.Config, tendermintBackend.New(&config, keys[0]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lc
+	}
+	genesis := gspec.ToBlock(nil).Header()
+	headers := tests_utils.MakeCommittedHeaders(genesis, 3*epoch-2, epoch, keys)
+
+	lc := newLightChain(headers)
+	if !lc.SyncFinality(context.Background(), 3*epoch-2) {
+		t.Fatal("failed to sync the final headers")
+	}
+	if head := lc.CurrentHeader(); head.Hash() != headers[len(headers)-1].Hash() {
+		t.Fatalf("head mismatch: have #%d, want #%d", head.Number, len(headers))
+	}
+	if checkpoint := lc.GetHeaderByNumber(epoch); checkpoint == nil || checkpoint.Hash() != headers[epoch-1].Hash() {
+		t.Fatal("checkpoint header not stored")
+	}
+
+	// the validator set of the checkpoint is not covered by its hash, it is by the sealed validator set hash
+	tamper := func(write func(*types.Header) error) []*types.Header {
+		tampered := make([]*types.Header, len(headers))
+		copy(tampered, headers)
+		checkpoint := types.CopyHeader(headers[epoch-1])
+		if err := write(checkpoint); err != nil {
+			t.Fatal(err)
+		}
+		if checkpoint.Hash() != headers[epoch-1].Hash() {
+			t.Fatal("tampered checkpoint hash mismatch")
+		}
+		tampered[epoch-1] = checkpoint
+		return tampered
+	}
+	forged := tamper(func(h *types.Header) error { return utils.WriteValSet(h, addresses(forgedKeys)) })
+	forged = append(forged[:epoch], tests_utils.MakeCommittedHeaders(forged[epoch-1], 2*epoch-2, epoch, forgedKeys)...)
+	withBLSKeys := tamper(func(h *types.Header) error { return utils.WriteBLSPublicKeys(h, [][]byte{{1}, {2}, {3}}) })
+
+	for name, headers := range map[string][]*types.Header{"validators": forged, "bls public keys": withBLSKeys} {
+		lc := newLightChain(headers)
+		if lc.SyncFinality(context.Background(), 3*epoch-2) {
+			t.Fatalf("%s: synced a checkpoint with a tampered validator set", name)
+		}
+		if head := lc.CurrentHeader().Number.Uint64(); head != 0 {
+			t.Fatalf("%s: head mismatch: have #%d, want #0", name, head)
+		}
+	}
+}
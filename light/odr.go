@@ -152,6 +152,19 @@ func (req *ChtRequest) StoreResult(db evrdb.Database) {
 	rawdb.WriteCanonicalHash(db, hash, num)
 }
 
+// CheckpointHeadersRequest is the ODR request type for retrieving canonical headers by number without a CHT proof.
+// It is used to download the checkpoint headers of a consensus engine with instant finality, the finality of the
+// headers is verified by the caller.
+type CheckpointHeadersRequest struct {
+	OdrRequest
+	Numbers []uint64
+	Headers []*types.Header
+}
+
+// StoreResult stores the retrieved data in local database.
+// The headers are stored by the light chain once their finality is verified.
+func (req *CheckpointHeadersRequest) StoreResult(db evrdb.Database) {}
+
 // BloomRequest is the ODR request type for retrieving bloom filters from a CHT structure
 type BloomRequest struct {
 	OdrRequest
@@ -69,6 +69,16 @@ func GetHeaderByNumber(ctx context.Context, odr OdrBackend, number uint64) (*typ
 	return r.Header, nil
 }
 
+// GetCheckpointHeaders retrieves the canonical headers of the given numbers from the network.
+// The headers come without any proof, their finality must be verified by the caller.
+func GetCheckpointHeaders(ctx context.Context, odr OdrBackend, numbers []uint64) ([]*types.Header, error) {
+	r := &CheckpointHeadersRequest{Numbers: numbers}
+	if err := odr.Retrieve(ctx, r); err != nil {
+		return nil, err
+	}
+	return r.Headers, nil
+}
+
 func GetCanonicalHash(ctx context.Context, odr OdrBackend, number uint64) (common.Hash, error) {
 	hash := rawdb.ReadCanonicalHash(odr.Database(), number)
 	if (hash != common.Hash{}) {
@@ -157,17 +157,17 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(GasPriceConfig), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil, nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(GasPriceConfig), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), new(EthashConfig), nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Evrynet core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(GasPriceConfig), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(GasPriceConfig), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
 
-	TestChainConfig           = &ChainConfig{big.NewInt(1), big.NewInt(GasPriceConfig), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil, nil}
-	TendermintTestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(GasPriceConfig), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(TendermintConfig)}
+	TestChainConfig           = &ChainConfig{big.NewInt(1), big.NewInt(GasPriceConfig), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), new(EthashConfig), nil, nil}
+	TendermintTestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(GasPriceConfig), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, new(TendermintConfig)}
 	TestRules                 = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -210,6 +210,11 @@ type ChainConfig struct {
 	PetersburgBlock     *big.Int `json:"petersburgBlock,omitempty"`     // Petersburg switch block (nil = same as Constantinople)
 	EWASMBlock          *big.Int `json:"ewasmBlock,omitempty"`          // EWASM switch block (nil = no fork, 0 = already activated)
 
+	ProviderManagementBlock *big.Int `json:"providerManagementBlock,omitempty"` // ProviderManagementBlock switch on the provider management contract of enterprise contracts (nil = no fork, 0 = already activated)
+	ProviderAllowanceBlock  *big.Int `json:"providerAllowanceBlock,omitempty"`  // ProviderAllowanceBlock switch on the gas allowances of the providers of enterprise contracts (nil = no fork, 0 = already activated)
+	ProviderContextBlock    *big.Int `json:"providerContextBlock,omitempty"`    // ProviderContextBlock switch on the provider and gas payer of the transaction in the provider management contract (nil = no fork, 0 = already activated)
+	ProviderGroupBlock      *big.Int `json:"providerGroupBlock,omitempty"`      // ProviderGroupBlock switch on the provider groups and the contract deployments sponsored by them (nil = no fork, 0 = already activated)
+
 	// Various consensus engines
 	Ethash     *EthashConfig     `json:"ethash,omitempty"`
 	Clique     *CliqueConfig     `json:"clique,omitempty"`
@@ -242,6 +247,27 @@ type TendermintConfig struct {
 	BlockReward      *big.Int         `json:"blockReward"`      // TendermintBlockReward for accumulating reward
 	StakingSCAddress *common.Address  `json:"stakingSCAddress"` // The staking SC address for validating when deploy SC
 	FixedValidators  []common.Address `json:"fixedValidators"`
+
+	SlashingBlock *big.Int `json:"slashingBlock,omitempty"` // SlashingBlock switch on equivocation evidence processing (nil = no fork)
+	SlashRate     uint64   `json:"slashRate,omitempty"`     // The percentage of the owner's stake which is burnt when its validator double signs
+	JailEpochs    uint64   `json:"jailEpochs,omitempty"`    // The number of epochs a double signing validator is excluded from the validator set after the current one
+
+	DowntimeJailingBlock *big.Int `json:"downtimeJailingBlock,omitempty"` // DowntimeJailingBlock switch on the exclusion of offline validators (nil = no fork)
+	UptimeWindow         uint64   `json:"uptimeWindow,omitempty"`         // The number of blocks the uptime of validators is computed on (0 = epoch)
+	MinUptime            uint64   `json:"minUptime,omitempty"`            // The minimum percentage of committed blocks in the uptime window a validator must have to stay in the validator set, at most 66 as proposers can omit the seals of a third of the blocks
+	ReduceRewardByUptime bool     `json:"reduceRewardByUptime,omitempty"` // Whether the epoch reward of a validator is proportional to its uptime
+
+	StakeWeightedBlock *big.Int `json:"stakeWeightedBlock,omitempty"` // StakeWeightedBlock switch on the voting power of validators proportional to their stake (nil = no fork)
+
+	BLSAggregationBlock *big.Int `json:"blsAggregationBlock,omitempty"` // BLSAggregationBlock switch on the registration of BLS keys and the aggregated commit signatures (nil = no fork)
+
+	GovernanceBlock *big.Int `json:"governanceBlock,omitempty"` // GovernanceBlock switch on the votes of the validators on the chain parameters (nil = no fork)
+
+	CommissionBlock         *big.Int `json:"commissionBlock,omitempty"`         // CommissionBlock switch on the commission rates set by the candidates (nil = no fork)
+	MaxCommissionRate       uint64   `json:"maxCommissionRate,omitempty"`       // The maximum commission rate of a candidate in percent (0 = 100)
+	MaxCommissionRateChange uint64   `json:"maxCommissionRateChange,omitempty"` // The maximum change of the commission rate of a candidate in an epoch in percent (0 = unlimited)
+
+	ValSetHashBlock *big.Int `json:"valSetHashBlock,omitempty"` // ValSetHashBlock switch on the hash of the validator set sealed in the checkpoint headers (nil = no fork)
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -249,6 +275,41 @@ func (c *TendermintConfig) String() string {
 	return "tendermint"
 }
 
+// IsSlashing returns whether num is either equal to the slashing fork block or greater.
+func (c *TendermintConfig) IsSlashing(num *big.Int) bool {
+	return isForked(c.SlashingBlock, num)
+}
+
+// IsDowntimeJailing returns whether num is either equal to the downtime jailing fork block or greater.
+func (c *TendermintConfig) IsDowntimeJailing(num *big.Int) bool {
+	return isForked(c.DowntimeJailingBlock, num)
+}
+
+// IsStakeWeighted returns whether num is either equal to the stake weighted voting fork block or greater.
+func (c *TendermintConfig) IsStakeWeighted(num *big.Int) bool {
+	return isForked(c.StakeWeightedBlock, num)
+}
+
+// IsBLSAggregation returns whether num is either equal to the BLS aggregated commit fork block or greater.
+func (c *TendermintConfig) IsBLSAggregation(num *big.Int) bool {
+	return isForked(c.BLSAggregationBlock, num)
+}
+
+// IsGovernance returns whether num is either equal to the chain parameters governance fork block or greater.
+func (c *TendermintConfig) IsGovernance(num *big.Int) bool {
+	return isForked(c.GovernanceBlock, num)
+}
+
+// IsCommission returns whether num is either equal to the commission rates fork block or greater.
+func (c *TendermintConfig) IsCommission(num *big.Int) bool {
+	return isForked(c.CommissionBlock, num)
+}
+
+// IsValSetHash returns whether num is either equal to the validator set hash fork block or greater.
+func (c *TendermintConfig) IsValSetHash(num *big.Int) bool {
+	return isForked(c.ValSetHashBlock, num)
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -325,6 +386,26 @@ func (c *ChainConfig) IsEWASM(num *big.Int) bool {
 	return isForked(c.EWASMBlock, num)
 }
 
+// IsProviderManagement returns whether num is either equal to the provider management fork block or greater.
+func (c *ChainConfig) IsProviderManagement(num *big.Int) bool {
+	return isForked(c.ProviderManagementBlock, num)
+}
+
+// IsProviderAllowance returns whether num is either equal to the provider allowance fork block or greater.
+func (c *ChainConfig) IsProviderAllowance(num *big.Int) bool {
+	return isForked(c.ProviderAllowanceBlock, num)
+}
+
+// IsProviderContext returns whether num is either equal to the provider context fork block or greater.
+func (c *ChainConfig) IsProviderContext(num *big.Int) bool {
+	return isForked(c.ProviderContextBlock, num)
+}
+
+// IsProviderGroup returns whether num is either equal to the provider group fork block or greater.
+func (c *ChainConfig) IsProviderGroup(num *big.Int) bool {
+	return isForked(c.ProviderGroupBlock, num)
+}
+
 // GasTable returns the gas table corresponding to the current phase (homestead or homestead reprice).
 //
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
@@ -396,6 +477,18 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.EWASMBlock, newcfg.EWASMBlock, head) {
 		return newCompatError("ewasm fork block", c.EWASMBlock, newcfg.EWASMBlock)
 	}
+	if isForkIncompatible(c.ProviderManagementBlock, newcfg.ProviderManagementBlock, head) {
+		return newCompatError("provider management fork block", c.ProviderManagementBlock, newcfg.ProviderManagementBlock)
+	}
+	if isForkIncompatible(c.ProviderAllowanceBlock, newcfg.ProviderAllowanceBlock, head) {
+		return newCompatError("provider allowance fork block", c.ProviderAllowanceBlock, newcfg.ProviderAllowanceBlock)
+	}
+	if isForkIncompatible(c.ProviderContextBlock, newcfg.ProviderContextBlock, head) {
+		return newCompatError("provider context fork block", c.ProviderContextBlock, newcfg.ProviderContextBlock)
+	}
+	if isForkIncompatible(c.ProviderGroupBlock, newcfg.ProviderGroupBlock, head) {
+		return newCompatError("provider group fork block", c.ProviderGroupBlock, newcfg.ProviderGroupBlock)
+	}
 	return nil
 }
 
@@ -85,6 +85,9 @@ const (
 	Bn256ScalarMulGas       uint64 = 40000  // Gas needed for an elliptic curve scalar multiplication
 	Bn256PairingBaseGas     uint64 = 100000 // Base price for an elliptic curve pairing check
 	Bn256PairingPerPointGas uint64 = 80000  // Per-point price for an elliptic curve pairing check
+
+	ProviderManagementReadGas  uint64 = 200   // Price for reading the owner or the providers of an enterprise contract
+	ProviderManagementWriteGas uint64 = 20000 // Price for changing the owner or the providers of an enterprise contract
 )
 
 var (
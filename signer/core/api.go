@@ -23,8 +23,10 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/Evrynetlabs/evrynet-node/accounts"
 	"github.com/Evrynetlabs/evrynet-node/accounts/keystore"
@@ -32,6 +34,8 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/accounts/usbwallet"
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	tmsigner "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/signer"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/internal/evrapi"
 	"github.com/Evrynetlabs/evrynet-node/log"
@@ -118,6 +122,10 @@ type SignerAPI struct {
 	validator   Validator
 	rejectMode  bool
 	credentials storage.Storage
+
+	voteGuardDir string                             // voteGuardDir stores the last Tendermint vote signed by each account
+	voteGuards   map[common.Address]*tmsigner.Guard // voteGuards protects the accounts against Tendermint double signing
+	voteGuardsMu sync.Mutex
 }
 
 // Metadata about a request
@@ -240,6 +248,8 @@ type (
 		Message     []*NameValueType        `json:"message"`
 		Hash        hexutil.Bytes           `json:"hash"`
 		Meta        Metadata                `json:"meta"`
+
+		vote *tendermint.VoteStep // vote is the Tendermint vote the data is signed for, if any
 	}
 	SignDataResponse struct {
 		Approved bool `json:"approved"`
@@ -284,12 +294,49 @@ func NewSignerAPI(am *accounts.Manager, chainID int64, noUSB bool, ui UIClientAP
 	if advancedMode {
 		log.Info("Clef is in advanced mode: will warn instead of reject")
 	}
-	signer := &SignerAPI{big.NewInt(chainID), am, ui, validator, !advancedMode, credentials}
+	signer := &SignerAPI{
+		chainID:     big.NewInt(chainID),
+		am:          am,
+		UI:          ui,
+		validator:   validator,
+		rejectMode:  !advancedMode,
+		credentials: credentials,
+		voteGuards:  make(map[common.Address]*tmsigner.Guard),
+	}
 	if !noUSB {
 		signer.startUSBListener()
 	}
 	return signer
 }
+
+// SetVoteGuardDir sets the directory where the last Tendermint vote signed by each account is persisted, so that
+// the double-sign protection holds across restarts. The votes are only kept in memory by default.
+func (api *SignerAPI) SetVoteGuardDir(dir string) {
+	api.voteGuardsMu.Lock()
+	defer api.voteGuardsMu.Unlock()
+	api.voteGuardDir = dir
+	api.voteGuards = make(map[common.Address]*tmsigner.Guard)
+}
+
+// voteGuard returns the guard against Tendermint double signing of the account
+func (api *SignerAPI) voteGuard(addr common.Address) (*tmsigner.Guard, error) {
+	api.voteGuardsMu.Lock()
+	defer api.voteGuardsMu.Unlock()
+	if guard, ok := api.voteGuards[addr]; ok {
+		return guard, nil
+	}
+	var path string
+	if api.voteGuardDir != "" {
+		path = filepath.Join(api.voteGuardDir, fmt.Sprintf("tendermint-votes-%x.json", addr))
+	}
+	guard, err := tmsigner.NewGuard(path)
+	if err != nil {
+		return nil, err
+	}
+	api.voteGuards[addr] = guard
+	return guard, nil
+}
+
 func (api *SignerAPI) openTrezor(url accounts.URL) {
 	resp, err := api.UI.OnInputRequired(UserInputRequest{
 		Prompt: "Pin required to open Trezor wallet\n" +
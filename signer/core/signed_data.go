@@ -36,6 +36,10 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
 	"github.com/Evrynetlabs/evrynet-node/common/math"
 	"github.com/Evrynetlabs/evrynet-node/consensus/clique"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	tmcore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	tmsigner "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/signer"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
 	"github.com/Evrynetlabs/evrynet-node/rlp"
@@ -149,6 +153,16 @@ func (api *SignerAPI) sign(addr common.MixedcaseAddress, req *SignDataRequest, l
 	if err != nil {
 		return nil, err
 	}
+	// Refuse to sign a Tendermint vote conflicting with one signed before by the account
+	if req.vote != nil {
+		guard, err := api.voteGuard(account.Address)
+		if err != nil {
+			return nil, err
+		}
+		if err := guard.Check(req.vote); err != nil {
+			return nil, err
+		}
+	}
 	// Sign the data with the wallet
 	signature, err := wallet.SignDataWithPassphrase(account, pw, req.ContentType, req.Rawdata)
 	if err != nil {
@@ -261,6 +275,58 @@ func (api *SignerAPI) determineSignatureFormat(ctx context.Context, contentType
 		// Clique uses V on the form 0 or 1
 		useEvrynetV = false
 		req = &SignDataRequest{ContentType: mediaType, Rawdata: cliqueRlp, Message: message, Hash: sighash}
+	case accounts.MimetypeTendermint:
+		// Tendermint consensus messages which are not votes are signed as is
+		stringData, ok := data.(string)
+		if !ok {
+			return nil, useEvrynetV, fmt.Errorf("input for %v must be an hex-encoded string", accounts.MimetypeTendermint)
+		}
+		tendermintData, err := hexutil.Decode(stringData)
+		if err != nil {
+			return nil, useEvrynetV, err
+		}
+		// votes and committed seals must be checked against the votes signed before
+		if _, ok := utils.CommittedSealHash(tendermintData); ok || tmcore.IsVoteMessage(tendermintData) {
+			return nil, useEvrynetV, fmt.Errorf("input for %v is a vote, it must be signed as %v", accounts.MimetypeTendermint, accounts.MimetypeTendermintVote)
+		}
+		message := []*NameValueType{
+			{
+				Name:  "Tendermint message",
+				Typ:   "hexdata",
+				Value: stringData,
+			},
+		}
+		// Tendermint uses V on the form 0 or 1
+		useEvrynetV = false
+		req = &SignDataRequest{ContentType: mediaType, Rawdata: tendermintData, Message: message, Hash: crypto.Keccak256(tendermintData)}
+	case accounts.MimetypeTendermintVote:
+		// Tendermint votes are checked against the votes signed before by the account
+		stringData, ok := data.(string)
+		if !ok {
+			return nil, useEvrynetV, fmt.Errorf("input for %v must be an hex-encoded string", accounts.MimetypeTendermintVote)
+		}
+		voteData, err := hexutil.Decode(stringData)
+		if err != nil {
+			return nil, useEvrynetV, err
+		}
+		var vote tmsigner.VoteRequest
+		if err := rlp.DecodeBytes(voteData, &vote); err != nil {
+			return nil, useEvrynetV, err
+		}
+		step, err := tendermintVoteStep(&vote)
+		if err != nil {
+			return nil, useEvrynetV, fmt.Errorf("input for %v is not a vote: %v", accounts.MimetypeTendermintVote, err)
+		}
+		message := []*NameValueType{
+			{
+				Name:  "Tendermint vote",
+				Typ:   "tendermint",
+				Value: step.String(),
+			},
+		}
+		// Tendermint uses V on the form 0 or 1
+		useEvrynetV = false
+		req = &SignDataRequest{ContentType: mediaType, Rawdata: vote.Data, Message: message, Hash: crypto.Keccak256(vote.Data), vote: step}
 	default: // also case TextPlain.Mime:
 		// Calculates an Evrynet ECDSA signature for:
 		// hash = keccak256("\x19${byteVersion}Ethereum Signed Message:\n${message length}${message}")
@@ -297,6 +363,21 @@ func SignTextValidator(validatorData ValidatorData) (hexutil.Bytes, string) {
 	return crypto.Keccak256([]byte(msg)), msg
 }
 
+// tendermintVoteStep returns the step of the vote signed by the data of the request. The step of a proposal, a prevote
+// or a precommit message is decoded from the message itself, the step reported by the request is ignored. A committed
+// seal only carries the hash of the block it commits, which also binds its number, so the reported step is used once
+// its block hash and its step are checked.
+func tendermintVoteStep(vote *tmsigner.VoteRequest) (*tendermint.VoteStep, error) {
+	if hash, ok := utils.CommittedSealHash(vote.Data); ok {
+		if vote.Vote.BlockNumber == nil || vote.Vote.BlockHash != hash ||
+			(vote.Vote.Step != tmcore.MsgPrevote && vote.Vote.Step != tmcore.MsgPrecommit) {
+			return nil, errors.New("committed seal does not match its vote")
+		}
+		return &vote.Vote, nil
+	}
+	return tmcore.DecodeVoteStep(vote.Data)
+}
+
 // cliqueHeaderHashAndRlp returns the hash which is used as input for the proof-of-authority
 // signing. It is the hash of the entire header apart from the 65 byte signature
 // contained at the end of the extra data.
@@ -21,15 +21,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"path"
+	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/Evrynetlabs/evrynet-node/accounts"
 	"github.com/Evrynetlabs/evrynet-node/accounts/keystore"
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
 	"github.com/Evrynetlabs/evrynet-node/common/math"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint"
+	tmcore "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/core"
+	tmsigner "github.com/Evrynetlabs/evrynet-node/consensus/tendermint/signer"
+	"github.com/Evrynetlabs/evrynet-node/consensus/tendermint/utils"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
 	"github.com/Evrynetlabs/evrynet-node/signer/core"
 )
 
@@ -230,6 +238,82 @@ func TestSignData(t *testing.T) {
 	}
 }
 
+func TestSignTendermintVote(t *testing.T) {
+	api, control := setup(t)
+	createAccount(control, api, t)
+	control.approveCh <- "A"
+	list, err := api.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := common.NewMixedcaseAddress(list[0])
+
+	sign := func(mimeType string, data []byte) ([]byte, error) {
+		control.approveCh <- "Y"
+		control.inputCh <- "a_long_password"
+		return api.SignData(context.Background(), mimeType, a, hexutil.Encode(data))
+	}
+	signVote := func(vote tendermint.VoteStep, data []byte) ([]byte, error) {
+		req, err := rlp.EncodeToBytes(&tmsigner.VoteRequest{Vote: vote, Data: data})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sign(accounts.MimetypeTendermintVote, req)
+	}
+	// the payload without signature of a prevote message
+	prevote := func(number int64, round int64, hash common.Hash) []byte {
+		vote, err := rlp.EncodeToBytes([]interface{}{&hash, big.NewInt(number), strconv.FormatInt(round, 10), []byte{}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, err := rlp.EncodeToBytes([]interface{}{tmcore.MsgPrevote, vote, list[0], []byte{}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+	var (
+		hash1 = common.HexToHash("0x01")
+		hash2 = common.HexToHash("0x02")
+		seal  = utils.PrepareCommittedSeal(hash1)
+	)
+	signature, err := signVote(tendermint.VoteStep{BlockNumber: big.NewInt(1), Step: tmcore.MsgPrevote, BlockHash: hash1}, seal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signature == nil || len(signature) != 65 || signature[64] > 1 {
+		t.Errorf("Expected 65 byte signature with V 0 or 1 (got %x)", signature)
+	}
+	pubkey, err := crypto.SigToPub(crypto.Keccak256(seal), signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := crypto.PubkeyToAddress(*pubkey); have != list[0] {
+		t.Errorf("Signer mismatch: have %x, want %x", have, list[0])
+	}
+	// the seal must commit the block of its vote
+	if _, err := signVote(tendermint.VoteStep{BlockNumber: big.NewInt(2), Step: tmcore.MsgPrevote, BlockHash: hash2}, seal); err == nil {
+		t.Error("Expected error signing a seal of another block")
+	}
+	// the step of a message is decoded from the message, not from the vote of the request
+	if _, err := signVote(tendermint.VoteStep{BlockNumber: big.NewInt(5), Step: tmcore.MsgPrevote, BlockHash: hash2}, prevote(1, 0, hash2)); err != tendermint.ErrDoubleSign {
+		t.Errorf("Expected ErrDoubleSign! '%v'", err)
+	}
+	if _, err := signVote(tendermint.VoteStep{}, prevote(1, 1, hash2)); err != nil {
+		t.Fatal(err)
+	}
+	// votes and seals can not be signed as other consensus messages
+	if _, err := sign(accounts.MimetypeTendermint, prevote(2, 0, hash2)); err == nil {
+		t.Error("Expected error signing a vote as a Tendermint message")
+	}
+	if _, err := sign(accounts.MimetypeTendermint, utils.PrepareCommittedSeal(hash2)); err == nil {
+		t.Error("Expected error signing a committed seal as a Tendermint message")
+	}
+	if _, err := sign(accounts.MimetypeTendermint, hash2.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestDomainChainId(t *testing.T) {
 	withoutChainID := core.TypedData{
 		Types: core.Types{